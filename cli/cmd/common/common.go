@@ -12,6 +12,7 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
 	"github.com/sassoftware/event-provenance-registry/pkg/client"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/spf13/cobra"
@@ -117,6 +118,10 @@ func ProcessSearchFields(fields []string, i interface{}) ([]string, error) {
 
 			e := reflect.ValueOf(i).Elem()
 			for i := 0; i < e.NumField(); i++ {
+				// object fields such as the group policy need a selection of their own
+				if isObject(e.Type().Field(i).Type) {
+					continue
+				}
 				matches = re.FindStringSubmatch(string(e.Type().Field(i).Tag))
 				if len(matches) > 0 {
 					if matches[1] != "-" {
@@ -137,6 +142,18 @@ func ProcessSearchFields(fields []string, i interface{}) ([]string, error) {
 	return trimmedFields, nil
 }
 
+// isObject reports whether the field is a GraphQL object or a list of them, the JSON and Time
+// scalars are structs too
+func isObject(t reflect.Type) bool {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(types.JSON{}) || t == reflect.TypeOf(types.Time{}) {
+		return false
+	}
+	return t.Kind() == reflect.Struct
+}
+
 // BindFlagsE is run under the cobra command as preRunE. It simply binds the flags.
 func BindFlagsE(cmd *cobra.Command, _ []string) error {
 	err := viper.BindPFlags(cmd.Flags())
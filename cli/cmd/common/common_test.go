@@ -5,7 +5,10 @@ package common
 
 import (
 	"reflect"
+	"slices"
 	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 func TestToJSON(t *testing.T) {
@@ -31,3 +34,18 @@ func TestToJSON(t *testing.T) {
 		t.Errorf("Expected %s, but got %s", expected, result)
 	}
 }
+
+func TestProcessSearchFieldsAll(t *testing.T) {
+	fields, err := ProcessSearchFields([]string{"all"}, &storage.EventReceiverGroup{})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, field := range fields {
+		if field == "policy" || field == "conditions" {
+			t.Errorf("Expected object field %s to be left out of %v", field, fields)
+		}
+	}
+	if !slices.Contains(fields, "event_receiver_ids") || !slices.Contains(fields, "created_at") {
+		t.Errorf("Expected scalar fields in %v", fields)
+	}
+}
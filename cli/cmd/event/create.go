@@ -4,11 +4,15 @@
 package event
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/client"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -54,8 +58,28 @@ func runCreateEvent(_ *cobra.Command, _ []string) error {
 		Payload:         types.JSON{JSON: []byte(payload)},
 	}
 
+	var envelope *signing.Envelope
+	if keyFile := viper.GetString("signing-key"); keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return err
+		}
+		key, err := signing.ParsePrivateKey(data)
+		if err != nil {
+			return err
+		}
+		envelope, err = signing.Sign(*e, key)
+		if err != nil {
+			return err
+		}
+	}
+
 	if dryrun {
-		content, err := e.ToJSON()
+		var body any = e
+		if envelope != nil {
+			body = client.SignedEvent{Event: e, Signature: envelope}
+		}
+		content, err := json.MarshalIndent(body, "", "    ")
 		if err != nil {
 			return err
 		}
@@ -63,7 +87,12 @@ func runCreateEvent(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	content, err := c.CreateEvent(e)
+	var content string
+	if envelope != nil {
+		content, err = c.CreateSignedEvent(e, envelope)
+	} else {
+		content, err = c.CreateEvent(e)
+	}
 	if err != nil {
 		return err
 	}
@@ -93,6 +122,7 @@ func NewCreateCmd() *cobra.Command {
 	createCmd.Flags().Bool("success", false, "specify if the event succeeded")
 	createCmd.Flags().String("event-receiver-id", "", "ID of the event receiver")
 	createCmd.Flags().String("payload", "", "JSON string of event payload")
+	createCmd.Flags().String("signing-key", "", "PEM file of the ed25519 or ECDSA private key to sign the event with")
 	createCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
 	createCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
 	createCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
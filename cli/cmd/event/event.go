@@ -10,8 +10,8 @@ import (
 // eventCmd represents the event command
 var eventCmd = &cobra.Command{
 	Use:   "event",
-	Short: "Create, Search, and Generate Events",
-	Long:  `Create, Search, and Generate Events for the Event Provenance Registry Service`,
+	Short: "Create, Search, Revoke, and Generate Events",
+	Long:  `Create, Search, Revoke, and Generate Events for the Event Provenance Registry Service`,
 }
 
 // NewEventCmd command for new events
@@ -20,6 +20,8 @@ func NewEventCmd() *cobra.Command {
 	eventCmd.AddCommand(searchCmd)
 	createCmd := NewCreateCmd()
 	eventCmd.AddCommand(createCmd)
+	revokeCmd := NewRevokeCmd()
+	eventCmd.AddCommand(revokeCmd)
 	generateCmd := NewGenerateCmd()
 	eventCmd.AddCommand(generateCmd)
 	return eventCmd
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// revokeCmd represents the revoke command
+var revokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "revokes an Event",
+	Long: `revokes an Event that was sent by mistake or by a compromised job. The event is kept
+for the audit trail but no longer counts towards any Event Receiver Group.`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runRevokeEvent,
+}
+
+// runRevokeEvent revokes the Event, returns error
+func runRevokeEvent(_ *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	revokedBy := viper.GetString("revoked-by")
+	reason := viper.GetString("reason")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.RevokeEvent(graphql.ID(id), revokedBy, reason)
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewRevokeCmd creates a new command
+func NewRevokeCmd() *cobra.Command {
+	revokeCmd.Flags().String("id", "", "ID of the Event")
+	revokeCmd.Flags().String("revoked-by", "", "Who revokes the Event")
+	revokeCmd.Flags().String("reason", "", "Why the Event is revoked")
+	revokeCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	revokeCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = revokeCmd.MarkFlagRequired("id")
+	_ = revokeCmd.MarkFlagRequired("revoked-by")
+	_ = revokeCmd.MarkFlagRequired("reason")
+
+	return revokeCmd
+}
@@ -6,6 +6,7 @@ package event
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
@@ -75,6 +76,47 @@ func runSearchEvent(_ *cobra.Command, _ []string) error {
 		params["event_receiver_id"] = eventReceiverID
 	}
 
+	for _, column := range []string{"name", "version", "release"} {
+		for _, match := range []string{"prefix", "regex"} {
+			if value := viper.GetString(column + "-" + match); value != "" {
+				params[column+"_"+match] = value
+			}
+		}
+	}
+
+	for _, column := range []string{"name", "version", "release", "platform_id", "package", "event_receiver_id"} {
+		if values := viper.GetStringSlice(strings.ReplaceAll(column, "_", "-") + "-in"); len(values) > 0 {
+			params[column+"_in"] = values
+		}
+	}
+
+	for _, bound := range []string{"created-after", "created-before"} {
+		if value := viper.GetString(bound); value != "" {
+			params[strings.ReplaceAll(bound, "-", "_")] = value
+		}
+	}
+
+	payloadContains := viper.GetString("payload-contains")
+	if payloadContains != "" {
+		params["payload_contains"] = payloadContains
+	}
+
+	var payloadPaths []map[string]interface{}
+	for _, p := range viper.GetStringSlice("payload-path") {
+		path, err := storage.ParsePayloadPath(p)
+		if err != nil {
+			return err
+		}
+		payloadPaths = append(payloadPaths, map[string]interface{}{
+			"path":   path.Path,
+			"equals": path.Equals,
+			"prefix": path.Prefix,
+		})
+	}
+	if payloadPaths != nil {
+		params["payload_path"] = payloadPaths
+	}
+
 	fields, err := common.ProcessSearchFields(viper.GetStringSlice("fields"), &storage.Event{})
 	if err != nil {
 		return err
@@ -89,6 +131,7 @@ func runSearchEvent(_ *cobra.Command, _ []string) error {
 		fmt.Printf("Package: %s\n", pkg)
 		fmt.Printf("Success: %s\n", success)
 		fmt.Printf("EventReceiverID: %s\n", eventReceiverID)
+		fmt.Printf("Filters: %v\n", params)
 		fmt.Printf("Fields: %v\n", fields)
 		curlcmd, err := c.GetCurlSearch("events", params, fields)
 		if err != nil {
@@ -129,6 +172,22 @@ func NewSearchCmd() *cobra.Command {
 	searchCmd.Flags().String("package", "", "Package of the event")
 	searchCmd.Flags().String("success", "", "Success of the event")
 	searchCmd.Flags().String("event-receiver-id", "", "Event receiver id of the event")
+	searchCmd.Flags().String("name-prefix", "", "Prefix the name of the event starts with")
+	searchCmd.Flags().String("name-regex", "", "Regular expression the name of the event matches")
+	searchCmd.Flags().StringSlice("name-in", nil, "Comma separated list of event names")
+	searchCmd.Flags().String("version-prefix", "", "Prefix the version of the event starts with")
+	searchCmd.Flags().String("version-regex", "", "Regular expression the version of the event matches")
+	searchCmd.Flags().StringSlice("version-in", nil, "Comma separated list of event versions")
+	searchCmd.Flags().String("release-prefix", "", "Prefix the release of the event starts with")
+	searchCmd.Flags().String("release-regex", "", "Regular expression the release of the event matches")
+	searchCmd.Flags().StringSlice("release-in", nil, "Comma separated list of event releases")
+	searchCmd.Flags().StringSlice("platform-id-in", nil, "Comma separated list of event platform ids")
+	searchCmd.Flags().StringSlice("package-in", nil, "Comma separated list of event packages")
+	searchCmd.Flags().StringSlice("event-receiver-id-in", nil, "Comma separated list of event receiver ids")
+	searchCmd.Flags().String("created-after", "", "Only events created at or after this RFC 3339 time")
+	searchCmd.Flags().String("created-before", "", "Only events created before this RFC 3339 time")
+	searchCmd.Flags().String("payload-contains", "", "JSON document the event payload must contain")
+	searchCmd.Flags().StringArray("payload-path", nil, "Payload match as path=value or path^=prefix, e.g. git.sha^=abc (repeatable)")
 	searchCmd.Flags().String("fields", "id name version release platform_id package success", "Space delimited list of fields, or 'all' for all user fields")
 	searchCmd.Flags().String("jsonpath", "", "JSONPath expression to apply to output")
 	searchCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
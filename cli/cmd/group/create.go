@@ -6,6 +6,7 @@ package group
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
@@ -24,7 +25,7 @@ var createCmd = &cobra.Command{
 }
 
 // runCreateEventReceiverGroup creates the Event Receiver Group, returns error
-func runCreateEventReceiverGroup(_ *cobra.Command, _ []string) error {
+func runCreateEventReceiverGroup(cmd *cobra.Command, _ []string) error {
 	url := viper.GetString("url")
 	c, err := common.GetClient(url)
 	if err != nil {
@@ -37,6 +38,14 @@ func runCreateEventReceiverGroup(_ *cobra.Command, _ []string) error {
 	desc := viper.GetString("description")
 	evrIDs := viper.GetStringSlice("event-receiver-ids")
 	enabled := viper.GetBool("enabled")
+	policyType := viper.GetString("policy")
+	policyN := viper.GetInt32("policy-n")
+	optionalIDs := viper.GetStringSlice("optional-event-receiver-ids")
+	// read directly from the flag, viper would split expressions on commas
+	conditionFlags, err := cmd.Flags().GetStringArray("condition")
+	if err != nil {
+		return err
+	}
 	dryrun := viper.GetBool("dry-run")
 	noindent := viper.GetBool("no-indent")
 
@@ -45,6 +54,23 @@ func runCreateEventReceiverGroup(_ *cobra.Command, _ []string) error {
 		eventReceiverIDs = append(eventReceiverIDs, graphql.ID(id))
 	}
 
+	policy := storage.GatePolicy{Type: policyType, OptionalEventReceiverIDs: []graphql.ID{}}
+	if policyN > 0 {
+		policy.N = &policyN
+	}
+	for _, id := range optionalIDs {
+		policy.OptionalEventReceiverIDs = append(policy.OptionalEventReceiverIDs, graphql.ID(id))
+	}
+
+	conditions := []storage.EventReceiverCondition{}
+	for _, c := range conditionFlags {
+		id, expression, ok := strings.Cut(c, "=")
+		if !ok || id == "" || expression == "" {
+			return fmt.Errorf("invalid condition %q, expected event-receiver-id=expression", c)
+		}
+		conditions = append(conditions, storage.EventReceiverCondition{EventReceiverID: graphql.ID(id), Expression: expression})
+	}
+
 	erg := &storage.EventReceiverGroup{
 		Name:             name,
 		Type:             etype,
@@ -52,6 +78,8 @@ func runCreateEventReceiverGroup(_ *cobra.Command, _ []string) error {
 		Description:      desc,
 		EventReceiverIDs: eventReceiverIDs,
 		Enabled:          enabled,
+		Policy:           policy,
+		Conditions:       conditions,
 	}
 
 	if dryrun {
@@ -63,7 +91,11 @@ func runCreateEventReceiverGroup(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	content, err := c.CreateEventReceiverGroup(erg)
+	create := c.CreateEventReceiverGroup
+	if viper.GetBool("get-or-create") {
+		create = c.GetOrCreateEventReceiverGroup
+	}
+	content, err := create(erg)
 	if err != nil {
 		return err
 	}
@@ -89,6 +121,11 @@ func NewCreateCmd() *cobra.Command {
 	createCmd.Flags().String("description", "", "Description of the Event Receiver Group")
 	createCmd.Flags().String("event-receiver-ids", "", "Space delimited set of receiver ids")
 	createCmd.Flags().Bool("enabled", true, "Enable the Event Receiver Group")
+	createCmd.Flags().String("policy", storage.PolicyAllOf, "Gate policy of the Event Receiver Group: ALL_OF, ANY_OF or N_OF")
+	createCmd.Flags().Int32("policy-n", 0, "Number of receivers that must succeed for the N_OF policy")
+	createCmd.Flags().String("optional-event-receiver-ids", "", "Space delimited set of receiver ids that are advisory and never block the group")
+	createCmd.Flags().StringArray("condition", nil, "CEL condition of a receiver as event-receiver-id=expression, e.g. 01HKX90FLM4ZKP7RBVGDA0N7SS='payload.critical == 0' (repeatable)")
+	createCmd.Flags().Bool("get-or-create", false, "return the ID of the Event Receiver Group with the same name, type, version and description if there is one")
 	createCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
 	createCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
 	createCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
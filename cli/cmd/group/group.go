@@ -10,8 +10,8 @@ import (
 // groupCmd represents the group command
 var groupCmd = &cobra.Command{
 	Use:   "group",
-	Short: "Create, Search, or Generate Event Receiver Groups",
-	Long:  `Create, Search, or Generate Event Receiver Groups for the Event Provenance Registry Service`,
+	Short: "Create, Search, Modify, Delete or Generate Event Receiver Groups",
+	Long:  `Create, Search, Modify, Delete or Generate Event Receiver Groups for the Event Provenance Registry Service`,
 }
 
 // NewGroupCmd create a new command
@@ -22,6 +22,10 @@ func NewGroupCmd() *cobra.Command {
 	groupCmd.AddCommand(createCmd)
 	generateCmd := NewGenerateCmd()
 	groupCmd.AddCommand(generateCmd)
+	modifyCmd := NewModifyCmd()
+	groupCmd.AddCommand(modifyCmd)
+	deleteCmd := NewDeleteCmd()
+	groupCmd.AddCommand(deleteCmd)
 
 	return groupCmd
 }
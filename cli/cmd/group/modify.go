@@ -6,6 +6,8 @@ package group
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
@@ -14,42 +16,72 @@ import (
 	"github.com/spf13/viper"
 )
 
-// createCmd represents the create command
+// modifyCmd represents the modify command
 var modifyCmd = &cobra.Command{
 	Use:     "modify",
-	Short:   "Modifies a stage",
-	Long:    `modifies a stage`,
+	Short:   "modifies a Event Receiver Group",
+	Long:    `modifies the description, enabled flag or receivers of a Event Receiver Group`,
 	PreRunE: common.BindFlagsE,
 	RunE:    runModifyEventReceiverGroup,
 }
 
-// runModifyEventReceiverGroup modifys stage and returns error
-func runModifyEventReceiverGroup(_ *cobra.Command, _ []string) error {
+// runModifyEventReceiverGroup modifies the Event Receiver Group, returns error
+func runModifyEventReceiverGroup(cmd *cobra.Command, _ []string) error {
 	id := viper.GetString("id")
 	disable := viper.GetBool("disable")
 	enable := viper.GetBool("enable")
+	addIDs := viper.GetStringSlice("add-event-receiver-ids")
+	optionalIDs := viper.GetStringSlice("optional-event-receiver-ids")
+	removeIDs := viper.GetStringSlice("remove-event-receiver-ids")
+	// read directly from the flag, viper would split expressions on commas
+	conditionFlags, err := cmd.Flags().GetStringArray("condition")
+	if err != nil {
+		return err
+	}
 	dryrun := viper.GetBool("dry-run")
 	noindent := viper.GetBool("no-indent")
 
-	url := viper.GetString("url")
-	c, err := common.GetClient(url)
-	if err != nil {
-		return err
+	if enable && disable {
+		return fmt.Errorf("--enable and --disable cannot be used together")
+	}
+
+	update := storage.EventReceiverGroupUpdate{}
+	if cmd.Flags().Changed("description") {
+		desc := viper.GetString("description")
+		update.Description = &desc
+	}
+	if enable || disable {
+		update.Enabled = &enable
 	}
-	enabled := true
-	if disable {
-		enabled = false
+	conditions := map[string]string{}
+	for _, c := range conditionFlags {
+		id, expression, ok := strings.Cut(c, "=")
+		if !ok || id == "" || expression == "" {
+			return fmt.Errorf("invalid condition %q, expected event-receiver-id=expression", c)
+		}
+		if !slices.Contains(addIDs, id) {
+			return fmt.Errorf("condition event receiver %s is not added to the group", id)
+		}
+		conditions[id] = expression
 	}
-	if enable {
-		enabled = true
+	for _, id := range optionalIDs {
+		if !slices.Contains(addIDs, id) {
+			return fmt.Errorf("optional event receiver %s is not added to the group", id)
+		}
 	}
-	erg := &storage.EventReceiverGroup{
-		ID:      graphql.ID(id),
-		Enabled: enabled,
+	for _, id := range addIDs {
+		update.AddEventReceivers = append(update.AddEventReceivers, storage.EventReceiverMembership{
+			EventReceiverID: graphql.ID(id),
+			Condition:       conditions[id],
+			Optional:        slices.Contains(optionalIDs, id),
+		})
+	}
+	for _, id := range removeIDs {
+		update.RemoveEventReceiverIDs = append(update.RemoveEventReceiverIDs, graphql.ID(id))
 	}
 
 	if dryrun {
-		content, err := json.Marshal(erg)
+		content, err := json.MarshalIndent(update, "", "  ")
 		if err != nil {
 			return err
 		}
@@ -57,7 +89,13 @@ func runModifyEventReceiverGroup(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	content, err := c.ModifyEventReceiverGroup(erg)
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.UpdateEventReceiverGroup(graphql.ID(id), update)
 	if err != nil {
 		return err
 	}
@@ -80,6 +118,14 @@ func NewModifyCmd() *cobra.Command {
 	modifyCmd.Flags().String("id", "", "ID of the Event Receiver Group")
 	modifyCmd.Flags().Bool("disable", false, "Disable the Event Receiver Group")
 	modifyCmd.Flags().Bool("enable", false, "Enable the Event Receiver Group")
+	modifyCmd.Flags().String("description", "", "New description of the Event Receiver Group")
+	modifyCmd.Flags().String("add-event-receiver-ids", "", "Space delimited set of receiver ids to add to the group")
+	modifyCmd.Flags().String("optional-event-receiver-ids", "", "Space delimited set of the added receiver ids that are advisory and never block the group")
+	modifyCmd.Flags().StringArray("condition", nil, "CEL condition of an added receiver as event-receiver-id=expression (repeatable)")
+	modifyCmd.Flags().String("remove-event-receiver-ids", "", "Space delimited set of receiver ids to remove from the group")
+	modifyCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	modifyCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
+	modifyCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
 
 	_ = modifyCmd.MarkFlagRequired("id")
 
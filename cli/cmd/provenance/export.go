@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "exports the provenance of an artifact",
+	Long: `exports the events and passed Event Receiver Groups of an artifact as an in-toto
+statement with a SLSA provenance or EPR predicate, ready to be attached to the artifact.`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runExportProvenance,
+}
+
+// runExportProvenance exports the provenance, returns error
+func runExportProvenance(_ *cobra.Command, _ []string) error {
+	tuple := storage.Event{
+		Name:       viper.GetString("name"),
+		Version:    viper.GetString("version"),
+		Release:    viper.GetString("release"),
+		PlatformID: viper.GetString("platform-id"),
+		Package:    viper.GetString("package"),
+	}
+	format := viper.GetString("format")
+	digests := viper.GetStringSlice("digest")
+	output := viper.GetString("output")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.ExportProvenance(tuple, format, digests)
+	if err != nil {
+		return err
+	}
+
+	if !noindent {
+		content, err = common.IndentJSON(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	if output != "" {
+		return os.WriteFile(output, []byte(content+"\n"), 0o644)
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewExportCmd creates a new command
+func NewExportCmd() *cobra.Command {
+	exportCmd.Flags().String("name", "", "Name of the artifact")
+	exportCmd.Flags().String("version", "", "Version of the artifact")
+	exportCmd.Flags().String("release", "", "Release of the artifact")
+	exportCmd.Flags().String("platform-id", "", "Platform ID of the artifact")
+	exportCmd.Flags().String("package", "", "Package of the artifact")
+	exportCmd.Flags().String("format", "SLSA", "Predicate of the statement: SLSA or EPR")
+	exportCmd.Flags().StringSlice("digest", []string{}, "Digest of the artifact of the form algorithm:hex, can be repeated")
+	exportCmd.Flags().String("output", "", "File to write the statement to instead of stdout")
+	exportCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	exportCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = exportCmd.MarkFlagRequired("name")
+	_ = exportCmd.MarkFlagRequired("version")
+	_ = exportCmd.MarkFlagRequired("release")
+	_ = exportCmd.MarkFlagRequired("platform-id")
+	_ = exportCmd.MarkFlagRequired("package")
+
+	return exportCmd
+}
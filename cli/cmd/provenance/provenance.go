@@ -0,0 +1,22 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// provenanceCmd represents the provenance command
+var provenanceCmd = &cobra.Command{
+	Use:   "provenance",
+	Short: "Export the provenance of artifacts",
+	Long:  `Export the provenance of artifacts recorded in the Event Provenance Registry Service`,
+}
+
+// NewProvenanceCmd command for provenance
+func NewProvenanceCmd() *cobra.Command {
+	exportCmd := NewExportCmd()
+	provenanceCmd.AddCommand(exportCmd)
+	return provenanceCmd
+}
@@ -35,6 +35,10 @@ func runCreateEventReceiver(_ *cobra.Command, _ []string) error {
 	version := viper.GetString("version")
 	desc := viper.GetString("description")
 	schema := viper.GetString("schema")
+	publicKeys, err := readPublicKeys(viper.GetStringSlice("public-key"))
+	if err != nil {
+		return err
+	}
 
 	dryrun := viper.GetBool("dry-run")
 	noindent := viper.GetBool("no-indent")
@@ -45,6 +49,9 @@ func runCreateEventReceiver(_ *cobra.Command, _ []string) error {
 		Version:     version,
 		Description: desc,
 		Schema:      types.JSON{JSON: []byte(schema)},
+
+		PublicKeys:       publicKeys,
+		RequireSignature: viper.GetBool("require-signature"),
 	}
 
 	if dryrun {
@@ -56,7 +63,11 @@ func runCreateEventReceiver(_ *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	content, err := c.CreateEventReceiver(er)
+	create := c.CreateEventReceiver
+	if viper.GetBool("get-or-create") {
+		create = c.GetOrCreateEventReceiver
+	}
+	content, err := create(er)
 	if err != nil {
 		return err
 	}
@@ -81,6 +92,9 @@ func NewCreateCmd() *cobra.Command {
 	createCmd.Flags().String("version", "", "Version of the Event Receiver Group")
 	createCmd.Flags().String("description", "", "Description of the Event Receiver")
 	createCmd.Flags().String("schema", "{}", "Schema of the Event Receiver")
+	createCmd.Flags().StringSlice("public-key", nil, "PEM file of a public key trusted to sign events, can be repeated")
+	createCmd.Flags().Bool("require-signature", false, "reject events that are not signed by a trusted public key")
+	createCmd.Flags().Bool("get-or-create", false, "return the ID of the Event Receiver with the same name, type, version and description if there is one")
 	createCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
 	createCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
 	createCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
@@ -23,5 +23,9 @@ func NewReceiverCmd() *cobra.Command {
 	receiverCmd.AddCommand(createCmd)
 	generateCmd := NewGenerateCmd()
 	receiverCmd.AddCommand(generateCmd)
+	schemaCmd := NewSchemaCmd()
+	receiverCmd.AddCommand(schemaCmd)
+	signingCmd := NewSigningCmd()
+	receiverCmd.AddCommand(signingCmd)
 	return receiverCmd
 }
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package receiver
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Add or list the schema revisions of a Event Receiver",
+	Long:  `Add or list the schema revisions of a Event Receiver`,
+}
+
+// schemaAddCmd represents the schema add command
+var schemaAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Adds a schema revision to a Event Receiver",
+	Long: `Adds a schema revision to a Event Receiver. The schema is checked for
+compatibility with the current revision before it replaces it.`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runAddEventReceiverSchema,
+}
+
+// schemaHistoryCmd represents the schema history command
+var schemaHistoryCmd = &cobra.Command{
+	Use:     "history",
+	Short:   "Lists the schema revisions of a Event Receiver",
+	Long:    `Lists the schema revisions of a Event Receiver, oldest first`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runEventReceiverSchemaHistory,
+}
+
+// runAddEventReceiverSchema adds the schema revision, returns error
+func runAddEventReceiverSchema(_ *cobra.Command, _ []string) error {
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	id := viper.GetString("id")
+	schema := viper.GetString("schema")
+	compatibility := viper.GetString("compatibility")
+	noindent := viper.GetBool("no-indent")
+
+	content, err := c.CreateEventReceiverSchema(graphql.ID(id), types.JSON{JSON: []byte(schema)}, compatibility)
+	if err != nil {
+		return err
+	}
+
+	return printContent(content, noindent)
+}
+
+// runEventReceiverSchemaHistory lists the schema revisions, returns error
+func runEventReceiverSchemaHistory(_ *cobra.Command, _ []string) error {
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	id := viper.GetString("id")
+	noindent := viper.GetBool("no-indent")
+
+	content, err := c.GetEventReceiverSchemas(graphql.ID(id))
+	if err != nil {
+		return err
+	}
+
+	return printContent(content, noindent)
+}
+
+func printContent(content string, noindent bool) error {
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err := common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewSchemaCmd creates a new command
+func NewSchemaCmd() *cobra.Command {
+	schemaAddCmd.Flags().String("id", "", "ID of the Event Receiver")
+	schemaAddCmd.Flags().String("schema", "", "New schema of the Event Receiver")
+	schemaAddCmd.Flags().String("compatibility", storage.CompatibilityFull, "Compatibility with the current schema: BACKWARD, FORWARD, FULL or NONE")
+	schemaAddCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	schemaAddCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+	_ = schemaAddCmd.MarkFlagRequired("id")
+	_ = schemaAddCmd.MarkFlagRequired("schema")
+
+	schemaHistoryCmd.Flags().String("id", "", "ID of the Event Receiver")
+	schemaHistoryCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	schemaHistoryCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+	_ = schemaHistoryCmd.MarkFlagRequired("id")
+
+	schemaCmd.AddCommand(schemaAddCmd)
+	schemaCmd.AddCommand(schemaHistoryCmd)
+	return schemaCmd
+}
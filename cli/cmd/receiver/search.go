@@ -54,6 +54,11 @@ func runEventReceiverSearch(_ *cobra.Command, _ []string) error {
 		params["type"] = typeStr
 	}
 
+	fingerprint := viper.GetString("fingerprint")
+	if fingerprint != "" {
+		params["fingerprint"] = fingerprint
+	}
+
 	fields, err := common.ProcessSearchFields(viper.GetStringSlice("fields"), &storage.EventReceiver{})
 	if err != nil {
 		return err
@@ -64,6 +69,7 @@ func runEventReceiverSearch(_ *cobra.Command, _ []string) error {
 		fmt.Printf("Name: %s\n", name)
 		fmt.Printf("Version: %s\n", version)
 		fmt.Printf("Type: %s\n", typeStr)
+		fmt.Printf("Fingerprint: %s\n", fingerprint)
 		fmt.Printf("Fields: %v\n", fields)
 		curlcmd, err := c.GetCurlSearch("events", params, fields)
 		if err != nil {
@@ -100,6 +106,7 @@ func NewSearchCmd() *cobra.Command {
 	searchCmd.Flags().String("name", "", "Name of the event receiver")
 	searchCmd.Flags().String("version", "", "Version of the event receiver")
 	searchCmd.Flags().String("type", "", "Type of the event receiver")
+	searchCmd.Flags().String("fingerprint", "", "Fingerprint of the event receiver")
 	searchCmd.Flags().String("fields", "id name version type", "Space delimited list of fields, or 'all' for all user fields")
 	searchCmd.Flags().String("jsonpath", "", "JSONPath expression to apply to output")
 	searchCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
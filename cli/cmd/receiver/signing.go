@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package receiver
+
+import (
+	"os"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signingCmd represents the signing command
+var signingCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "Sets the keys trusted to sign the events of a Event Receiver",
+	Long: `Sets the public keys trusted to sign the events of a Event Receiver and whether
+its events must be signed. The keys replace the ones the Event Receiver had.`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runSetEventReceiverSigning,
+}
+
+// runSetEventReceiverSigning sets the trusted keys, returns error
+func runSetEventReceiverSigning(_ *cobra.Command, _ []string) error {
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	id := viper.GetString("id")
+	requireSignature := viper.GetBool("require-signature")
+	noindent := viper.GetBool("no-indent")
+	publicKeys, err := readPublicKeys(viper.GetStringSlice("public-key"))
+	if err != nil {
+		return err
+	}
+
+	content, err := c.SetEventReceiverSigning(graphql.ID(id), publicKeys, requireSignature)
+	if err != nil {
+		return err
+	}
+
+	return printContent(content, noindent)
+}
+
+// readPublicKeys reads the PEM files of the public keys
+func readPublicKeys(paths []string) ([]string, error) {
+	keys := []string{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, string(data))
+	}
+	return keys, nil
+}
+
+// NewSigningCmd creates a new command
+func NewSigningCmd() *cobra.Command {
+	signingCmd.Flags().String("id", "", "ID of the Event Receiver")
+	signingCmd.Flags().StringSlice("public-key", nil, "PEM file of a public key trusted to sign events, can be repeated")
+	signingCmd.Flags().Bool("require-signature", false, "reject events that are not signed by a trusted public key")
+	signingCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	signingCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+	_ = signingCmd.MarkFlagRequired("id")
+
+	return signingCmd
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/adrg/xdg"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/event"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/group"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/provenance"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/receiver"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/sbom"
 	"github.com/sassoftware/event-provenance-registry/cli/cmd/status"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/webhook"
 	"github.com/sassoftware/event-provenance-registry/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -85,6 +88,12 @@ func init() {
 	rootCmd.AddCommand(receiverCmd)
 	groupCmd := group.NewGroupCmd()
 	rootCmd.AddCommand(groupCmd)
+	provenanceCmd := provenance.NewProvenanceCmd()
+	rootCmd.AddCommand(provenanceCmd)
+	sbomCmd := sbom.NewSBOMCmd()
+	rootCmd.AddCommand(sbomCmd)
+	webhookCmd := webhook.NewWebhookCmd()
+	rootCmd.AddCommand(webhookCmd)
 	statusCmd := status.NewStatusCmd()
 	rootCmd.AddCommand(statusCmd)
 
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"os"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ingestCmd represents the ingest command
+var ingestCmd = &cobra.Command{
+	Use:   "ingest",
+	Short: "ingests a CycloneDX or SPDX JSON SBOM",
+	Long: `ingests a CycloneDX or SPDX JSON SBOM as an event of an Event Receiver. The name,
+version and package of the event default to the artifact the SBOM describes.`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runIngestSBOM,
+}
+
+// runIngestSBOM ingests the SBOM, returns error
+func runIngestSBOM(_ *cobra.Command, _ []string) error {
+	document, err := os.ReadFile(viper.GetString("file"))
+	if err != nil {
+		return err
+	}
+
+	e := storage.Event{
+		EventReceiverID: graphql.ID(viper.GetString("event-receiver-id")),
+		Release:         viper.GetString("release"),
+		PlatformID:      viper.GetString("platform-id"),
+		Name:            viper.GetString("name"),
+		Version:         viper.GetString("version"),
+		Package:         viper.GetString("package"),
+		Description:     viper.GetString("description"),
+	}
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.IngestSBOM(document, e)
+	if err != nil {
+		return err
+	}
+
+	return printContent(content, noindent)
+}
+
+// NewIngestCmd creates a new command
+func NewIngestCmd() *cobra.Command {
+	ingestCmd.Flags().String("file", "", "Path to the CycloneDX or SPDX JSON SBOM")
+	ingestCmd.Flags().String("event-receiver-id", "", "ID of the Event Receiver to record the SBOM with")
+	ingestCmd.Flags().String("release", "", "Release of the artifact")
+	ingestCmd.Flags().String("platform-id", "", "Platform ID of the artifact")
+	ingestCmd.Flags().String("name", "", "Name of the artifact, defaults to the one in the SBOM")
+	ingestCmd.Flags().String("version", "", "Version of the artifact, defaults to the one in the SBOM")
+	ingestCmd.Flags().String("package", "", "Package of the artifact, defaults to the one in the SBOM")
+	ingestCmd.Flags().String("description", "", "Description of the event, defaults to the format of the SBOM")
+	ingestCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	ingestCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = ingestCmd.MarkFlagRequired("file")
+	_ = ingestCmd.MarkFlagRequired("event-receiver-id")
+	_ = ingestCmd.MarkFlagRequired("release")
+	_ = ingestCmd.MarkFlagRequired("platform-id")
+
+	return ingestCmd
+}
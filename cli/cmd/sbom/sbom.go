@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"fmt"
+
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+// sbomCmd represents the sbom command
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Ingest SBOMs and search their components",
+	Long:  `Ingest CycloneDX and SPDX SBOMs into the Event Provenance Registry Service and search their components`,
+}
+
+// NewSBOMCmd command for sbom
+func NewSBOMCmd() *cobra.Command {
+	ingestCmd := NewIngestCmd()
+	sbomCmd.AddCommand(ingestCmd)
+	searchCmd := NewSearchCmd()
+	sbomCmd.AddCommand(searchCmd)
+	return sbomCmd
+}
+
+func printContent(content string, noindent bool) error {
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err := common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
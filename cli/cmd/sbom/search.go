@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "searches the components of ingested SBOMs",
+	Long: `searches the components of ingested SBOMs. Each component comes with the event
+of the artifact that contains it, e.g. to find which artifacts contain log4j-core 2.14:
+
+  epr-cli sbom search --name log4j-core --version-prefix 2.14`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runSearchSBOM,
+}
+
+// runSearchSBOM searches the components, returns error
+func runSearchSBOM(_ *cobra.Command, _ []string) error {
+	filter := storage.SBOMComponentFilter{
+		Name:          viper.GetString("name"),
+		Group:         viper.GetString("group"),
+		Version:       viper.GetString("version"),
+		VersionPrefix: viper.GetString("version-prefix"),
+		PURLPrefix:    viper.GetString("purl-prefix"),
+	}
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.SearchSBOMComponents(filter)
+	if err != nil {
+		return err
+	}
+
+	return printContent(content, noindent)
+}
+
+// NewSearchCmd creates a new command
+func NewSearchCmd() *cobra.Command {
+	searchCmd.Flags().String("name", "", "Name of the component")
+	searchCmd.Flags().String("group", "", "Group of the component, e.g. the Maven group id")
+	searchCmd.Flags().String("version", "", "Exact version of the component")
+	searchCmd.Flags().String("version-prefix", "", "Prefix of the version of the component")
+	searchCmd.Flags().String("purl-prefix", "", "Prefix of the package URL of the component")
+	searchCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	searchCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	return searchCmd
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// createCmd represents the create command
+var createCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "creates a Webhook Subscription",
+	Long:    `creates a Webhook Subscription posting the messages that match every filter given to a url`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runCreateWebhookSubscription,
+}
+
+// runCreateWebhookSubscription creates the Webhook Subscription, returns error
+func runCreateWebhookSubscription(_ *cobra.Command, _ []string) error {
+	name := viper.GetString("name")
+	endpoint := viper.GetString("endpoint")
+	secret := viper.GetString("secret")
+	messageTypes := viper.GetStringSlice("message-types")
+	evrIDs := viper.GetStringSlice("event-receiver-ids")
+	groupIDs := viper.GetStringSlice("event-receiver-group-ids")
+	dryrun := viper.GetBool("dry-run")
+	noindent := viper.GetBool("no-indent")
+
+	subscription := &storage.WebhookSubscription{
+		Name:                  name,
+		URL:                   endpoint,
+		Secret:                secret,
+		MessageTypes:          messageTypes,
+		EventReceiverIDs:      []graphql.ID{},
+		EventReceiverGroupIDs: []graphql.ID{},
+	}
+	for _, id := range evrIDs {
+		subscription.EventReceiverIDs = append(subscription.EventReceiverIDs, graphql.ID(id))
+	}
+	for _, id := range groupIDs {
+		subscription.EventReceiverGroupIDs = append(subscription.EventReceiverGroupIDs, graphql.ID(id))
+	}
+
+	if dryrun {
+		content, err := json.MarshalIndent(subscription, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.CreateWebhookSubscription(subscription)
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewCreateCmd creates a new command
+func NewCreateCmd() *cobra.Command {
+	createCmd.Flags().String("name", "", "Name of the Webhook Subscription")
+	createCmd.Flags().String("endpoint", "", "URL the messages are posted to")
+	createCmd.Flags().String("secret", "", "Secret the deliveries are signed with, at least 16 characters")
+	createCmd.Flags().String("message-types", "", "Space delimited set of message types to deliver, all when empty")
+	createCmd.Flags().String("event-receiver-ids", "", "Space delimited set of receiver ids the messages must be about")
+	createCmd.Flags().String("event-receiver-group-ids", "", "Space delimited set of group ids the messages must be about")
+	createCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	createCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
+	createCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = createCmd.MarkFlagRequired("name")
+	_ = createCmd.MarkFlagRequired("endpoint")
+	_ = createCmd.MarkFlagRequired("secret")
+
+	return createCmd
+}
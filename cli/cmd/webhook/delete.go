@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:     "delete",
+	Short:   "deletes a Webhook Subscription",
+	Long:    `deletes a Webhook Subscription and its delivery log`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runDeleteWebhookSubscription,
+}
+
+// runDeleteWebhookSubscription deletes the Webhook Subscription, returns error
+func runDeleteWebhookSubscription(_ *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.DeleteWebhookSubscription(graphql.ID(id))
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewDeleteCmd creates a new command
+func NewDeleteCmd() *cobra.Command {
+	deleteCmd.Flags().String("id", "", "ID of the Webhook Subscription")
+	deleteCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	deleteCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = deleteCmd.MarkFlagRequired("id")
+
+	return deleteCmd
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// deliveriesCmd represents the deliveries command
+var deliveriesCmd = &cobra.Command{
+	Use:     "deliveries",
+	Short:   "lists the deliveries of a Webhook Subscription",
+	Long:    `lists the latest deliveries of a Webhook Subscription, newest first, with the log of their attempts`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runListWebhookDeliveries,
+}
+
+// runListWebhookDeliveries lists the deliveries of the Webhook Subscription, returns error
+func runListWebhookDeliveries(_ *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	state := viper.GetString("state")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.GetWebhookDeliveries(graphql.ID(id), state)
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewDeliveriesCmd creates a new command
+func NewDeliveriesCmd() *cobra.Command {
+	deliveriesCmd.Flags().String("id", "", "ID of the Webhook Subscription")
+	deliveriesCmd.Flags().String("state", "", "Only list the deliveries in this state: PENDING, SUCCEEDED or FAILED")
+	deliveriesCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	deliveriesCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = deliveriesCmd.MarkFlagRequired("id")
+
+	return deliveriesCmd
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// modifyCmd represents the modify command
+var modifyCmd = &cobra.Command{
+	Use:     "modify",
+	Short:   "modifies a Webhook Subscription",
+	Long:    `modifies the name, endpoint, secret or filters of a Webhook Subscription, enabling it resumes a paused subscription`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runModifyWebhookSubscription,
+}
+
+// runModifyWebhookSubscription modifies the Webhook Subscription, returns error
+func runModifyWebhookSubscription(cmd *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	disable := viper.GetBool("disable")
+	enable := viper.GetBool("enable")
+	dryrun := viper.GetBool("dry-run")
+	noindent := viper.GetBool("no-indent")
+
+	if enable && disable {
+		return fmt.Errorf("--enable and --disable cannot be used together")
+	}
+
+	update := storage.WebhookSubscriptionUpdate{}
+	if cmd.Flags().Changed("name") {
+		name := viper.GetString("name")
+		update.Name = &name
+	}
+	if cmd.Flags().Changed("endpoint") {
+		endpoint := viper.GetString("endpoint")
+		update.URL = &endpoint
+	}
+	if cmd.Flags().Changed("secret") {
+		secret := viper.GetString("secret")
+		update.Secret = &secret
+	}
+	if cmd.Flags().Changed("message-types") {
+		messageTypes := viper.GetStringSlice("message-types")
+		update.MessageTypes = &messageTypes
+	}
+	if cmd.Flags().Changed("event-receiver-ids") {
+		ids := toIDs(viper.GetStringSlice("event-receiver-ids"))
+		update.EventReceiverIDs = &ids
+	}
+	if cmd.Flags().Changed("event-receiver-group-ids") {
+		ids := toIDs(viper.GetStringSlice("event-receiver-group-ids"))
+		update.EventReceiverGroupIDs = &ids
+	}
+	if enable || disable {
+		update.Enabled = &enable
+	}
+
+	if dryrun {
+		content, err := json.MarshalIndent(update, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.UpdateWebhookSubscription(graphql.ID(id), update)
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+func toIDs(values []string) []graphql.ID {
+	ids := []graphql.ID{}
+	for _, v := range values {
+		ids = append(ids, graphql.ID(v))
+	}
+	return ids
+}
+
+// NewModifyCmd creates a new command
+func NewModifyCmd() *cobra.Command {
+	modifyCmd.Flags().String("id", "", "ID of the Webhook Subscription")
+	modifyCmd.Flags().Bool("disable", false, "Disable the Webhook Subscription")
+	modifyCmd.Flags().Bool("enable", false, "Enable the Webhook Subscription, resuming it when it is paused")
+	modifyCmd.Flags().String("name", "", "New name of the Webhook Subscription")
+	modifyCmd.Flags().String("endpoint", "", "New URL the messages are posted to")
+	modifyCmd.Flags().String("secret", "", "New secret the deliveries are signed with")
+	modifyCmd.Flags().String("message-types", "", "Space delimited set of message types replacing the filter, empty for all")
+	modifyCmd.Flags().String("event-receiver-ids", "", "Space delimited set of receiver ids replacing the filter")
+	modifyCmd.Flags().String("event-receiver-group-ids", "", "Space delimited set of group ids replacing the filter")
+	modifyCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	modifyCmd.Flags().Bool("dry-run", false, "do a dry run of the command")
+	modifyCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = modifyCmd.MarkFlagRequired("id")
+
+	return modifyCmd
+}
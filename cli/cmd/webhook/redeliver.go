@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// redeliverCmd represents the redeliver command
+var redeliverCmd = &cobra.Command{
+	Use:     "redeliver",
+	Short:   "redelivers a Webhook Delivery",
+	Long:    `posts a Webhook Delivery again with a fresh set of retries, whatever its state`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runRedeliverWebhookDelivery,
+}
+
+// runRedeliverWebhookDelivery redelivers the Webhook Delivery, returns error
+func runRedeliverWebhookDelivery(_ *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.RedeliverWebhookDelivery(graphql.ID(id))
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewRedeliverCmd creates a new command
+func NewRedeliverCmd() *cobra.Command {
+	redeliverCmd.Flags().String("id", "", "ID of the Webhook Delivery")
+	redeliverCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	redeliverCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	_ = redeliverCmd.MarkFlagRequired("id")
+
+	return redeliverCmd
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/cli/cmd/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:     "search",
+	Short:   "lists Webhook Subscriptions",
+	Long:    `lists every Webhook Subscription, or the one with the id`,
+	PreRunE: common.BindFlagsE,
+	RunE:    runSearchWebhookSubscriptions,
+}
+
+// runSearchWebhookSubscriptions lists the Webhook Subscriptions, returns error
+func runSearchWebhookSubscriptions(_ *cobra.Command, _ []string) error {
+	id := viper.GetString("id")
+	noindent := viper.GetBool("no-indent")
+
+	url := viper.GetString("url")
+	c, err := common.GetClient(url)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.GetWebhookSubscriptions(graphql.ID(id))
+	if err != nil {
+		return err
+	}
+
+	if noindent {
+		fmt.Printf("%s\n", content)
+		return nil
+	}
+
+	content, err = common.IndentJSON(content)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", content)
+	return nil
+}
+
+// NewSearchCmd creates a new command
+func NewSearchCmd() *cobra.Command {
+	searchCmd.Flags().String("id", "", "ID of the Webhook Subscription, all of them when empty")
+	searchCmd.Flags().String("url", "http://localhost:8042", "EPR base url")
+	searchCmd.Flags().Bool("no-indent", false, "do not indent the JSON output")
+
+	return searchCmd
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// webhookCmd represents the webhook command
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Create, Search, Modify or Delete Webhook Subscriptions and inspect their deliveries",
+	Long:  `Create, Search, Modify or Delete Webhook Subscriptions of the Event Provenance Registry Service, list their deliveries and redeliver them`,
+}
+
+// NewWebhookCmd create a new command
+func NewWebhookCmd() *cobra.Command {
+	searchCmd := NewSearchCmd()
+	webhookCmd.AddCommand(searchCmd)
+	createCmd := NewCreateCmd()
+	webhookCmd.AddCommand(createCmd)
+	modifyCmd := NewModifyCmd()
+	webhookCmd.AddCommand(modifyCmd)
+	deleteCmd := NewDeleteCmd()
+	webhookCmd.AddCommand(deleteCmd)
+	deliveriesCmd := NewDeliveriesCmd()
+	webhookCmd.AddCommand(deliveriesCmd)
+	redeliverCmd := NewRedeliverCmd()
+	webhookCmd.AddCommand(redeliverCmd)
+
+	return webhookCmd
+}
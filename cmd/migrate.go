@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newMigrateCmd returns the command for managing the database schema version
+func newMigrateCmd() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema version",
+		Long: `Apply, revert and inspect the versioned database schema
+	migrations embedded in the server binary.`,
+	}
+	migrateCmd.PersistentFlags().String("db", "postgres://localhost:5432", "database connection string")
+
+	upCmd := &cobra.Command{
+		Use:     "up",
+		Short:   "Apply all pending migrations",
+		PreRunE: preRun,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			migrator, err := newMigrator()
+			if err != nil {
+				return err
+			}
+			applied, err := migrator.Up(cmd.Context())
+			for _, migration := range applied {
+				fmt.Printf("applied %04d %s\n", migration.Version, migration.Name)
+			}
+			if err == nil && len(applied) == 0 {
+				fmt.Println("schema is up to date")
+			}
+			return err
+		},
+	}
+
+	downCmd := &cobra.Command{
+		Use:     "down",
+		Short:   "Revert the most recently applied migrations",
+		PreRunE: preRun,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			migrator, err := newMigrator()
+			if err != nil {
+				return err
+			}
+			reverted, err := migrator.Down(cmd.Context(), viper.GetInt("steps"))
+			for _, migration := range reverted {
+				fmt.Printf("reverted %04d %s\n", migration.Version, migration.Name)
+			}
+			return err
+		},
+	}
+	downCmd.Flags().Int("steps", 1, "number of migrations to revert")
+
+	statusCmd := &cobra.Command{
+		Use:     "status",
+		Short:   "List migrations and whether they have been applied",
+		PreRunE: preRun,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			migrator, err := newMigrator()
+			if err != nil {
+				return err
+			}
+			return printMigrationStatus(cmd.Context(), migrator)
+		},
+	}
+
+	migrateCmd.AddCommand(upCmd, downCmd, statusCmd)
+	return migrateCmd
+}
+
+func newMigrator() (*storage.Migrator, error) {
+	setupLogger()
+	dbhost, dbport, err := parseDB()
+	if err != nil {
+		return nil, err
+	}
+	dbConn, err := storage.New(dbhost, "postgres", "", "", "postgres", dbport)
+	if err != nil {
+		return nil, err
+	}
+	return dbConn.Migrator()
+}
+
+func printMigrationStatus(ctx context.Context, migrator *storage.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := migrator.Current(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\ndatabase version %d, binary version %d\n", current, migrator.Latest())
+	return nil
+}
@@ -24,7 +24,9 @@ import (
 	"github.com/sassoftware/event-provenance-registry/pkg/api"
 	"github.com/sassoftware/event-provenance-registry/pkg/config"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/sassoftware/event-provenance-registry/pkg/webhook"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
@@ -74,17 +76,7 @@ func run(_ *cobra.Command, _ []string) error {
 	cert := viper.GetString("tls-cert")
 	key := viper.GetString("tls-key")
 
-	dburl, err := url.Parse(viper.GetString("db"))
-	if err != nil {
-		return err
-	}
-
-	dbhost, dbportstr, err := net.SplitHostPort(dburl.Host)
-	if err != nil {
-		return err
-	}
-
-	dbport, err := strconv.Atoi(dbportstr)
+	dbhost, dbport, err := parseDB()
 	if err != nil {
 		return err
 	}
@@ -92,7 +84,18 @@ func run(_ *cobra.Command, _ []string) error {
 	cfg, err := config.New(
 		config.WithServer(host, port, "", true, true),
 		config.WithStorage(dbhost, "postgres", "", "", "postgres", dbport, 10, 10, 10),
-		config.WithKafka(false, "3.4.0", brokers, topic),
+		config.WithStorageDriver(viper.GetString("storage")),
+		config.WithStorageMaxPageSize(viper.GetInt("max-page-size")),
+		config.WithKafka(viper.GetBool("kafka-tls"), viper.GetString("kafka-version"), brokers, topic),
+		config.WithKafkaTLS(viper.GetString("kafka-ca-file"), viper.GetString("kafka-cert-file"), viper.GetString("kafka-key-file")),
+		config.WithKafkaSASL(viper.GetString("kafka-sasl-mechanism"), viper.GetString("kafka-sasl-user"), viper.GetString("kafka-sasl-password")),
+		config.WithKafkaMessages(viper.GetString("message-format"), viper.GetString("message-mode"), viper.GetBool("legacy-messages")),
+		config.WithKafkaOutbox(viper.GetDuration("outbox-interval"), viper.GetInt("outbox-batch-size")),
+		config.WithSinks(strings.Split(viper.GetString("sinks"), ",")),
+		config.WithNATSSink(viper.GetString("nats-url"), viper.GetString("nats-subject")),
+		config.WithWebhookSink(viper.GetString("webhook-url"), viper.GetDuration("webhook-timeout")),
+		config.WithFileSink(viper.GetString("message-file")),
+		config.WithWebhooks(viper.GetInt("webhook-max-attempts"), viper.GetInt("webhook-pause-after")),
 		// TODO: add this once auth have been turned on
 		// config.WithAuth(),
 	)
@@ -100,13 +103,14 @@ func run(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
-	dbConn, err := setupDatabase(cfg.Storage)
+	ctx, ccancel := context.WithCancel(context.Background())
+	defer ccancel()
+
+	dbConn, err := setupDatabase(ctx, cfg.Storage)
 	if err != nil {
 		return err
 	}
 
-	ctx, ccancel := context.WithCancel(context.Background())
-	defer ccancel()
 	interruptChan := make(chan os.Signal, 1)
 	signal.Notify(interruptChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -118,17 +122,37 @@ func run(_ *cobra.Command, _ []string) error {
 
 	errGroup, ctx := errgroup.WithContext(ctx)
 
-	producer, err := setupKafka(cfg.Kafka)
+	sink, err := setupSink(cfg)
 	if err != nil {
 		return err
 	}
 	errGroup.Go(func() error {
 		<-ctx.Done()
-		return producer.Close()
+		return sink.Close()
 	})
-	topicProducer := message.NewTopicProducer(producer, cfg.Kafka.Topic)
+	topicProducer, err := setupTopicProducer(sink, cfg.Kafka)
+	if err != nil {
+		return err
+	}
 
-	router, err := api.Initialize(dbConn, topicProducer, cfg.Server)
+	// messages are stored with the records they announce and relayed to the sinks from there, and
+	// to the webhook subscriptions they match
+	relay := message.NewRelay(dbConn, message.NewFanOut(topicProducer, webhook.NewDispatcher(dbConn)))
+	relay.Interval = cfg.Kafka.OutboxInterval
+	relay.BatchSize = cfg.Kafka.OutboxBatchSize
+	errGroup.Go(func() error {
+		return relay.Run(ctx)
+	})
+	deliverer := webhook.NewDeliverer(dbConn)
+	deliverer.MaxAttempts = cfg.Webhooks.MaxAttempts
+	deliverer.PauseAfter = cfg.Webhooks.PauseAfter
+	errGroup.Go(func() error {
+		return deliverer.Run(ctx)
+	})
+
+	// the API streams the messages it stores once they are committed
+	broker := pubsub.NewBroker(pubsub.DefaultHistorySize)
+	router, err := api.Initialize(dbConn, message.NewOutbox(dbConn).Notify(broker), broker, cfg.Server)
 	if err != nil {
 		return err
 	}
@@ -196,22 +220,82 @@ func run(_ *cobra.Command, _ []string) error {
 	return errGroup.Wait()
 }
 
-func setupDatabase(cfg *config.StorageConfig) (*storage.Database, error) {
-	dbConn, err := storage.New(cfg.Host, cfg.User, cfg.Pass, cfg.SSLMode, cfg.Name, cfg.Port)
+// parseDB splits the db flag into the host and port of the database
+func parseDB() (string, int, error) {
+	dburl, err := url.Parse(viper.GetString("db"))
 	if err != nil {
-		return nil, err
+		return "", 0, err
 	}
-	if err := dbConn.SyncSchema(); err != nil {
-		return nil, err
+
+	dbhost, dbportstr, err := net.SplitHostPort(dburl.Host)
+	if err != nil {
+		return "", 0, err
+	}
+
+	dbport, err := strconv.Atoi(dbportstr)
+	if err != nil {
+		return "", 0, err
+	}
+	return dbhost, dbport, nil
+}
+
+func setupDatabase(ctx context.Context, cfg *config.StorageConfig) (storage.Repository, error) {
+	switch cfg.Driver {
+	case storage.DriverMemory:
+		slog.Warn("using in-memory storage, records will be lost when the server stops")
+		memory := storage.NewMemory()
+		memory.MaxPageSize = cfg.MaxPageSize
+		return memory, nil
+	case storage.DriverPostgres:
+		dbConn, err := storage.New(cfg.Host, cfg.User, cfg.Pass, cfg.SSLMode, cfg.Name, cfg.Port)
+		if err != nil {
+			return nil, err
+		}
+		dbConn.MaxPageSize = cfg.MaxPageSize
+		migrator, err := dbConn.Migrator()
+		if err != nil {
+			return nil, err
+		}
+		// refuse to run against a schema written by a newer release
+		if err := migrator.Check(ctx); err != nil {
+			return nil, err
+		}
+		if viper.GetBool("auto-migrate") {
+			if _, err := migrator.Up(ctx); err != nil {
+				return nil, err
+			}
+			return dbConn, nil
+		}
+		current, err := migrator.Current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if current < migrator.Latest() {
+			return nil, fmt.Errorf("database schema version %d is older than %d, run 'epr-server migrate up'", current, migrator.Latest())
+		}
+		return dbConn, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", cfg.Driver)
 	}
-	return dbConn, nil
 }
 
 func setupKafka(cfg *config.KafkaConfig) (message.Producer, error) {
-	kafkaCfg, err := message.NewConfig(cfg.Version)
+	kafkaCfg, err := message.NewSecureConfig(cfg.Version, message.Security{
+		TLS:           cfg.TLS,
+		CAFile:        cfg.CAFile,
+		CertFile:      cfg.CertFile,
+		KeyFile:       cfg.KeyFile,
+		SASLMechanism: cfg.SASLMechanism,
+		SASLUser:      cfg.SASLUser,
+		SASLPassword:  cfg.SASLPassword,
+	})
 	if err != nil {
 		return nil, err
 	}
+	// fail on startup with the reason the brokers cannot be reached rather than on the first message
+	if err := message.CheckBrokers(cfg.Peers, kafkaCfg); err != nil {
+		return nil, err
+	}
 	kafkaProducer, err := message.NewProducer(cfg.Peers, kafkaCfg)
 	if err != nil {
 		return nil, err
@@ -221,6 +305,70 @@ func setupKafka(cfg *config.KafkaConfig) (message.Producer, error) {
 	return kafkaProducer, nil
 }
 
+// setupSink returns the sink of each configured backend, fanned out when there are several
+func setupSink(cfg *config.Config) (message.Sink, error) {
+	sinks := []message.Sink{}
+	for _, backend := range cfg.Sinks.Backends {
+		sink, err := newSink(backend, cfg)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return message.NewFanOutSink(sinks...), nil
+}
+
+func newSink(backend string, cfg *config.Config) (message.Sink, error) {
+	switch backend {
+	case message.SinkKafka:
+		producer, err := setupKafka(cfg.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		return message.NewKafkaSink(producer, cfg.Kafka.Topic), nil
+	case message.SinkNATS:
+		return message.NewNATSSink(cfg.Sinks.NATSURL, cfg.Sinks.NATSSubject)
+	case message.SinkWebhook:
+		return message.NewWebhookSink(cfg.Sinks.WebhookURL, cfg.Sinks.WebhookTimeout), nil
+	case message.SinkFile:
+		return message.NewFileSink(cfg.Sinks.FilePath)
+	default:
+		return nil, fmt.Errorf("unsupported sink %q", backend)
+	}
+}
+
+func setupTopicProducer(topicProducer message.TopicProducer, cfg *config.KafkaConfig) (message.TopicProducer, error) {
+	switch cfg.MessageMode {
+	case message.ModeStructured:
+	case message.ModeBinary:
+		topicProducer = message.NewBinaryProducer(topicProducer)
+	default:
+		return nil, fmt.Errorf("unsupported message mode %q", cfg.MessageMode)
+	}
+
+	if cfg.LegacyMessages {
+		if cfg.MessageFormat != message.FormatEPR || cfg.MessageMode != message.ModeStructured {
+			return nil, fmt.Errorf("legacy messages are only produced in the %s format and %s mode", message.FormatEPR, message.ModeStructured)
+		}
+		return message.NewLegacyProducer(topicProducer), nil
+	}
+
+	switch cfg.MessageFormat {
+	case message.FormatEPR:
+		return topicProducer, nil
+	case message.FormatCDEvents:
+		return message.NewCDEventsProducer(topicProducer), nil
+	default:
+		return nil, fmt.Errorf("unsupported message format %q", cfg.MessageFormat)
+	}
+}
+
 func setupLogger() {
 	opts := &slog.HandlerOptions{
 		Level: slog.LevelInfo,
@@ -256,13 +404,39 @@ func initConfig() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.AddCommand(newMigrateCmd())
+	rootCmd.AddCommand(newVerifyCmd())
 
 	// create two new flags, one for host and one for port
 	rootCmd.Flags().String("host", "localhost", "host to listen on")
 	rootCmd.Flags().String("port", "8042", "port to listen on")
+	rootCmd.Flags().String("sinks", message.SinkKafka, "backends to publish messages to separated by commas (kafka, nats, webhook or file)")
 	rootCmd.Flags().String("brokers", "localhost:9092", "broker uris separated by commas")
 	rootCmd.Flags().String("topic", "epr.dev.events", "topic to produce events on")
+	rootCmd.Flags().String("kafka-version", "3.4.0", "version of the Kafka protocol spoken to the brokers")
+	rootCmd.Flags().Bool("kafka-tls", false, "connect to the brokers over TLS")
+	rootCmd.Flags().String("kafka-ca-file", "", "PEM bundle the broker certificates are verified with (default is the system pool)")
+	rootCmd.Flags().String("kafka-cert-file", "", "PEM client certificate presented to the brokers for mutual TLS")
+	rootCmd.Flags().String("kafka-key-file", "", "PEM key of the client certificate")
+	rootCmd.Flags().String("kafka-sasl-mechanism", "", "SASL mechanism to authenticate to the brokers with (PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512)")
+	rootCmd.Flags().String("kafka-sasl-user", "", "SASL user")
+	rootCmd.Flags().String("kafka-sasl-password", "", "SASL password, prefer the EPR_KAFKA_SASL_PASSWORD environment variable")
+	rootCmd.Flags().String("nats-url", "nats://localhost:4222", "url of the nats server of the nats sink")
+	rootCmd.Flags().String("nats-subject", "epr.dev.events", "JetStream subject to publish messages on")
+	rootCmd.Flags().String("webhook-url", "", "url the webhook sink posts messages to")
+	rootCmd.Flags().Duration("webhook-timeout", 10*time.Second, "time the webhook sink waits for a response")
+	rootCmd.Flags().String("message-file", "", "file the file sink appends messages to")
+	rootCmd.Flags().String("message-format", message.FormatEPR, "format of the produced messages (epr or cdevents)")
+	rootCmd.Flags().String("message-mode", message.ModeStructured, "CloudEvents content mode of the produced messages (structured or binary)")
+	rootCmd.Flags().Bool("legacy-messages", false, "produce messages in the format that predates CloudEvents compliance")
+	rootCmd.Flags().Duration("outbox-interval", message.DefaultRelayInterval, "time between two polls of the message outbox")
+	rootCmd.Flags().Int("outbox-batch-size", message.DefaultRelayBatchSize, "maximum number of outbox messages published per poll")
+	rootCmd.Flags().Int("webhook-max-attempts", webhook.DefaultMaxAttempts, "number of attempts after which a delivery to a webhook subscription fails")
+	rootCmd.Flags().Int("webhook-pause-after", webhook.DefaultPauseAfter, "number of failed deliveries in a row after which a webhook subscription is paused")
 	rootCmd.Flags().String("db", "postgres://localhost:5432", "database connection string")
+	rootCmd.Flags().String("storage", storage.DriverPostgres, "storage backend to use (postgres or memory)")
+	rootCmd.Flags().Int("max-page-size", storage.DefaultMaxPageSize, "maximum number of records a search returns per page")
+	rootCmd.Flags().Bool("auto-migrate", true, "apply pending database migrations on startup")
 	rootCmd.Flags().String("tls-cert", "", "Path to the cert for the server")
 	rootCmd.Flags().String("tls-key", "", "Path to the server key")
 	rootCmd.Flags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/epr/epr.yaml)")
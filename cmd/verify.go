@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newVerifyCmd returns the command for verifying the event hash chains
+func newVerifyCmd() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the hash chains of the stored events",
+		Long: `Walk the hash chain of the events of every receiver, or of a single
+	receiver, and report the first broken link of each. The command fails when
+	any chain is broken. Keep the printed heads to detect events removed from
+	the end of a chain on a later run.`,
+		PreRunE: preRun,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			setupLogger()
+			dbhost, dbport, err := parseDB()
+			if err != nil {
+				return err
+			}
+			dbConn, err := storage.New(dbhost, "postgres", "", "", "postgres", dbport)
+			if err != nil {
+				return err
+			}
+			verifications, err := epr.VerifyEventChains(dbConn, graphql.ID(viper.GetString("event-receiver-id")))
+			if err != nil {
+				return err
+			}
+			return printChainVerifications(verifications)
+		},
+	}
+	verifyCmd.Flags().String("db", "postgres://localhost:5432", "database connection string")
+	verifyCmd.Flags().String("event-receiver-id", "", "only verify the chain of this receiver")
+	return verifyCmd
+}
+
+func printChainVerifications(verifications []epr.ChainVerification) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RECEIVER\tEVENTS\tHEAD\tSTATUS")
+	broken := 0
+	for _, v := range verifications {
+		status := "ok"
+		if v.Broken != nil {
+			broken++
+			status = fmt.Sprintf("broken at event %s (sequence %d): %s", v.Broken.EventID, v.Broken.Sequence, v.Broken.Reason)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", v.EventReceiverID, v.Events, v.Head, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if broken > 0 {
+		return fmt.Errorf("%d of %d event chains are broken", broken, len(verifications))
+	}
+	return nil
+}
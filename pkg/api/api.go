@@ -17,17 +17,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sassoftware/event-provenance-registry/pkg/config"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/status"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 // Initialize starts the database, kafka message producer, middleware, and endpoints
-func Initialize(db *storage.Database, msgProducer message.TopicProducer, cfg *config.ServerConfig) (*chi.Mux, error) {
+func Initialize(db storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker, cfg *config.ServerConfig) (*chi.Mux, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("no config provided")
 	}
 
-	s, err := New(db, msgProducer)
+	s, err := New(db, msgProducer, broker)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -50,10 +51,10 @@ func Initialize(db *storage.Database, msgProducer message.TopicProducer, cfg *co
 
 	crs := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
-		ExposedHeaders:   []string{"X-Total-Count", "X-Last-Page"},
+		ExposedHeaders:   []string{"X-Total-Count", "X-Last-Page", "Link"},
 		MaxAge:           300,
 		Debug:            false,
 	})
@@ -75,22 +76,53 @@ func Initialize(db *storage.Database, msgProducer message.TopicProducer, cfg *co
 			r.Get("/openapi", s.Rest.ServeOpenAPIDoc(cfg.ResourceDir))
 			// REST endpoints
 			r.Route("/events", func(r chi.Router) {
+				r.Get("/", s.Rest.ListEvents())
 				r.Post("/", s.Rest.CreateEvent())
 				r.Route("/{eventID}", func(r chi.Router) {
 					r.Get("/", s.Rest.GetEventByID())
+					r.Post("/revoke", s.Rest.RevokeEvent())
 				})
 			})
 			r.Route("/receivers", func(r chi.Router) {
+				r.Get("/", s.Rest.ListReceivers())
 				r.Post("/", s.Rest.CreateReceiver())
 				r.Route("/{receiverID}", func(r chi.Router) {
 					r.Get("/", s.Rest.GetReceiverByID())
+					r.Get("/schemas", s.Rest.GetReceiverSchemas())
+					r.Post("/schemas", s.Rest.CreateReceiverSchema())
+					r.Put("/signing", s.Rest.SetReceiverSigning())
 				})
 			})
 			r.Route("/groups", func(r chi.Router) {
+				r.Get("/", s.Rest.ListGroups())
 				r.Post("/", s.Rest.CreateGroup())
 				r.Route("/{groupID}", func(r chi.Router) {
 					r.Get("/", s.Rest.GetGroupByID())
 					r.Patch("/", s.Rest.UpdateGroup())
+					r.Delete("/", s.Rest.DeleteGroup())
+					r.Get("/status", s.Rest.GetGroupStatus())
+					r.Get("/states", s.Rest.GetGroupStates())
+					r.Get("/evaluation", s.Rest.GetGroupEvaluation())
+					r.Post("/reevaluate", s.Rest.ReevaluateGroup())
+				})
+			})
+			r.Get("/provenance", s.Rest.GetProvenance())
+			r.Route("/sboms", func(r chi.Router) {
+				r.Post("/", s.Rest.IngestSBOM())
+				r.Get("/components", s.Rest.ListSBOMComponents())
+			})
+			r.Post("/cdevents", s.Rest.IngestCDEvent())
+			r.Get("/outbox/stuck", s.Rest.ListStuckOutboxMessages())
+			r.Get("/stream", s.Rest.Stream())
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", s.Rest.ListWebhookSubscriptions())
+				r.Post("/", s.Rest.CreateWebhookSubscription())
+				r.Post("/deliveries/{deliveryID}/redeliver", s.Rest.RedeliverWebhookDelivery())
+				r.Route("/{subscriptionID}", func(r chi.Router) {
+					r.Get("/", s.Rest.GetWebhookSubscriptionByID())
+					r.Patch("/", s.Rest.UpdateWebhookSubscription())
+					r.Delete("/", s.Rest.DeleteWebhookSubscription())
+					r.Get("/deliveries", s.Rest.ListWebhookDeliveries())
 				})
 			})
 		})
@@ -121,6 +153,8 @@ func Initialize(db *storage.Database, msgProducer message.TopicProducer, cfg *co
 		r.Use(crs.Handler)
 		r.Get("/", s.GraphQL.ServerGraphQLDoc())
 		r.Post("/query", s.GraphQL.GraphQLHandler())
+		// subscriptions upgrade to a websocket
+		r.Get("/query", s.GraphQL.SubscriptionHandler())
 	})
 
 	// Public Api Endpoints
@@ -4,21 +4,25 @@ import (
 	_ "embed"
 	"net/http"
 
+	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 type Server struct {
-	DBConnector *storage.Database
+	DBConnector storage.Repository
 	msgProducer message.TopicProducer
+	schema      *graphql.Schema
 }
 
-func New(conn *storage.Database, msgProducer message.TopicProducer) *Server {
+func New(conn storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker) *Server {
 	return &Server{
 		DBConnector: conn,
 		msgProducer: msgProducer,
+		schema:      schema.New(conn, msgProducer, broker),
 	}
 }
 
@@ -32,6 +36,6 @@ func (s *Server) ServerGraphQLDoc() http.HandlerFunc {
 }
 
 func (s *Server) GraphQLHandler() http.HandlerFunc {
-	handler := &relay.Handler{Schema: schema.New(s.DBConnector, s.msgProducer)}
+	handler := &relay.Handler{Schema: s.schema}
 	return handler.ServeHTTP
 }
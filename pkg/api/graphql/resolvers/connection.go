@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvers
+
+import (
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// PageArgs are the relay style pagination arguments shared by the connection queries
+type PageArgs struct {
+	First  *int32
+	After  *string
+	Last   *int32
+	Before *string
+	Order  *string
+}
+
+func (p PageArgs) toPage() storage.Page {
+	page := storage.Page{}
+	if p.First != nil {
+		first := int(*p.First)
+		page.First = &first
+	}
+	if p.Last != nil {
+		last := int(*p.Last)
+		page.Last = &last
+	}
+	if p.After != nil {
+		page.After = *p.After
+	}
+	if p.Before != nil {
+		page.Before = *p.Before
+	}
+	if p.Order != nil {
+		page.Order = *p.Order
+	}
+	return page
+}
+
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+type Edge[T any] struct {
+	Cursor string
+	Node   T
+}
+
+// Connection is a page of results in the shape of a relay connection
+type Connection[T any] struct {
+	Edges      []Edge[T]
+	PageInfo   PageInfo
+	TotalCount int32
+}
+
+func newConnection[T any](page *storage.Paginated[T], id func(T) graphql.ID) *Connection[T] {
+	conn := &Connection[T]{
+		Edges: make([]Edge[T], 0, len(page.Items)),
+		PageInfo: PageInfo{
+			HasNextPage:     page.PageInfo.HasNextPage,
+			HasPreviousPage: page.PageInfo.HasPreviousPage,
+		},
+		TotalCount: int32(page.PageInfo.TotalCount),
+	}
+	if page.PageInfo.StartCursor != "" {
+		conn.PageInfo.StartCursor = &page.PageInfo.StartCursor
+		conn.PageInfo.EndCursor = &page.PageInfo.EndCursor
+	}
+	for _, item := range page.Items {
+		conn.Edges = append(conn.Edges, Edge[T]{Cursor: storage.EncodeCursor(id(item)), Node: item})
+	}
+	return conn
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvers
+
+import (
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// GateResult is the graphql shape of a gate.Result
+type GateResult struct {
+	Passed  bool
+	Policy  storage.GatePolicy
+	Summary string
+	Reasons []GateReason
+}
+
+// GateReason is the graphql shape of a gate.Reason, empty values become null
+type GateReason struct {
+	EventReceiverID graphql.ID
+	EventID         *graphql.ID
+	Satisfied       bool
+	Optional        bool
+	Condition       *string
+	Error           *string
+	Message         string
+}
+
+func newGateResult(result gate.Result) GateResult {
+	reasons := make([]GateReason, 0, len(result.Reasons))
+	for _, reason := range result.Reasons {
+		r := GateReason{
+			EventReceiverID: reason.EventReceiverID,
+			Satisfied:       reason.Satisfied,
+			Optional:        reason.Optional,
+			Message:         reason.Message,
+		}
+		if reason.EventID != "" {
+			r.EventID = &reason.EventID
+		}
+		if reason.Condition != "" {
+			r.Condition = &reason.Condition
+		}
+		if reason.Error != "" {
+			r.Error = &reason.Error
+		}
+		reasons = append(reasons, r)
+	}
+	return GateResult{
+		Passed:  result.Passed,
+		Policy:  result.Policy,
+		Summary: result.Summary,
+		Reasons: reasons,
+	}
+}
+
+// Reevaluation is the graphql shape of an epr.Reevaluation
+type Reevaluation struct {
+	Name          string
+	Version       string
+	Release       string
+	PlatformID    string
+	Package       string
+	PreviousState string
+	State         string
+	MessageType   *string
+	Result        GateResult
+}
+
+func newReevaluation(reevaluation epr.Reevaluation) Reevaluation {
+	r := Reevaluation{
+		Name:          reevaluation.Name,
+		Version:       reevaluation.Version,
+		Release:       reevaluation.Release,
+		PlatformID:    reevaluation.PlatformID,
+		Package:       reevaluation.Package,
+		PreviousState: reevaluation.PreviousState,
+		State:         reevaluation.State,
+		Result:        newGateResult(reevaluation.Result),
+	}
+	if reevaluation.MessageType != "" {
+		r.MessageType = &reevaluation.MessageType
+	}
+	return r
+}
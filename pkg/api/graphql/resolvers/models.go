@@ -4,7 +4,14 @@
 package resolvers
 
 import (
+	"time"
+
 	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 type FindEventInput struct {
@@ -16,6 +23,29 @@ type FindEventInput struct {
 	Package         graphql.NullString
 	Success         graphql.NullBool
 	EventReceiverID *graphql.ID
+
+	NamePrefix        graphql.NullString
+	NameRegex         graphql.NullString
+	NameIn            *[]string
+	VersionPrefix     graphql.NullString
+	VersionRegex      graphql.NullString
+	VersionIn         *[]string
+	ReleasePrefix     graphql.NullString
+	ReleaseRegex      graphql.NullString
+	ReleaseIn         *[]string
+	PlatformIDIn      *[]string
+	PackageIn         *[]string
+	EventReceiverIDIn *[]graphql.ID
+	CreatedAfter      *types.Time
+	CreatedBefore     *types.Time
+	PayloadContains   *types.JSON
+	PayloadPath       *[]PayloadPathInput
+}
+
+type PayloadPathInput struct {
+	Path   string
+	Equals *string
+	Prefix *string
 }
 
 func (f FindEventInput) toMap() map[string]any {
@@ -47,11 +77,57 @@ func (f FindEventInput) toMap() map[string]any {
 	return m
 }
 
+func (f FindEventInput) toFilter() storage.EventFilter {
+	filter := storage.EventFilter{
+		Fields:     f.toMap(),
+		Name:       stringFilter(f.NamePrefix, f.NameRegex, f.NameIn),
+		Version:    stringFilter(f.VersionPrefix, f.VersionRegex, f.VersionIn),
+		Release:    stringFilter(f.ReleasePrefix, f.ReleaseRegex, f.ReleaseIn),
+		PlatformID: stringFilter(graphql.NullString{}, graphql.NullString{}, f.PlatformIDIn),
+		Package:    stringFilter(graphql.NullString{}, graphql.NullString{}, f.PackageIn),
+	}
+	if f.EventReceiverIDIn != nil {
+		filter.EventReceiverIDs = *f.EventReceiverIDIn
+	}
+	if f.CreatedAfter != nil {
+		after := time.Time(f.CreatedAfter.Date)
+		filter.CreatedAfter = &after
+	}
+	if f.CreatedBefore != nil {
+		before := time.Time(f.CreatedBefore.Date)
+		filter.CreatedBefore = &before
+	}
+	if f.PayloadContains != nil {
+		filter.PayloadContains = f.PayloadContains.JSON
+	}
+	if f.PayloadPath != nil {
+		for _, p := range *f.PayloadPath {
+			filter.PayloadPaths = append(filter.PayloadPaths, storage.PayloadPath(p))
+		}
+	}
+	return filter
+}
+
+func stringFilter(prefix, regex graphql.NullString, in *[]string) storage.StringFilter {
+	sf := storage.StringFilter{}
+	if prefix.Set && prefix.Value != nil {
+		sf.Prefix = *prefix.Value
+	}
+	if regex.Set && regex.Value != nil {
+		sf.Regex = *regex.Value
+	}
+	if in != nil {
+		sf.In = *in
+	}
+	return sf
+}
+
 type FindEventReceiverInput struct {
-	ID      *graphql.ID
-	Name    graphql.NullString
-	Type    graphql.NullString
-	Version graphql.NullString
+	ID          *graphql.ID
+	Name        graphql.NullString
+	Type        graphql.NullString
+	Version     graphql.NullString
+	Fingerprint graphql.NullString
 }
 
 func (f FindEventReceiverInput) toMap() map[string]any {
@@ -68,6 +144,9 @@ func (f FindEventReceiverInput) toMap() map[string]any {
 	if f.Version.Set {
 		m["version"] = f.Version.Value
 	}
+	if f.Fingerprint.Set {
+		m["fingerprint"] = f.Fingerprint.Value
+	}
 	return m
 }
 
@@ -94,3 +173,243 @@ func (f FindEventReceiverGroupInput) toMap() map[string]any {
 	}
 	return m
 }
+
+// CreateEventReceiverInput is the graphql input of an epr.EventReceiverInput. The signing fields
+// are nullable so that inputs passed as variables can leave them out.
+type CreateEventReceiverInput struct {
+	Name             string
+	Type             string
+	Version          string
+	Description      string
+	Schema           types.JSON
+	PublicKeys       *[]string
+	RequireSignature *bool
+}
+
+func (i CreateEventReceiverInput) toInput() epr.EventReceiverInput {
+	input := epr.EventReceiverInput{
+		Name:        i.Name,
+		Type:        i.Type,
+		Version:     i.Version,
+		Description: i.Description,
+		Schema:      i.Schema,
+	}
+	if i.PublicKeys != nil {
+		input.PublicKeys = *i.PublicKeys
+	}
+	if i.RequireSignature != nil {
+		input.RequireSignature = *i.RequireSignature
+	}
+	return input
+}
+
+// CreateEventReceiverGroupInput is the graphql input of an epr.EventReceiverGroupInput. The
+// conditions are nullable so that inputs passed as variables can leave them out.
+type CreateEventReceiverGroupInput struct {
+	Name             string
+	Type             string
+	Version          string
+	Description      string
+	Enabled          bool
+	EventReceiverIDs []graphql.ID
+	Policy           *GatePolicyInput
+	Conditions       *[]storage.EventReceiverCondition
+}
+
+func (i CreateEventReceiverGroupInput) toInput() epr.EventReceiverGroupInput {
+	input := epr.EventReceiverGroupInput{
+		Name:             i.Name,
+		Type:             i.Type,
+		Version:          i.Version,
+		Description:      i.Description,
+		Enabled:          i.Enabled,
+		EventReceiverIDs: i.EventReceiverIDs,
+		Policy:           i.Policy.toPolicy(),
+	}
+	if i.Conditions != nil {
+		input.Conditions = *i.Conditions
+	}
+	return input
+}
+
+// CreateWebhookSubscriptionInput is the graphql input of an epr.WebhookSubscriptionInput. The
+// filters are nullable so that inputs passed as variables can leave them out.
+type CreateWebhookSubscriptionInput struct {
+	Name                  string
+	URL                   string
+	Secret                string
+	MessageTypes          *[]string
+	EventReceiverIDs      *[]graphql.ID
+	EventReceiverGroupIDs *[]graphql.ID
+}
+
+func (i CreateWebhookSubscriptionInput) toInput() epr.WebhookSubscriptionInput {
+	input := epr.WebhookSubscriptionInput{
+		Name:   i.Name,
+		URL:    i.URL,
+		Secret: i.Secret,
+	}
+	if i.MessageTypes != nil {
+		input.MessageTypes = *i.MessageTypes
+	}
+	if i.EventReceiverIDs != nil {
+		input.EventReceiverIDs = *i.EventReceiverIDs
+	}
+	if i.EventReceiverGroupIDs != nil {
+		input.EventReceiverGroupIDs = *i.EventReceiverGroupIDs
+	}
+	return input
+}
+
+// GatePolicyInput is the graphql input of a storage.GatePolicy
+type GatePolicyInput struct {
+	Type                     string
+	N                        *int32
+	OptionalEventReceiverIDs *[]graphql.ID
+}
+
+func (i *GatePolicyInput) toPolicy() *storage.GatePolicy {
+	if i == nil {
+		return nil
+	}
+	policy := &storage.GatePolicy{Type: i.Type, N: i.N}
+	if i.OptionalEventReceiverIDs != nil {
+		policy.OptionalEventReceiverIDs = *i.OptionalEventReceiverIDs
+	}
+	return policy
+}
+
+// UpdateEventReceiverGroupInput is the graphql input of a storage.EventReceiverGroupUpdate
+type UpdateEventReceiverGroupInput struct {
+	Description            *string
+	Enabled                *bool
+	Policy                 *GatePolicyInput
+	AddEventReceivers      *[]EventReceiverMembershipInput
+	RemoveEventReceiverIDs *[]graphql.ID
+}
+
+// EventReceiverMembershipInput is the graphql input of a storage.EventReceiverMembership
+type EventReceiverMembershipInput struct {
+	EventReceiverID graphql.ID
+	Condition       *string
+	Optional        *bool
+}
+
+func (i UpdateEventReceiverGroupInput) toUpdate() storage.EventReceiverGroupUpdate {
+	update := storage.EventReceiverGroupUpdate{
+		Description: i.Description,
+		Enabled:     i.Enabled,
+		Policy:      i.Policy.toPolicy(),
+	}
+	if i.AddEventReceivers != nil {
+		for _, m := range *i.AddEventReceivers {
+			membership := storage.EventReceiverMembership{EventReceiverID: m.EventReceiverID}
+			if m.Condition != nil {
+				membership.Condition = *m.Condition
+			}
+			if m.Optional != nil {
+				membership.Optional = *m.Optional
+			}
+			update.AddEventReceivers = append(update.AddEventReceivers, membership)
+		}
+	}
+	if i.RemoveEventReceiverIDs != nil {
+		update.RemoveEventReceiverIDs = *i.RemoveEventReceiverIDs
+	}
+	return update
+}
+
+// IngestSBOMInput is the graphql input of an epr.SBOMInput
+type IngestSBOMInput struct {
+	EventReceiverID graphql.ID
+	Release         string
+	PlatformID      string
+	Name            *string
+	Version         *string
+	Package         *string
+	Description     *string
+	SBOM            types.JSON
+}
+
+func (i IngestSBOMInput) toInput() epr.SBOMInput {
+	input := epr.SBOMInput{
+		EventReceiverID: i.EventReceiverID,
+		Release:         i.Release,
+		PlatformID:      i.PlatformID,
+		SBOM:            i.SBOM,
+	}
+	for _, field := range []struct {
+		from *string
+		to   *string
+	}{
+		{i.Name, &input.Name},
+		{i.Version, &input.Version},
+		{i.Package, &input.Package},
+		{i.Description, &input.Description},
+	} {
+		if field.from != nil {
+			*field.to = *field.from
+		}
+	}
+	return input
+}
+
+// IngestCDEventInput is the graphql input of an epr.CDEventInput
+type IngestCDEventInput struct {
+	Name        *string
+	Version     *string
+	Release     *string
+	PlatformID  *string
+	Package     *string
+	Description *string
+	CloudEvent  types.JSON
+}
+
+func (i IngestCDEventInput) toInput() (epr.CDEventInput, error) {
+	cloudEvent, err := cdevents.ParseCloudEvent(i.CloudEvent.JSON)
+	if err != nil {
+		return epr.CDEventInput{}, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	input := epr.CDEventInput{CloudEvent: cloudEvent}
+	for _, field := range []struct {
+		from *string
+		to   *string
+	}{
+		{i.Name, &input.Name},
+		{i.Version, &input.Version},
+		{i.Release, &input.Release},
+		{i.PlatformID, &input.PlatformID},
+		{i.Package, &input.Package},
+		{i.Description, &input.Description},
+	} {
+		if field.from != nil {
+			*field.to = *field.from
+		}
+	}
+	return input, nil
+}
+
+// FindSBOMComponentInput is the graphql input of a storage.SBOMComponentFilter
+type FindSBOMComponentInput struct {
+	Name          *string
+	Group         *string
+	Version       *string
+	VersionPrefix *string
+	PURLPrefix    *string
+}
+
+func (f FindSBOMComponentInput) toFilter() storage.SBOMComponentFilter {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return storage.SBOMComponentFilter{
+		Name:          value(f.Name),
+		Group:         value(f.Group),
+		Version:       value(f.Version),
+		VersionPrefix: value(f.VersionPrefix),
+		PURLPrefix:    value(f.PURLPrefix),
+	}
+}
@@ -16,7 +16,7 @@ import (
 // is used to establish a connection to a database and perform various database operations such as
 // querying and modifying data.
 type MutationResolver struct {
-	Connection  *storage.Database
+	Connection  storage.Repository
 	msgProducer message.TopicProducer
 }
 
@@ -28,16 +28,88 @@ func (r *MutationResolver) CreateEvent(args struct{ Event epr.EventInput }) (gra
 	return event.ID, nil
 }
 
-func (r *MutationResolver) CreateEventReceiver(args struct{ EventReceiver epr.EventReceiverInput }) (graphql.ID, error) {
-	eventReceiver, err := epr.CreateEventReceiver(r.msgProducer, r.Connection, args.EventReceiver)
+// RevokeEvent revokes an event and re-evaluates the groups it counted towards
+func (r *MutationResolver) RevokeEvent(args struct {
+	ID         graphql.ID
+	Revocation epr.RevocationInput
+}) (graphql.ID, error) {
+	event, err := epr.RevokeEvent(r.msgProducer, r.Connection, args.ID, args.Revocation)
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return event.ID, nil
+}
+
+// IngestSBOM creates a successful event carrying the SBOM and stores its components
+func (r *MutationResolver) IngestSBOM(args struct{ SBOM IngestSBOMInput }) (graphql.ID, error) {
+	event, err := epr.IngestSBOM(r.msgProducer, r.Connection, args.SBOM.toInput())
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return event.ID, nil
+}
+
+// IngestCDEvent records the CDEvent as an event of the oldest receiver of its type
+func (r *MutationResolver) IngestCDEvent(args struct{ CDEvent IngestCDEventInput }) (graphql.ID, error) {
+	input, err := args.CDEvent.toInput()
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	event, err := epr.IngestCDEvent(r.msgProducer, r.Connection, input)
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return event.ID, nil
+}
+
+func (r *MutationResolver) CreateEventReceiver(args struct {
+	EventReceiver CreateEventReceiverInput
+	GetOrCreate   bool
+}) (graphql.ID, error) {
+	create := epr.CreateEventReceiver
+	if args.GetOrCreate {
+		create = epr.GetOrCreateEventReceiver
+	}
+	eventReceiver, err := create(r.msgProducer, r.Connection, args.EventReceiver.toInput())
 	if err != nil {
 		return "", eprErrors.SanitizeError(err)
 	}
 	return eventReceiver.ID, nil
 }
 
-func (r *MutationResolver) CreateEventReceiverGroup(args struct{ EventReceiverGroup epr.EventReceiverGroupInput }) (graphql.ID, error) {
-	eventReceiverGroup, err := epr.CreateEventReceiverGroup(r.msgProducer, r.Connection, args.EventReceiverGroup)
+// CreateEventReceiverSchema registers a new schema revision for a receiver and returns the revision
+func (r *MutationResolver) CreateEventReceiverSchema(args struct {
+	ID                  graphql.ID
+	EventReceiverSchema epr.EventReceiverSchemaInput
+}) (int32, error) {
+	schema, err := epr.CreateEventReceiverSchema(r.msgProducer, r.Connection, args.ID, args.EventReceiverSchema)
+	if err != nil {
+		return 0, eprErrors.SanitizeError(err)
+	}
+	return schema.Revision, nil
+}
+
+// SetEventReceiverSigning replaces the keys trusted to sign the events of a receiver
+func (r *MutationResolver) SetEventReceiverSigning(args struct {
+	ID      graphql.ID
+	Signing epr.EventReceiverSigningInput
+}) (graphql.ID, error) {
+	receiver, err := epr.SetEventReceiverSigning(r.msgProducer, r.Connection, args.ID, args.Signing)
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return receiver.ID, nil
+}
+
+func (r *MutationResolver) CreateEventReceiverGroup(args struct {
+	EventReceiverGroup CreateEventReceiverGroupInput
+	GetOrCreate        bool
+}) (graphql.ID, error) {
+	create := epr.CreateEventReceiverGroup
+	if args.GetOrCreate {
+		create = epr.GetOrCreateEventReceiverGroup
+	}
+	eventReceiverGroup, err := create(r.msgProducer, r.Connection, args.EventReceiverGroup.toInput())
 	if err != nil {
 		return "", eprErrors.SanitizeError(err)
 	}
@@ -45,7 +117,7 @@ func (r *MutationResolver) CreateEventReceiverGroup(args struct{ EventReceiverGr
 }
 
 func (r *MutationResolver) SetEventReceiverGroupEnabled(args struct{ ID graphql.ID }) (graphql.ID, error) {
-	err := storage.SetEventReceiverGroupEnabled(r.Connection.Client, args.ID, true)
+	err := epr.SetEventReceiverGroupEnabled(r.msgProducer, r.Connection, args.ID, true)
 	if err != nil {
 		slog.Error("error setting event receiver group enabled", "error", err, "id", args.ID)
 		return "", eprErrors.SanitizeError(err)
@@ -55,7 +127,7 @@ func (r *MutationResolver) SetEventReceiverGroupEnabled(args struct{ ID graphql.
 }
 
 func (r *MutationResolver) SetEventReceiverGroupDisabled(args struct{ ID graphql.ID }) (graphql.ID, error) {
-	err := storage.SetEventReceiverGroupEnabled(r.Connection.Client, args.ID, false)
+	err := epr.SetEventReceiverGroupEnabled(r.msgProducer, r.Connection, args.ID, false)
 	if err != nil {
 		slog.Error("error setting event receiver group disabled", "error", err, "id", args.ID)
 		return "", eprErrors.SanitizeError(err)
@@ -63,3 +135,82 @@ func (r *MutationResolver) SetEventReceiverGroupDisabled(args struct{ ID graphql
 	slog.Info("updated", "eventReceiverGroupDisabled", args.ID)
 	return args.ID, nil
 }
+
+// UpdateEventReceiverGroup changes the description, enabled flag, policy and receivers of a group
+func (r *MutationResolver) UpdateEventReceiverGroup(args struct {
+	ID                 graphql.ID
+	EventReceiverGroup UpdateEventReceiverGroupInput
+}) (graphql.ID, error) {
+	_, err := epr.UpdateEventReceiverGroup(r.msgProducer, r.Connection, args.ID, args.EventReceiverGroup.toUpdate())
+	if err != nil {
+		slog.Error("error updating event receiver group", "error", err, "id", args.ID)
+		return "", eprErrors.SanitizeError(err)
+	}
+	return args.ID, nil
+}
+
+// DeleteEventReceiverGroup soft deletes a group
+func (r *MutationResolver) DeleteEventReceiverGroup(args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if err := epr.DeleteEventReceiverGroup(r.msgProducer, r.Connection, args.ID); err != nil {
+		slog.Error("error deleting event receiver group", "error", err, "id", args.ID)
+		return "", eprErrors.SanitizeError(err)
+	}
+	return args.ID, nil
+}
+
+// ReevaluateEventReceiverGroup evaluates the group against the artifacts its receivers have
+// events for and sends the messages of the artifacts whose state changed. A dry run only
+// reports them.
+func (r *MutationResolver) ReevaluateEventReceiverGroup(args struct {
+	ID     graphql.ID
+	DryRun bool
+}) ([]Reevaluation, error) {
+	reevaluations, err := epr.ReevaluateEventReceiverGroup(r.msgProducer, r.Connection, args.ID, args.DryRun)
+	if err != nil {
+		slog.Error("error reevaluating event receiver group", "error", err, "id", args.ID)
+		return nil, eprErrors.SanitizeError(err)
+	}
+	results := make([]Reevaluation, 0, len(reevaluations))
+	for _, reevaluation := range reevaluations {
+		results = append(results, newReevaluation(reevaluation))
+	}
+	return results, nil
+}
+
+// CreateWebhookSubscription subscribes a URL to the messages that match its filters
+func (r *MutationResolver) CreateWebhookSubscription(args struct {
+	WebhookSubscription CreateWebhookSubscriptionInput
+}) (graphql.ID, error) {
+	subscription, err := epr.CreateWebhookSubscription(r.Connection, args.WebhookSubscription.toInput())
+	if err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return subscription.ID, nil
+}
+
+// UpdateWebhookSubscription changes a subscription, enabling it resumes a paused subscription
+func (r *MutationResolver) UpdateWebhookSubscription(args struct {
+	ID                  graphql.ID
+	WebhookSubscription storage.WebhookSubscriptionUpdate
+}) (graphql.ID, error) {
+	if _, err := epr.UpdateWebhookSubscription(r.Connection, args.ID, args.WebhookSubscription); err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return args.ID, nil
+}
+
+// DeleteWebhookSubscription deletes a subscription and its delivery log
+func (r *MutationResolver) DeleteWebhookSubscription(args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if err := epr.DeleteWebhookSubscription(r.Connection, args.ID); err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return args.ID, nil
+}
+
+// RedeliverWebhookDelivery posts a delivery again with a fresh set of retries
+func (r *MutationResolver) RedeliverWebhookDelivery(args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if _, err := epr.RedeliverWebhookDelivery(r.Connection, args.ID); err != nil {
+		return "", eprErrors.SanitizeError(err)
+	}
+	return args.ID, nil
+}
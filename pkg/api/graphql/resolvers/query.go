@@ -1,41 +1,251 @@
 package resolvers
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
 	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 type QueryResolver struct {
-	Connection *storage.Database
+	Connection storage.Repository
 }
 
+// Events returns the matching events, bounded by the configured maximum page size
 func (r *QueryResolver) Events(args struct{ Event FindEventInput }) ([]storage.Event, error) {
-	events, err := storage.FindEvent(r.Connection.Client, args.Event.toMap())
-	return events, eprErrors.SanitizeError(err)
+	events, err := r.Connection.FindEventPage(args.Event.toFilter(), storage.Page{})
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return events.Items, nil
 }
 
+// EventReceivers returns the matching event receivers, bounded by the configured maximum page size
 func (r *QueryResolver) EventReceivers(args struct{ EventReceiver FindEventReceiverInput }) ([]storage.EventReceiver, error) {
-	receivers, err := storage.FindEventReceiver(r.Connection.Client, args.EventReceiver.toMap())
-	return receivers, eprErrors.SanitizeError(err)
+	receivers, err := r.Connection.FindEventReceiverPage(args.EventReceiver.toMap(), storage.Page{})
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return receivers.Items, nil
 }
 
+// EventReceiverGroups returns the matching event receiver groups, bounded by the configured maximum page size
 func (r *QueryResolver) EventReceiverGroups(args struct{ EventReceiverGroup FindEventReceiverGroupInput }) ([]storage.EventReceiverGroup, error) {
-	groups, err := storage.FindEventReceiverGroup(r.Connection.Client, args.EventReceiverGroup.toMap())
-	return groups, eprErrors.SanitizeError(err)
+	groups, err := r.Connection.FindEventReceiverGroupPage(args.EventReceiverGroup.toMap(), storage.Page{})
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return groups.Items, nil
+}
+
+func (r *QueryResolver) EventsConnection(args struct {
+	Event FindEventInput
+	PageArgs
+}) (*Connection[storage.Event], error) {
+	events, err := r.Connection.FindEventPage(args.Event.toFilter(), args.toPage())
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return newConnection(events, func(e storage.Event) graphql.ID { return e.ID }), nil
+}
+
+func (r *QueryResolver) EventReceiversConnection(args struct {
+	EventReceiver FindEventReceiverInput
+	PageArgs
+}) (*Connection[storage.EventReceiver], error) {
+	receivers, err := r.Connection.FindEventReceiverPage(args.EventReceiver.toMap(), args.toPage())
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return newConnection(receivers, func(er storage.EventReceiver) graphql.ID { return er.ID }), nil
+}
+
+func (r *QueryResolver) EventReceiverGroupsConnection(args struct {
+	EventReceiverGroup FindEventReceiverGroupInput
+	PageArgs
+}) (*Connection[storage.EventReceiverGroup], error) {
+	groups, err := r.Connection.FindEventReceiverGroupPage(args.EventReceiverGroup.toMap(), args.toPage())
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return newConnection(groups, func(erg storage.EventReceiverGroup) graphql.ID { return erg.ID }), nil
 }
 
 func (r *QueryResolver) EventsByID(args struct{ ID graphql.ID }) ([]storage.Event, error) {
-	events, err := storage.FindEventByID(r.Connection.Client, args.ID)
+	events, err := r.Connection.FindEventByID(args.ID)
 	return events, eprErrors.SanitizeError(err)
 }
 
 func (r *QueryResolver) EventReceiversByID(args struct{ ID graphql.ID }) ([]storage.EventReceiver, error) {
-	receivers, err := storage.FindEventReceiverByID(r.Connection.Client, args.ID)
+	receivers, err := r.Connection.FindEventReceiverByID(args.ID)
 	return receivers, eprErrors.SanitizeError(err)
 }
 
 func (r *QueryResolver) EventReceiverGroupsByID(args struct{ ID graphql.ID }) ([]storage.EventReceiverGroup, error) {
-	groups, err := storage.FindEventReceiverGroupByID(r.Connection.Client, args.ID)
+	groups, err := r.Connection.FindEventReceiverGroupByID(args.ID)
 	return groups, eprErrors.SanitizeError(err)
 }
+
+// EventReceiverSchemas returns the schema revisions of a receiver, oldest first
+func (r *QueryResolver) EventReceiverSchemas(args struct{ ID graphql.ID }) ([]storage.EventReceiverSchema, error) {
+	schemas, err := r.Connection.FindEventReceiverSchemas(args.ID)
+	return schemas, eprErrors.SanitizeError(err)
+}
+
+// SBOMComponents returns the matching components of ingested SBOMs, bounded by the configured
+// maximum page size
+func (r *QueryResolver) SBOMComponents(args struct{ Component FindSBOMComponentInput }) ([]storage.SBOMComponent, error) {
+	components, err := r.Connection.FindSBOMComponentPage(args.Component.toFilter(), storage.Page{})
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	return components.Items, nil
+}
+
+// StuckOutboxMessages returns the outbox messages that are still pending the given number of
+// seconds after they were stored, bounded by the configured maximum page size
+func (r *QueryResolver) StuckOutboxMessages(args struct{ OlderThanSeconds int32 }) ([]storage.OutboxMessage, error) {
+	if args.OlderThanSeconds < 0 {
+		return nil, eprErrors.InvalidInputError{Msg: "older_than_seconds cannot be negative"}
+	}
+	createdBefore := time.Now().Add(-time.Duration(args.OlderThanSeconds) * time.Second)
+	messages, err := r.Connection.FindStuckOutboxMessages(createdBefore)
+	return messages, eprErrors.SanitizeError(err)
+}
+
+// WebhookSubscriptions returns every webhook subscription, or the one with the id
+func (r *QueryResolver) WebhookSubscriptions(args struct{ ID *graphql.ID }) ([]storage.WebhookSubscription, error) {
+	var id graphql.ID
+	if args.ID != nil {
+		id = *args.ID
+	}
+	subscriptions, err := r.Connection.FindWebhookSubscriptions(id)
+	return subscriptions, eprErrors.SanitizeError(err)
+}
+
+// WebhookDeliveries returns the latest deliveries of a subscription, bounded by the configured
+// maximum page size
+func (r *QueryResolver) WebhookDeliveries(args struct {
+	SubscriptionID graphql.ID
+	State          *string
+}) ([]storage.WebhookDelivery, error) {
+	state := ""
+	if args.State != nil {
+		state = *args.State
+	}
+	deliveries, err := r.Connection.FindWebhookDeliveries(args.SubscriptionID, state)
+	return deliveries, eprErrors.SanitizeError(err)
+}
+
+// VerifyEventChains walks the hash chain of the receiver, or of every receiver, and reports the
+// first broken link of each
+func (r *QueryResolver) VerifyEventChains(args struct{ EventReceiverID *graphql.ID }) ([]epr.ChainVerification, error) {
+	var id graphql.ID
+	if args.EventReceiverID != nil {
+		id = *args.EventReceiverID
+	}
+	verifications, err := epr.VerifyEventChains(r.Connection, id)
+	return verifications, eprErrors.SanitizeError(err)
+}
+
+// Provenance renders the events and passed groups of an artifact as an in-toto statement
+func (r *QueryResolver) Provenance(args struct {
+	Name       string
+	Version    string
+	Release    string
+	PlatformID string
+	Package    string
+	Format     *string
+	Digests    *[]string
+}) (types.JSON, error) {
+	input := epr.ProvenanceInput{
+		Name:       args.Name,
+		Version:    args.Version,
+		Release:    args.Release,
+		PlatformID: args.PlatformID,
+		Package:    args.Package,
+	}
+	if args.Format != nil {
+		input.Format = *args.Format
+	}
+	if args.Digests != nil {
+		input.Digests = *args.Digests
+	}
+	statement, err := epr.ExportProvenance(r.Connection, input)
+	if err != nil {
+		return types.JSON{}, eprErrors.SanitizeError(err)
+	}
+	enc, err := json.Marshal(statement)
+	if err != nil {
+		return types.JSON{}, err
+	}
+	return types.JSON{JSON: enc}, nil
+}
+
+// EventReceiverGroupStatus returns the completions of a group, newest first. Passing the
+// artifact fields narrows them down to a single artifact.
+func (r *QueryResolver) EventReceiverGroupStatus(args tupleArgs) ([]storage.EventReceiverGroupCompletion, error) {
+	completions, err := r.Connection.FindEventReceiverGroupCompletions(args.ID, args.tuple())
+	return completions, eprErrors.SanitizeError(err)
+}
+
+// EventReceiverGroupStates returns the pending, passed or failed state of a group for each
+// artifact it was evaluated for, most recently changed first.
+func (r *QueryResolver) EventReceiverGroupStates(args tupleArgs) ([]storage.EventReceiverGroupState, error) {
+	states, err := r.Connection.FindEventReceiverGroupStates(args.ID, args.tuple())
+	return states, eprErrors.SanitizeError(err)
+}
+
+// tupleArgs are a group id and the optional fields of an artifact
+type tupleArgs struct {
+	ID         graphql.ID
+	Name       graphql.NullString
+	Version    graphql.NullString
+	Release    graphql.NullString
+	PlatformID graphql.NullString
+	Package    graphql.NullString
+}
+
+func (args tupleArgs) tuple() map[string]any {
+	tuple := map[string]any{}
+	for key, value := range map[string]graphql.NullString{
+		"name":        args.Name,
+		"version":     args.Version,
+		"release":     args.Release,
+		"platform_id": args.PlatformID,
+		"package":     args.Package,
+	} {
+		if value.Set && value.Value != nil {
+			tuple[key] = *value.Value
+		}
+	}
+	return tuple
+}
+
+// EventReceiverGroupEvaluation evaluates the group for an artifact as it stands, without
+// recording a completion. Receivers whose condition fails to evaluate report the error.
+func (r *QueryResolver) EventReceiverGroupEvaluation(args struct {
+	ID         graphql.ID
+	Name       string
+	Version    string
+	Release    string
+	PlatformID string
+	Package    string
+}) (*GateResult, error) {
+	result, err := epr.EvaluateEventReceiverGroup(r.Connection, args.ID, storage.Event{
+		Name:       args.Name,
+		Version:    args.Version,
+		Release:    args.Release,
+		PlatformID: args.PlatformID,
+		Package:    args.Package,
+	})
+	if err != nil {
+		return nil, eprErrors.SanitizeError(err)
+	}
+	gateResult := newGateResult(*result)
+	return &gateResult, nil
+}
@@ -2,18 +2,21 @@ package resolvers
 
 import (
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 type Resolver struct {
-	Connection  *storage.Database
+	Connection  storage.Repository
 	msgProducer message.TopicProducer
+	broker      *pubsub.Broker
 }
 
-func New(connection *storage.Database, msgProducer message.TopicProducer) *Resolver {
+func New(connection storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker) *Resolver {
 	return &Resolver{
 		Connection:  connection,
 		msgProducer: msgProducer,
+		broker:      broker,
 	}
 }
 
@@ -29,3 +32,9 @@ func (r *Resolver) Mutation() *MutationResolver {
 		msgProducer: r.msgProducer,
 	}
 }
+
+func (r *Resolver) Subscription() *SubscriptionResolver {
+	return &SubscriptionResolver{
+		broker: r.broker,
+	}
+}
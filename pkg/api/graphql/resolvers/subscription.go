@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package resolvers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+type SubscriptionResolver struct {
+	broker *pubsub.Broker
+}
+
+// StreamFilter is the graphql shape of a pubsub.Filter
+type StreamFilter struct {
+	EventReceiverIDs      *[]graphql.ID
+	EventReceiverGroupIDs *[]graphql.ID
+	Name                  *string
+	Version               *string
+	Release               *string
+	PlatformID            *string
+	Package               *string
+}
+
+func (f *StreamFilter) toFilter(kind string) pubsub.Filter {
+	filter := pubsub.Filter{Kinds: []string{kind}}
+	if f == nil {
+		return filter
+	}
+	if f.EventReceiverIDs != nil {
+		filter.EventReceiverIDs = *f.EventReceiverIDs
+	}
+	if f.EventReceiverGroupIDs != nil {
+		filter.EventReceiverGroupIDs = *f.EventReceiverGroupIDs
+	}
+	for _, field := range []struct {
+		from *string
+		to   *string
+	}{
+		{f.Name, &filter.Name},
+		{f.Version, &filter.Version},
+		{f.Release, &filter.Release},
+		{f.PlatformID, &filter.PlatformID},
+		{f.Package, &filter.Package},
+	} {
+		if field.from != nil {
+			*field.to = *field.from
+		}
+	}
+	return filter
+}
+
+// EventReceiverGroupCompleted is the graphql shape of a group complete message
+type EventReceiverGroupCompleted struct {
+	ID                 graphql.ID
+	Name               string
+	Version            string
+	Release            string
+	PlatformID         string
+	Package            string
+	EventReceiverGroup storage.EventReceiverGroup
+	Event              storage.Event
+	Result             GateResult
+}
+
+// EventCreated streams the events created from now on that match the filter
+func (r *SubscriptionResolver) EventCreated(ctx context.Context, args struct{ Filter *StreamFilter }) (<-chan storage.Event, error) {
+	return stream(ctx, r.broker, args.Filter.toFilter(pubsub.KindEventCreated), func(msg message.Message) storage.Event {
+		return msg.Data.Events[0]
+	})
+}
+
+// EventReceiverGroupCompleted streams the groups that pass from now on for the artifacts that
+// match the filter
+func (r *SubscriptionResolver) EventReceiverGroupCompleted(ctx context.Context, args struct{ Filter *StreamFilter }) (<-chan EventReceiverGroupCompleted, error) {
+	return stream(ctx, r.broker, args.Filter.toFilter(pubsub.KindEventReceiverGroupCompleted), func(msg message.Message) EventReceiverGroupCompleted {
+		return EventReceiverGroupCompleted{
+			ID:                 graphql.ID(msg.ID),
+			Name:               msg.Name,
+			Version:            msg.Version,
+			Release:            msg.Release,
+			PlatformID:         msg.PlatformID,
+			Package:            msg.Package,
+			EventReceiverGroup: msg.Data.EventReceiverGroups[0],
+			Event:              msg.Data.Events[0],
+			Result:             newGateResult(*msg.Data.Gate),
+		}
+	})
+}
+
+// stream converts the messages of the subscription until the context is done or the broker
+// drops it
+func stream[T any](ctx context.Context, broker *pubsub.Broker, filter pubsub.Filter, convert func(message.Message) T) (<-chan T, error) {
+	if broker == nil {
+		return nil, errors.New("streaming is not enabled on this server")
+	}
+	messages := broker.Subscribe(ctx, filter, "")
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for msg := range messages {
+			select {
+			case out <- convert(msg):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -12,16 +12,17 @@ import (
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/resolvers"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
-func New(connection *storage.Database, msgProducer message.TopicProducer) *graphql.Schema {
+func New(connection storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker) *graphql.Schema {
 	s, err := String()
 	if err != nil {
 		log.Fatalf("reading embedded schema contents: %s", err)
 	}
 	opts := []graphql.SchemaOpt{graphql.UseFieldResolvers()}
-	return graphql.MustParseSchema(s, resolvers.New(connection, msgProducer), opts...)
+	return graphql.MustParseSchema(s, resolvers.New(connection, msgProducer, broker), opts...)
 }
 
 //go:embed *.graphql types/*.graphql
@@ -1,9 +1,21 @@
 package schema_test
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"testing"
 
+	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,3 +25,582 @@ func TestString(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, s)
 }
+
+func TestEventsConnection(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "receiver", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := repo.CreateEvent(storage.Event{Name: "event", EventReceiverID: receiver.ID, Payload: types.JSON{JSON: []byte(`{}`)}})
+		require.NoError(t, err)
+	}
+
+	s := schema.New(repo, nil, nil)
+	query := `query($after: String) {
+		events_connection(event: {name: "event"}, first: 2, after: $after) {
+			total_count
+			edges { cursor node { id } }
+			page_info { has_next_page has_previous_page end_cursor }
+		}
+	}`
+	type response struct {
+		EventsConnection struct {
+			TotalCount int `json:"total_count"`
+			Edges      []struct {
+				Cursor string
+			}
+			PageInfo struct {
+				HasNextPage     bool   `json:"has_next_page"`
+				HasPreviousPage bool   `json:"has_previous_page"`
+				EndCursor       string `json:"end_cursor"`
+			} `json:"page_info"`
+		} `json:"events_connection"`
+	}
+
+	result := s.Exec(context.Background(), query, "", nil)
+	require.Empty(t, result.Errors)
+	var first response
+	require.NoError(t, json.Unmarshal(result.Data, &first))
+	require.Equal(t, 3, first.EventsConnection.TotalCount)
+	require.Len(t, first.EventsConnection.Edges, 2)
+	require.True(t, first.EventsConnection.PageInfo.HasNextPage)
+
+	result = s.Exec(context.Background(), query, "", map[string]any{"after": first.EventsConnection.PageInfo.EndCursor})
+	require.Empty(t, result.Errors)
+	var second response
+	require.NoError(t, json.Unmarshal(result.Data, &second))
+	require.Len(t, second.EventsConnection.Edges, 1)
+	require.False(t, second.EventsConnection.PageInfo.HasNextPage)
+	require.True(t, second.EventsConnection.PageInfo.HasPreviousPage)
+}
+
+func TestEventsFilter(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "receiver", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	for _, sha := range []string{"abc123", "def456"} {
+		_, err := repo.CreateEvent(storage.Event{Name: "event", EventReceiverID: receiver.ID, Payload: types.JSON{JSON: []byte(`{"git": {"sha": "` + sha + `"}}`)}})
+		require.NoError(t, err)
+	}
+
+	s := schema.New(repo, nil, nil)
+	query := `query {
+		events(event: {
+			name_prefix: "ev"
+			name_in: ["event"]
+			created_after: "2000-01-01T00:00:00Z"
+			payload_contains: "{\"git\": {}}"
+			payload_path: [{path: "git.sha", prefix: "abc"}]
+		}) { id }
+	}`
+	result := s.Exec(context.Background(), query, "", nil)
+	require.Empty(t, result.Errors)
+	var response struct {
+		Events []struct{ ID string }
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	require.Len(t, response.Events, 1)
+}
+
+type discard struct{}
+
+func (discard) Async(any)      {}
+func (discard) Send(any) error { return nil }
+
+func TestEventReceiverGroupPolicy(t *testing.T) {
+	repo := storage.NewMemory()
+	ids := []any{}
+	for _, name := range []string{"unit", "integration", "lint"} {
+		receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: name, Schema: types.JSON{JSON: []byte(`{}`)}})
+		require.NoError(t, err)
+		ids = append(ids, string(receiver.ID))
+	}
+
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($ids: [ID!]!, $optional: [ID!]!, $n: Int) {
+		create_event_receiver_group(event_receiver_group: {
+			name: "tests", type: "test.passed", version: "1.0.0", description: "tests", enabled: true,
+			event_receiver_ids: $ids,
+			policy: {type: N_OF, n: $n, optional_event_receiver_ids: $optional}
+		})
+	}`
+	result := s.Exec(context.Background(), mutation, "", map[string]any{"ids": ids, "optional": ids[2:], "n": 3})
+	require.NotEmpty(t, result.Errors)
+	require.Contains(t, result.Errors[0].Message, "policy N_OF needs n between 1 and 2")
+
+	result = s.Exec(context.Background(), mutation, "", map[string]any{"ids": ids, "optional": ids[2:], "n": 2})
+	require.Empty(t, result.Errors)
+	var created struct {
+		ID string `json:"create_event_receiver_group"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &created))
+
+	result = s.Exec(context.Background(), `query($id: ID!) {
+		event_receiver_groups(event_receiver_group: {id: $id}) {
+			policy { type n optional_event_receiver_ids }
+		}
+	}`, "", map[string]any{"id": created.ID})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"event_receiver_groups": [{"policy": {"type": "N_OF", "n": 2, "optional_event_receiver_ids": ["`+ids[2].(string)+`"]}}]}`, string(result.Data))
+}
+
+func TestUpdateAndDeleteEventReceiverGroup(t *testing.T) {
+	repo := storage.NewMemory()
+	ids := []any{}
+	for _, name := range []string{"build", "scan"} {
+		receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: name, Schema: types.JSON{JSON: []byte(`{}`)}})
+		require.NoError(t, err)
+		ids = append(ids, string(receiver.ID))
+	}
+	group, err := repo.CreateEventReceiverGroup(storage.EventReceiverGroup{
+		Name:             "release",
+		Description:      "build",
+		EventReceiverIDs: []graphql.ID{graphql.ID(ids[0].(string))},
+	})
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	result := s.Exec(context.Background(), `mutation($id: ID!, $add: ID!) {
+		update_event_receiver_group(id: $id, event_receiver_group: {
+			description: "build and scan"
+			add_event_receivers: [{event_receiver_id: $add, condition: "success", optional: true}]
+		})
+	}`, "", map[string]any{"id": string(group.ID), "add": ids[1]})
+	require.Empty(t, result.Errors)
+
+	result = s.Exec(context.Background(), `query($id: ID!) {
+		event_receiver_groups(event_receiver_group: {id: $id}) {
+			description event_receiver_ids policy { optional_event_receiver_ids } conditions { event_receiver_id expression }
+		}
+	}`, "", map[string]any{"id": string(group.ID)})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"event_receiver_groups": [{
+		"description": "build and scan",
+		"event_receiver_ids": ["`+ids[0].(string)+`", "`+ids[1].(string)+`"],
+		"policy": {"optional_event_receiver_ids": ["`+ids[1].(string)+`"]},
+		"conditions": [{"event_receiver_id": "`+ids[1].(string)+`", "expression": "success"}]
+	}]}`, string(result.Data))
+
+	result = s.Exec(context.Background(), `mutation($id: ID!) { delete_event_receiver_group(id: $id) }`, "", map[string]any{"id": string(group.ID)})
+	require.Empty(t, result.Errors)
+
+	result = s.Exec(context.Background(), `query($id: ID!) {
+		event_receiver_groups(event_receiver_group: {id: $id}) { id }
+	}`, "", map[string]any{"id": string(group.ID)})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"event_receiver_groups": []}`, string(result.Data))
+}
+
+func TestEventReceiverGroupInputVariables(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "build", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	s := schema.New(repo, discard{}, nil)
+
+	// list fields left out of inputs passed as variables are empty
+	result := s.Exec(context.Background(), `mutation($group: CreateEventReceiverGroupInput!) {
+		create_event_receiver_group(event_receiver_group: $group)
+	}`, "", map[string]any{"group": map[string]any{
+		"name": "release", "type": "epr.release", "version": "1.0.0", "description": "release", "enabled": true,
+		"event_receiver_ids": []any{string(receiver.ID)}, "policy": map[string]any{"type": "ALL_OF"},
+	}})
+	require.Empty(t, result.Errors)
+	var created struct {
+		ID string `json:"create_event_receiver_group"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &created))
+
+	result = s.Exec(context.Background(), `mutation($id: ID!, $group: UpdateEventReceiverGroupInput!) {
+		update_event_receiver_group(id: $id, event_receiver_group: $group)
+	}`, "", map[string]any{"id": created.ID, "group": map[string]any{"description": "nightly release"}})
+	require.Empty(t, result.Errors)
+
+	result = s.Exec(context.Background(), `query($id: ID!) {
+		event_receiver_groups(event_receiver_group: {id: $id}) { description conditions { expression } policy { optional_event_receiver_ids } }
+	}`, "", map[string]any{"id": created.ID})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"event_receiver_groups": [{"description": "nightly release", "conditions": [], "policy": {"optional_event_receiver_ids": []}}]}`, string(result.Data))
+}
+
+func TestEventReceiverSchemas(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{
+		Name:   "build",
+		Schema: types.JSON{JSON: []byte(`{"type": "object", "required": ["sha"]}`)},
+	})
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($id: ID!, $schema: JSON!) {
+		create_event_receiver_schema(id: $id, event_receiver_schema: {schema: $schema})
+	}`
+	result := s.Exec(context.Background(), mutation, "", map[string]any{"id": string(receiver.ID), "schema": `{"type": "object"}`})
+	require.NotEmpty(t, result.Errors)
+	require.Contains(t, result.Errors[0].Message, "required field sha removed")
+
+	result = s.Exec(context.Background(), mutation, "", map[string]any{"id": string(receiver.ID), "schema": `{"type": "object", "required": ["sha"], "properties": {"url": {}}}`})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"create_event_receiver_schema": 2}`, string(result.Data))
+
+	result = s.Exec(context.Background(), `query($id: ID!) {
+		event_receiver_schemas(id: $id) { revision compatibility }
+		event_receivers_by_id(id: $id) { schema_revision }
+	}`, "", map[string]any{"id": string(receiver.ID)})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{
+		"event_receiver_schemas": [{"revision": 1, "compatibility": "NONE"}, {"revision": 2, "compatibility": "FULL"}],
+		"event_receivers_by_id": [{"schema_revision": 2}]
+	}`, string(result.Data))
+}
+
+func TestGetOrCreateEventReceiver(t *testing.T) {
+	repo := storage.NewMemory()
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($getOrCreate: Boolean) {
+		create_event_receiver(event_receiver: {
+			name: "build", type: "epr.build", version: "1.0.0", description: "build", schema: "{}"
+		}, get_or_create: $getOrCreate)
+	}`
+	var created [3]struct {
+		ID string `json:"create_event_receiver"`
+	}
+	for i, getOrCreate := range []bool{true, true, false} {
+		result := s.Exec(context.Background(), mutation, "", map[string]any{"getOrCreate": getOrCreate})
+		require.Empty(t, result.Errors)
+		require.NoError(t, json.Unmarshal(result.Data, &created[i]))
+	}
+	require.Equal(t, created[0].ID, created[1].ID)
+	require.NotEqual(t, created[0].ID, created[2].ID)
+
+	receivers, err := repo.FindEventReceiverByID(graphql.ID(created[0].ID))
+	require.NoError(t, err)
+	result := s.Exec(context.Background(), `query($fingerprint: String) {
+		event_receivers(event_receiver: {fingerprint: $fingerprint}) { id }
+	}`, "", map[string]any{"fingerprint": receivers[0].Fingerprint})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"event_receivers": [{"id": "`+created[0].ID+`"}, {"id": "`+created[2].ID+`"}]}`, string(result.Data))
+}
+
+func TestRevokeEvent(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "receiver", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	event, err := repo.CreateEvent(storage.Event{Name: "event", EventReceiverID: receiver.ID, Payload: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	result := s.Exec(context.Background(), `mutation($id: ID!) {
+		revoke_event(id: $id, revocation: {revoked_by: "jdoe", reason: "compromised runner"})
+	}`, "", map[string]any{"id": string(event.ID)})
+	require.Empty(t, result.Errors)
+
+	result = s.Exec(context.Background(), `query($id: ID) {
+		events(event: {id: $id}) { revocation { event_id revoked_by reason } }
+	}`, "", map[string]any{"id": string(event.ID)})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"events": [{"revocation": {"event_id": "`+string(event.ID)+`", "revoked_by": "jdoe", "reason": "compromised runner"}}]}`, string(result.Data))
+}
+
+func TestVerifyEventChains(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "receiver", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	var event *storage.Event
+	for i := 0; i < 2; i++ {
+		event, err = repo.CreateEvent(storage.Event{Name: "event", EventReceiverID: receiver.ID, Payload: types.JSON{JSON: []byte(`{}`)}})
+		require.NoError(t, err)
+	}
+
+	s := schema.New(repo, nil, nil)
+	result := s.Exec(context.Background(), `{
+		verify_event_chains { event_receiver_id events head broken { event_id reason } }
+	}`, "", nil)
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"verify_event_chains": [{"event_receiver_id": "`+string(receiver.ID)+`", "events": 2, "head": "`+event.Hash+`", "broken": null}]}`, string(result.Data))
+}
+
+func TestSignedEvents(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	repo := storage.NewMemory()
+	s := schema.New(repo, discard{}, nil)
+	result := s.Exec(context.Background(), `mutation {
+		create_event_receiver(event_receiver: {
+			name: "build", type: "epr.build", version: "1.0.0", description: "build", schema: "{}"
+		})
+	}`, "", nil)
+	require.Empty(t, result.Errors)
+	var created struct {
+		ID string `json:"create_event_receiver"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &created))
+
+	result = s.Exec(context.Background(), `mutation($id: ID!, $key: String!) {
+		set_event_receiver_signing(id: $id, signing: {public_keys: [$key], require_signature: true})
+	}`, "", map[string]any{"id": created.ID, "key": publicKey})
+	require.Empty(t, result.Errors)
+
+	event := storage.Event{
+		Name: "foo", Version: "1.0.0", Release: "1", PlatformID: "linux", Package: "rpm", Description: "foo",
+		Payload: types.JSON{JSON: []byte(`{}`)}, Success: true, EventReceiverID: graphql.ID(created.ID),
+	}
+	envelope, err := signing.Sign(event, private)
+	require.NoError(t, err)
+	mutation := `mutation($id: ID!, $signature: EventSignatureInput) {
+		create_event(event: {
+			name: "foo", version: "1.0.0", release: "1", platform_id: "linux", package: "rpm", description: "foo",
+			payload: "{}", success: true, event_receiver_id: $id, signature: $signature
+		})
+	}`
+	result = s.Exec(context.Background(), mutation, "", map[string]any{"id": created.ID})
+	require.NotEmpty(t, result.Errors)
+
+	result = s.Exec(context.Background(), mutation, "", map[string]any{
+		"id": created.ID,
+		"signature": map[string]any{
+			"payloadType": envelope.PayloadType,
+			"payload":     envelope.Payload,
+			"signatures":  []any{map[string]any{"keyid": envelope.Signatures[0].KeyID, "sig": envelope.Signatures[0].Sig}},
+		},
+	})
+	require.Empty(t, result.Errors)
+
+	result = s.Exec(context.Background(), `query($id: ID) {
+		events(event: {event_receiver_id: $id}) { signature { key_id payload verified reason } }
+	}`, "", map[string]any{"id": created.ID})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"events": [{"signature": {"key_id": "`+envelope.Signatures[0].KeyID+`", "payload": "`+envelope.Payload+`", "verified": true, "reason": ""}}]}`, string(result.Data))
+}
+
+func TestProvenance(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "build", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	event, err := repo.CreateEvent(storage.Event{
+		Name: "foo", Version: "1.0.0", Release: "1", PlatformID: "linux", Package: "rpm",
+		Payload: types.JSON{JSON: []byte(`{}`)}, Success: true, EventReceiverID: receiver.ID,
+	})
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	query := `query($format: ProvenanceFormat, $digests: [String!]) {
+		provenance(name: "foo", version: "1.0.0", release: "1", platform_id: "linux", package: "rpm", format: $format, digests: $digests)
+	}`
+	result := s.Exec(context.Background(), query, "", map[string]any{"format": "EPR", "digests": []any{"sha256:ab12"}})
+	require.Empty(t, result.Errors)
+	var response struct {
+		Provenance struct {
+			Type      string `json:"_type"`
+			Subject   []map[string]any
+			Predicate struct {
+				Events []storage.Event
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	require.Equal(t, "https://in-toto.io/Statement/v1", response.Provenance.Type)
+	require.Equal(t, map[string]any{"sha256": "ab12"}, response.Provenance.Subject[0]["digest"])
+	require.Len(t, response.Provenance.Predicate.Events, 1)
+	require.Equal(t, event.ID, response.Provenance.Predicate.Events[0].ID)
+
+	// the SLSA predicate is the default
+	result = s.Exec(context.Background(), query, "", nil)
+	require.Empty(t, result.Errors)
+	require.Contains(t, string(result.Data), `"predicateType":"https://slsa.dev/provenance/v1"`)
+
+	result = s.Exec(context.Background(), query, "", map[string]any{"digests": []any{"sha256"}})
+	require.NotEmpty(t, result.Errors)
+	require.Contains(t, result.Errors[0].Message, "is not of the form algorithm:hex")
+}
+
+func TestSBOM(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "sbom", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($sbom: IngestSBOMInput!) { ingest_sbom(sbom: $sbom) }`
+	result := s.Exec(context.Background(), mutation, "", map[string]any{"sbom": map[string]any{
+		"event_receiver_id": string(receiver.ID),
+		"release":           "1",
+		"platform_id":       "linux",
+		"sbom": `{
+			"bomFormat": "CycloneDX",
+			"specVersion": "1.5",
+			"metadata": {"component": {"type": "application", "name": "foo", "version": "1.0.0"}},
+			"components": [{"type": "library", "name": "log4j-core", "version": "2.14.1"}]
+		}`,
+	}})
+	require.Empty(t, result.Errors)
+
+	query := `query($component: FindSBOMComponentInput!) {
+		sbom_components(component: $component) { name version event { name version package } }
+	}`
+	result = s.Exec(context.Background(), query, "", map[string]any{"component": map[string]any{"name": "log4j-core", "version_prefix": "2.14"}})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"sbom_components": [{"name": "log4j-core", "version": "2.14.1", "event": {"name": "foo", "version": "1.0.0", "package": "application"}}]}`, string(result.Data))
+
+	result = s.Exec(context.Background(), query, "", map[string]any{"component": map[string]any{"version": "2.14.1"}})
+	require.NotEmpty(t, result.Errors)
+	require.Contains(t, result.Errors[0].Message, "component search needs a name, group or purl prefix")
+}
+
+func TestIngestCDEvent(t *testing.T) {
+	repo := storage.NewMemory()
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($cdevent: IngestCDEventInput!) { ingest_cdevent(cdevent: $cdevent) }`
+	cloudEvent := `{
+		"specversion": "1.0",
+		"id": "01HGBDRPZRT96R4586GFA13W91",
+		"source": "/ci",
+		"type": "dev.cdevents.artifact.published.0.1.1",
+		"data": {
+			"context": {"version": "0.3.0", "id": "01HGBDRPZRT96R4586GFA13W91", "source": "/ci", "type": "dev.cdevents.artifact.published.0.1.1", "timestamp": "2024-01-01T00:00:00Z"},
+			"subject": {"id": "pkg:oci/foo@1.0.0", "type": "artifact", "content": {}}
+		}
+	}`
+	result := s.Exec(context.Background(), mutation, "", map[string]any{"cdevent": map[string]any{"release": "1", "cloudevent": cloudEvent}})
+	require.Empty(t, result.Errors)
+	var response struct {
+		IngestCDEvent graphql.ID `json:"ingest_cdevent"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	events, err := repo.FindEventByID(response.IngestCDEvent)
+	require.NoError(t, err)
+	require.Equal(t, "foo", events[0].Name)
+	require.Equal(t, "1", events[0].Release)
+	require.Equal(t, "oci", events[0].Package)
+
+	result = s.Exec(context.Background(), mutation, "", map[string]any{"cdevent": map[string]any{"cloudevent": `{"specversion": "1.0"}`}})
+	require.NotEmpty(t, result.Errors)
+	require.Contains(t, result.Errors[0].Message, "CloudEvent id is required")
+}
+
+func TestStuckOutboxMessages(t *testing.T) {
+	repo := storage.NewMemory()
+	_, err := repo.CreateOutboxMessage([]byte(`{"type": "epr.test"}`))
+	require.NoError(t, err)
+
+	s := schema.New(repo, discard{}, nil)
+	query := `query($seconds: Int!) { stuck_outbox_messages(older_than_seconds: $seconds) { id payload attempts last_error } }`
+	var response struct {
+		StuckOutboxMessages []struct {
+			ID       graphql.ID
+			Payload  json.RawMessage
+			Attempts int
+		} `json:"stuck_outbox_messages"`
+	}
+	// by default only messages pending for five minutes are stuck
+	result := s.Exec(context.Background(), `{ stuck_outbox_messages { id payload attempts last_error } }`, "", nil)
+	require.Empty(t, result.Errors)
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	require.Empty(t, response.StuckOutboxMessages)
+
+	result = s.Exec(context.Background(), query, "", map[string]any{"seconds": 0})
+	require.Empty(t, result.Errors)
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	require.Len(t, response.StuckOutboxMessages, 1)
+	require.JSONEq(t, `{"type": "epr.test"}`, string(response.StuckOutboxMessages[0].Payload))
+
+	result = s.Exec(context.Background(), query, "", map[string]any{"seconds": -1})
+	require.NotEmpty(t, result.Errors)
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	repo := storage.NewMemory()
+	s := schema.New(repo, discard{}, nil)
+	mutation := `mutation($subscription: CreateWebhookSubscriptionInput!) { create_webhook_subscription(webhook_subscription: $subscription) }`
+	result := s.Exec(context.Background(), mutation, "", map[string]any{"subscription": map[string]any{
+		"name": "ci", "url": "https://ci.example.com/hooks", "secret": "0123456789abcdef", "message_types": []any{"epr.test"},
+	}})
+	require.Empty(t, result.Errors)
+	var created struct {
+		CreateWebhookSubscription graphql.ID `json:"create_webhook_subscription"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &created))
+
+	result = s.Exec(context.Background(), mutation, "", map[string]any{"subscription": map[string]any{
+		"name": "ci", "url": "ftp://ci.example.com", "secret": "short",
+	}})
+	require.NotEmpty(t, result.Errors)
+
+	update := `mutation($id: ID!) { update_webhook_subscription(id: $id, webhook_subscription: {enabled: false}) }`
+	result = s.Exec(context.Background(), update, "", map[string]any{"id": string(created.CreateWebhookSubscription)})
+	require.Empty(t, result.Errors)
+
+	query := `query($id: ID) { webhook_subscriptions(id: $id) { id name url message_types event_receiver_ids state } }`
+	result = s.Exec(context.Background(), query, "", map[string]any{"id": string(created.CreateWebhookSubscription)})
+	require.Empty(t, result.Errors)
+	var response struct {
+		WebhookSubscriptions []struct {
+			ID               graphql.ID
+			Name             string
+			MessageTypes     []string `json:"message_types"`
+			EventReceiverIDs []string `json:"event_receiver_ids"`
+			State            string
+		} `json:"webhook_subscriptions"`
+	}
+	require.NoError(t, json.Unmarshal(result.Data, &response))
+	require.Len(t, response.WebhookSubscriptions, 1)
+	require.Equal(t, []string{"epr.test"}, response.WebhookSubscriptions[0].MessageTypes)
+	require.Empty(t, response.WebhookSubscriptions[0].EventReceiverIDs)
+	require.Equal(t, storage.SubscriptionStateDisabled, response.WebhookSubscriptions[0].State)
+
+	// the secret cannot be queried
+	result = s.Exec(context.Background(), `{ webhook_subscriptions { secret } }`, "", nil)
+	require.NotEmpty(t, result.Errors)
+
+	deliveries := `query($id: ID!) { webhook_deliveries(subscription_id: $id) { id state attempts { response_code error duration_ms } } }`
+	result = s.Exec(context.Background(), deliveries, "", map[string]any{"id": string(created.CreateWebhookSubscription)})
+	require.Empty(t, result.Errors)
+	require.JSONEq(t, `{"webhook_deliveries": []}`, string(result.Data))
+
+	result = s.Exec(context.Background(), `mutation($id: ID!) { delete_webhook_subscription(id: $id) }`, "", map[string]any{"id": string(created.CreateWebhookSubscription)})
+	require.Empty(t, result.Errors)
+	result = s.Exec(context.Background(), deliveries, "", map[string]any{"id": string(created.CreateWebhookSubscription)})
+	require.NotEmpty(t, result.Errors)
+}
+
+func TestSubscriptions(t *testing.T) {
+	repo := storage.NewMemory()
+	receiver, err := repo.CreateEventReceiver(storage.EventReceiver{Name: "receiver", Type: "epr.test", Schema: types.JSON{JSON: []byte(`{}`)}})
+	require.NoError(t, err)
+	broker := pubsub.NewBroker(pubsub.DefaultHistorySize)
+	s := schema.New(repo, message.NewOutbox(repo).Notify(broker), broker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	responses, err := s.Subscribe(ctx, `subscription($id: ID!) {
+		event_created(filter: {event_receiver_ids: [$id], name: "foo"}) { name version event_receiver_id }
+	}`, "", map[string]any{"id": string(receiver.ID)})
+	require.NoError(t, err)
+
+	mutation := `mutation($name: String!, $id: ID!) {
+		create_event(event: {name: $name, version: "1.0.0", release: "1", platform_id: "linux", package: "rpm",
+			description: "test", payload: "{}", event_receiver_id: $id, success: true})
+	}`
+	for _, name := range []string{"bar", "foo"} {
+		result := s.Exec(context.Background(), mutation, "", map[string]any{"name": name, "id": string(receiver.ID)})
+		require.Empty(t, result.Errors)
+	}
+
+	response := (<-responses).(*graphql.Response)
+	require.Empty(t, response.Errors)
+	require.JSONEq(t, `{"event_created": {"name": "foo", "version": "1.0.0", "event_receiver_id": "`+string(receiver.ID)+`"}}`, string(response.Data))
+
+	cancel()
+	for range responses {
+	}
+
+	// a server without a broker has no streams
+	responses, err = schema.New(repo, discard{}, nil).Subscribe(context.Background(), `subscription { event_receiver_group_completed { id } }`, "", nil)
+	require.NoError(t, err)
+	response = (<-responses).(*graphql.Response)
+	require.NotEmpty(t, response.Errors)
+	for range responses {
+	}
+}
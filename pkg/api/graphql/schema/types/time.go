@@ -3,8 +3,10 @@
 package types
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"gorm.io/datatypes"
 )
@@ -30,6 +32,13 @@ func (t *Time) UnmarshalGraphQL(input interface{}) error {
 	case datatypes.Date:
 		t.Date = input
 		return nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, input)
+		if err != nil {
+			return fmt.Errorf("invalid Time %q, expected RFC 3339: %w", input, err)
+		}
+		t.Date = datatypes.Date(parsed)
+		return nil
 	default:
 		return fmt.Errorf("wrong type for Time: %T", input)
 	}
@@ -42,3 +51,8 @@ func (t *Time) UnmarshalGraphQL(input interface{}) error {
 func (t Time) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.Date)
 }
+
+// Value stores the full instant, datatypes.Date would truncate it to the day
+func (t Time) Value() (driver.Value, error) {
+	return time.Time(t.Date), nil
+}
@@ -27,8 +27,18 @@ func TestUnmarshalGraphQL(t *testing.T) {
 		t.Errorf("expected %+v, but got %+v", expectedOutput, time)
 	}
 
-	// Negative test case
-	invalidInput := "invalid"
+	// RFC 3339 strings are accepted as input
+	err = time.UnmarshalGraphQL("2022-01-01T00:00:00Z")
+	assert.NilError(t, err)
+	result, err := time.MarshalJSON()
+	assert.NilError(t, err)
+	assert.Equal(t, string(result), `"2022-01-01T00:00:00Z"`)
+
+	// Negative test cases
+	err = time.UnmarshalGraphQL("invalid")
+	assert.ErrorContains(t, err, `invalid Time "invalid"`)
+
+	invalidInput := 42
 	err = time.UnmarshalGraphQL(invalidInput)
 	assert.Assert(t, nil != err)
 	expectedError := fmt.Errorf("wrong type for Time: %T", invalidInput)
@@ -44,3 +54,10 @@ func TestMarshalJSON(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, string(result), string(expected), "expected %s, but got %s", expected, result)
 }
+
+func TestValue(t *testing.T) {
+	instant := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	value, err := Time{Date: datatypes.Date(instant)}.Value()
+	assert.NilError(t, err)
+	assert.Equal(t, value, instant)
+}
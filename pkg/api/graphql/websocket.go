@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// The graphql-transport-ws protocol of the graphql-ws library,
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const (
+	subprotocol = "graphql-transport-ws"
+
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+const (
+	initTimeout  = 10 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type subscribePayload struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type wsError struct {
+	Message string `json:"message"`
+}
+
+// SubscriptionHandler runs the operations sent over a websocket speaking graphql-transport-ws,
+// the subscriptions stream their results until they complete or the socket closes
+func (s *Server) SubscriptionHandler() http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{subprotocol},
+		// the API allows every origin, see the CORS options of the router
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Debug("websocket upgrade failed", "error", err)
+			return
+		}
+		session := &wsSession{conn: conn, schema: s.schema, operations: map[string]*wsOperation{}}
+		if conn.Subprotocol() != subprotocol {
+			session.close(4406, "Subprotocol not acceptable")
+			return
+		}
+		session.run(r.Context())
+	}
+}
+
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+type wsSession struct {
+	conn   *websocket.Conn
+	schema *graphql.Schema
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	initialized bool
+	operations  map[string]*wsOperation
+	wg          sync.WaitGroup
+}
+
+func (s *wsSession) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		_ = s.conn.Close()
+	}()
+
+	timer := time.AfterFunc(initTimeout, func() {
+		s.mu.Lock()
+		initialized := s.initialized
+		s.mu.Unlock()
+		if !initialized {
+			s.close(4408, "Connection initialisation timeout")
+		}
+	})
+	defer timer.Stop()
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.close(4400, "Invalid message received")
+			return
+		}
+		if !s.handle(ctx, msg) {
+			return
+		}
+	}
+}
+
+// handle acts on a message of the client, it returns false once the socket is closed
+func (s *wsSession) handle(ctx context.Context, msg wsMessage) bool {
+	switch msg.Type {
+	case msgConnectionInit:
+		s.mu.Lock()
+		initialized := s.initialized
+		s.initialized = true
+		s.mu.Unlock()
+		if initialized {
+			s.close(4429, "Too many initialisation requests")
+			return false
+		}
+		return s.send(wsMessage{Type: msgConnectionAck}) == nil
+	case msgPing:
+		return s.send(wsMessage{Type: msgPong}) == nil
+	case msgPong:
+		return true
+	case msgSubscribe:
+		return s.subscribe(ctx, msg)
+	case msgComplete:
+		s.mu.Lock()
+		if op, ok := s.operations[msg.ID]; ok {
+			op.cancel()
+			delete(s.operations, msg.ID)
+		}
+		s.mu.Unlock()
+		return true
+	default:
+		s.close(4400, "Invalid message received")
+		return false
+	}
+}
+
+func (s *wsSession) subscribe(ctx context.Context, msg wsMessage) bool {
+	var payload subscribePayload
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil || payload.Query == "" {
+		s.close(4400, "Invalid message received")
+		return false
+	}
+
+	s.mu.Lock()
+	if !s.initialized {
+		s.mu.Unlock()
+		s.close(4401, "Unauthorized")
+		return false
+	}
+	if _, ok := s.operations[msg.ID]; ok {
+		s.mu.Unlock()
+		s.close(4409, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+		return false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	op := &wsOperation{cancel: cancel}
+	s.operations[msg.ID] = op
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.execute(ctx, msg.ID, payload)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.operations[msg.ID] == op {
+			delete(s.operations, msg.ID)
+		}
+	}()
+	return true
+}
+
+// execute sends the results of the operation until it completes or the client completes it
+func (s *wsSession) execute(ctx context.Context, id string, payload subscribePayload) {
+	responses, err := s.schema.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables)
+	if err != nil {
+		_ = s.sendPayload(id, msgError, []wsError{{Message: err.Error()}})
+		return
+	}
+
+	first, done := true, false
+	// the responses are drained until closed, the schema blocks on each of them
+	for r := range responses {
+		resp, ok := r.(*graphql.Response)
+		if !ok || done || ctx.Err() != nil {
+			continue
+		}
+		if first && resp.Data == nil && len(resp.Errors) > 0 {
+			// the operation failed before it ran, no result or complete follows the error
+			_ = s.sendPayload(id, msgError, resp.Errors)
+			done = true
+			continue
+		}
+		first = false
+		done = s.sendPayload(id, msgNext, resp) != nil
+	}
+	if !done && ctx.Err() == nil {
+		_ = s.send(wsMessage{ID: id, Type: msgComplete})
+	}
+}
+
+func (s *wsSession) sendPayload(id, msgType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return s.send(wsMessage{ID: id, Type: msgType, Payload: data})
+}
+
+func (s *wsSession) send(msg wsMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteJSON(msg)
+}
+
+// close tells the client why the socket closes and closes it, which ends the read loop
+func (s *wsSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		slog.Debug("closing websocket", "error", err)
+	}
+	_ = s.conn.Close()
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+)
+
+// IngestCDEvent records the CDEvent of the CloudEvent in the request as an event. The CloudEvent
+// is read in binary mode when the request has a ce-specversion header and in structured mode
+// otherwise. The name, version, release, platform_id, package and description query parameters
+// override the values derived from the CDEvent.
+func (s *Server) IngestCDEvent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+
+		var cloudEvent *cdevents.CloudEvent
+		if r.Header.Get("ce-specversion") != "" {
+			cloudEvent = &cdevents.CloudEvent{
+				SpecVersion:     r.Header.Get("ce-specversion"),
+				ID:              r.Header.Get("ce-id"),
+				Source:          r.Header.Get("ce-source"),
+				Type:            r.Header.Get("ce-type"),
+				Time:            r.Header.Get("ce-time"),
+				DataContentType: r.Header.Get("Content-Type"),
+				Data:            body,
+			}
+		} else {
+			cloudEvent, err = cdevents.ParseCloudEvent(body)
+			if err != nil {
+				handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+				return
+			}
+		}
+
+		query := r.URL.Query()
+		event, err := epr.IngestCDEvent(s.msgProducer, s.DBConnector, epr.CDEventInput{
+			Name:        query.Get("name"),
+			Version:     query.Get("version"),
+			Release:     query.Get("release"),
+			PlatformID:  query.Get("platform_id"),
+			Package:     query.Get("package"),
+			Description: query.Get("description"),
+			CloudEvent:  cloudEvent,
+		})
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, event.ID, nil)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/epr"
 	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
-	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 func (s *Server) CreateEvent() http.HandlerFunc {
@@ -21,14 +20,55 @@ func (s *Server) CreateEvent() http.HandlerFunc {
 	}
 }
 
+// ListEvents returns a page of the events matching the query parameters
+func (s *Server) ListEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseEventFilter(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		page, err := parsePage(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		results, err := s.DBConnector.FindEventPage(filter, page)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		setPageHeaders(w, r, results.PageInfo)
+		handleResponse(w, r, results.Items, nil)
+	}
+}
+
 func (s *Server) GetEventByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "eventID")
-		event, err := storage.FindEventByID(s.DBConnector.Client, graphql.ID(id))
+		event, err := s.DBConnector.FindEventByID(graphql.ID(id))
 		handleResponse(w, r, event, err)
 	}
 }
 
+// RevokeEvent revokes an event and re-evaluates the groups it counted towards
+func (s *Server) RevokeEvent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "eventID")
+		var input epr.RevocationInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+		event, err := epr.RevokeEvent(s.msgProducer, s.DBConnector, graphql.ID(id), input)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, event.ID, nil)
+	}
+}
+
 func (s *Server) createEvent(r *http.Request) (graphql.ID, error) {
 	var input epr.EventInput
 	err := json.NewDecoder(r.Body).Decode(&input)
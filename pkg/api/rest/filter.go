@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// fields that can be used to filter the list endpoints
+var (
+	eventFilters    = []string{"id", "name", "version", "release", "platform_id", "package", "success", "event_receiver_id"}
+	receiverFilters = []string{"id", "name", "type", "version", "fingerprint"}
+	groupFilters    = []string{"id", "name", "type", "version", "enabled"}
+	tupleFilters    = []string{"name", "version", "release", "platform_id", "package"}
+)
+
+// parseFilter builds a storage filter from the allowed query parameters of the request
+func parseFilter(r *http.Request, allowed []string) (map[string]any, error) {
+	query := r.URL.Query()
+	filter := map[string]any{}
+	for _, key := range allowed {
+		if !query.Has(key) {
+			continue
+		}
+		value := query.Get(key)
+		switch key {
+		case "success", "enabled":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid value %q for %s", value, key)}
+			}
+			filter[key] = b
+		default:
+			filter[key] = value
+		}
+	}
+	return filter, nil
+}
+
+// parseFlag reads a boolean query parameter, false when it is missing
+func parseFlag(r *http.Request, key string) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid %s %q", key, value)}
+	}
+	return b, nil
+}
+
+// parseEventFilter builds an event filter from the query parameters of the request.
+// List parameters such as name_in may be repeated or comma separated.
+func parseEventFilter(r *http.Request) (storage.EventFilter, error) {
+	fields, err := parseFilter(r, eventFilters)
+	if err != nil {
+		return storage.EventFilter{}, err
+	}
+	query := r.URL.Query()
+	stringFilter := func(column string) storage.StringFilter {
+		return storage.StringFilter{
+			Prefix: query.Get(column + "_prefix"),
+			Regex:  query.Get(column + "_regex"),
+			In:     queryList(query[column+"_in"]),
+		}
+	}
+	filter := storage.EventFilter{
+		Fields:     fields,
+		Name:       stringFilter("name"),
+		Version:    stringFilter("version"),
+		Release:    stringFilter("release"),
+		PlatformID: storage.StringFilter{In: queryList(query["platform_id_in"])},
+		Package:    storage.StringFilter{In: queryList(query["package_in"])},
+	}
+	for _, id := range queryList(query["event_receiver_id_in"]) {
+		filter.EventReceiverIDs = append(filter.EventReceiverIDs, graphql.ID(id))
+	}
+	for key, bound := range map[string]**time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		if !query.Has(key) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, query.Get(key))
+		if err != nil {
+			return filter, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid value %q for %s, expected RFC 3339", query.Get(key), key)}
+		}
+		*bound = &t
+	}
+	if query.Has("payload_contains") {
+		filter.PayloadContains = []byte(query.Get("payload_contains"))
+	}
+	for _, p := range query["payload_path"] {
+		path, err := storage.ParsePayloadPath(p)
+		if err != nil {
+			return filter, err
+		}
+		filter.PayloadPaths = append(filter.PayloadPaths, path)
+	}
+	return filter, filter.Validate()
+}
+
+// queryList splits repeated and comma separated query values into one list
+func queryList(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	list := []string{}
+	for _, value := range values {
+		list = append(list, strings.Split(value, ",")...)
+	}
+	return list
+}
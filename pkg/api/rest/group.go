@@ -28,38 +28,129 @@ func (s *Server) CreateGroup() http.HandlerFunc {
 	}
 }
 
+// ListGroups returns a page of the event receiver groups matching the query parameters
+func (s *Server) ListGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r, groupFilters)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		page, err := parsePage(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		results, err := s.DBConnector.FindEventReceiverGroupPage(filter, page)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		setPageHeaders(w, r, results.PageInfo)
+		handleResponse(w, r, results.Items, nil)
+	}
+}
+
 func (s *Server) GetGroupByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "groupID")
 		slog.Info("GetGroupByID", "groupID", id)
-		rec, err := storage.FindEventReceiverGroupByID(s.DBConnector.Client, graphql.ID(id))
+		rec, err := s.DBConnector.FindEventReceiverGroupByID(graphql.ID(id))
 		handleResponse(w, r, rec, err)
 	}
 }
 
+// GetGroupStatus returns the completions of a group. The name, version, release, platform_id
+// and package query parameters narrow them down to a single artifact.
+func (s *Server) GetGroupStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "groupID")
+		tuple, err := parseFilter(r, tupleFilters)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		completions, err := s.DBConnector.FindEventReceiverGroupCompletions(graphql.ID(id), tuple)
+		handleResponse(w, r, completions, err)
+	}
+}
+
+// GetGroupStates returns the states of a group, narrowed down by the same query parameters as
+// GetGroupStatus.
+func (s *Server) GetGroupStates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "groupID")
+		tuple, err := parseFilter(r, tupleFilters)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		states, err := s.DBConnector.FindEventReceiverGroupStates(graphql.ID(id), tuple)
+		handleResponse(w, r, states, err)
+	}
+}
+
+// GetGroupEvaluation evaluates a group for the artifact given by the name, version, release,
+// platform_id and package query parameters, reporting conditions that could not be evaluated.
+func (s *Server) GetGroupEvaluation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "groupID")
+		query := r.URL.Query()
+		result, err := epr.EvaluateEventReceiverGroup(s.DBConnector, graphql.ID(id), storage.Event{
+			Name:       query.Get("name"),
+			Version:    query.Get("version"),
+			Release:    query.Get("release"),
+			PlatformID: query.Get("platform_id"),
+			Package:    query.Get("package"),
+		})
+		handleResponse(w, r, result, err)
+	}
+}
+
+// UpdateGroup changes the description, enabled flag, policy and receivers of a group. Fields
+// left out of the body are not changed.
 func (s *Server) UpdateGroup() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "groupID")
 		slog.Info("update group", "groupID", id)
 
-		var patch struct {
-			Enabled *bool `json:"enabled,omitempty"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		var update storage.EventReceiverGroupUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 			err = eprErrors.InvalidInputError{Msg: err.Error()}
 			handleResponse(w, r, id, err)
 			return
 		}
 
-		var err error
-		if patch.Enabled != nil {
-			slog.Info("set group enabled", "groupID", id, "enabled", patch.Enabled)
-			err = storage.SetEventReceiverGroupEnabled(s.DBConnector.Client, graphql.ID(id), *patch.Enabled)
-		}
+		_, err := epr.UpdateEventReceiverGroup(s.msgProducer, s.DBConnector, graphql.ID(id), update)
+		handleResponse(w, r, id, err)
+	}
+}
+
+// DeleteGroup soft deletes a group
+func (s *Server) DeleteGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "groupID")
+		slog.Info("delete group", "groupID", id)
+		err := epr.DeleteEventReceiverGroup(s.msgProducer, s.DBConnector, graphql.ID(id))
 		handleResponse(w, r, id, err)
 	}
 }
 
+// ReevaluateGroup evaluates a group against the artifacts its receivers have events for and
+// sends the messages of the artifacts whose state changed. With dry_run=true it only reports them.
+func (s *Server) ReevaluateGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "groupID")
+		dryRun, err := parseFlag(r, "dry_run")
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		reevaluations, err := epr.ReevaluateEventReceiverGroup(s.msgProducer, s.DBConnector, graphql.ID(id), dryRun)
+		handleResponse(w, r, reevaluations, err)
+	}
+}
+
 func (s *Server) createGroup(r *http.Request) (graphql.ID, error) {
 	var input epr.EventReceiverGroupInput
 	err := json.NewDecoder(r.Body).Decode(&input)
@@ -67,7 +158,15 @@ func (s *Server) createGroup(r *http.Request) (graphql.ID, error) {
 		return "", eprErrors.InvalidInputError{Msg: err.Error()}
 	}
 
-	eventReceiverGroup, err := epr.CreateEventReceiverGroup(s.msgProducer, s.DBConnector, input)
+	getOrCreate, err := parseFlag(r, "get_or_create")
+	if err != nil {
+		return "", err
+	}
+	create := epr.CreateEventReceiverGroup
+	if getOrCreate {
+		create = epr.GetOrCreateEventReceiverGroup
+	}
+	eventReceiverGroup, err := create(s.msgProducer, s.DBConnector, input)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+)
+
+// defaultStuckAfter is how long an outbox message waits before it is reported as stuck
+const defaultStuckAfter = 300
+
+// ListStuckOutboxMessages returns the outbox messages still waiting to be published the number
+// of seconds of the older_than_seconds query parameter after they were stored, oldest first
+func (s *Server) ListStuckOutboxMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		olderThan := defaultStuckAfter
+		if value := r.URL.Query().Get("older_than_seconds"); value != "" {
+			var err error
+			olderThan, err = strconv.Atoi(value)
+			if err != nil || olderThan < 0 {
+				handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid value %q for older_than_seconds", value)})
+				return
+			}
+		}
+		messages, err := s.DBConnector.FindStuckOutboxMessages(time.Now().Add(-time.Duration(olderThan) * time.Second))
+		handleResponse(w, r, messages, err)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// parsePage reads the first, after, last, before and order query parameters
+func parsePage(r *http.Request) (storage.Page, error) {
+	query := r.URL.Query()
+	page := storage.Page{
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+		Order:  query.Get("order"),
+	}
+	for key, size := range map[string]**int{"first": &page.First, "last": &page.Last} {
+		if !query.Has(key) {
+			continue
+		}
+		n, err := strconv.Atoi(query.Get(key))
+		if err != nil {
+			return page, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid value %q for %s", query.Get(key), key)}
+		}
+		*size = &n
+	}
+	return page, nil
+}
+
+// setPageHeaders reports the total number of matches, whether there are no further
+// results and links to the neighbouring pages.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, info storage.PageInfo) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(info.TotalCount))
+	w.Header().Set("X-Last-Page", strconv.FormatBool(!info.HasNextPage))
+
+	if info.HasNextPage && info.EndCursor != "" {
+		w.Header().Add("Link", pageLink(r, "next", "first", "after", info.EndCursor))
+	}
+	if info.HasPreviousPage && info.StartCursor != "" {
+		w.Header().Add("Link", pageLink(r, "prev", "last", "before", info.StartCursor))
+	}
+}
+
+// pageLink rewrites the request query to fetch the page on the given side of the cursor,
+// keeping the filters and the requested page size.
+func pageLink(r *http.Request, rel, sizeKey, cursorKey, cursor string) string {
+	query := r.URL.Query()
+	size := query.Get("first")
+	if size == "" {
+		size = query.Get("last")
+	}
+	for _, key := range []string{"first", "last", "after", "before"} {
+		query.Del(key)
+	}
+	if size != "" {
+		query.Set(sizeKey, size)
+	}
+	query.Set(cursorKey, cursor)
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+)
+
+// GetProvenance renders the artifact given by the name, version, release, platform_id and
+// package query parameters as an in-toto statement. The format parameter picks the SLSA or EPR
+// predicate and the repeatable digest parameter identifies the artifact as the subject.
+func (s *Server) GetProvenance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		statement, err := epr.ExportProvenance(s.DBConnector, epr.ProvenanceInput{
+			Name:       query.Get("name"),
+			Version:    query.Get("version"),
+			Release:    query.Get("release"),
+			PlatformID: query.Get("platform_id"),
+			Package:    query.Get("package"),
+			Format:     strings.ToUpper(query.Get("format")),
+			Digests:    query["digest"],
+		})
+		handleResponse(w, r, statement, err)
+	}
+}
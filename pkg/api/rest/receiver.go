@@ -12,7 +12,6 @@ import (
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/epr"
 	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
-	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 func (s *Server) CreateReceiver() http.HandlerFunc {
@@ -22,15 +21,89 @@ func (s *Server) CreateReceiver() http.HandlerFunc {
 	}
 }
 
+// ListReceivers returns a page of the event receivers matching the query parameters
+func (s *Server) ListReceivers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r, receiverFilters)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		page, err := parsePage(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		results, err := s.DBConnector.FindEventReceiverPage(filter, page)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		setPageHeaders(w, r, results.PageInfo)
+		handleResponse(w, r, results.Items, nil)
+	}
+}
+
 func (s *Server) GetReceiverByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "receiverID")
 		slog.Info("getting receiver", "id", id)
-		eventReceiver, err := storage.FindEventReceiverByID(s.DBConnector.Client, graphql.ID(id))
+		eventReceiver, err := s.DBConnector.FindEventReceiverByID(graphql.ID(id))
 		handleResponse(w, r, eventReceiver, err)
 	}
 }
 
+// GetReceiverSchemas returns the schema revisions of a receiver, oldest first
+func (s *Server) GetReceiverSchemas() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "receiverID")
+		slog.Info("getting receiver schemas", "id", id)
+		schemas, err := s.DBConnector.FindEventReceiverSchemas(graphql.ID(id))
+		handleResponse(w, r, schemas, err)
+	}
+}
+
+// CreateReceiverSchema registers a new schema revision for a receiver and returns the revision
+func (s *Server) CreateReceiverSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "receiverID")
+		slog.Info("creating receiver schema", "id", id)
+
+		var input epr.EventReceiverSchemaInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+
+		schema, err := epr.CreateEventReceiverSchema(s.msgProducer, s.DBConnector, graphql.ID(id), input)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, schema.Revision, nil)
+	}
+}
+
+// SetReceiverSigning replaces the keys trusted to sign the events of a receiver
+func (s *Server) SetReceiverSigning() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "receiverID")
+
+		var input epr.EventReceiverSigningInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+
+		receiver, err := epr.SetEventReceiverSigning(s.msgProducer, s.DBConnector, graphql.ID(id), input)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, receiver.ID, nil)
+	}
+}
+
 func (s *Server) createReceiver(r *http.Request) (graphql.ID, error) {
 	var input epr.EventReceiverInput
 	err := json.NewDecoder(r.Body).Decode(&input)
@@ -38,7 +111,15 @@ func (s *Server) createReceiver(r *http.Request) (graphql.ID, error) {
 		return "", eprErrors.InvalidInputError{Msg: err.Error()}
 	}
 
-	eventReceiver, err := epr.CreateEventReceiver(s.msgProducer, s.DBConnector, input)
+	getOrCreate, err := parseFlag(r, "get_or_create")
+	if err != nil {
+		return "", err
+	}
+	create := epr.CreateEventReceiver
+	if getOrCreate {
+		create = epr.GetOrCreateEventReceiver
+	}
+	eventReceiver, err := create(s.msgProducer, s.DBConnector, input)
 	if err != nil {
 		return "", err
 	}
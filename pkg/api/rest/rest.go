@@ -7,19 +7,22 @@ import (
 	"github.com/go-chi/render"
 	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
 type Server struct {
-	DBConnector *storage.Database
+	DBConnector storage.Repository
 
 	msgProducer message.TopicProducer
+	broker      *pubsub.Broker
 }
 
-func New(conn *storage.Database, msgProducer message.TopicProducer) *Server {
+func New(conn storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker) *Server {
 	svr := &Server{
 		DBConnector: conn,
 		msgProducer: msgProducer,
+		broker:      broker,
 	}
 	return svr
 }
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// IngestSBOM creates an event from the CycloneDX or SPDX JSON document in the body. The
+// event_receiver_id, release and platform_id query parameters are required, name, version,
+// package and description override the values derived from the document.
+func (s *Server) IngestSBOM() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+		query := r.URL.Query()
+		event, err := epr.IngestSBOM(s.msgProducer, s.DBConnector, epr.SBOMInput{
+			EventReceiverID: graphql.ID(query.Get("event_receiver_id")),
+			Release:         query.Get("release"),
+			PlatformID:      query.Get("platform_id"),
+			Name:            query.Get("name"),
+			Version:         query.Get("version"),
+			Package:         query.Get("package"),
+			Description:     query.Get("description"),
+			SBOM:            types.JSON{JSON: document},
+		})
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, event.ID, nil)
+	}
+}
+
+// ListSBOMComponents returns a page of the SBOM components matching the name, group, version,
+// version_prefix and purl_prefix query parameters, each with the event carrying its SBOM
+func (s *Server) ListSBOMComponents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := parsePage(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		query := r.URL.Query()
+		results, err := s.DBConnector.FindSBOMComponentPage(storage.SBOMComponentFilter{
+			Name:          query.Get("name"),
+			Group:         query.Get("group"),
+			Version:       query.Get("version"),
+			VersionPrefix: query.Get("version_prefix"),
+			PURLPrefix:    query.Get("purl_prefix"),
+		}, page)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		setPageHeaders(w, r, results.PageInfo)
+		handleResponse(w, r, results.Items, nil)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
+)
+
+// keepAliveInterval is how often an idle stream gets a comment so proxies keep it open
+const keepAliveInterval = 15 * time.Second
+
+// Stream sends the messages this server records as Server-Sent Events until the client goes
+// away. The kind of each event is event_created or event_receiver_group_completed, its data the
+// message and its id the message ID, which the Last-Event-ID header or the last_event_id
+// parameter resumes after.
+func (s *Server) Stream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if s.broker == nil || !ok {
+			handleResponse(w, r, nil, errors.New("streaming is not enabled on this server"))
+			return
+		}
+		filter, err := streamFilter(r)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+
+		messages := s.broker.Subscribe(r.Context(), filter, lastID)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					// the client went away or fell behind, it resumes from the last id it got
+					return
+				}
+				data, err := json.Marshal(msg)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, pubsub.Kind(msg), data); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func streamFilter(r *http.Request) (pubsub.Filter, error) {
+	query := r.URL.Query()
+	filter := pubsub.Filter{
+		Kinds:      queryList(query["kind"]),
+		Name:       query.Get("name"),
+		Version:    query.Get("version"),
+		Release:    query.Get("release"),
+		PlatformID: query.Get("platform_id"),
+		Package:    query.Get("package"),
+	}
+	for _, kind := range filter.Kinds {
+		if kind != pubsub.KindEventCreated && kind != pubsub.KindEventReceiverGroupCompleted {
+			return filter, eprErrors.InvalidInputError{Msg: fmt.Sprintf("unknown kind %q", kind)}
+		}
+	}
+	for _, id := range queryList(query["event_receiver_id"]) {
+		filter.EventReceiverIDs = append(filter.EventReceiverIDs, graphql.ID(id))
+	}
+	for _, id := range queryList(query["event_receiver_group_id"]) {
+		filter.EventReceiverGroupIDs = append(filter.EventReceiverGroupIDs, graphql.ID(id))
+	}
+	return filter, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/epr"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// CreateWebhookSubscription subscribes a URL to the messages that match the filters of the body
+func (s *Server) CreateWebhookSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input epr.WebhookSubscriptionInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			handleResponse(w, r, nil, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+		subscription, err := epr.CreateWebhookSubscription(s.DBConnector, input)
+		if err != nil {
+			handleResponse(w, r, nil, err)
+			return
+		}
+		handleResponse(w, r, subscription.ID, nil)
+	}
+}
+
+// ListWebhookSubscriptions returns every webhook subscription
+func (s *Server) ListWebhookSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscriptions, err := s.DBConnector.FindWebhookSubscriptions("")
+		handleResponse(w, r, subscriptions, err)
+	}
+}
+
+func (s *Server) GetWebhookSubscriptionByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "subscriptionID")
+		subscriptions, err := s.DBConnector.FindWebhookSubscriptions(graphql.ID(id))
+		handleResponse(w, r, subscriptions, err)
+	}
+}
+
+// UpdateWebhookSubscription changes a subscription. Fields left out of the body are not changed,
+// setting enabled to true resumes a paused subscription.
+func (s *Server) UpdateWebhookSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "subscriptionID")
+		slog.Info("update webhook subscription", "subscriptionID", id)
+
+		var update storage.WebhookSubscriptionUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			handleResponse(w, r, id, eprErrors.InvalidInputError{Msg: err.Error()})
+			return
+		}
+		_, err := epr.UpdateWebhookSubscription(s.DBConnector, graphql.ID(id), update)
+		handleResponse(w, r, id, err)
+	}
+}
+
+// DeleteWebhookSubscription deletes a subscription and its delivery log
+func (s *Server) DeleteWebhookSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "subscriptionID")
+		slog.Info("delete webhook subscription", "subscriptionID", id)
+		err := epr.DeleteWebhookSubscription(s.DBConnector, graphql.ID(id))
+		handleResponse(w, r, id, err)
+	}
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a subscription, newest first, only
+// those in the state of the state query parameter when it is given
+func (s *Server) ListWebhookDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "subscriptionID")
+		deliveries, err := s.DBConnector.FindWebhookDeliveries(graphql.ID(id), r.URL.Query().Get("state"))
+		handleResponse(w, r, deliveries, err)
+	}
+}
+
+// RedeliverWebhookDelivery posts a delivery again with a fresh set of retries
+func (s *Server) RedeliverWebhookDelivery() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "deliveryID")
+		_, err := epr.RedeliverWebhookDelivery(s.DBConnector, graphql.ID(id))
+		handleResponse(w, r, id, err)
+	}
+}
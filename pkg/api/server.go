@@ -9,6 +9,7 @@ import (
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/rest"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/pubsub"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
@@ -17,12 +18,12 @@ type Server struct {
 	Rest    *rest.Server
 }
 
-func New(conn *storage.Database, msgProducer message.TopicProducer) (*Server, error) {
+func New(conn storage.Repository, msgProducer message.TopicProducer, broker *pubsub.Broker) (*Server, error) {
 	if conn == nil {
 		return nil, errors.New("database connector cannot be nil")
 	}
 	return &Server{
-		GraphQL: graphql.New(conn, msgProducer),
-		Rest:    rest.New(conn, msgProducer),
+		GraphQL: graphql.New(conn, msgProducer, broker),
+		Rest:    rest.New(conn, msgProducer, broker),
 	}, nil
 }
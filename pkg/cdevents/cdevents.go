@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cdevents reads and writes CDEvents carried by CloudEvents. Incoming events are
+// validated against the schema of their type in the CDEvents spec SpecVersion, the schemas of
+// the spec are embedded from the schemas directory.
+package cdevents
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	// CloudEventsSpecVersion is the CloudEvents version of the envelope
+	CloudEventsSpecVersion = "1.0"
+	// ContentType is the media type of a CloudEvent in structured mode
+	ContentType = "application/cloudevents+json"
+	// SpecVersion is the CDEvents version of the events EPR emits
+	SpecVersion = "0.3.0"
+	// TypePrefix starts the type of every CDEvent
+	TypePrefix = "dev.cdevents."
+)
+
+const (
+	// TypeTaskRunFinished is the type EPR emits for its events
+	TypeTaskRunFinished = "dev.cdevents.taskrun.finished.0.1.1"
+	// TypePipelineRunFinished is the type EPR emits for the completions, failures and
+	// regressions of its groups
+	TypePipelineRunFinished = "dev.cdevents.pipelinerun.finished.0.1.1"
+)
+
+const (
+	// OutcomeSuccess is the outcome of a run that succeeded
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of a run that failed
+	OutcomeFailure = "failure"
+	// OutcomeError is the outcome of a run that could not complete
+	OutcomeError = "error"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+var typePattern = regexp.MustCompile(`^dev\.cdevents\.([a-z]+)\.([a-z]+)\.([0-9]+\.[0-9]+\.[0-9]+(?:-.+)?)$`)
+
+// CloudEvent is the envelope of a CDEvent in structured mode
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// CDEvent is the body of a CloudEvent whose type starts with TypePrefix
+type CDEvent struct {
+	Context               Context         `json:"context"`
+	Subject               Subject         `json:"subject"`
+	CustomData            json.RawMessage `json:"customData,omitempty"`
+	CustomDataContentType string          `json:"customDataContentType,omitempty"`
+}
+
+// Context identifies a CDEvent
+type Context struct {
+	Version   string `json:"version"`
+	ID        string `json:"id"`
+	Source    string `json:"source"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Subject is what a CDEvent is about, the content depends on the type of the event
+type Subject struct {
+	ID      string         `json:"id"`
+	Source  string         `json:"source,omitempty"`
+	Type    string         `json:"type"`
+	Content map[string]any `json:"content"`
+}
+
+// Type is the parsed type of a CDEvent, e.g. dev.cdevents.artifact.packaged.0.1.1
+type Type struct {
+	Subject   string
+	Predicate string
+	Version   string
+}
+
+// ParseType splits the type of a CDEvent into its subject, predicate and version
+func ParseType(t string) (Type, error) {
+	match := typePattern.FindStringSubmatch(t)
+	if match == nil {
+		return Type{}, fmt.Errorf("%q is not a CDEvents type", t)
+	}
+	return Type{Subject: match[1], Predicate: match[2], Version: match[3]}, nil
+}
+
+// Schema returns the schema of the CDEvents type in the spec SpecVersion. Each subject and
+// predicate has a single version in the spec, other versions are rejected.
+func Schema(t string) ([]byte, error) {
+	parsed, err := ParseType(t)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := schemas.ReadFile(fmt.Sprintf("schemas/%s%s.json", parsed.Subject, parsed.Predicate))
+	if err != nil {
+		return nil, fmt.Errorf("unknown CDEvents type %q", t)
+	}
+
+	var probe struct {
+		Properties struct {
+			Context struct {
+				Properties struct {
+					Type struct {
+						Enum []string `json:"enum"`
+					} `json:"type"`
+				} `json:"properties"`
+			} `json:"context"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse the schema of %q: %w", t, err)
+	}
+	if types := probe.Properties.Context.Properties.Type.Enum; !slices.Contains(types, t) {
+		return nil, fmt.Errorf("unsupported CDEvents type %q, CDEvents %s defines %s", t, SpecVersion, strings.Join(types, ", "))
+	}
+	return schema, nil
+}
+
+// ParseCloudEvent reads a CloudEvent in structured mode
+func ParseCloudEvent(data []byte) (*CloudEvent, error) {
+	event := &CloudEvent{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudEvent: %w", err)
+	}
+	return event, event.Validate()
+}
+
+// Validate checks the required attributes of the CloudEvent
+func (c CloudEvent) Validate() error {
+	var err error
+	if c.SpecVersion != CloudEventsSpecVersion {
+		err = errors.Join(err, fmt.Errorf("unsupported CloudEvents specversion %q, expected %s", c.SpecVersion, CloudEventsSpecVersion))
+	}
+	for attribute, value := range map[string]string{"id": c.ID, "source": c.Source, "type": c.Type} {
+		if strings.TrimSpace(value) == "" {
+			err = errors.Join(err, fmt.Errorf("CloudEvent %s is required", attribute))
+		}
+	}
+	if len(c.Data) == 0 {
+		err = errors.Join(err, errors.New("CloudEvent data is required"))
+	}
+	return err
+}
+
+// CDEvent validates the data of the CloudEvent against the schema of its type and returns it.
+// The type of the CloudEvent must be the type of the CDEvent.
+func (c CloudEvent) CDEvent() (*CDEvent, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	event, err := Parse(c.Data)
+	if err != nil {
+		return nil, err
+	}
+	if event.Context.Type != c.Type {
+		return nil, fmt.Errorf("CloudEvent type %q does not match the CDEvent type %q", c.Type, event.Context.Type)
+	}
+	return event, nil
+}
+
+// Parse validates the CDEvent against the schema of its type and returns it
+func Parse(data []byte) (*CDEvent, error) {
+	var probe struct {
+		Context struct {
+			Type string `json:"type"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse CDEvent: %w", err)
+	}
+	schema, err := Schema(probe.Context.Type)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate CDEvent: %w", err)
+	}
+	if !result.Valid() {
+		err = fmt.Errorf("CDEvent does not match the schema of %s", probe.Context.Type)
+		for _, e := range result.Errors() {
+			err = errors.Join(err, errors.New(e.String()))
+		}
+		return nil, err
+	}
+
+	event := &CDEvent{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to parse CDEvent: %w", err)
+	}
+	return event, nil
+}
+
+// Success reports whether the outcome of the subject, if it has one, is a success
+func (e CDEvent) Success() bool {
+	outcome, ok := e.Subject.Content["outcome"].(string)
+	return !ok || outcome == "" || outcome == OutcomeSuccess
+}
+
+// Artifact returns the package URL of the artifact the event is about: the subject when it is
+// an artifact, else the artifactId of its content. It is empty when the event names none.
+func (e CDEvent) Artifact() *PURL {
+	if purl, err := ParsePURL(e.Subject.ID); err == nil {
+		return purl
+	}
+	if id, ok := e.Subject.Content["artifactId"].(string); ok {
+		if purl, err := ParsePURL(id); err == nil {
+			return purl
+		}
+	}
+	return nil
+}
+
+// PURL is a package URL, pkg:type/namespace/name@version
+type PURL struct {
+	Type      string
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// ParsePURL reads the type, namespace, name and version of a package URL
+func ParsePURL(s string) (*PURL, error) {
+	rest, ok := strings.CutPrefix(s, "pkg:")
+	if !ok {
+		return nil, fmt.Errorf("%q is not a package URL", s)
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+	rest, version, _ := strings.Cut(rest, "@")
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" || segments[len(segments)-1] == "" {
+		return nil, fmt.Errorf("%q is not a package URL", s)
+	}
+
+	purl := &PURL{
+		Type:      strings.ToLower(segments[0]),
+		Namespace: strings.Join(segments[1:len(segments)-1], "/"),
+		Name:      segments[len(segments)-1],
+		Version:   version,
+	}
+	for _, field := range []*string{&purl.Namespace, &purl.Name, &purl.Version} {
+		unescaped, err := url.PathUnescape(*field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a package URL: %w", s, err)
+		}
+		*field = unescaped
+	}
+	return purl, nil
+}
+
+// New returns a CloudEvent carrying a CDEvent of the type about the subject. The context of the
+// CDEvent and the attributes of the CloudEvent share the id, source, type and time.
+func New(id, source, eventType, timestamp string, subject Subject, customData any) (*CloudEvent, error) {
+	event := CDEvent{
+		Context: Context{
+			Version:   SpecVersion,
+			ID:        id,
+			Source:    source,
+			Type:      eventType,
+			Timestamp: timestamp,
+		},
+		Subject: subject,
+	}
+	if customData != nil {
+		data, err := json.Marshal(customData)
+		if err != nil {
+			return nil, err
+		}
+		event.CustomData = data
+		event.CustomDataContentType = "application/json"
+	}
+	return Wrap(event)
+}
+
+// Wrap puts the CDEvent in a CloudEvent with the same id, source, type and time
+func Wrap(event CDEvent) (*CloudEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              event.Context.ID,
+		Source:          event.Context.Source,
+		Type:            event.Context.Type,
+		Time:            event.Context.Timestamp,
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cdevents
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseType(t *testing.T) {
+	parsed, err := ParseType("dev.cdevents.artifact.packaged.0.1.1")
+	assert.NilError(t, err)
+	assert.Equal(t, parsed, Type{Subject: "artifact", Predicate: "packaged", Version: "0.1.1"})
+
+	parsed, err = ParseType("dev.cdevents.pipelinerun.finished.0.2.0-draft")
+	assert.NilError(t, err)
+	assert.Equal(t, parsed.Version, "0.2.0-draft")
+
+	_, err = ParseType("com.example.artifact.packaged")
+	assert.ErrorContains(t, err, "is not a CDEvents type")
+
+	_, err = Schema("dev.cdevents.artifact.frobnicated.0.1.0")
+	assert.ErrorContains(t, err, "unknown CDEvents type")
+
+	_, err = Schema("dev.cdevents.artifact.packaged.0.2.0")
+	assert.ErrorContains(t, err, `unsupported CDEvents type "dev.cdevents.artifact.packaged.0.2.0", CDEvents 0.3.0 defines dev.cdevents.artifact.packaged.0.1.1`)
+
+	for _, eventType := range []string{TypeTaskRunFinished, TypePipelineRunFinished} {
+		_, err = Schema(eventType)
+		assert.NilError(t, err)
+	}
+}
+
+func TestParsePURL(t *testing.T) {
+	purl, err := ParsePURL("pkg:golang/mygit.com/myorg/myapp@234fd47e07d1004f0aed9c")
+	assert.NilError(t, err)
+	assert.Equal(t, *purl, PURL{Type: "golang", Namespace: "mygit.com/myorg", Name: "myapp", Version: "234fd47e07d1004f0aed9c"})
+
+	purl, err = ParsePURL("pkg:oci/foo@sha256%3Aabc123?repository_url=example.com/foo")
+	assert.NilError(t, err)
+	assert.Equal(t, *purl, PURL{Type: "oci", Name: "foo", Version: "sha256:abc123"})
+
+	_, err = ParsePURL("myapp")
+	assert.ErrorContains(t, err, "is not a package URL")
+	_, err = ParsePURL("pkg:npm")
+	assert.ErrorContains(t, err, "is not a package URL")
+}
+
+func TestCloudEventCDEvent(t *testing.T) {
+	data, err := os.ReadFile("testdata/artifact-packaged.json")
+	assert.NilError(t, err)
+
+	cloudEvent, err := ParseCloudEvent(data)
+	assert.NilError(t, err)
+	event, err := cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Context.Type, "dev.cdevents.artifact.packaged.0.1.1")
+	assert.Equal(t, event.Success(), true)
+	assert.Equal(t, event.Artifact().Name, "myapp")
+
+	mismatched := *cloudEvent
+	mismatched.Type = "dev.cdevents.artifact.published.0.1.1"
+	_, err = mismatched.CDEvent()
+	assert.ErrorContains(t, err, "does not match the CDEvent type")
+
+	// artifact packaged events must name the change the artifact was packaged from
+	invalid := *cloudEvent
+	invalid.Data = json.RawMessage(strings.Replace(string(cloudEvent.Data), `"change"`, `"changes"`, 1))
+	_, err = invalid.CDEvent()
+	assert.ErrorContains(t, err, "does not match the schema of dev.cdevents.artifact.packaged.0.1.1")
+	assert.ErrorContains(t, err, "change is required")
+
+	_, err = ParseCloudEvent([]byte(`{"specversion": "0.3", "data": {}}`))
+	assert.ErrorContains(t, err, `unsupported CloudEvents specversion "0.3"`)
+	assert.ErrorContains(t, err, "CloudEvent id is required")
+}
+
+func TestNew(t *testing.T) {
+	cloudEvent, err := New("01HGBDRPZRT96R4586GFA13W91", "epr", TypeTaskRunFinished, "2024-01-01T00:00:00Z", Subject{
+		ID:      "01HGBDRPZRT96R4586GFA13W91",
+		Type:    "taskRun",
+		Content: map[string]any{"taskName": "build", "outcome": OutcomeFailure},
+	}, map[string]string{"name": "foo"})
+	assert.NilError(t, err)
+	assert.Equal(t, cloudEvent.Type, TypeTaskRunFinished)
+
+	// what EPR emits is valid against the schemas it ingests with
+	event, err := cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Success(), false)
+	assert.Equal(t, string(event.CustomData), `{"name":"foo"}`)
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"net/url"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// IngestCDEvent records the CDEvent of a structured mode CloudEvent as an event of the oldest
+// receiver of its type. The non empty name, version, release, platform id, package and
+// description of the event override the values derived from the CDEvent. This function returns
+// a JSON blob with the ID of the Event it created.
+func (c *Client) IngestCDEvent(cloudEvent []byte, e storage.Event) (string, error) {
+	endpoint, err := c.GetEndpoint("/cdevents")
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"name":        e.Name,
+		"version":     e.Version,
+		"release":     e.Release,
+		"platform_id": e.PlatformID,
+		"package":     e.Package,
+		"description": e.Description,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	content, err := c.DoPost(endpoint+"?"+query.Encode(), cloudEvent)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
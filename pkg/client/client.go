@@ -13,6 +13,9 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
@@ -22,9 +25,28 @@ var _ Contract = &Client{}
 // Contract handles communications with the EPR service
 type Contract interface {
 	CreateEvent(e *storage.Event) (string, error)
+	CreateSignedEvent(e *storage.Event, envelope *signing.Envelope) (string, error)
+	RevokeEvent(id graphql.ID, revokedBy, reason string) (string, error)
 	CreateEventReceiver(er *storage.EventReceiver) (string, error)
+	GetOrCreateEventReceiver(er *storage.EventReceiver) (string, error)
+	CreateEventReceiverSchema(id graphql.ID, schema types.JSON, compatibility string) (string, error)
+	GetEventReceiverSchemas(id graphql.ID) (string, error)
+	SetEventReceiverSigning(id graphql.ID, publicKeys []string, requireSignature bool) (string, error)
 	CreateEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error)
+	GetOrCreateEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error)
 	ModifyEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error)
+	UpdateEventReceiverGroup(id graphql.ID, update storage.EventReceiverGroupUpdate) (string, error)
+	DeleteEventReceiverGroup(id graphql.ID) (string, error)
+	ExportProvenance(tuple storage.Event, format string, digests []string) (string, error)
+	IngestSBOM(document []byte, e storage.Event) (string, error)
+	SearchSBOMComponents(filter storage.SBOMComponentFilter) (string, error)
+	IngestCDEvent(cloudEvent []byte, e storage.Event) (string, error)
+	CreateWebhookSubscription(subscription *storage.WebhookSubscription) (string, error)
+	GetWebhookSubscriptions(id graphql.ID) (string, error)
+	UpdateWebhookSubscription(id graphql.ID, update storage.WebhookSubscriptionUpdate) (string, error)
+	DeleteWebhookSubscription(id graphql.ID) (string, error)
+	GetWebhookDeliveries(id graphql.ID, state string) (string, error)
+	RedeliverWebhookDelivery(id graphql.ID) (string, error)
 	Search(operation string, params map[string]interface{}, fields []string) (string, error)
 	SearchEvents(params map[string]interface{}, fields []string) ([]storage.Event, error)
 	SearchEventReceivers(params map[string]interface{}, fields []string) ([]storage.EventReceiver, error)
@@ -6,6 +6,9 @@ package client
 import (
 	"encoding/json"
 
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
 
@@ -28,6 +31,51 @@ func (c *Client) CreateEvent(e *storage.Event) (string, error) {
 	return content, nil
 }
 
+// CreateSignedEvent creates an Event signed with the DSSE envelope
+func (c *Client) CreateSignedEvent(e *storage.Event, envelope *signing.Envelope) (string, error) {
+	endpoint, err := c.GetEndpoint("/events")
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(SignedEvent{Event: e, Signature: envelope})
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// SignedEvent is the body of a request that creates a signed event
+type SignedEvent struct {
+	*storage.Event
+	Signature *signing.Envelope `json:"signature"`
+}
+
+// RevokeEvent revokes an Event, recording who revoked it and why. This function returns a JSON blob
+// with the ID of the Event it revoked.
+func (c *Client) RevokeEvent(id graphql.ID, revokedBy, reason string) (string, error) {
+	endpoint, err := c.GetEndpoint("/events/" + string(id) + "/revoke")
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(map[string]any{"revoked_by": revokedBy, "reason": reason})
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
 // CreateEventReceiver used to create an EventReceiver
 func (c *Client) CreateEventReceiver(er *storage.EventReceiver) (string, error) {
 	endpoint, err := c.GetEndpoint("/receivers")
@@ -47,6 +95,85 @@ func (c *Client) CreateEventReceiver(er *storage.EventReceiver) (string, error)
 	return content, nil
 }
 
+// GetOrCreateEventReceiver returns a JSON blob with the ID of the EventReceiver with the same name, type,
+// version and description, creating it when there is none
+func (c *Client) GetOrCreateEventReceiver(er *storage.EventReceiver) (string, error) {
+	endpoint, err := c.GetEndpoint("/receivers")
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(er)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint+"?get_or_create=true", enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// CreateEventReceiverSchema registers a new schema revision for an EventReceiver. The compatibility
+// defaults to FULL when blank. This function returns a JSON blob with the new revision.
+func (c *Client) CreateEventReceiverSchema(id graphql.ID, schema types.JSON, compatibility string) (string, error) {
+	endpoint, err := c.GetEndpoint("/receivers/" + string(id) + "/schemas")
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(map[string]any{"schema": schema, "compatibility": compatibility})
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// SetEventReceiverSigning replaces the PEM encoded public keys trusted to sign the events of an
+// EventReceiver and whether its events must be signed. This function returns a JSON blob with
+// the ID of the EventReceiver.
+func (c *Client) SetEventReceiverSigning(id graphql.ID, publicKeys []string, requireSignature bool) (string, error) {
+	endpoint, err := c.GetEndpoint("/receivers/" + string(id) + "/signing")
+	if err != nil {
+		return "", err
+	}
+	if publicKeys == nil {
+		publicKeys = []string{}
+	}
+	enc, err := json.Marshal(map[string]any{"public_keys": publicKeys, "require_signature": requireSignature})
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPut(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// GetEventReceiverSchemas returns a JSON blob with the schema revisions of an EventReceiver
+func (c *Client) GetEventReceiverSchemas(id graphql.ID) (string, error) {
+	endpoint, err := c.GetEndpoint("/receivers/" + string(id) + "/schemas")
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoGet(endpoint)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
 // CreateEventReceiverGroup used to create an EventReceiverGroup
 func (c *Client) CreateEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error) {
 	endpoint, err := c.GetEndpoint("/groups")
@@ -66,14 +193,40 @@ func (c *Client) CreateEventReceiverGroup(erg *storage.EventReceiverGroup) (stri
 	return content, nil
 }
 
-// ModifyEventReceiverGroup takes a EventReceiverGroup object and updates the "Disabled" field in the EPR based on the EventReceiverGroup ID. This
+// GetOrCreateEventReceiverGroup returns a JSON blob with the ID of the EventReceiverGroup with the same
+// name, type, version and description, creating it when there is none
+func (c *Client) GetOrCreateEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error) {
+	endpoint, err := c.GetEndpoint("/groups")
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(erg)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint+"?get_or_create=true", enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// ModifyEventReceiverGroup takes a EventReceiverGroup object and updates the "Enabled" field in the EPR based on the EventReceiverGroup ID. This
 // function returns a JSON blob with the ID of the EventReceiverGroup it modified.
 func (c *Client) ModifyEventReceiverGroup(erg *storage.EventReceiverGroup) (string, error) {
-	endpoint, err := c.GetEndpoint("/groups/" + string(erg.ID))
+	return c.UpdateEventReceiverGroup(erg.ID, storage.EventReceiverGroupUpdate{Enabled: &erg.Enabled})
+}
+
+// UpdateEventReceiverGroup changes the fields of an EventReceiverGroup that are set in the update. This
+// function returns a JSON blob with the ID of the EventReceiverGroup it updated.
+func (c *Client) UpdateEventReceiverGroup(id graphql.ID, update storage.EventReceiverGroupUpdate) (string, error) {
+	endpoint, err := c.GetEndpoint("/groups/" + string(id))
 	if err != nil {
 		return "", err
 	}
-	enc, err := json.Marshal(erg)
+	enc, err := json.Marshal(update)
 	if err != nil {
 		return "", err
 	}
@@ -85,3 +238,19 @@ func (c *Client) ModifyEventReceiverGroup(erg *storage.EventReceiverGroup) (stri
 
 	return content, nil
 }
+
+// DeleteEventReceiverGroup deletes an EventReceiverGroup. This function returns a JSON blob with the ID of
+// the EventReceiverGroup it deleted.
+func (c *Client) DeleteEventReceiverGroup(id graphql.ID) (string, error) {
+	endpoint, err := c.GetEndpoint("/groups/" + string(id))
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoDelete(endpoint, nil)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
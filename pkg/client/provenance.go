@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// ExportProvenance renders the events and passed groups of the artifact of the tuple as an
+// in-toto statement with the SLSA or EPR predicate. The digests of the form algorithm:hex
+// identify the artifact as the subject. This function returns the JSON blob of the statement.
+func (c *Client) ExportProvenance(tuple storage.Event, format string, digests []string) (string, error) {
+	endpoint, err := c.GetEndpoint("/provenance")
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("name", tuple.Name)
+	query.Set("version", tuple.Version)
+	query.Set("release", tuple.Release)
+	query.Set("platform_id", tuple.PlatformID)
+	query.Set("package", tuple.Package)
+	if format != "" {
+		query.Set("format", format)
+	}
+	for _, digest := range digests {
+		query.Add("digest", digest)
+	}
+
+	content, err := c.DoGet(endpoint + "?" + query.Encode())
+	if err != nil {
+		return content, err
+	}
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(content), &resp); err != nil {
+		return content, err
+	}
+	return string(resp.Data), nil
+}
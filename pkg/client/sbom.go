@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"net/url"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// IngestSBOM creates an event from a CycloneDX or SPDX JSON document. The event receiver id,
+// release and platform id of the event are required, its name, version, package and
+// description default to values derived from the document when they are empty. This function
+// returns a JSON blob with the ID of the Event it created.
+func (c *Client) IngestSBOM(document []byte, e storage.Event) (string, error) {
+	endpoint, err := c.GetEndpoint("/sboms")
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"event_receiver_id": string(e.EventReceiverID),
+		"release":           e.Release,
+		"platform_id":       e.PlatformID,
+		"name":              e.Name,
+		"version":           e.Version,
+		"package":           e.Package,
+		"description":       e.Description,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	content, err := c.DoPost(endpoint+"?"+query.Encode(), document)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// SearchSBOMComponents finds the components of ingested SBOMs matching the filter along with
+// the events carrying them. This function returns a JSON blob with the components.
+func (c *Client) SearchSBOMComponents(filter storage.SBOMComponentFilter) (string, error) {
+	endpoint, err := c.GetEndpoint("/sboms/components")
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"name":           filter.Name,
+		"group":          filter.Group,
+		"version":        filter.Version,
+		"version_prefix": filter.VersionPrefix,
+		"purl_prefix":    filter.PURLPrefix,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	content, err := c.DoGet(endpoint + "?" + query.Encode())
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// CreateWebhookSubscription subscribes the URL of the subscription to the messages that match
+// its filters. This function returns a JSON blob with the ID of the subscription it created.
+func (c *Client) CreateWebhookSubscription(subscription *storage.WebhookSubscription) (string, error) {
+	endpoint, err := c.GetEndpoint("/webhooks")
+	if err != nil {
+		return "", err
+	}
+	// the secret is left out of the JSON of a subscription
+	enc, err := json.Marshal(struct {
+		*storage.WebhookSubscription
+		Secret string `json:"secret"`
+	}{subscription, subscription.Secret})
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// GetWebhookSubscriptions returns a JSON blob with every webhook subscription, or with the one
+// with the id when it is not empty
+func (c *Client) GetWebhookSubscriptions(id graphql.ID) (string, error) {
+	path := "/webhooks"
+	if id != "" {
+		path += "/" + string(id)
+	}
+	endpoint, err := c.GetEndpoint(path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoGet(endpoint)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// UpdateWebhookSubscription changes the fields of the subscription set in the update. This
+// function returns a JSON blob with the ID of the subscription.
+func (c *Client) UpdateWebhookSubscription(id graphql.ID, update storage.WebhookSubscriptionUpdate) (string, error) {
+	endpoint, err := c.GetEndpoint("/webhooks/" + string(id))
+	if err != nil {
+		return "", err
+	}
+	enc, err := json.Marshal(update)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPatch(endpoint, enc)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// DeleteWebhookSubscription deletes a subscription and its delivery log. This function returns
+// a JSON blob with the ID of the subscription it deleted.
+func (c *Client) DeleteWebhookSubscription(id graphql.ID) (string, error) {
+	endpoint, err := c.GetEndpoint("/webhooks/" + string(id))
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoDelete(endpoint, nil)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// GetWebhookDeliveries returns a JSON blob with the latest deliveries of a subscription, only
+// those in the state when it is not empty
+func (c *Client) GetWebhookDeliveries(id graphql.ID, state string) (string, error) {
+	endpoint, err := c.GetEndpoint("/webhooks/" + string(id) + "/deliveries")
+	if err != nil {
+		return "", err
+	}
+	if state != "" {
+		endpoint += "?" + url.Values{"state": {state}}.Encode()
+	}
+
+	content, err := c.DoGet(endpoint)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
+
+// RedeliverWebhookDelivery posts a delivery again with a fresh set of retries. This function
+// returns a JSON blob with the ID of the delivery.
+func (c *Client) RedeliverWebhookDelivery(id graphql.ID) (string, error) {
+	endpoint, err := c.GetEndpoint("/webhooks/deliveries/" + string(id) + "/redeliver")
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.DoPost(endpoint, nil)
+	if err != nil {
+		return content, err
+	}
+
+	return content, nil
+}
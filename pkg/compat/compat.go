@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package compat checks that a new revision of an event receiver JSON schema is compatible with
+// the revision it replaces. The check is structural: it compares the type, enum, required,
+// properties, additionalProperties and items keywords and ignores the rest.
+package compat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// Validate checks that the compatibility mode is known
+func Validate(mode string) error {
+	switch mode {
+	case storage.CompatibilityBackward, storage.CompatibilityForward, storage.CompatibilityFull, storage.CompatibilityNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown schema compatibility %q", mode)
+	}
+}
+
+// Check returns the changes from the previous to the next schema that break the mode, joined in
+// a single error. BACKWARD rejects changes that make payloads valid under the previous schema
+// invalid, such as added required fields. FORWARD rejects changes that let payloads through the
+// previous schema would have rejected, such as removed required fields. FULL rejects both.
+func Check(previous, next []byte, mode string) error {
+	if err := Validate(mode); err != nil {
+		return err
+	}
+	if mode == storage.CompatibilityNone {
+		return nil
+	}
+
+	var before, after any
+	if err := json.Unmarshal(previous, &before); err != nil {
+		return fmt.Errorf("failed to parse previous schema: %w", err)
+	}
+	if err := json.Unmarshal(next, &after); err != nil {
+		return fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	c := checker{
+		backward: mode == storage.CompatibilityBackward || mode == storage.CompatibilityFull,
+		forward:  mode == storage.CompatibilityForward || mode == storage.CompatibilityFull,
+	}
+	c.compare("(root)", object(before), object(after))
+	return errors.Join(c.errs...)
+}
+
+type checker struct {
+	backward bool
+	forward  bool
+	errs     []error
+}
+
+func (c *checker) fail(format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf(format, args...))
+}
+
+func (c *checker) compare(path string, before, after map[string]any) {
+	beforeTypes, afterTypes := typesOf(before), typesOf(after)
+	if c.backward && len(afterTypes) > 0 && !subset(beforeTypes, afterTypes) {
+		c.fail("%s: type narrowed from %v to %v", path, names(beforeTypes), names(afterTypes))
+	}
+	if c.forward && len(beforeTypes) > 0 && !subset(afterTypes, beforeTypes) {
+		c.fail("%s: type widened from %v to %v", path, names(beforeTypes), names(afterTypes))
+	}
+
+	beforeEnum, beforeHasEnum := before["enum"].([]any)
+	afterEnum, afterHasEnum := after["enum"].([]any)
+	if c.backward && afterHasEnum && (!beforeHasEnum || !containsAll(afterEnum, beforeEnum)) {
+		c.fail("%s: enum values removed", path)
+	}
+	if c.forward && beforeHasEnum && (!afterHasEnum || !containsAll(beforeEnum, afterEnum)) {
+		c.fail("%s: enum values added", path)
+	}
+
+	beforeRequired, afterRequired := requiredOf(before), requiredOf(after)
+	for _, field := range sortedKeys(beforeRequired) {
+		if c.forward && !afterRequired[field] {
+			c.fail("%s: required field %s removed", path, field)
+		}
+	}
+	for _, field := range sortedKeys(afterRequired) {
+		if c.backward && !beforeRequired[field] {
+			c.fail("%s: required field %s added", path, field)
+		}
+	}
+
+	beforeClosed, afterClosed := closed(before), closed(after)
+	if c.backward && afterClosed && !beforeClosed {
+		c.fail("%s: additional properties are no longer allowed", path)
+	}
+	if c.forward && beforeClosed && !afterClosed {
+		c.fail("%s: additional properties are now allowed", path)
+	}
+
+	beforeProperties, afterProperties := object(before["properties"]), object(after["properties"])
+	for _, field := range sortedKeys(beforeProperties) {
+		if _, ok := afterProperties[field]; !ok && c.backward && afterClosed {
+			c.fail("%s: field %s removed while additional properties are not allowed", path, field)
+		}
+	}
+	for _, field := range sortedKeys(afterProperties) {
+		beforeProperty, ok := beforeProperties[field]
+		if !ok {
+			if c.forward && beforeClosed {
+				c.fail("%s: field %s added while additional properties were not allowed", path, field)
+			}
+			continue
+		}
+		c.compare(join(path, field), object(beforeProperty), object(afterProperties[field]))
+	}
+
+	beforeItems, beforeHasItems := before["items"].(map[string]any)
+	afterItems, afterHasItems := after["items"].(map[string]any)
+	if beforeHasItems && afterHasItems {
+		c.compare(path+"[]", beforeItems, afterItems)
+	}
+}
+
+// object returns the schema as a map, schemas such as true or a missing keyword accept anything
+func object(schema any) map[string]any {
+	if m, ok := schema.(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+func typesOf(schema map[string]any) map[string]bool {
+	types := map[string]bool{}
+	switch t := schema["type"].(type) {
+	case string:
+		types[t] = true
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types[s] = true
+			}
+		}
+	}
+	return types
+}
+
+// subset reports whether every type of a is allowed by b. An empty a allows anything, so it is
+// only a subset of an empty b.
+func subset(a, b map[string]bool) bool {
+	if len(b) == 0 {
+		return true
+	}
+	if len(a) == 0 {
+		return false
+	}
+	for t := range a {
+		if !b[t] && !(t == "integer" && b["number"]) {
+			return false
+		}
+	}
+	return true
+}
+
+func requiredOf(schema map[string]any) map[string]bool {
+	required := map[string]bool{}
+	fields, _ := schema["required"].([]any)
+	for _, field := range fields {
+		if s, ok := field.(string); ok {
+			required[s] = true
+		}
+	}
+	return required
+}
+
+func closed(schema map[string]any) bool {
+	additional, ok := schema["additionalProperties"].(bool)
+	return ok && !additional
+}
+
+// containsAll reports whether every value of want is in values
+func containsAll(values, want []any) bool {
+	for _, w := range want {
+		found := false
+		for _, v := range values {
+			if reflect.DeepEqual(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func names(types map[string]bool) []string {
+	if len(types) == 0 {
+		return []string{"any"}
+	}
+	return sortedKeys(types)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, field string) string {
+	if path == "(root)" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package compat
+
+import (
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+const base = `{
+	"type": "object",
+	"required": ["sha"],
+	"properties": {
+		"sha": {"type": "string"},
+		"count": {"type": "integer"},
+		"level": {"enum": ["low", "high"]}
+	}
+}`
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		next string
+		mode string
+		err  string
+	}{
+		{
+			name: "added optional field",
+			next: `{"type": "object", "required": ["sha"], "properties": {"sha": {"type": "string"}, "count": {"type": "integer"}, "level": {"enum": ["low", "high"]}, "url": {"type": "string"}}}`,
+			mode: storage.CompatibilityFull,
+		},
+		{
+			name: "removed required field",
+			next: `{"type": "object", "properties": {"count": {"type": "integer"}, "level": {"enum": ["low", "high"]}}}`,
+			mode: storage.CompatibilityFull,
+			err:  "(root): required field sha removed",
+		},
+		{
+			name: "removed required field is backward compatible",
+			next: `{"type": "object", "properties": {"count": {"type": "integer"}, "level": {"enum": ["low", "high"]}}}`,
+			mode: storage.CompatibilityBackward,
+		},
+		{
+			name: "added required field",
+			next: `{"type": "object", "required": ["sha", "count"], "properties": {"sha": {"type": "string"}, "count": {"type": "integer"}, "level": {"enum": ["low", "high"]}}}`,
+			mode: storage.CompatibilityBackward,
+			err:  "(root): required field count added",
+		},
+		{
+			name: "added required field is forward compatible",
+			next: `{"type": "object", "required": ["sha", "count"], "properties": {"sha": {"type": "string"}, "count": {"type": "integer"}, "level": {"enum": ["low", "high"]}}}`,
+			mode: storage.CompatibilityForward,
+		},
+		{
+			name: "widened type",
+			next: `{"type": "object", "required": ["sha"], "properties": {"sha": {"type": "string"}, "count": {"type": "number"}, "level": {"enum": ["low", "high"]}}}`,
+			mode: storage.CompatibilityFull,
+			err:  "count: type widened from [integer] to [number]",
+		},
+		{
+			name: "narrowed enum",
+			next: `{"type": "object", "required": ["sha"], "properties": {"sha": {"type": "string"}, "count": {"type": "integer"}, "level": {"enum": ["low"]}}}`,
+			mode: storage.CompatibilityBackward,
+			err:  "level: enum values removed",
+		},
+		{
+			name: "closed additional properties",
+			next: `{"type": "object", "required": ["sha"], "additionalProperties": false, "properties": {"sha": {"type": "string"}}}`,
+			mode: storage.CompatibilityBackward,
+			err: "(root): additional properties are no longer allowed\n" +
+				"(root): field count removed while additional properties are not allowed\n" +
+				"(root): field level removed while additional properties are not allowed",
+		},
+		{
+			name: "anything goes",
+			next: `{"type": "string"}`,
+			mode: storage.CompatibilityNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check([]byte(base), []byte(tt.next), tt.mode)
+			if tt.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tt.err)
+		})
+	}
+}
+
+func TestCheckNested(t *testing.T) {
+	previous := `{"properties": {"build": {"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}}}}`
+	next := `{"properties": {"build": {"type": "object", "properties": {"id": {"type": "string"}}}}}`
+	assert.Error(t, Check([]byte(previous), []byte(next), storage.CompatibilityFull), "build: required field id removed")
+
+	previous = `{"type": "array", "items": {"type": "string"}}`
+	next = `{"type": "array", "items": {"type": "integer"}}`
+	assert.ErrorContains(t, Check([]byte(previous), []byte(next), storage.CompatibilityBackward), "(root)[]: type narrowed from [string] to [integer]")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NilError(t, Validate(storage.CompatibilityFull))
+	assert.Error(t, Validate("SIDEWAYS"), `unknown schema compatibility "SIDEWAYS"`)
+	assert.Error(t, Check([]byte(`{}`), []byte(`{}`), ""), `unknown schema compatibility ""`)
+}
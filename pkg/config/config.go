@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 )
 
@@ -18,10 +19,12 @@ var (
 
 // Config contains application data for the gatekeeper application
 type Config struct {
-	Server  *ServerConfig  `json:"server"`
-	Storage *StorageConfig `json:"storage"`
-	Kafka   *KafkaConfig   `json:"kafka"`
-	Auth    *AuthConfig    `json:"-"`
+	Server   *ServerConfig  `json:"server"`
+	Storage  *StorageConfig `json:"storage"`
+	Kafka    *KafkaConfig   `json:"kafka"`
+	Sinks    *SinkConfig    `json:"sinks"`
+	Webhooks *WebhookConfig `json:"webhooks"`
+	Auth     *AuthConfig    `json:"-"`
 }
 
 type ServerConfig struct {
@@ -40,6 +43,7 @@ func (s *ServerConfig) GetSrvAddr() string {
 
 // StorageConfig holds config information about the database.
 type StorageConfig struct {
+	Driver          string `json:"driver"`
 	Name            string `json:"name"`
 	Host            string `json:"-"`
 	Port            int    `json:"-"`
@@ -49,6 +53,7 @@ type StorageConfig struct {
 	MaxConnections  int    `json:"db_max_connections"`
 	IdleConnections int    `json:"db_idle_connections"`
 	ConnectionLife  int    `json:"db_connection_max_life"`
+	MaxPageSize     int    `json:"max_page_size"`
 }
 
 // AuthConfig holds config data for authentication.
@@ -63,18 +68,79 @@ type KafkaConfig struct {
 	Version string   `json:"version"`
 	Topic   string   `json:"topic"`
 	Peers   []string `json:"peers"`
+	// MessageFormat is the format of the produced messages, epr or cdevents
+	MessageFormat string `json:"message_format"`
+	// MessageMode is the CloudEvents content mode of the produced messages, structured or binary
+	MessageMode string `json:"message_mode"`
+	// LegacyMessages produces messages as they were before they were valid CloudEvents
+	LegacyMessages bool `json:"legacy_messages"`
+	// OutboxInterval is the time between two polls of the outbox by the relay
+	OutboxInterval time.Duration `json:"outbox_interval"`
+	// OutboxBatchSize is the largest number of outbox messages the relay publishes per poll
+	OutboxBatchSize int `json:"outbox_batch_size"`
+	// CAFile is the PEM bundle the broker certificates are verified with, the system pool when empty
+	CAFile string `json:"ca_file"`
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, SASL is off when it is empty
+	SASLMechanism string `json:"sasl_mechanism"`
+	SASLUser      string `json:"sasl_user"`
+	SASLPassword  string `json:"-"`
+}
+
+// SinkConfig holds config information about the backends messages are published to
+type SinkConfig struct {
+	// Backends are the sinks messages are published to, kafka, nats, webhook or file
+	Backends       []string      `json:"backends"`
+	NATSURL        string        `json:"nats_url"`
+	NATSSubject    string        `json:"nats_subject"`
+	WebhookURL     string        `json:"-"`
+	WebhookTimeout time.Duration `json:"webhook_timeout"`
+	FilePath       string        `json:"file_path"`
+}
+
+// Has reports whether messages are published to the backend
+func (s *SinkConfig) Has(backend string) bool {
+	return slices.Contains(s.Backends, backend)
+}
+
+// WebhookConfig holds config information about the deliveries of webhook subscriptions
+type WebhookConfig struct {
+	// MaxAttempts is the number of attempts after which a delivery fails
+	MaxAttempts int32 `json:"max_attempts"`
+	// PauseAfter is the number of deliveries in a row that fail before a subscription is paused
+	PauseAfter int32 `json:"pause_after"`
 }
 
 // LogInfo Dumps most of the config info to the log.
 func (c *Config) LogInfo() {
 	slog.Info("Host: " + c.Server.Host)
 	slog.Info("Port: " + c.Server.Port)
+	slog.Info("Storage Driver: " + c.Storage.Driver)
 	slog.Info("Storage Host: " + c.Storage.Host)
 	slog.Info("Storage Name: " + c.Storage.Name)
+	slog.Info(fmt.Sprintf("Storage Max Page Size: %d", c.Storage.MaxPageSize))
 	slog.Info(fmt.Sprintf("Kafka Peers: %v", c.Kafka.Peers))
 	slog.Info("Kafka Version: " + c.Kafka.Version)
 	slog.Info(fmt.Sprintf("Kafka TLS: %v", c.Kafka.TLS))
+	slog.Info("Kafka CA File: " + c.Kafka.CAFile)
+	slog.Info("Kafka Client Certificate: " + c.Kafka.CertFile)
+	slog.Info("Kafka SASL Mechanism: " + c.Kafka.SASLMechanism)
+	slog.Info("Kafka SASL User: " + c.Kafka.SASLUser)
 	slog.Info("Kafka Topic: " + c.Kafka.Topic)
+	slog.Info("Kafka Message Format: " + c.Kafka.MessageFormat)
+	slog.Info("Kafka Message Mode: " + c.Kafka.MessageMode)
+	slog.Info(fmt.Sprintf("Kafka Legacy Messages: %v", c.Kafka.LegacyMessages))
+	slog.Info(fmt.Sprintf("Kafka Outbox Interval: %v", c.Kafka.OutboxInterval))
+	slog.Info(fmt.Sprintf("Kafka Outbox Batch Size: %d", c.Kafka.OutboxBatchSize))
+	slog.Info(fmt.Sprintf("Sinks: %v", c.Sinks.Backends))
+	slog.Info("NATS URL: " + c.Sinks.NATSURL)
+	slog.Info("NATS Subject: " + c.Sinks.NATSSubject)
+	slog.Info(fmt.Sprintf("Webhook Timeout: %v", c.Sinks.WebhookTimeout))
+	slog.Info("Message File: " + c.Sinks.FilePath)
+	slog.Info(fmt.Sprintf("Webhook Subscription Max Attempts: %d", c.Webhooks.MaxAttempts))
+	slog.Info(fmt.Sprintf("Webhook Subscription Pause After: %d", c.Webhooks.PauseAfter))
 	slog.Info(fmt.Sprintf("Debug: %v", c.Server.Debug))
 	slog.Info(fmt.Sprintf("Verbose API: %v", c.Server.VerboseAPI))
 }
@@ -112,6 +178,32 @@ func WithStorage(host, user, pass, sslMode, name string, port, maxConnections, i
 	}
 }
 
+// WithStorageDriver returns an option that sets the storage backend. It must be applied after WithStorage.
+func WithStorageDriver(driver string) Options {
+	return func(cfg *Config) error {
+		if cfg.Storage == nil {
+			return fmt.Errorf("storage driver set before storage config")
+		}
+		cfg.Storage.Driver = driver
+		return nil
+	}
+}
+
+// WithStorageMaxPageSize returns an option that bounds the size of a page of search results.
+// It must be applied after WithStorage.
+func WithStorageMaxPageSize(size int) Options {
+	return func(cfg *Config) error {
+		if cfg.Storage == nil {
+			return fmt.Errorf("storage max page size set before storage config")
+		}
+		if size <= 0 {
+			return fmt.Errorf("storage max page size must be positive, got %d", size)
+		}
+		cfg.Storage.MaxPageSize = size
+		return nil
+	}
+}
+
 // WithServer returns an option that sets the server config
 func WithServer(host, port, resourceDir string, debug, verbose bool) Options {
 	return func(cfg *Config) error {
@@ -140,6 +232,157 @@ func WithKafka(tls bool, version string, peers []string, topic string) Options {
 	}
 }
 
+// WithKafkaMessages returns an option that sets the format, the CloudEvents content mode and the
+// legacy compatibility of the produced messages. It must be applied after WithKafka.
+func WithKafkaMessages(format, mode string, legacy bool) Options {
+	return func(cfg *Config) error {
+		if cfg.Kafka == nil {
+			return fmt.Errorf("kafka messages set before kafka config")
+		}
+		cfg.Kafka.MessageFormat = format
+		cfg.Kafka.MessageMode = mode
+		cfg.Kafka.LegacyMessages = legacy
+		return nil
+	}
+}
+
+// WithKafkaOutbox returns an option that sets how often and how many outbox messages the relay
+// publishes. It must be applied after WithKafka.
+func WithKafkaOutbox(interval time.Duration, batchSize int) Options {
+	return func(cfg *Config) error {
+		if cfg.Kafka == nil {
+			return fmt.Errorf("kafka outbox set before kafka config")
+		}
+		if interval <= 0 {
+			return fmt.Errorf("kafka outbox interval must be positive, got %v", interval)
+		}
+		if batchSize <= 0 {
+			return fmt.Errorf("kafka outbox batch size must be positive, got %d", batchSize)
+		}
+		cfg.Kafka.OutboxInterval = interval
+		cfg.Kafka.OutboxBatchSize = batchSize
+		return nil
+	}
+}
+
+// WithKafkaTLS returns an option that sets the CA bundle the brokers are verified with and the
+// client certificate and key presented to them. It must be applied after WithKafka.
+func WithKafkaTLS(caFile, certFile, keyFile string) Options {
+	return func(cfg *Config) error {
+		if cfg.Kafka == nil {
+			return fmt.Errorf("kafka tls set before kafka config")
+		}
+		if !cfg.Kafka.TLS && (caFile != "" || certFile != "" || keyFile != "") {
+			return fmt.Errorf("kafka CA, certificate and key files require kafka tls")
+		}
+		if (certFile == "") != (keyFile == "") {
+			return fmt.Errorf("kafka client certificate and key must be given together")
+		}
+		cfg.Kafka.CAFile = caFile
+		cfg.Kafka.CertFile = certFile
+		cfg.Kafka.KeyFile = keyFile
+		return nil
+	}
+}
+
+// WithKafkaSASL returns an option that sets how the server authenticates to the brokers, SASL is
+// off when the mechanism is empty. It must be applied after WithKafka.
+func WithKafkaSASL(mechanism, user, password string) Options {
+	return func(cfg *Config) error {
+		if cfg.Kafka == nil {
+			return fmt.Errorf("kafka sasl set before kafka config")
+		}
+		if mechanism != "" && (user == "" || password == "") {
+			return fmt.Errorf("kafka sasl %s requires a user and a password", mechanism)
+		}
+		cfg.Kafka.SASLMechanism = mechanism
+		cfg.Kafka.SASLUser = user
+		cfg.Kafka.SASLPassword = password
+		return nil
+	}
+}
+
+// WithSinks returns an option that sets the backends messages are published to
+func WithSinks(backends []string) Options {
+	return func(cfg *Config) error {
+		if len(backends) == 0 {
+			return fmt.Errorf("at least one sink is required")
+		}
+		for i, backend := range backends {
+			if slices.Contains(backends[:i], backend) {
+				return fmt.Errorf("sink %q is given twice", backend)
+			}
+		}
+		cfg.Sinks = &SinkConfig{Backends: backends}
+		return nil
+	}
+}
+
+// WithNATSSink returns an option that sets the server and the JetStream subject of the nats
+// sink. It must be applied after WithSinks.
+func WithNATSSink(url, subject string) Options {
+	return func(cfg *Config) error {
+		if cfg.Sinks == nil {
+			return fmt.Errorf("nats sink set before sinks config")
+		}
+		if cfg.Sinks.Has("nats") && (url == "" || subject == "") {
+			return fmt.Errorf("nats sink requires a url and a subject")
+		}
+		cfg.Sinks.NATSURL = url
+		cfg.Sinks.NATSSubject = subject
+		return nil
+	}
+}
+
+// WithWebhookSink returns an option that sets the endpoint of the webhook sink and how long it
+// waits for a response. It must be applied after WithSinks.
+func WithWebhookSink(url string, timeout time.Duration) Options {
+	return func(cfg *Config) error {
+		if cfg.Sinks == nil {
+			return fmt.Errorf("webhook sink set before sinks config")
+		}
+		if cfg.Sinks.Has("webhook") && url == "" {
+			return fmt.Errorf("webhook sink requires a url")
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("webhook sink timeout must be positive, got %v", timeout)
+		}
+		cfg.Sinks.WebhookURL = url
+		cfg.Sinks.WebhookTimeout = timeout
+		return nil
+	}
+}
+
+// WithFileSink returns an option that sets the file of the file sink. It must be applied after
+// WithSinks.
+func WithFileSink(path string) Options {
+	return func(cfg *Config) error {
+		if cfg.Sinks == nil {
+			return fmt.Errorf("file sink set before sinks config")
+		}
+		if cfg.Sinks.Has("file") && path == "" {
+			return fmt.Errorf("file sink requires a path")
+		}
+		cfg.Sinks.FilePath = path
+		return nil
+	}
+}
+
+// WithWebhooks returns an option that sets how many times a delivery to a webhook subscription
+// is attempted and how many deliveries in a row fail before the subscription is paused
+func WithWebhooks(maxAttempts, pauseAfter int) Options {
+	return func(cfg *Config) error {
+		if maxAttempts <= 0 {
+			return fmt.Errorf("webhook max attempts must be positive, got %d", maxAttempts)
+		}
+		if pauseAfter <= 0 {
+			return fmt.Errorf("webhook pause after must be positive, got %d", pauseAfter)
+		}
+		cfg.Webhooks = &WebhookConfig{MaxAttempts: int32(maxAttempts), PauseAfter: int32(pauseAfter)}
+		return nil
+	}
+}
+
 // WithAuth returns an option that sets the auth config
 func WithAuth(clientID string, trustedIssuers []string) Options {
 	return func(cfg *Config) error {
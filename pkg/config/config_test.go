@@ -5,6 +5,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 )
@@ -13,7 +14,12 @@ func TestNewConfig(t *testing.T) {
 	cfg, err := New(
 		WithServer("localhost", "8080", "/resources", true, true),
 		WithStorage("clash.london.com", "joe", "brixton", "disable", "postgres", 5432, 10, 10, 10),
+		WithStorageDriver("postgres"),
+		WithStorageMaxPageSize(50),
 		WithKafka(true, "2.6", []string{"kafka.svc.cluster.local:9092"}, "server.events"),
+		WithSinks([]string{"kafka", "file"}),
+		WithFileSink("/var/log/epr/messages.ndjson"),
+		WithWebhooks(8, 5),
 		WithAuth("01HGX8QDVTMSXXQHNV9AH7X8QQ", []string{"foo", "bar"}),
 	)
 
@@ -24,6 +30,8 @@ func TestNewConfig(t *testing.T) {
 
 	assert.Assert(t, cfg.Server.Host == "localhost", "Expected host to be 'localhost', got %s", cfg.Server.Host)
 	assert.Assert(t, cfg.Server.Port == "8080", "Expected port to be '8080', got %s", cfg.Server.Port)
+	assert.Assert(t, cfg.Storage.Driver == "postgres", "Expected driver to be 'postgres', got %s", cfg.Storage.Driver)
+	assert.Equal(t, cfg.Storage.MaxPageSize, 50)
 	assert.Assert(t, cfg.Storage.Host == "clash.london.com", "Expected host to be 'clash.london.com', got %s", cfg.Storage.Host)
 	assert.Assert(t, cfg.Storage.Port == 5432, "Expected port to be 5432, got %d", cfg.Storage.Port)
 	assert.Assert(t, cfg.Storage.User == "joe", "Expected user to be 'joe', got %s", cfg.Storage.User)
@@ -34,5 +42,47 @@ func TestNewConfig(t *testing.T) {
 	assert.Assert(t, cfg.Storage.IdleConnections == 10, "Expected idleConnections to be 10, got %d", cfg.Storage.IdleConnections)
 	assert.Assert(t, cfg.Storage.ConnectionLife == 10, "Expected connectionLife to be 10, got %d", cfg.Storage.ConnectionLife)
 	assert.Assert(t, cfg.Kafka.Version == "2.6", "Expected kafka version to be '2.6', got %s", cfg.Kafka.Version)
+	assert.Assert(t, cfg.Sinks.Has("file") && !cfg.Sinks.Has("nats"))
+	assert.Equal(t, cfg.Sinks.FilePath, "/var/log/epr/messages.ndjson")
+	assert.Equal(t, cfg.Webhooks.PauseAfter, int32(5))
 	assert.Assert(t, cfg.Auth.ClientID == "01HGX8QDVTMSXXQHNV9AH7X8QQ", "Expected auth client id to be '01HGX8QDVTMSXXQHNV9AH7X8QQ', got %s", cfg.Auth.ClientID)
 }
+
+func TestSinkConfig(t *testing.T) {
+	_, err := New(WithSinks(nil))
+	assert.ErrorContains(t, err, "at least one sink")
+	_, err = New(WithSinks([]string{"kafka", "kafka"}))
+	assert.ErrorContains(t, err, "given twice")
+	_, err = New(WithNATSSink("nats://localhost:4222", "epr.events"))
+	assert.ErrorContains(t, err, "before sinks config")
+
+	// settings are only required by the selected backends
+	_, err = New(WithSinks([]string{"kafka"}), WithWebhookSink("", time.Second), WithNATSSink("", ""))
+	assert.NilError(t, err)
+	_, err = New(WithSinks([]string{"webhook"}), WithWebhookSink("", time.Second))
+	assert.ErrorContains(t, err, "requires a url")
+	_, err = New(WithSinks([]string{"nats"}), WithNATSSink("nats://localhost:4222", ""))
+	assert.ErrorContains(t, err, "requires a url and a subject")
+	_, err = New(WithWebhooks(0, 5))
+	assert.ErrorContains(t, err, "max attempts must be positive")
+}
+
+func TestKafkaSecurityConfig(t *testing.T) {
+	cfg, err := New(
+		WithKafka(true, "3.4.0", []string{"kafka:9093"}, "epr.events"),
+		WithKafkaTLS("/etc/epr/ca.pem", "/etc/epr/client.pem", "/etc/epr/client-key.pem"),
+		WithKafkaSASL("SCRAM-SHA-512", "epr", "secret"),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Kafka.CAFile, "/etc/epr/ca.pem")
+	assert.Equal(t, cfg.Kafka.SASLMechanism, "SCRAM-SHA-512")
+
+	_, err = New(WithKafkaTLS("", "", ""))
+	assert.ErrorContains(t, err, "before kafka config")
+	_, err = New(WithKafka(false, "3.4.0", nil, ""), WithKafkaTLS("/etc/epr/ca.pem", "", ""))
+	assert.ErrorContains(t, err, "require kafka tls")
+	_, err = New(WithKafka(true, "3.4.0", nil, ""), WithKafkaTLS("", "/etc/epr/client.pem", ""))
+	assert.ErrorContains(t, err, "given together")
+	_, err = New(WithKafka(false, "3.4.0", nil, ""), WithKafkaSASL("PLAIN", "epr", ""))
+	assert.ErrorContains(t, err, "requires a user and a password")
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gorm.io/datatypes"
+)
+
+// CDEventInput is a CDEvent in its CloudEvent envelope to record as an event
+type CDEventInput struct {
+	// Name, Version and Package default to the package URL of the subject, or of its
+	// artifactId, the name falling back to the id of the subject
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Package string `json:"package"`
+	// Release defaults to the timestamp of the CDEvent and PlatformID to its source
+	Release    string `json:"release"`
+	PlatformID string `json:"platform_id"`
+	// Description defaults to the type of the CDEvent
+	Description string               `json:"description"`
+	CloudEvent  *cdevents.CloudEvent `json:"cloudevent"`
+}
+
+// IngestCDEvent validates the CDEvent against the schema of its type and records it as an event
+// of the oldest receiver of that type. A receiver with the schema of the type is created when
+// there is none. The outcome of the subject, if any, is the success of the event.
+func IngestCDEvent(msgProducer message.TopicProducer, db storage.Repository, input CDEventInput) (*storage.Event, error) {
+	if input.CloudEvent == nil {
+		return nil, eprErrors.InvalidInputError{Msg: "cloudevent is required"}
+	}
+	event, err := input.CloudEvent.CDEvent()
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	subject := &cdevents.PURL{Name: event.Subject.ID}
+	if artifact := event.Artifact(); artifact != nil {
+		subject = artifact
+	}
+	derived := []struct {
+		field string
+		value *string
+		from  string
+	}{
+		{"name", &input.Name, subject.Name},
+		{"version", &input.Version, subject.Version},
+		{"package", &input.Package, subject.Type},
+		{"release", &input.Release, event.Context.Timestamp},
+		{"platform id", &input.PlatformID, event.Context.Source},
+	}
+	for _, d := range derived {
+		if strings.TrimSpace(*d.value) == "" {
+			*d.value = d.from
+		}
+		if strings.TrimSpace(*d.value) == "" {
+			err = errors.Join(err, fmt.Errorf("%s is not in the CDEvent and was not given", d.field))
+		}
+	}
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	if strings.TrimSpace(input.Description) == "" {
+		input.Description = event.Context.Type
+	}
+
+	receiver, err := cdeventReceiver(msgProducer, db, event.Context.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateEvent(msgProducer, db, EventInput{
+		Name:            input.Name,
+		Version:         input.Version,
+		Release:         input.Release,
+		PlatformID:      input.PlatformID,
+		Package:         input.Package,
+		Description:     input.Description,
+		Payload:         types.JSON{JSON: datatypes.JSON(input.CloudEvent.Data)},
+		Success:         event.Success(),
+		EventReceiverID: receiver.ID,
+	})
+}
+
+// cdeventReceiver returns the oldest receiver of the CDEvents type, creating one named after
+// the subject and predicate of the type when there is none
+func cdeventReceiver(msgProducer message.TopicProducer, db storage.Repository, eventType string) (*storage.EventReceiver, error) {
+	receivers, err := db.FindEventReceiver(map[string]any{"type": eventType})
+	if err != nil {
+		return nil, err
+	}
+	if len(receivers) > 0 {
+		// IDs are ULIDs, the smallest is the oldest
+		sort.Slice(receivers, func(i, j int) bool { return receivers[i].ID < receivers[j].ID })
+		return &receivers[0], nil
+	}
+
+	parsed, err := cdevents.ParseType(eventType)
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	schema, err := cdevents.Schema(eventType)
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	return GetOrCreateEventReceiver(msgProducer, db, EventReceiverInput{
+		Name:        parsed.Subject + "-" + parsed.Predicate,
+		Type:        eventType,
+		Version:     parsed.Version,
+		Description: fmt.Sprintf("CDEvents %s %s", parsed.Subject, parsed.Predicate),
+		Schema:      types.JSON{JSON: schema},
+	})
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestIngestCDEvent(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	data, err := os.ReadFile("../cdevents/testdata/artifact-packaged.json")
+	assert.NilError(t, err)
+	cloudEvent, err := cdevents.ParseCloudEvent(data)
+	assert.NilError(t, err)
+
+	event, err := IngestCDEvent(producer, db, CDEventInput{CloudEvent: cloudEvent})
+	assert.NilError(t, err)
+	assert.Equal(t, event.Name, "myapp")
+	assert.Equal(t, event.Version, "234fd47e07d1004f0aed9c")
+	assert.Equal(t, event.Package, "golang")
+	assert.Equal(t, event.Release, "2023-03-20T14:27:05.315384Z")
+	assert.Equal(t, event.PlatformID, "/event/source/123")
+	assert.Equal(t, event.Description, "dev.cdevents.artifact.packaged.0.1.1")
+	assert.Equal(t, event.Success, true)
+
+	// the receiver is created with the schema of the type, then reused
+	receivers, err := db.FindEventReceiver(map[string]any{"type": "dev.cdevents.artifact.packaged.0.1.1"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(receivers), 1)
+	assert.Equal(t, receivers[0].Name, "artifact-packaged")
+	assert.Equal(t, receivers[0].Version, "0.1.1")
+	assert.Equal(t, event.EventReceiverID, receivers[0].ID)
+
+	again, err := IngestCDEvent(producer, db, CDEventInput{CloudEvent: cloudEvent, Release: "2023.11.16", PlatformID: "aarch64-gnu-linux-7"})
+	assert.NilError(t, err)
+	assert.Equal(t, again.EventReceiverID, receivers[0].ID)
+	assert.Equal(t, again.Release, "2023.11.16")
+	assert.Equal(t, again.PlatformID, "aarch64-gnu-linux-7")
+	assert.Equal(t, len(producer.messages), 3)
+	assert.Equal(t, producer.messages[0].Type, message.NewEventReceiver(receivers[0]).Type)
+}
+
+func TestIngestCDEventOutcome(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	cloudEvent, err := cdevents.New("01HGBDRPZRT96R4586GFA13W91", "/ci/build", "dev.cdevents.taskrun.finished.0.1.1", "2024-01-01T00:00:00Z", cdevents.Subject{
+		ID:      "build-42",
+		Type:    "taskRun",
+		Content: map[string]any{"taskName": "build", "outcome": cdevents.OutcomeFailure},
+	}, nil)
+	assert.NilError(t, err)
+
+	// the subject is not an artifact, its id is the name and the version must be given
+	_, err = IngestCDEvent(producer, db, CDEventInput{CloudEvent: cloudEvent})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "version is not in the CDEvent and was not given")
+	assert.ErrorContains(t, err, "package is not in the CDEvent and was not given")
+
+	event, err := IngestCDEvent(producer, db, CDEventInput{CloudEvent: cloudEvent, Version: "1.0.0", Package: "rpm"})
+	assert.NilError(t, err)
+	assert.Equal(t, event.Name, "build-42")
+	assert.Equal(t, event.Success, false)
+
+	_, err = IngestCDEvent(producer, db, CDEventInput{})
+	assert.ErrorContains(t, err, "cloudevent is required")
+}
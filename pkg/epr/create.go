@@ -9,6 +9,7 @@ import (
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
 	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
 	"github.com/sassoftware/event-provenance-registry/pkg/message"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/xeipuuv/gojsonschema"
@@ -24,6 +25,8 @@ type EventInput struct {
 	Payload         types.JSON `json:"payload"`
 	Success         bool       `json:"success"`
 	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	// Signature is required by receivers that require signed events
+	Signature *EventSignatureInput `json:"signature"`
 }
 
 func (e EventInput) Validate() error {
@@ -50,6 +53,9 @@ func (e EventInput) Validate() error {
 	if strings.TrimSpace(string(e.EventReceiverID)) == "" {
 		err = errors.Join(err, errors.New("event receiver id cannot be blank"))
 	}
+	if e.Signature != nil {
+		err = errors.Join(err, e.Signature.Validate())
+	}
 
 	return err
 }
@@ -60,6 +66,10 @@ type EventReceiverInput struct {
 	Version     string     `json:"version"`
 	Description string     `json:"description"`
 	Schema      types.JSON `json:"schema"`
+	// PublicKeys are the PEM encoded ed25519 or ECDSA public keys trusted to sign events
+	PublicKeys []string `json:"public_keys"`
+	// RequireSignature rejects events that are not signed by one of PublicKeys
+	RequireSignature bool `json:"require_signature"`
 }
 
 func (r EventReceiverInput) Validate() error {
@@ -77,23 +87,26 @@ func (r EventReceiverInput) Validate() error {
 	if strings.TrimSpace(r.Description) == "" {
 		err = errors.Join(err, errors.New("description cannot be blank"))
 	}
-	schemaErr := func() error {
-		schema := r.Schema.String()
-		if schema == "" {
-			return errors.New("schema is required")
-		}
-		loader := gojsonschema.NewStringLoader(schema)
-		_, err := gojsonschema.NewSchema(loader)
-		if err != nil {
-			return fmt.Errorf("failed to parse schema: %w", err)
-		}
-		return nil
-	}()
-	err = errors.Join(err, schemaErr)
+	err = errors.Join(err, validateSchema(r.Schema))
+	err = errors.Join(err, validatePublicKeys(r.PublicKeys, r.RequireSignature))
 
 	return err
 }
 
+// validateSchema checks that the receiver schema is a JSON schema
+func validateSchema(s types.JSON) error {
+	schema := s.String()
+	if schema == "" {
+		return errors.New("schema is required")
+	}
+	loader := gojsonschema.NewStringLoader(schema)
+	_, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return nil
+}
+
 type EventReceiverGroupInput struct {
 	Name             string       `json:"name"`
 	Type             string       `json:"type"`
@@ -101,6 +114,10 @@ type EventReceiverGroupInput struct {
 	Description      string       `json:"description"`
 	Enabled          bool         `json:"enabled"`
 	EventReceiverIDs []graphql.ID `json:"event_receiver_ids"`
+	// Policy defaults to requiring every receiver when nil
+	Policy *storage.GatePolicy `json:"policy"`
+	// Conditions are CEL expressions the latest event of a receiver must also satisfy
+	Conditions []storage.EventReceiverCondition `json:"conditions"`
 }
 
 func (g EventReceiverGroupInput) Validate() error {
@@ -130,11 +147,23 @@ func (g EventReceiverGroupInput) Validate() error {
 		return nil
 	}()
 	err = errors.Join(err, receiverIDErr)
+	if g.Policy != nil && receiverIDErr == nil {
+		err = errors.Join(err, gate.ValidatePolicy(*g.Policy, g.EventReceiverIDs))
+	}
+	if receiverIDErr == nil {
+		err = errors.Join(err, gate.ValidateConditions(g.Conditions, g.EventReceiverIDs))
+	}
 
 	return err
 }
 
-func CreateEvent(msgProducer message.TopicProducer, db *storage.Database, input EventInput) (*storage.Event, error) {
+func CreateEvent(msgProducer message.TopicProducer, db storage.Repository, input EventInput) (*storage.Event, error) {
+	return createEvent(msgProducer, db, input, nil)
+}
+
+// createEvent stores the event with the components of the SBOM in its payload, if any, announces
+// it and moves the groups of its receiver along, all in one transaction
+func createEvent(msgProducer message.TopicProducer, db storage.Repository, input EventInput, components []storage.SBOMComponent) (*storage.Event, error) {
 	err := input.Validate()
 	if err != nil {
 		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
@@ -150,78 +179,192 @@ func CreateEvent(msgProducer message.TopicProducer, db *storage.Database, input
 		Payload:         input.Payload,
 		Success:         input.Success,
 		EventReceiverID: input.EventReceiverID,
+		Components:      components,
 	}
-	event, err := storage.CreateEvent(db.Client, partial)
+	partial.Signature, err = verifyEventSignature(db, partial, input.Signature)
 	if err != nil {
-		slog.Error("error creating event", "error", err, "input", input)
 		return nil, err
 	}
+	var event *storage.Event
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		event, err = tx.CreateEvent(partial)
+		if err != nil {
+			return err
+		}
+		if err := producer.Send(message.NewEvent(*event)); err != nil {
+			return err
+		}
 
-	msgProducer.Async(message.NewEvent(*event))
-	slog.Info("created", "event", event)
-
-	eventReceiverGroups, err := storage.FindTriggeredEventReceiverGroups(db.Client, *event)
+		results, err := evaluateGroups(tx, *event)
+		if err != nil {
+			slog.Error("error evaluating event receiver groups", "error", err, "input", input)
+			return err
+		}
+		for _, r := range results {
+			if err := transitionAndNotifyGroup(producer, tx, r, *event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		slog.Error("error finding triggered event receiver groups", "error", err, "input", input)
+		slog.Error("error creating event", "error", err, "input", input)
 		return nil, err
 	}
-
-	for _, eventReceiverGroup := range eventReceiverGroups {
-		msgProducer.Async(message.NewEventReceiverGroupComplete(*event, eventReceiverGroup))
-	}
+	slog.Info("created", "event", event)
 
 	return event, nil
 }
 
-func CreateEventReceiver(msgProducer message.TopicProducer, db *storage.Database, input EventReceiverInput) (*storage.EventReceiver, error) {
+// recordCompletion persists that the group passed for the artifact of the event along with
+// the events that satisfied it and the result of each receiver.
+func recordCompletion(db storage.Repository, group storage.EventReceiverGroup, event storage.Event, result gate.Result) (*storage.EventReceiverGroupCompletion, error) {
+	return db.SaveEventReceiverGroupCompletion(storage.EventReceiverGroupCompletion{
+		EventReceiverGroupID: group.ID,
+		Name:                 event.Name,
+		Version:              event.Version,
+		Release:              event.Release,
+		PlatformID:           event.PlatformID,
+		Package:              event.Package,
+		EventIDs:             result.EventIDs(),
+		ReceiverResults:      result.ReceiverResults(),
+	})
+}
+
+func CreateEventReceiver(msgProducer message.TopicProducer, db storage.Repository, input EventReceiverInput) (*storage.EventReceiver, error) {
 	err := input.Validate()
 	if err != nil {
 		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
 	}
 
-	partial := storage.EventReceiver{
-		Name:        input.Name,
-		Type:        input.Type,
-		Version:     input.Version,
-		Description: input.Description,
-		Schema:      input.Schema,
-	}
-
-	receiver, err := storage.CreateEventReceiver(db.Client, partial)
+	var receiver *storage.EventReceiver
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		receiver, err = tx.CreateEventReceiver(input.toEventReceiver())
+		if err != nil {
+			return err
+		}
+		return producer.Send(message.NewEventReceiver(*receiver))
+	})
 	if err != nil {
 		slog.Error("error creating event receiver", "error", err, "input", input)
 		return nil, err
 	}
-
-	msgProducer.Async(message.NewEventReceiver(*receiver))
 	slog.Info("created", "eventReceiver", receiver)
 
 	return receiver, nil
 }
 
-func CreateEventReceiverGroup(msgProducer message.TopicProducer, db *storage.Database, input EventReceiverGroupInput) (*storage.EventReceiverGroup, error) {
+// GetOrCreateEventReceiver returns the receiver with the fingerprint of the input, that is the
+// same name, type, version and description, creating it when there is none. The schema of an
+// existing receiver is left as is.
+func GetOrCreateEventReceiver(msgProducer message.TopicProducer, db storage.Repository, input EventReceiverInput) (*storage.EventReceiver, error) {
 	err := input.Validate()
 	if err != nil {
 		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
 	}
 
-	partial := storage.EventReceiverGroup{
-		Name:             input.Name,
-		Type:             input.Type,
-		Version:          input.Version,
-		Description:      input.Description,
-		Enabled:          input.Enabled,
-		EventReceiverIDs: input.EventReceiverIDs,
+	var receiver *storage.EventReceiver
+	var created bool
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		receiver, created, err = tx.FindOrCreateEventReceiver(input.toEventReceiver())
+		if err != nil || !created {
+			return err
+		}
+		return producer.Send(message.NewEventReceiver(*receiver))
+	})
+	if err != nil {
+		slog.Error("error getting or creating event receiver", "error", err, "input", input)
+		return nil, err
 	}
+	if !created {
+		slog.Info("found", "eventReceiver", receiver.ID, "fingerprint", receiver.Fingerprint)
+		return receiver, nil
+	}
+	slog.Info("created", "eventReceiver", receiver)
 
-	group, err := storage.CreateEventReceiverGroup(db.Client, partial)
+	return receiver, nil
+}
+
+func (r EventReceiverInput) toEventReceiver() storage.EventReceiver {
+	return storage.EventReceiver{
+		Name:        r.Name,
+		Type:        r.Type,
+		Version:     r.Version,
+		Description: r.Description,
+		Schema:      r.Schema,
+
+		PublicKeys:       r.PublicKeys,
+		RequireSignature: r.RequireSignature,
+	}
+}
+
+func CreateEventReceiverGroup(msgProducer message.TopicProducer, db storage.Repository, input EventReceiverGroupInput) (*storage.EventReceiverGroup, error) {
+	err := input.Validate()
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	var group *storage.EventReceiverGroup
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		group, err = tx.CreateEventReceiverGroup(input.toEventReceiverGroup())
+		if err != nil {
+			return err
+		}
+		return producer.Send(message.NewEventReceiverGroupCreated(*group))
+	})
 	if err != nil {
 		slog.Error("error creating event receiver group", "error", err, "input", input)
 		return nil, err
 	}
+	slog.Info("created", "eventReceiverGroup", group)
+
+	return group, nil
+}
+
+// GetOrCreateEventReceiverGroup returns the group with the fingerprint of the input, that is the
+// same name, type, version and description, creating it when there is none. The receivers,
+// policy and conditions of an existing group are left as is.
+func GetOrCreateEventReceiverGroup(msgProducer message.TopicProducer, db storage.Repository, input EventReceiverGroupInput) (*storage.EventReceiverGroup, error) {
+	err := input.Validate()
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
 
-	msgProducer.Async(message.NewEventReceiverGroupCreated(*group))
+	var group *storage.EventReceiverGroup
+	var created bool
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		group, created, err = tx.FindOrCreateEventReceiverGroup(input.toEventReceiverGroup())
+		if err != nil || !created {
+			return err
+		}
+		return producer.Send(message.NewEventReceiverGroupCreated(*group))
+	})
+	if err != nil {
+		slog.Error("error getting or creating event receiver group", "error", err, "input", input)
+		return nil, err
+	}
+	if !created {
+		slog.Info("found", "eventReceiverGroup", group.ID, "fingerprint", group.Fingerprint)
+		return group, nil
+	}
 	slog.Info("created", "eventReceiverGroup", group)
 
 	return group, nil
 }
+
+func (g EventReceiverGroupInput) toEventReceiverGroup() storage.EventReceiverGroup {
+	group := storage.EventReceiverGroup{
+		Name:             g.Name,
+		Type:             g.Type,
+		Version:          g.Version,
+		Description:      g.Description,
+		Enabled:          g.Enabled,
+		EventReceiverIDs: g.EventReceiverIDs,
+		Policy:           gate.NormalizePolicy(storage.GatePolicy{}),
+		Conditions:       append([]storage.EventReceiverCondition{}, g.Conditions...),
+	}
+	if g.Policy != nil {
+		group.Policy = gate.NormalizePolicy(*g.Policy)
+	}
+	return group
+}
@@ -0,0 +1,411 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+// recorder is a message.TopicProducer that keeps the messages it is given
+type recorder struct {
+	messages []message.Message
+}
+
+func (r *recorder) Async(data any) {
+	r.messages = append(r.messages, data.(message.Message))
+}
+
+func (r *recorder) Send(data any) error {
+	r.Async(data)
+	return nil
+}
+
+func (r *recorder) types() []string {
+	types := []string{}
+	for _, m := range r.messages {
+		types = append(types, m.Type)
+	}
+	return types
+}
+
+func newTestReceiver(t *testing.T, producer message.TopicProducer, db storage.Repository, name string) *storage.EventReceiver {
+	t.Helper()
+	receiver, err := CreateEventReceiver(producer, db, EventReceiverInput{
+		Name:        name,
+		Type:        "epr.test." + name,
+		Version:     "1.0.0",
+		Description: "test receiver",
+		Schema:      types.JSON{JSON: []byte(`{}`)},
+	})
+	assert.NilError(t, err)
+	return receiver
+}
+
+func newTestEvent(t *testing.T, producer message.TopicProducer, db storage.Repository, receiverID graphql.ID, success bool) *storage.Event {
+	t.Helper()
+	event, err := CreateEvent(producer, db, EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{}`)},
+		Success:         success,
+		EventReceiverID: receiverID,
+	})
+	assert.NilError(t, err)
+	return event
+}
+
+func TestCreateEventCompletesGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+	producer.messages = nil
+
+	buildEvent := newTestEvent(t, producer, db, build.ID, true)
+	newTestEvent(t, producer, db, test.ID, false)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.build", "epr.test.test", "epr.event.receiver.group.failed"})
+
+	completions, err := db.FindEventReceiverGroupCompletions(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), 0)
+
+	testEvent := newTestEvent(t, producer, db, test.ID, true)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.build", "epr.test.test", "epr.event.receiver.group.failed", "epr.test.test", "epr.test.release"})
+
+	completions, err = db.FindEventReceiverGroupCompletions(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), 1)
+	assert.DeepEqual(t, completions[0].EventIDs, []graphql.ID{buildEvent.ID, testEvent.ID})
+}
+
+func TestCreateEventCompletesGroupConcurrently(t *testing.T) {
+	db := storage.NewMemory()
+	producer := message.NewOutbox(db)
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+
+	// the events of both receivers of each artifact are created at the same time
+	const artifacts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*artifacts)
+	for i := 0; i < artifacts; i++ {
+		for _, receiverID := range []graphql.ID{build.ID, test.ID} {
+			input := EventInput{
+				Name:            "foo",
+				Version:         fmt.Sprintf("1.0.%d", i),
+				Release:         "20240101",
+				PlatformID:      "x86-64-gnu-linux-7",
+				Package:         "rpm",
+				Description:     "test event",
+				Payload:         types.JSON{JSON: []byte(`{}`)},
+				Success:         true,
+				EventReceiverID: receiverID,
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := CreateEvent(producer, db, input)
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+
+	completions, err := db.FindEventReceiverGroupCompletions(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), artifacts)
+	states, err := db.FindEventReceiverGroupStates(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(states), artifacts)
+	for _, state := range states {
+		assert.Equal(t, state.State, storage.GroupStatePassed)
+	}
+}
+
+func TestCreateEventCompletesAnyOfGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	trivy := newTestReceiver(t, producer, db, "trivy")
+	grype := newTestReceiver(t, producer, db, "grype")
+	lint := newTestReceiver(t, producer, db, "lint")
+	_, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "scanned",
+		Type:             "epr.test.scanned",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{trivy.ID, grype.ID, lint.ID},
+		Policy:           &storage.GatePolicy{Type: "any_of", OptionalEventReceiverIDs: []graphql.ID{lint.ID}},
+	})
+	assert.NilError(t, err)
+	producer.messages = nil
+
+	newTestEvent(t, producer, db, lint.ID, true)
+	newTestEvent(t, producer, db, trivy.ID, false)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.lint", "epr.test.trivy"})
+
+	grypeEvent := newTestEvent(t, producer, db, grype.ID, true)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.lint", "epr.test.trivy", "epr.test.grype", "epr.test.scanned"})
+
+	result := producer.messages[3].Data.Gate
+	assert.Assert(t, result != nil)
+	assert.Equal(t, result.Policy.Type, storage.PolicyAnyOf)
+	assert.Equal(t, result.Summary, "ANY_OF: 1 of 2 required receivers satisfied, 1 needed")
+	assert.DeepEqual(t, result.EventIDs(), []graphql.ID{grypeEvent.ID})
+}
+
+func TestEventReceiverGroupInputPolicy(t *testing.T) {
+	input := EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		EventReceiverIDs: []graphql.ID{"a", "b"},
+	}
+	assert.NilError(t, input.Validate())
+
+	n := int32(3)
+	input.Policy = &storage.GatePolicy{Type: storage.PolicyNOf, N: &n}
+	assert.Error(t, input.Validate(), "policy N_OF needs n between 1 and 2, the number of required event receivers")
+}
+
+func TestEventReceiverGroupInputConditions(t *testing.T) {
+	input := EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		EventReceiverIDs: []graphql.ID{"a", "b"},
+		Conditions:       []storage.EventReceiverCondition{{EventReceiverID: "a", Expression: "payload.critical == 0"}},
+	}
+	assert.NilError(t, input.Validate())
+
+	input.Conditions[0].Expression = "payload.critical +"
+	assert.ErrorContains(t, input.Validate(), `invalid condition "payload.critical +"`)
+}
+
+func TestEvaluateEventReceiverGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	scan := newTestReceiver(t, producer, db, "scan")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "scanned",
+		Type:             "epr.test.scanned",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{scan.ID},
+		Conditions:       []storage.EventReceiverCondition{{EventReceiverID: scan.ID, Expression: "payload.critical == 0"}},
+	})
+	assert.NilError(t, err)
+	producer.messages = nil
+
+	event := newTestEvent(t, producer, db, scan.ID, true)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.scan", "epr.event.receiver.group.failed"})
+
+	result, err := EvaluateEventReceiverGroup(db, group.ID, *event)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Passed, false)
+	assert.Equal(t, result.Reasons[0].Error, "no such key: critical")
+
+	_, err = EvaluateEventReceiverGroup(db, group.ID, storage.Event{Name: "foo"})
+	assert.ErrorContains(t, err, "version cannot be blank")
+}
+
+func TestCreateEventRecordsReceiverResults(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	scan := newTestReceiver(t, producer, db, "scan")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "scanned",
+		Type:             "epr.test.scanned",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, scan.ID},
+		Conditions:       []storage.EventReceiverCondition{{EventReceiverID: scan.ID, Expression: "payload.critical == 0"}},
+	})
+	assert.NilError(t, err)
+
+	unscanned := newTestEvent(t, producer, db, scan.ID, true)
+	states, err := db.FindEventReceiverGroupStates(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, states[0].State, storage.GroupStateFailed)
+	assert.DeepEqual(t, states[0].ReceiverResults, []storage.ReceiverResult{
+		{EventReceiverID: build.ID},
+		{EventReceiverID: scan.ID, EventID: unscanned.ID, Condition: "payload.critical == 0", Error: "no such key: critical"},
+	})
+
+	// a new result is recorded even when the state stays the same
+	built := newTestEvent(t, producer, db, build.ID, true)
+	states, err = db.FindEventReceiverGroupStates(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, states[0].State, storage.GroupStateFailed)
+	assert.Equal(t, states[0].EventID, unscanned.ID)
+	assert.DeepEqual(t, states[0].ReceiverResults[0], storage.ReceiverResult{EventReceiverID: build.ID, EventID: built.ID, Satisfied: true})
+
+	scanned, err := CreateEvent(producer, db, EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{"critical": 0}`)},
+		Success:         true,
+		EventReceiverID: scan.ID,
+	})
+	assert.NilError(t, err)
+	completions, err := db.FindEventReceiverGroupCompletions(group.ID, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, completions[0].ReceiverResults, []storage.ReceiverResult{
+		{EventReceiverID: build.ID, EventID: built.ID, Satisfied: true},
+		{EventReceiverID: scan.ID, EventID: scanned.ID, Satisfied: true, Condition: "payload.critical == 0"},
+	})
+}
+
+func TestCreateEventGroupTransitions(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+
+	state := func() string {
+		states, err := db.FindEventReceiverGroupStates(group.ID, nil)
+		assert.NilError(t, err)
+		if len(states) == 0 {
+			return storage.GroupStatePending
+		}
+		assert.Equal(t, len(states), 1)
+		return states[0].State
+	}
+
+	steps := []struct {
+		receiverID graphql.ID
+		success    bool
+		state      string
+		messages   []string
+	}{
+		{receiverID: build.ID, success: true, state: storage.GroupStatePending, messages: []string{"epr.test.build"}},
+		{receiverID: test.ID, success: true, state: storage.GroupStatePassed, messages: []string{"epr.test.test", "epr.test.release"}},
+		{receiverID: test.ID, success: false, state: storage.GroupStateFailed, messages: []string{"epr.test.test", "epr.event.receiver.group.regressed"}},
+		{receiverID: build.ID, success: false, state: storage.GroupStateFailed, messages: []string{"epr.test.build"}},
+		{receiverID: build.ID, success: true, state: storage.GroupStateFailed, messages: []string{"epr.test.build"}},
+		{receiverID: test.ID, success: true, state: storage.GroupStatePassed, messages: []string{"epr.test.test", "epr.test.release"}},
+	}
+	for i, step := range steps {
+		producer.messages = nil
+		event := newTestEvent(t, producer, db, step.receiverID, step.success)
+		assert.Equal(t, state(), step.state, "step %d", i)
+		assert.DeepEqual(t, producer.types(), step.messages)
+
+		last := producer.messages[len(producer.messages)-1]
+		if len(producer.messages) > 1 {
+			assert.Equal(t, last.Data.Events[0].ID, event.ID, "step %d carries the triggering event", i)
+			assert.Assert(t, last.Data.Gate != nil)
+		}
+	}
+
+	regressed := []message.Message{}
+	producer.messages = nil
+	newTestEvent(t, producer, db, build.ID, false)
+	for _, m := range producer.messages {
+		if m.Type == "epr.event.receiver.group.regressed" {
+			regressed = append(regressed, m)
+		}
+	}
+	assert.Equal(t, len(regressed), 1)
+	assert.Equal(t, regressed[0].Success, false)
+	assert.Equal(t, regressed[0].Subject, string(group.ID))
+}
+
+func TestGetOrCreateEventReceiverAndGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	input := EventReceiverInput{
+		Name:        "build",
+		Type:        "epr.test.build",
+		Version:     "1.0.0",
+		Description: "test receiver",
+		Schema:      types.JSON{JSON: []byte(`{}`)},
+	}
+	first, err := GetOrCreateEventReceiver(producer, db, input)
+	assert.NilError(t, err)
+	second, err := GetOrCreateEventReceiver(producer, db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, second.ID, first.ID)
+
+	input.Version = "2.0.0"
+	other, err := GetOrCreateEventReceiver(producer, db, input)
+	assert.NilError(t, err)
+	assert.Assert(t, other.ID != first.ID)
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.created", "epr.event.receiver.created"})
+
+	groupInput := EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{first.ID},
+	}
+	group, err := GetOrCreateEventReceiverGroup(producer, db, groupInput)
+	assert.NilError(t, err)
+	assert.Assert(t, group.Fingerprint != "")
+	again, err := GetOrCreateEventReceiverGroup(producer, db, groupInput)
+	assert.NilError(t, err)
+	assert.Equal(t, again.ID, group.ID)
+
+	// a changed description changes the fingerprint
+	description := "renamed group"
+	_, err = UpdateEventReceiverGroup(producer, db, group.ID, storage.EventReceiverGroupUpdate{Description: &description})
+	assert.NilError(t, err)
+	renamed, err := GetOrCreateEventReceiverGroup(producer, db, groupInput)
+	assert.NilError(t, err)
+	assert.Assert(t, renamed.ID != group.ID)
+}
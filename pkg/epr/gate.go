@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// groupResult is the outcome of evaluating one group for an artifact
+type groupResult struct {
+	Group  storage.EventReceiverGroup
+	Result gate.Result
+}
+
+// evaluateGroups evaluates the enabled groups that the receiver of the event belongs to
+// for the artifact of the event. It must run in the transaction that records the outcome,
+// which holds each group for the artifact until it ends.
+func evaluateGroups(db storage.Repository, event storage.Event) ([]groupResult, error) {
+	groups, err := db.FindEventReceiverGroupsByEventReceiverID(event.EventReceiverID)
+	if err != nil {
+		return nil, err
+	}
+	// the groups are locked in the order of their ids so that events of different receivers
+	// sharing groups cannot wait on each other
+	slices.SortFunc(groups, func(a, b storage.EventReceiverGroup) int { return strings.Compare(string(a.ID), string(b.ID)) })
+	var results []groupResult
+	for _, group := range groups {
+		if !group.Enabled {
+			continue
+		}
+		if err := db.LockEventReceiverGroup(group.ID, event); err != nil {
+			return nil, err
+		}
+		result, err := evaluateGroup(db, group, event)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, groupResult{Group: group, Result: result})
+	}
+	return results, nil
+}
+
+// EvaluateEventReceiverGroup evaluates the group for the artifact of the tuple as it stands,
+// without recording a completion or sending messages.
+func EvaluateEventReceiverGroup(db storage.Repository, id graphql.ID, tuple storage.Event) (*gate.Result, error) {
+	if err := validateTuple(tuple); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	groups, err := db.FindEventReceiverGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := evaluateGroup(db, groups[0], tuple)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// validateTuple checks that every field identifying the artifact of the tuple is set
+func validateTuple(tuple storage.Event) error {
+	var err error
+	for _, field := range []struct{ name, value string }{
+		{"name", tuple.Name},
+		{"version", tuple.Version},
+		{"release", tuple.Release},
+		{"platform id", tuple.PlatformID},
+		{"package", tuple.Package},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			err = errors.Join(err, fmt.Errorf("%s cannot be blank", field.name))
+		}
+	}
+	return err
+}
+
+// evaluateGroup fetches the receivers of the group and their latest events for the artifact
+// of the tuple and hands them to the gate evaluator.
+func evaluateGroup(db storage.Repository, group storage.EventReceiverGroup, tuple storage.Event) (gate.Result, error) {
+	input, err := groupInput(db, group, tuple)
+	if err != nil {
+		return gate.Result{}, err
+	}
+	return gate.Default.Evaluate(input), nil
+}
+
+func groupInput(db storage.Repository, group storage.EventReceiverGroup, tuple storage.Event) (gate.Input, error) {
+	receivers, err := db.FindEventReceiversByIDs(group.EventReceiverIDs)
+	if err != nil {
+		return gate.Input{}, err
+	}
+	events, err := db.FindLatestEvents(group.EventReceiverIDs, tuple)
+	if err != nil {
+		return gate.Input{}, err
+	}
+	return gate.Input{
+		Group:     group,
+		Receivers: receivers,
+		Events:    events,
+	}, nil
+}
+
+// nextState returns the state of a group for an artifact after an evaluation. A group that
+// passed and no longer does is failed even when it could still pass, so that the regression
+// is reported.
+func nextState(previous string, result gate.Result) string {
+	switch {
+	case result.Passed:
+		return storage.GroupStatePassed
+	case result.Failed(), previous == storage.GroupStatePassed:
+		return storage.GroupStateFailed
+	default:
+		return storage.GroupStatePending
+	}
+}
+
+// transitionGroup moves the group to its next state for the artifact of the event and returns
+// the state it was in before. The result of each receiver is stored with the state, an
+// evaluation that only changes those keeps the event of the last change of state. A dry run
+// leaves the stored state alone.
+func transitionGroup(db storage.Repository, r groupResult, event storage.Event, dryRun bool) (string, string, error) {
+	states, err := db.FindEventReceiverGroupStates(r.Group.ID, map[string]any{
+		"name":        event.Name,
+		"version":     event.Version,
+		"release":     event.Release,
+		"platform_id": event.PlatformID,
+		"package":     event.Package,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	previous := storage.GroupStatePending
+	eventID := event.ID
+	if len(states) > 0 {
+		previous = states[0].State
+	}
+	next := nextState(previous, r.Result)
+	if dryRun {
+		return previous, next, nil
+	}
+	results := r.Result.ReceiverResults()
+	if len(states) > 0 && next == previous {
+		if slices.Equal(states[0].ReceiverResults, results) {
+			return previous, next, nil
+		}
+		eventID = states[0].EventID
+	}
+	_, err = db.SaveEventReceiverGroupState(storage.EventReceiverGroupState{
+		EventReceiverGroupID: r.Group.ID,
+		Name:                 event.Name,
+		Version:              event.Version,
+		Release:              event.Release,
+		PlatformID:           event.PlatformID,
+		Package:              event.Package,
+		State:                next,
+		EventID:              eventID,
+		ReceiverResults:      results,
+	})
+	return previous, next, err
+}
+
+// transitionMessage returns the message for a group moving between states for the artifact of
+// the event, if any. Passing always sends the group complete message.
+func transitionMessage(r groupResult, event storage.Event, previous, next string) (message.Message, bool) {
+	switch {
+	case next == storage.GroupStatePassed:
+		return message.NewEventReceiverGroupComplete(event, r.Group, r.Result), true
+	case next == storage.GroupStateFailed && previous == storage.GroupStatePassed:
+		return message.NewEventReceiverGroupRegressed(event, r.Group, r.Result), true
+	case next == storage.GroupStateFailed && previous == storage.GroupStatePending:
+		return message.NewEventReceiverGroupFailed(event, r.Group, r.Result), true
+	default:
+		return message.Message{}, false
+	}
+}
+
+// transitionAndNotifyGroup moves the group to its next state for the artifact of the event and
+// notifies the transition. It runs in the transaction of the caller.
+func transitionAndNotifyGroup(producer message.TopicProducer, tx storage.Repository, r groupResult, event storage.Event) error {
+	previous, next, err := transitionGroup(tx, r, event, false)
+	if err != nil {
+		slog.Error("error recording event receiver group state", "error", err, "eventReceiverGroup", r.Group.ID)
+		return err
+	}
+	return notifyGroup(producer, tx, r, event, previous, next)
+}
+
+// notifyGroup records the completion of a group that passed and sends the message of its
+// transition.
+func notifyGroup(msgProducer message.TopicProducer, db storage.Repository, r groupResult, event storage.Event, previous, next string) error {
+	msg, ok := transitionMessage(r, event, previous, next)
+	if !ok {
+		slog.Debug("event receiver group not complete", "eventReceiverGroup", r.Group.ID, "state", next, "reasons", r.Result.Reasons)
+		return nil
+	}
+	if next == storage.GroupStatePassed {
+		if _, err := recordCompletion(db, r.Group, event, r.Result); err != nil {
+			slog.Error("error recording event receiver group completion", "error", err, "eventReceiverGroup", r.Group.ID)
+			return err
+		}
+	}
+	slog.Info("event receiver group "+next, "eventReceiverGroup", r.Group.ID, "event", event.ID, "message", msg.Type, "policy", r.Result.Summary)
+	return msgProducer.Send(msg)
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/provenance"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// ProvenanceInput identifies the artifact to export the provenance of
+type ProvenanceInput struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Release    string `json:"release"`
+	PlatformID string `json:"platform_id"`
+	Package    string `json:"package"`
+	// Format of the predicate, SLSA or EPR, defaults to SLSA
+	Format string `json:"format"`
+	// Digests of the artifact of the form algorithm:hex, they identify it as the subject
+	Digests []string `json:"digests"`
+}
+
+func (p ProvenanceInput) Validate() error {
+	err := validateTuple(p.tuple())
+	if p.Format != "" {
+		err = errors.Join(err, provenance.ValidateFormat(p.Format))
+	}
+	if _, digestErr := provenance.ParseDigests(p.Digests); digestErr != nil {
+		err = errors.Join(err, digestErr)
+	}
+	return err
+}
+
+func (p ProvenanceInput) tuple() storage.Event {
+	return storage.Event{
+		Name:       p.Name,
+		Version:    p.Version,
+		Release:    p.Release,
+		PlatformID: p.PlatformID,
+		Package:    p.Package,
+	}
+}
+
+// ExportProvenance renders every event of the artifact and the completions of the groups of
+// their receivers as an in-toto Statement. Revoked events are kept and marked as such.
+func ExportProvenance(db storage.Repository, input ProvenanceInput) (*provenance.Statement, error) {
+	if err := input.Validate(); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	if input.Format == "" {
+		input.Format = provenance.FormatSLSA
+	}
+	digest, _ := provenance.ParseDigests(input.Digests)
+
+	tuple := map[string]any{
+		"name":        input.Name,
+		"version":     input.Version,
+		"release":     input.Release,
+		"platform_id": input.PlatformID,
+		"package":     input.Package,
+	}
+	events, err := db.FindEvent(tuple)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, eprErrors.MissingObjectError{Msg: "no events found for the artifact"}
+	}
+
+	predicate := provenance.Predicate{
+		Artifact: provenance.Artifact{
+			Name:       input.Name,
+			Version:    input.Version,
+			Release:    input.Release,
+			PlatformID: input.PlatformID,
+			Package:    input.Package,
+		},
+		Events: events,
+		Groups: []provenance.PassedGroup{},
+	}
+
+	seenReceivers := map[graphql.ID]bool{}
+	seenGroups := map[graphql.ID]bool{}
+	for _, event := range events {
+		if seenReceivers[event.EventReceiverID] {
+			continue
+		}
+		seenReceivers[event.EventReceiverID] = true
+
+		groups, err := db.FindEventReceiverGroupsByEventReceiverID(event.EventReceiverID)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if seenGroups[group.ID] {
+				continue
+			}
+			seenGroups[group.ID] = true
+
+			completions, err := db.FindEventReceiverGroupCompletions(group.ID, tuple)
+			if err != nil {
+				return nil, err
+			}
+			for _, completion := range completions {
+				predicate.Groups = append(predicate.Groups, provenance.PassedGroup{
+					ID:          group.ID,
+					Name:        group.Name,
+					Type:        group.Type,
+					Version:     group.Version,
+					EventIDs:    completion.EventIDs,
+					CompletedAt: completion.CompletedAt,
+				})
+			}
+		}
+	}
+
+	return provenance.NewStatement(predicate, input.Format, digest)
+}
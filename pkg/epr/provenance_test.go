@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/provenance"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestExportProvenance(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+	buildEvent := newTestEvent(t, producer, db, build.ID, true)
+	testEvent := newTestEvent(t, producer, db, test.ID, true)
+
+	input := ProvenanceInput{
+		Name:       "foo",
+		Version:    "1.0.0",
+		Release:    "20240101",
+		PlatformID: "x86-64-gnu-linux-7",
+		Package:    "rpm",
+		Digests:    []string{"sha256:ABC123"},
+	}
+	statement, err := ExportProvenance(db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, statement.Type, provenance.StatementType)
+	assert.Equal(t, statement.PredicateType, provenance.PredicateTypeSLSA)
+	assert.DeepEqual(t, statement.Subject, []provenance.ResourceDescriptor{{Name: "foo", Digest: map[string]string{"sha256": "abc123"}}})
+
+	predicate := statement.Predicate.(provenance.SLSAPredicate)
+	assert.Equal(t, predicate.BuildDefinition.ExternalParameters.PlatformID, "x86-64-gnu-linux-7")
+	byproducts := predicate.RunDetails.Byproducts
+	assert.Equal(t, len(byproducts), 3)
+	assert.Equal(t, byproducts[0].Name, "events/"+string(buildEvent.ID))
+	assert.DeepEqual(t, byproducts[0].Digest, map[string]string{"sha256": buildEvent.Hash})
+	assert.Equal(t, byproducts[0].Annotations["event_receiver_name"], "build")
+	assert.Equal(t, byproducts[1].Name, "events/"+string(testEvent.ID))
+	assert.Equal(t, byproducts[2].Name, "groups/"+string(group.ID))
+	assert.DeepEqual(t, byproducts[2].Annotations["event_ids"], []graphql.ID{buildEvent.ID, testEvent.ID})
+
+	input.Format = provenance.FormatEPR
+	statement, err = ExportProvenance(db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, statement.PredicateType, provenance.PredicateTypeEPR)
+	eprPredicate := statement.Predicate.(provenance.Predicate)
+	assert.Equal(t, len(eprPredicate.Events), 2)
+	assert.Equal(t, eprPredicate.Groups[0].Name, "release")
+
+	input.Release = "20240102"
+	_, err = ExportProvenance(db, input)
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	_, err = ExportProvenance(db, ProvenanceInput{Name: "foo", Format: "SPDX", Digests: []string{"abc"}})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "version cannot be blank")
+	assert.ErrorContains(t, err, `unknown provenance format "SPDX"`)
+	assert.ErrorContains(t, err, `digest "abc" is not of the form algorithm:hex`)
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// Reevaluation is the outcome of re-evaluating a group for one artifact
+type Reevaluation struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Release    string `json:"release"`
+	PlatformID string `json:"platform_id"`
+	Package    string `json:"package"`
+
+	PreviousState string `json:"previous_state"`
+	State         string `json:"state"`
+	// MessageType is the type of the message that was sent, or would be on a dry run
+	MessageType string      `json:"message_type,omitempty"`
+	Result      gate.Result `json:"result"`
+}
+
+// ReevaluateEventReceiverGroup evaluates the group against every artifact its receivers have
+// events for. Artifacts whose state changes are recorded and their message is sent, so a group
+// that is enabled or gains a receiver after its events arrived still completes. A dry run only
+// reports what would happen, also for a disabled group.
+func ReevaluateEventReceiverGroup(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, dryRun bool) ([]Reevaluation, error) {
+	groups, err := db.FindEventReceiverGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	group := groups[0]
+	// a dry run previews what enabling a disabled group would fire
+	if !group.Enabled && !dryRun {
+		return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("eventReceiverGroup with id %s is disabled", id)}
+	}
+
+	tuples, err := db.FindEventTuples(group.EventReceiverIDs)
+	if err != nil {
+		return nil, err
+	}
+	reevaluations := []Reevaluation{}
+	for _, tuple := range tuples {
+		var reevaluation *Reevaluation
+		err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+			reevaluation, err = reevaluateArtifact(producer, tx, group, tuple, dryRun)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if reevaluation != nil {
+			reevaluations = append(reevaluations, *reevaluation)
+		}
+	}
+	slog.Info("reevaluated", "eventReceiverGroup", id, "artifacts", len(reevaluations), "dryRun", dryRun)
+	return reevaluations, nil
+}
+
+// reevaluateGroup re-evaluates the group against every artifact its receivers have events for,
+// in the transaction of the caller
+func reevaluateGroup(producer message.TopicProducer, tx storage.Repository, group storage.EventReceiverGroup) error {
+	tuples, err := tx.FindEventTuples(group.EventReceiverIDs)
+	if err != nil {
+		return err
+	}
+	for _, tuple := range tuples {
+		if _, err := reevaluateArtifact(producer, tx, group, tuple, false); err != nil {
+			return err
+		}
+	}
+	slog.Info("reevaluated", "eventReceiverGroup", group.ID, "artifacts", len(tuples), "dryRun", false)
+	return nil
+}
+
+// reevaluateArtifact evaluates the group for the artifact of the tuple, holding the group for
+// the artifact until the transaction ends, and moves it to its next state. It returns nil when
+// the receivers of the group have no event for the artifact.
+func reevaluateArtifact(producer message.TopicProducer, tx storage.Repository, group storage.EventReceiverGroup, tuple storage.Event, dryRun bool) (*Reevaluation, error) {
+	if err := tx.LockEventReceiverGroup(group.ID, tuple); err != nil {
+		return nil, err
+	}
+	input, err := groupInput(tx, group, tuple)
+	if err != nil {
+		return nil, err
+	}
+	// the latest event of the artifact stands in for the event that triggers the change
+	event, ok := gate.Latest(input.Events)
+	if !ok {
+		return nil, nil
+	}
+	r := groupResult{Group: group, Result: gate.Default.Evaluate(input)}
+	reevaluation := &Reevaluation{
+		Name:       tuple.Name,
+		Version:    tuple.Version,
+		Release:    tuple.Release,
+		PlatformID: tuple.PlatformID,
+		Package:    tuple.Package,
+		Result:     r.Result,
+	}
+	previous, next, err := transitionGroup(tx, r, event, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	reevaluation.PreviousState = previous
+	reevaluation.State = next
+	// an artifact that already passed has been announced
+	if previous == next {
+		return reevaluation, nil
+	}
+	msg, ok := transitionMessage(r, event, previous, next)
+	if !ok {
+		return reevaluation, nil
+	}
+	reevaluation.MessageType = msg.Type
+	if dryRun {
+		return reevaluation, nil
+	}
+	return reevaluation, notifyGroup(producer, tx, r, event, previous, next)
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestReevaluateEventReceiverGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          false,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+
+	// 1.0.0 passes, 2.0.0 is missing a test event and 3.0.0 failed
+	for _, e := range []struct {
+		version    string
+		receiverID graphql.ID
+		success    bool
+	}{
+		{"1.0.0", build.ID, true},
+		{"1.0.0", test.ID, true},
+		{"2.0.0", build.ID, true},
+		{"3.0.0", build.ID, false},
+	} {
+		_, err := CreateEvent(producer, db, EventInput{
+			Name:            "foo",
+			Version:         e.version,
+			Release:         "20240101",
+			PlatformID:      "x86-64-gnu-linux-7",
+			Package:         "rpm",
+			Description:     "test event",
+			Payload:         types.JSON{JSON: []byte(`{}`)},
+			Success:         e.success,
+			EventReceiverID: e.receiverID,
+		})
+		assert.NilError(t, err)
+	}
+	producer.messages = nil
+
+	_, err = ReevaluateEventReceiverGroup(producer, db, group.ID, false)
+	assert.ErrorContains(t, err, "is disabled")
+
+	// a dry run previews what enabling the group would fire
+	reevaluations, err := ReevaluateEventReceiverGroup(producer, db, group.ID, true)
+	assert.NilError(t, err)
+	assert.Equal(t, len(producer.messages), 0)
+	got := map[string][2]string{}
+	for _, r := range reevaluations {
+		got[r.Version] = [2]string{r.State, r.MessageType}
+	}
+	assert.DeepEqual(t, got, map[string][2]string{
+		"1.0.0": {storage.GroupStatePassed, "epr.test.release"},
+		"2.0.0": {storage.GroupStatePending, ""},
+		"3.0.0": {storage.GroupStateFailed, "epr.event.receiver.group.failed"},
+	})
+	states, err := db.FindEventReceiverGroupStates(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(states), 0)
+
+	// enabling through epr re-evaluates for real
+	assert.NilError(t, SetEventReceiverGroupEnabled(producer, db, group.ID, true))
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.group.modified", "epr.test.release", "epr.event.receiver.group.failed"})
+	completions, err := db.FindEventReceiverGroupCompletions(group.ID, map[string]any{"version": "1.0.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), 1)
+
+	// nothing changed since, so nothing fires again
+	producer.messages = nil
+	_, err = ReevaluateEventReceiverGroup(producer, db, group.ID, false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(producer.messages), 0)
+}
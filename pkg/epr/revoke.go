@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// RevocationInput records who revokes an event and why
+type RevocationInput struct {
+	RevokedBy string `json:"revoked_by"`
+	Reason    string `json:"reason"`
+}
+
+func (r RevocationInput) Validate() error {
+	var err error
+
+	if strings.TrimSpace(r.RevokedBy) == "" {
+		err = errors.Join(err, errors.New("revoked by cannot be blank"))
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		err = errors.Join(err, errors.New("reason cannot be blank"))
+	}
+
+	return err
+}
+
+// RevokeEvent revokes the event and re-evaluates the enabled groups of its receiver for its
+// artifact without it, in one transaction. Groups that passed because of the event regress. A group that still
+// passes keeps its state and is not announced again, but its completion is updated to the
+// events that satisfy it now.
+func RevokeEvent(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, input RevocationInput) (*storage.Event, error) {
+	if err := input.Validate(); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	var event *storage.Event
+	err := inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		var err error
+		event, err = tx.RevokeEvent(storage.EventRevocation{
+			EventID:   id,
+			RevokedBy: input.RevokedBy,
+			Reason:    input.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		if err := producer.Send(message.NewEventRevoked(*event)); err != nil {
+			return err
+		}
+
+		results, err := evaluateGroups(tx, *event)
+		if err != nil {
+			slog.Error("error evaluating event receiver groups", "error", err, "event", id)
+			return err
+		}
+		for _, r := range results {
+			if err := reevaluateRevokedGroup(producer, tx, r, *event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("error revoking event", "error", err, "id", id)
+		return nil, err
+	}
+	slog.Info("revoked", "event", id, "revokedBy", input.RevokedBy, "reason", input.Reason)
+
+	return event, nil
+}
+
+// reevaluateRevokedGroup moves the group to its state without the revoked event. A group that
+// still passes only has its completion updated.
+func reevaluateRevokedGroup(producer message.TopicProducer, tx storage.Repository, r groupResult, event storage.Event) error {
+	previous, next, err := transitionGroup(tx, r, event, false)
+	if err != nil {
+		slog.Error("error recording event receiver group state", "error", err, "eventReceiverGroup", r.Group.ID)
+		return err
+	}
+	if previous != next {
+		return notifyGroup(producer, tx, r, event, previous, next)
+	}
+	if next == storage.GroupStatePassed {
+		if _, err := recordCompletion(tx, r.Group, event, r.Result); err != nil {
+			slog.Error("error recording event receiver group completion", "error", err, "eventReceiverGroup", r.Group.ID)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestRevokeEvent(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+	newTestEvent(t, producer, db, build.ID, true)
+	testEvent := newTestEvent(t, producer, db, test.ID, true)
+
+	_, err = RevokeEvent(producer, db, testEvent.ID, RevocationInput{RevokedBy: "jdoe"})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+
+	producer.messages = nil
+	revoked, err := RevokeEvent(producer, db, testEvent.ID, RevocationInput{RevokedBy: "jdoe", Reason: "compromised runner"})
+	assert.NilError(t, err)
+	assert.Equal(t, revoked.Revocation.RevokedBy, "jdoe")
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.revoked", "epr.event.receiver.group.regressed"})
+	assert.Equal(t, producer.messages[0].Success, false)
+
+	states, err := db.FindEventReceiverGroupStates(group.ID, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, states[0].State, storage.GroupStateFailed)
+	assert.Equal(t, states[0].EventID, testEvent.ID)
+
+	events, err := db.FindEventByID(testEvent.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, events[0].Revocation.Reason, "compromised runner")
+
+	_, err = RevokeEvent(producer, db, testEvent.ID, RevocationInput{RevokedBy: "jdoe", Reason: "again"})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	_, err = RevokeEvent(producer, db, "missing", RevocationInput{RevokedBy: "jdoe", Reason: "missing"})
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	// a new event passes the group again
+	producer.messages = nil
+	newTestEvent(t, producer, db, test.ID, true)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.test", "epr.test.release"})
+}
+
+func TestRevokeEventStillPassing(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+		Policy:           &storage.GatePolicy{Type: storage.PolicyAnyOf},
+	})
+	assert.NilError(t, err)
+	buildEvent := newTestEvent(t, producer, db, build.ID, true)
+	testEvent := newTestEvent(t, producer, db, test.ID, true)
+
+	producer.messages = nil
+	_, err = RevokeEvent(producer, db, testEvent.ID, RevocationInput{RevokedBy: "jdoe", Reason: "mistake"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.revoked"})
+
+	completions, err := db.FindEventReceiverGroupCompletions(group.ID, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, completions[0].EventIDs, []graphql.ID{buildEvent.ID})
+}
+
+func TestRevokeEventRollsBackWhenGroupTransitionFails(t *testing.T) {
+	memory := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, memory, "build")
+	_, err := CreateEventReceiverGroup(producer, memory, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	event := newTestEvent(t, producer, memory, build.ID, true)
+
+	producer.messages = nil
+	_, err = RevokeEvent(producer, brokenGroupState{Memory: memory}, event.ID, RevocationInput{RevokedBy: "jdoe", Reason: "compromised runner"})
+	assert.ErrorContains(t, err, "group state is locked")
+	assert.Equal(t, len(producer.messages), 0)
+
+	events, err := memory.FindEventByID(event.ID)
+	assert.NilError(t, err)
+	assert.Assert(t, events[0].Revocation == nil)
+}
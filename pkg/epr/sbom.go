@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/sbom"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// SBOMInput is a CycloneDX or SPDX JSON document to record as an event of the receiver
+type SBOMInput struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	Release         string     `json:"release"`
+	PlatformID      string     `json:"platform_id"`
+	// Name, Version and Package default to the artifact the document describes
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Package string `json:"package"`
+	// Description defaults to the format and version of the document
+	Description string     `json:"description"`
+	SBOM        types.JSON `json:"sbom"`
+}
+
+// IngestSBOM validates the document and creates a successful event carrying it as payload.
+// The components of the document are stored with the event so that artifacts can be found by
+// the components they contain.
+func IngestSBOM(msgProducer message.TopicProducer, db storage.Repository, input SBOMInput) (*storage.Event, error) {
+	if len(input.SBOM.JSON) == 0 {
+		return nil, eprErrors.InvalidInputError{Msg: "sbom is required"}
+	}
+	doc, err := sbom.Parse(input.SBOM.JSON)
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	derived := []struct {
+		field string
+		value *string
+		from  string
+	}{
+		{"name", &input.Name, doc.Name},
+		{"version", &input.Version, doc.Version},
+		{"package", &input.Package, doc.Package},
+	}
+	for _, d := range derived {
+		if strings.TrimSpace(*d.value) == "" {
+			*d.value = d.from
+		}
+		if strings.TrimSpace(*d.value) == "" {
+			err = errors.Join(err, fmt.Errorf("%s is not in the SBOM and was not given", d.field))
+		}
+	}
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	if strings.TrimSpace(input.Description) == "" {
+		input.Description = fmt.Sprintf("%s %s SBOM", doc.Format, doc.SpecVersion)
+	}
+
+	return createEvent(msgProducer, db, EventInput{
+		Name:            input.Name,
+		Version:         input.Version,
+		Release:         input.Release,
+		PlatformID:      input.PlatformID,
+		Package:         input.Package,
+		Description:     input.Description,
+		Payload:         input.SBOM,
+		Success:         true,
+		EventReceiverID: input.EventReceiverID,
+	}, doc.Components)
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestIngestSBOM(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	receiver := newTestReceiver(t, producer, db, "sbom")
+
+	for _, file := range []string{"cyclonedx.json", "spdx.json"} {
+		data, err := os.ReadFile("../sbom/testdata/" + file)
+		assert.NilError(t, err)
+		_, err = IngestSBOM(producer, db, SBOMInput{
+			EventReceiverID: receiver.ID,
+			Release:         "20240101",
+			PlatformID:      "x86-64-gnu-linux-7",
+			SBOM:            types.JSON{JSON: data},
+		})
+		assert.NilError(t, err)
+	}
+
+	events, err := db.FindEvent(map[string]any{"name": "foo"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Version, "1.0.0")
+	assert.Equal(t, events[0].Package, "maven")
+	assert.Equal(t, events[0].Description, "CycloneDX 1.5 SBOM")
+	assert.Equal(t, events[0].Success, true)
+
+	// which artifacts contain log4j-core 2.14
+	page, err := db.FindSBOMComponentPage(storage.SBOMComponentFilter{Name: "log4j-core", VersionPrefix: "2.14"}, storage.Page{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Items), 2)
+	artifacts := map[string]string{}
+	for _, component := range page.Items {
+		artifacts[component.Event.Name] = component.Version
+	}
+	assert.DeepEqual(t, artifacts, map[string]string{"foo": "2.14.1", "bar": "2.14.0"})
+
+	page, err = db.FindSBOMComponentPage(storage.SBOMComponentFilter{Name: "log4j-core", Version: "2.14.0"}, storage.Page{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Items), 1)
+	assert.Equal(t, page.Items[0].Event.Name, "bar")
+}
+
+func TestIngestSBOMInvalid(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	receiver := newTestReceiver(t, producer, db, "sbom")
+
+	_, err := IngestSBOM(producer, db, SBOMInput{
+		EventReceiverID: receiver.ID,
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		SBOM:            types.JSON{JSON: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.5"}`)},
+	})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "name is not in the SBOM and was not given")
+	assert.ErrorContains(t, err, "version is not in the SBOM and was not given")
+
+	_, err = IngestSBOM(producer, db, SBOMInput{
+		EventReceiverID: receiver.ID,
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Name:            "foo",
+		Version:         "1.0.0",
+		Package:         "rpm",
+		SBOM:            types.JSON{JSON: []byte(`{"name": "foo"}`)},
+	})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.Equal(t, len(producer.messages), 1)
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/compat"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// EventReceiverSchemaInput is a new revision of the schema of a receiver
+type EventReceiverSchemaInput struct {
+	Schema types.JSON `json:"schema"`
+	// Compatibility the schema must have with the current revision, defaults to FULL
+	Compatibility string `json:"compatibility"`
+}
+
+func (s EventReceiverSchemaInput) Validate() error {
+	var err error
+
+	err = errors.Join(err, validateSchema(s.Schema))
+	if s.Compatibility != "" {
+		err = errors.Join(err, compat.Validate(s.Compatibility))
+	}
+
+	return err
+}
+
+// CreateEventReceiverSchema registers a new schema revision for the receiver after checking it
+// is compatible with the current one. Events created afterwards are validated against it and
+// the groups of the receiver are left untouched.
+func CreateEventReceiverSchema(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, input EventReceiverSchemaInput) (*storage.EventReceiverSchema, error) {
+	if err := input.Validate(); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	if input.Compatibility == "" {
+		input.Compatibility = storage.CompatibilityFull
+	}
+
+	receivers, err := db.FindEventReceiverByID(id)
+	if err != nil {
+		return nil, err
+	}
+	receiver := receivers[0]
+
+	if err := compat.Check(receiver.Schema.JSON, input.Schema.JSON, input.Compatibility); err != nil {
+		msg := fmt.Sprintf("schema is not %s compatible with revision %d: %s", input.Compatibility, receiver.SchemaRevision, err)
+		return nil, eprErrors.InvalidInputError{Msg: msg}
+	}
+
+	var schema *storage.EventReceiverSchema
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		schema, err = tx.CreateEventReceiverSchema(storage.EventReceiverSchema{
+			EventReceiverID: id,
+			Revision:        receiver.SchemaRevision + 1,
+			Schema:          input.Schema,
+			Compatibility:   input.Compatibility,
+		})
+		if err != nil {
+			return err
+		}
+		receiver.Schema = schema.Schema
+		receiver.SchemaRevision = schema.Revision
+		return producer.Send(message.NewEventReceiverModified(receiver))
+	})
+	if err != nil {
+		slog.Error("error creating event receiver schema", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Info("created", "eventReceiverSchema", schema)
+
+	return schema, nil
+}
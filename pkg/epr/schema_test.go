@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestCreateEventReceiverSchema(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	receiver, err := CreateEventReceiver(producer, db, EventReceiverInput{
+		Name:        "build",
+		Type:        "epr.test.build",
+		Version:     "1.0.0",
+		Description: "test receiver",
+		Schema:      types.JSON{JSON: []byte(`{"type": "object", "required": ["sha"], "properties": {"sha": {"type": "string"}}}`)},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, receiver.SchemaRevision, int32(1))
+
+	input := EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{"sha": "abc"}`)},
+		Success:         true,
+		EventReceiverID: receiver.ID,
+	}
+	first, err := CreateEvent(producer, db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, first.SchemaRevision, int32(1))
+
+	// dropping a required field breaks consumers
+	_, err = CreateEventReceiverSchema(producer, db, receiver.ID, EventReceiverSchemaInput{
+		Schema: types.JSON{JSON: []byte(`{"type": "object", "properties": {"sha": {"type": "string"}}}`)},
+	})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "schema is not FULL compatible with revision 1: (root): required field sha removed")
+
+	producer.messages = nil
+	schema, err := CreateEventReceiverSchema(producer, db, receiver.ID, EventReceiverSchemaInput{
+		Schema: types.JSON{JSON: []byte(`{"type": "object", "required": ["sha"], "properties": {"sha": {"type": "string"}, "url": {"type": "string"}}}`)},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, schema.Revision, int32(2))
+	assert.Equal(t, schema.Compatibility, storage.CompatibilityFull)
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.modified"})
+
+	_, err = CreateEventReceiverSchema(producer, db, receiver.ID, EventReceiverSchemaInput{
+		Schema:        types.JSON{JSON: []byte(`{"type": "object", "properties": {"url": {"type": "integer"}}}`)},
+		Compatibility: "SIDEWAYS",
+	})
+	assert.ErrorContains(t, err, `unknown schema compatibility "SIDEWAYS"`)
+
+	second, err := CreateEvent(producer, db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, second.SchemaRevision, int32(2))
+
+	schemas, err := db.FindEventReceiverSchemas(receiver.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(schemas), 2)
+	assert.Equal(t, schemas[0].Compatibility, storage.CompatibilityNone)
+	assert.Equal(t, schemas[1].Revision, int32(2))
+
+	_, err = CreateEventReceiverSchema(producer, db, "missing", EventReceiverSchemaInput{Schema: types.JSON{JSON: []byte(`{}`)}})
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+}
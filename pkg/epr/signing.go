@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// EventSignatureInput is a DSSE envelope over an event, see package signing
+type EventSignatureInput struct {
+	PayloadType string `json:"payloadType"`
+	// Payload is the base64 encoded JSON of the signed event fields
+	Payload    string               `json:"payload"`
+	Signatures []DSSESignatureInput `json:"signatures"`
+}
+
+// DSSESignatureInput is a signature of an EventSignatureInput
+type DSSESignatureInput struct {
+	// KeyID optionally names the trusted key that made the signature
+	KeyID *string `json:"keyid"`
+	// Sig is the base64 encoded signature
+	Sig string `json:"sig"`
+}
+
+func (s EventSignatureInput) Validate() error {
+	var err error
+
+	if strings.TrimSpace(s.Payload) == "" {
+		err = errors.Join(err, errors.New("signature payload cannot be blank"))
+	}
+	if len(s.Signatures) == 0 {
+		err = errors.Join(err, errors.New("signature envelope needs at least one signature"))
+	}
+	for i, sig := range s.Signatures {
+		if strings.TrimSpace(sig.Sig) == "" {
+			err = errors.Join(err, fmt.Errorf("signature %d cannot be blank", i))
+		}
+	}
+
+	return err
+}
+
+func (s EventSignatureInput) envelope() signing.Envelope {
+	envelope := signing.Envelope{PayloadType: s.PayloadType, Payload: s.Payload}
+	for _, sig := range s.Signatures {
+		signature := signing.Signature{Sig: sig.Sig}
+		if sig.KeyID != nil {
+			signature.KeyID = *sig.KeyID
+		}
+		envelope.Signatures = append(envelope.Signatures, signature)
+	}
+	return envelope
+}
+
+// EventReceiverSigningInput replaces the keys trusted to sign the events of a receiver
+type EventReceiverSigningInput struct {
+	PublicKeys       []string `json:"public_keys"`
+	RequireSignature bool     `json:"require_signature"`
+}
+
+func (s EventReceiverSigningInput) Validate() error {
+	return validatePublicKeys(s.PublicKeys, s.RequireSignature)
+}
+
+func validatePublicKeys(publicKeys []string, requireSignature bool) error {
+	var err error
+
+	for i, key := range publicKeys {
+		if _, keyErr := signing.ParsePublicKey(key); keyErr != nil {
+			err = errors.Join(err, fmt.Errorf("public key %d: %w", i, keyErr))
+		}
+	}
+	if requireSignature && len(publicKeys) == 0 {
+		err = errors.Join(err, errors.New("require signature needs at least one public key"))
+	}
+
+	return err
+}
+
+// SetEventReceiverSigning replaces the trusted public keys of the receiver and whether its
+// events must be signed. Events that were already created keep their verification result.
+func SetEventReceiverSigning(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, input EventReceiverSigningInput) (*storage.EventReceiver, error) {
+	if err := input.Validate(); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	var receiver *storage.EventReceiver
+	err := inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		var err error
+		receiver, err = tx.SetEventReceiverSigning(id, input.PublicKeys, input.RequireSignature)
+		if err != nil {
+			return err
+		}
+		return producer.Send(message.NewEventReceiverModified(*receiver))
+	})
+	if err != nil {
+		slog.Error("error setting event receiver signing", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Info("updated signing", "eventReceiver", id, "publicKeys", len(input.PublicKeys), "requireSignature", input.RequireSignature)
+
+	return receiver, nil
+}
+
+// verifyEventSignature verifies the signature against the trusted keys of the receiver of the
+// event. Receivers that require signatures reject events without one that verifies, the others
+// store the outcome with the event.
+func verifyEventSignature(db storage.Repository, event storage.Event, input *EventSignatureInput) (*storage.EventSignature, error) {
+	receivers, err := db.FindEventReceiverByID(event.EventReceiverID)
+	if err != nil {
+		switch err.(type) {
+		case eprErrors.MissingObjectError:
+			return nil, eprErrors.InvalidInputError{Msg: "receiver for event does not exist"}
+		default:
+			return nil, err
+		}
+	}
+	receiver := receivers[0]
+
+	if input == nil {
+		if receiver.RequireSignature {
+			return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("event receiver %s requires signed events", receiver.ID)}
+		}
+		return nil, nil
+	}
+
+	signature := signing.Verify(event, input.envelope(), receiver.PublicKeys)
+	if !signature.Verified && receiver.RequireSignature {
+		return nil, eprErrors.InvalidInputError{Msg: "signature of the event does not verify: " + signature.Reason}
+	}
+	return &signature, nil
+}
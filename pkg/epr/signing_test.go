@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/signing"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestCreateSignedEvent(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(public)
+	assert.NilError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	db := storage.NewMemory()
+	producer := &recorder{}
+	_, err = CreateEventReceiver(producer, db, EventReceiverInput{
+		Name:             "build",
+		Type:             "epr.test.build",
+		Version:          "1.0.0",
+		Description:      "test receiver",
+		Schema:           types.JSON{JSON: []byte(`{}`)},
+		RequireSignature: true,
+	})
+	assert.ErrorContains(t, err, "require signature needs at least one public key")
+	receiver, err := CreateEventReceiver(producer, db, EventReceiverInput{
+		Name:             "build",
+		Type:             "epr.test.build",
+		Version:          "1.0.0",
+		Description:      "test receiver",
+		Schema:           types.JSON{JSON: []byte(`{}`)},
+		PublicKeys:       []string{publicKey},
+		RequireSignature: true,
+	})
+	assert.NilError(t, err)
+
+	input := EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{"sha": "abc"}`)},
+		Success:         true,
+		EventReceiverID: receiver.ID,
+	}
+	_, err = CreateEvent(producer, db, input)
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "requires signed events")
+
+	envelope, err := signing.Sign(storage.Event{
+		Name:            input.Name,
+		Version:         input.Version,
+		Release:         input.Release,
+		PlatformID:      input.PlatformID,
+		Package:         input.Package,
+		Description:     input.Description,
+		Payload:         input.Payload,
+		Success:         input.Success,
+		EventReceiverID: input.EventReceiverID,
+	}, private)
+	assert.NilError(t, err)
+
+	signature := envelope.Signatures[0]
+	input.Signature = &EventSignatureInput{
+		PayloadType: envelope.PayloadType,
+		Payload:     envelope.Payload,
+		Signatures:  []DSSESignatureInput{{Sig: signature.Sig}},
+	}
+
+	// the signed payload must hold the fields of the event
+	input.Success = false
+	_, err = CreateEvent(producer, db, input)
+	assert.ErrorContains(t, err, "signature of the event does not verify: signed payload does not match the event: success")
+
+	input.Success = true
+	input.Signature.Signatures = nil
+	_, err = CreateEvent(producer, db, input)
+	assert.ErrorContains(t, err, "signature envelope needs at least one signature")
+
+	input.Signature.Signatures = []DSSESignatureInput{{Sig: signature.Sig}}
+	producer.messages = nil
+	event, err := CreateEvent(producer, db, input)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, event.Signature, &storage.EventSignature{KeyID: signature.KeyID, Signature: signature.Sig, Payload: envelope.Payload, Verified: true})
+	assert.DeepEqual(t, producer.messages[0].Data.Events[0].Signature, event.Signature)
+
+	// receivers that do not require signatures keep the outcome
+	producer.messages = nil
+	receiver, err = SetEventReceiverSigning(producer, db, receiver.ID, EventReceiverSigningInput{})
+	assert.NilError(t, err)
+	assert.Equal(t, receiver.RequireSignature, false)
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.modified"})
+	event, err = CreateEvent(producer, db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, event.Signature.Verified, false)
+	assert.Equal(t, event.Signature.Reason, "event receiver has no trusted public keys")
+
+	_, err = SetEventReceiverSigning(producer, db, receiver.ID, EventReceiverSigningInput{PublicKeys: []string{"not a key"}})
+	assert.ErrorContains(t, err, "public key 0: public key is not PEM encoded")
+	_, err = SetEventReceiverSigning(producer, db, "missing", EventReceiverSigningInput{})
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	input.EventReceiverID = "missing"
+	_, err = CreateEvent(producer, db, input)
+	assert.ErrorContains(t, err, "receiver for event does not exist")
+}
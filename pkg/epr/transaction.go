@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// inTransaction runs fn in a transaction of the repository. When the producer is an outbox the
+// messages fn sends are stored in that transaction, so that they are published if and only if
+// its writes commit. Other producers, and those the outbox notifies, get the messages once the
+// transaction commits.
+func inTransaction(msgProducer message.TopicProducer, db storage.Repository, fn func(producer message.TopicProducer, tx storage.Repository) error) error {
+	held := &heldMessages{}
+	if outbox, ok := msgProducer.(*message.Outbox); ok {
+		err := db.Transaction(func(tx storage.Repository) error {
+			held.messages = nil
+			return fn(message.NewFanOut(outbox.In(tx), held), tx)
+		})
+		if err != nil {
+			return err
+		}
+		outbox.Committed(held.messages...)
+		return nil
+	}
+
+	err := db.Transaction(func(tx storage.Repository) error {
+		held.messages = nil
+		return fn(held, tx)
+	})
+	if err != nil {
+		return err
+	}
+	for _, msg := range held.messages {
+		msgProducer.Async(msg)
+	}
+	return nil
+}
+
+// heldMessages keeps the messages of a transaction until it commits
+type heldMessages struct {
+	messages []any
+}
+
+func (h *heldMessages) Async(data any) {
+	h.messages = append(h.messages, data)
+}
+
+func (h *heldMessages) Send(data any) error {
+	h.Async(data)
+	return nil
+}
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+// brokenOutbox is a repository that fails to store outbox messages
+type brokenOutbox struct {
+	*storage.Memory
+}
+
+func (b brokenOutbox) CreateOutboxMessage(_ []byte) (*storage.OutboxMessage, error) {
+	return nil, errors.New("outbox is full")
+}
+
+func (b brokenOutbox) Transaction(fn func(tx storage.Repository) error) error {
+	return fn(b)
+}
+
+func TestCreateEventStoresMessagesInOutbox(t *testing.T) {
+	db := storage.NewMemory()
+	outbox := message.NewOutbox(db)
+	build := newTestReceiver(t, outbox, db, "build")
+	_, err := CreateEventReceiverGroup(outbox, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	event := newTestEvent(t, outbox, db, build.ID, true)
+
+	pending, err := db.FindPendingOutboxMessages(10)
+	assert.NilError(t, err)
+	stored := []string{}
+	for _, entry := range pending {
+		var msg message.Message
+		assert.NilError(t, json.Unmarshal(entry.Payload.JSON, &msg))
+		stored = append(stored, msg.Type)
+	}
+	assert.DeepEqual(t, stored, []string{"epr.event.receiver.created", "epr.event.receiver.group.created", "epr.test.build", "epr.test.release"})
+
+	stuck, err := db.FindStuckOutboxMessages(time.Now().Add(time.Minute))
+	assert.NilError(t, err)
+	assert.Equal(t, len(stuck), 4)
+
+	var msg message.Message
+	assert.NilError(t, json.Unmarshal(pending[2].Payload.JSON, &msg))
+	assert.Equal(t, msg.Subject, string(event.ID))
+}
+
+func TestCreateEventFailsWithoutOutbox(t *testing.T) {
+	memory := storage.NewMemory()
+	build := newTestReceiver(t, &recorder{}, memory, "build")
+
+	db := brokenOutbox{Memory: memory}
+	_, err := CreateEvent(message.NewOutbox(db), db, EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{}`)},
+		Success:         true,
+		EventReceiverID: build.ID,
+	})
+	assert.ErrorContains(t, err, "outbox is full")
+}
+
+// brokenGroupState is a repository that fails to record the state of groups
+type brokenGroupState struct {
+	*storage.Memory
+}
+
+func (b brokenGroupState) SaveEventReceiverGroupState(_ storage.EventReceiverGroupState) (*storage.EventReceiverGroupState, error) {
+	return nil, errors.New("group state is locked")
+}
+
+func (b brokenGroupState) Transaction(fn func(tx storage.Repository) error) error {
+	return b.Memory.Transaction(func(tx storage.Repository) error {
+		return fn(brokenGroupState{Memory: tx.(*storage.Memory)})
+	})
+}
+
+func TestCreateEventRollsBackWhenGroupTransitionFails(t *testing.T) {
+	memory := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, memory, "build")
+	_, err := CreateEventReceiverGroup(producer, memory, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	producer.messages = nil
+
+	db := brokenGroupState{Memory: memory}
+	input := EventInput{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{}`)},
+		Success:         true,
+		EventReceiverID: build.ID,
+	}
+	_, err = CreateEvent(producer, db, input)
+	assert.ErrorContains(t, err, "group state is locked")
+
+	events, err := memory.FindLatestEvents([]graphql.ID{build.ID}, storage.Event{
+		Name:       input.Name,
+		Version:    input.Version,
+		Release:    input.Release,
+		PlatformID: input.PlatformID,
+		Package:    input.Package,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+	assert.Equal(t, len(producer.messages), 0)
+}
+
+func TestEnableGroupRollsBackWhenReevaluationFails(t *testing.T) {
+	memory := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, memory, "build")
+	group, err := CreateEventReceiverGroup(producer, memory, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          false,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	newTestEvent(t, producer, memory, build.ID, true)
+	producer.messages = nil
+
+	// the group is only enabled along with its re-evaluation
+	err = SetEventReceiverGroupEnabled(producer, brokenGroupState{Memory: memory}, group.ID, true)
+	assert.ErrorContains(t, err, "group state is locked")
+
+	groups, err := memory.FindEventReceiverGroupByID(group.ID)
+	assert.NilError(t, err)
+	assert.Assert(t, !groups[0].Enabled)
+	assert.Equal(t, len(producer.messages), 0)
+}
+
+func TestInTransactionHoldsMessagesUntilCommit(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	err := inTransaction(producer, db, func(p message.TopicProducer, _ storage.Repository) error {
+		assert.NilError(t, p.Send(message.Message{Type: "first"}))
+		assert.Equal(t, len(producer.messages), 0)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, producer.types(), []string{"first"})
+
+	err = inTransaction(producer, db, func(p message.TopicProducer, _ storage.Repository) error {
+		p.Async(message.Message{Type: "second"})
+		return errors.New("rolled back")
+	})
+	assert.ErrorContains(t, err, "rolled back")
+	assert.DeepEqual(t, producer.types(), []string{"first"})
+}
+
+func TestOutboxNotifiesCommittedMessages(t *testing.T) {
+	db := storage.NewMemory()
+	notified := &recorder{}
+	outbox := message.NewOutbox(db).Notify(notified)
+	err := inTransaction(outbox, db, func(p message.TopicProducer, _ storage.Repository) error {
+		assert.NilError(t, p.Send(message.Message{Type: "first"}))
+		assert.Equal(t, len(notified.messages), 0)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, notified.types(), []string{"first"})
+
+	err = inTransaction(outbox, db, func(p message.TopicProducer, _ storage.Repository) error {
+		assert.NilError(t, p.Send(message.Message{Type: "second"}))
+		return errors.New("rolled back")
+	})
+	assert.ErrorContains(t, err, "rolled back")
+	assert.DeepEqual(t, notified.types(), []string{"first"})
+
+	// messages sent outside of a transaction are committed right away
+	assert.NilError(t, outbox.Send(message.Message{Type: "third"}))
+	assert.DeepEqual(t, notified.types(), []string{"first", "third"})
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// validateGroupUpdate checks the update against the current receivers of the group
+func validateGroupUpdate(group storage.EventReceiverGroup, update storage.EventReceiverGroupUpdate) error {
+	var err error
+
+	if update.Description == nil && update.Enabled == nil && update.Policy == nil &&
+		len(update.AddEventReceivers) == 0 && len(update.RemoveEventReceiverIDs) == 0 {
+		return errors.New("nothing to update")
+	}
+	if update.Description != nil && strings.TrimSpace(*update.Description) == "" {
+		err = errors.Join(err, errors.New("description cannot be blank"))
+	}
+	seen := map[graphql.ID]bool{}
+	added := []graphql.ID{}
+	conditions := []storage.EventReceiverCondition{}
+	for _, membership := range update.AddEventReceivers {
+		id := membership.EventReceiverID
+		switch {
+		case strings.TrimSpace(string(id)) == "":
+			err = errors.Join(err, errors.New("event receiver ids cannot be blank"))
+		case containsID(group.EventReceiverIDs, id):
+			err = errors.Join(err, fmt.Errorf("event receiver %s is already a member of the group", id))
+		case seen[id]:
+			err = errors.Join(err, fmt.Errorf("event receiver %s is added more than once", id))
+		}
+		seen[id] = true
+		added = append(added, id)
+		if membership.Condition != "" {
+			conditions = append(conditions, storage.EventReceiverCondition{EventReceiverID: id, Expression: membership.Condition})
+		}
+	}
+	err = errors.Join(err, gate.ValidateConditions(conditions, added))
+	for _, id := range update.RemoveEventReceiverIDs {
+		if !containsID(group.EventReceiverIDs, id) {
+			err = errors.Join(err, fmt.Errorf("event receiver %s is not a member of the group", id))
+		}
+	}
+	return err
+}
+
+// UpdateEventReceiverGroup changes the description, enabled flag, policy and receivers of a
+// group. Receivers join the group with their condition and are added to its optional receivers
+// when they are optional, receivers removed from the group are also removed from its optional
+// receivers. An enabled group whose receivers or policy changed, or that was just enabled, is
+// re-evaluated in the transaction of the update.
+func UpdateEventReceiverGroup(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, update storage.EventReceiverGroupUpdate) (*storage.EventReceiverGroup, error) {
+	groups, err := db.FindEventReceiverGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	group := groups[0]
+	if err := validateGroupUpdate(group, update); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	eventReceiverIDs := []graphql.ID{}
+	for _, eventReceiverID := range group.EventReceiverIDs {
+		if !containsID(update.RemoveEventReceiverIDs, eventReceiverID) {
+			eventReceiverIDs = append(eventReceiverIDs, eventReceiverID)
+		}
+	}
+	for _, membership := range update.AddEventReceivers {
+		eventReceiverIDs = append(eventReceiverIDs, membership.EventReceiverID)
+	}
+	if len(eventReceiverIDs) == 0 {
+		return nil, eprErrors.InvalidInputError{Msg: "need at least one event receiver id"}
+	}
+
+	policy := group.Policy
+	if update.Policy != nil {
+		policy = *update.Policy
+	}
+	policy = gate.NormalizePolicy(policy)
+	optional := []graphql.ID{}
+	for _, eventReceiverID := range policy.OptionalEventReceiverIDs {
+		if !containsID(update.RemoveEventReceiverIDs, eventReceiverID) {
+			optional = append(optional, eventReceiverID)
+		}
+	}
+	for _, membership := range update.AddEventReceivers {
+		if membership.Optional && !containsID(optional, membership.EventReceiverID) {
+			optional = append(optional, membership.EventReceiverID)
+		}
+	}
+	if update.Policy != nil || !slices.Equal(optional, policy.OptionalEventReceiverIDs) {
+		policy.OptionalEventReceiverIDs = optional
+		update.Policy = &policy
+	}
+	if err := gate.ValidatePolicy(policy, eventReceiverIDs); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+
+	changed := len(update.AddEventReceivers) > 0 || len(update.RemoveEventReceiverIDs) > 0 || update.Policy != nil
+	var updated *storage.EventReceiverGroup
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		updated, err = tx.UpdateEventReceiverGroup(id, update)
+		if err != nil {
+			return err
+		}
+		if err := producer.Send(message.NewEventReceiverGroupModified(*updated)); err != nil {
+			return err
+		}
+		// the group is re-evaluated with the update, so the update never persists without it
+		enabled := !group.Enabled && updated.Enabled
+		if updated.Enabled && (changed || enabled) {
+			return reevaluateGroup(producer, tx, *updated)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("error updating event receiver group", "error", err, "id", id, "update", update)
+		return nil, err
+	}
+	slog.Info("updated", "eventReceiverGroup", updated)
+	return updated, nil
+}
+
+// SetEventReceiverGroupEnabled enables or disables the group. Enabling re-evaluates the group
+// so that artifacts which satisfied it while it was disabled complete.
+func SetEventReceiverGroupEnabled(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID, enabled bool) error {
+	_, err := UpdateEventReceiverGroup(msgProducer, db, id, storage.EventReceiverGroupUpdate{Enabled: &enabled})
+	return err
+}
+
+// DeleteEventReceiverGroup soft deletes the group. It no longer shows up in searches or gets
+// evaluated, but its completions and states stay in the database.
+func DeleteEventReceiverGroup(msgProducer message.TopicProducer, db storage.Repository, id graphql.ID) error {
+	groups, err := db.FindEventReceiverGroupByID(id)
+	if err != nil {
+		return err
+	}
+	err = inTransaction(msgProducer, db, func(producer message.TopicProducer, tx storage.Repository) error {
+		if err := tx.DeleteEventReceiverGroup(id); err != nil {
+			return err
+		}
+		return producer.Send(message.NewEventReceiverGroupDeleted(groups[0]))
+	})
+	if err != nil {
+		slog.Error("error deleting event receiver group", "error", err, "id", id)
+		return err
+	}
+	slog.Info("deleted", "eventReceiverGroup", id)
+	return nil
+}
+
+func containsID(ids []graphql.ID, id graphql.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
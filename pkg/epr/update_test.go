@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestUpdateEventReceiverGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	test := newTestReceiver(t, producer, db, "test")
+	scan := newTestReceiver(t, producer, db, "scan")
+	lint := newTestReceiver(t, producer, db, "lint")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+		Policy:           &storage.GatePolicy{OptionalEventReceiverIDs: []graphql.ID{test.ID}},
+	})
+	assert.NilError(t, err)
+
+	// the scanner already has an event when it joins the group
+	newTestEvent(t, producer, db, build.ID, false)
+	newTestEvent(t, producer, db, scan.ID, true)
+	producer.messages = nil
+	time.Sleep(time.Millisecond)
+
+	description := "build and scan"
+	updated, err := UpdateEventReceiverGroup(producer, db, group.ID, storage.EventReceiverGroupUpdate{
+		Description: &description,
+		AddEventReceivers: []storage.EventReceiverMembership{
+			{EventReceiverID: scan.ID, Condition: `name == "foo"`},
+			{EventReceiverID: lint.ID, Optional: true},
+		},
+		RemoveEventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, updated.Description, description)
+	assert.DeepEqual(t, updated.EventReceiverIDs, []graphql.ID{scan.ID, lint.ID})
+	assert.DeepEqual(t, updated.Policy.OptionalEventReceiverIDs, []graphql.ID{lint.ID})
+	assert.DeepEqual(t, updated.Conditions, []storage.EventReceiverCondition{{EventReceiverID: scan.ID, Expression: `name == "foo"`}})
+	assert.Assert(t, time.Time(updated.UpdatedAt.Date).After(time.Time(updated.CreatedAt.Date)))
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.group.modified", "epr.test.release"})
+
+	tests := map[string]storage.EventReceiverGroupUpdate{
+		"nothing to update": {},
+		"blank description": {Description: new(string)},
+		"already a member":  {AddEventReceivers: []storage.EventReceiverMembership{{EventReceiverID: scan.ID}}},
+		"invalid condition": {AddEventReceivers: []storage.EventReceiverMembership{{EventReceiverID: test.ID, Condition: "payload."}}},
+		"not a member":      {RemoveEventReceiverIDs: []graphql.ID{build.ID}},
+		"no receivers left": {RemoveEventReceiverIDs: []graphql.ID{scan.ID, lint.ID}},
+		"no required left":  {RemoveEventReceiverIDs: []graphql.ID{scan.ID}},
+		"invalid policy":    {Policy: &storage.GatePolicy{Type: storage.PolicyNOf, N: new(int32)}},
+	}
+	for name, update := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := UpdateEventReceiverGroup(producer, db, group.ID, update)
+			assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+		})
+	}
+}
+
+func TestDeleteEventReceiverGroup(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	group, err := CreateEventReceiverGroup(producer, db, EventReceiverGroupInput{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	producer.messages = nil
+
+	assert.NilError(t, DeleteEventReceiverGroup(producer, db, group.ID))
+	assert.DeepEqual(t, producer.types(), []string{"epr.event.receiver.group.deleted"})
+
+	_, err = db.FindEventReceiverGroupByID(group.ID)
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	// a deleted group is no longer evaluated
+	producer.messages = nil
+	newTestEvent(t, producer, db, build.ID, true)
+	assert.DeepEqual(t, producer.types(), []string{"epr.test.build"})
+
+	err = DeleteEventReceiverGroup(producer, db, group.ID)
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// chainPageSize is the number of events read at a time while walking a chain
+const chainPageSize = 500
+
+// ChainVerification is the outcome of walking the hash chain of a receiver
+type ChainVerification struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	// Events is the number of events that verified
+	Events int32 `json:"events"`
+	// Head is the hash of the last event that verified. Recording it elsewhere lets a later
+	// verification detect that events were removed from the end of the chain.
+	Head string `json:"head"`
+	// Broken is the first link that did not verify, nil when the whole chain did
+	Broken *ChainBreak `json:"broken,omitempty"`
+}
+
+// ChainBreak is an event whose link in the chain does not verify
+type ChainBreak struct {
+	EventID  graphql.ID `json:"event_id"`
+	Sequence int32      `json:"sequence"`
+	Reason   string     `json:"reason"`
+}
+
+// VerifyEventChains walks the hash chain of the receiver, or of every receiver when the id is
+// empty, and reports the first broken link of each. Events stored before the chain was
+// introduced are not part of it and are not verified.
+func VerifyEventChains(db storage.Repository, eventReceiverID graphql.ID) ([]ChainVerification, error) {
+	var receivers []storage.EventReceiver
+	var err error
+	if eventReceiverID != "" {
+		receivers, err = db.FindEventReceiverByID(eventReceiverID)
+	} else {
+		receivers, err = db.FindEventReceiver(map[string]any{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(receivers, func(i, j int) bool { return receivers[i].ID < receivers[j].ID })
+
+	verifications := make([]ChainVerification, 0, len(receivers))
+	for _, receiver := range receivers {
+		verification, err := verifyEventChain(db, receiver.ID)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, verification)
+	}
+	return verifications, nil
+}
+
+func verifyEventChain(db storage.Repository, eventReceiverID graphql.ID) (ChainVerification, error) {
+	verification := ChainVerification{EventReceiverID: eventReceiverID}
+	var sequence int32
+	for {
+		events, err := db.FindEventChain(eventReceiverID, sequence, chainPageSize)
+		if err != nil {
+			return verification, err
+		}
+		for _, event := range events {
+			if reason := verifyLink(event, sequence, verification.Head); reason != "" {
+				verification.Broken = &ChainBreak{EventID: event.ID, Sequence: event.Sequence, Reason: reason}
+				return verification, nil
+			}
+			sequence = event.Sequence
+			verification.Head = event.Hash
+			verification.Events++
+		}
+		if len(events) < chainPageSize {
+			return verification, nil
+		}
+	}
+}
+
+// verifyLink returns why the event does not follow the event with the sequence and hash, empty
+// when it does
+func verifyLink(event storage.Event, sequence int32, hash string) string {
+	if event.Sequence != sequence+1 {
+		return fmt.Sprintf("expected sequence %d, events were removed", sequence+1)
+	}
+	if event.PreviousHash != hash {
+		return "previous hash does not match the hash of the event before it"
+	}
+	computed, err := event.ContentHash()
+	if err != nil {
+		return err.Error()
+	}
+	if computed != event.Hash {
+		return "hash does not match the content of the event, it was altered"
+	}
+	return ""
+}
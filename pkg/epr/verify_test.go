@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+// tampered alters the events of the chains it returns, like an edit made directly in the database
+type tampered struct {
+	*storage.Memory
+	alter func([]storage.Event) []storage.Event
+}
+
+func (t tampered) FindEventChain(eventReceiverID graphql.ID, after int32, limit int) ([]storage.Event, error) {
+	events, err := t.Memory.FindEventChain(eventReceiverID, after, limit)
+	if err != nil || t.alter == nil {
+		return events, err
+	}
+	return t.alter(events), nil
+}
+
+func TestVerifyEventChains(t *testing.T) {
+	memory := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, memory, "build")
+	newTestReceiver(t, producer, memory, "test")
+	var events []*storage.Event
+	for i := 0; i < 3; i++ {
+		events = append(events, newTestEvent(t, producer, memory, build.ID, true))
+	}
+
+	verifications, err := VerifyEventChains(memory, "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(verifications), 2)
+	verification, err := VerifyEventChains(memory, build.ID)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, verification, []ChainVerification{{EventReceiverID: build.ID, Events: 3, Head: events[2].Hash}})
+
+	tests := []struct {
+		name     string
+		alter    func([]storage.Event) []storage.Event
+		sequence int32
+		reason   string
+	}{
+		{
+			name: "altered payload",
+			alter: func(events []storage.Event) []storage.Event {
+				events[1].Payload = types.JSON{JSON: []byte(`{"forged": true}`)}
+				return events
+			},
+			sequence: 2,
+			reason:   "hash does not match the content of the event, it was altered",
+		},
+		{
+			name: "rehashed event",
+			alter: func(events []storage.Event) []storage.Event {
+				events[1].Success = false
+				events[1].Hash, _ = events[1].ContentHash()
+				return events
+			},
+			sequence: 3,
+			reason:   "previous hash does not match the hash of the event before it",
+		},
+		{
+			name: "removed event",
+			alter: func(events []storage.Event) []storage.Event {
+				return append(events[:1], events[2:]...)
+			},
+			sequence: 3,
+			reason:   "expected sequence 2, events were removed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifications, err := VerifyEventChains(tampered{Memory: memory, alter: tt.alter}, build.ID)
+			assert.NilError(t, err)
+			assert.Assert(t, verifications[0].Broken != nil)
+			assert.Equal(t, verifications[0].Broken.Sequence, tt.sequence)
+			assert.Equal(t, verifications[0].Broken.Reason, tt.reason)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// minSecretLength is the shortest secret a subscription can be signed with
+const minSecretLength = 16
+
+// WebhookSubscriptionInput subscribes a URL to the messages that match every filter given
+type WebhookSubscriptionInput struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret is the key of the HMAC signature of each delivery
+	Secret                string       `json:"secret"`
+	MessageTypes          []string     `json:"message_types"`
+	EventReceiverIDs      []graphql.ID `json:"event_receiver_ids"`
+	EventReceiverGroupIDs []graphql.ID `json:"event_receiver_group_ids"`
+}
+
+func (s WebhookSubscriptionInput) Validate() error {
+	var err error
+
+	if strings.TrimSpace(s.Name) == "" {
+		err = errors.Join(err, errors.New("name cannot be blank"))
+	}
+	err = errors.Join(err, validateWebhookURL(s.URL), validateWebhookSecret(s.Secret))
+
+	return err
+}
+
+// CreateWebhookSubscription creates an active subscription after checking that the receivers and
+// groups of its filters exist
+func CreateWebhookSubscription(db storage.Repository, input WebhookSubscriptionInput) (*storage.WebhookSubscription, error) {
+	if err := input.Validate(); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	if err := validateWebhookFilters(db, input.EventReceiverIDs, input.EventReceiverGroupIDs); err != nil {
+		return nil, err
+	}
+
+	subscription, err := db.CreateWebhookSubscription(storage.WebhookSubscription{
+		Name:                  input.Name,
+		URL:                   input.URL,
+		Secret:                input.Secret,
+		MessageTypes:          input.MessageTypes,
+		EventReceiverIDs:      input.EventReceiverIDs,
+		EventReceiverGroupIDs: input.EventReceiverGroupIDs,
+	})
+	if err != nil {
+		slog.Error("error creating webhook subscription", "error", err, "input", input.Name)
+		return nil, err
+	}
+	slog.Info("created", "webhookSubscription", subscription.ID, "url", subscription.URL)
+	return subscription, nil
+}
+
+// UpdateWebhookSubscription changes the subscription. Enabling a paused subscription resumes its
+// deliveries, including the ones that were pending when it was paused.
+func UpdateWebhookSubscription(db storage.Repository, id graphql.ID, update storage.WebhookSubscriptionUpdate) (*storage.WebhookSubscription, error) {
+	var err error
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		err = errors.Join(err, errors.New("name cannot be blank"))
+	}
+	if update.URL != nil {
+		err = errors.Join(err, validateWebhookURL(*update.URL))
+	}
+	if update.Secret != nil {
+		err = errors.Join(err, validateWebhookSecret(*update.Secret))
+	}
+	if err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	var eventReceiverIDs, groupIDs []graphql.ID
+	if update.EventReceiverIDs != nil {
+		eventReceiverIDs = *update.EventReceiverIDs
+	}
+	if update.EventReceiverGroupIDs != nil {
+		groupIDs = *update.EventReceiverGroupIDs
+	}
+	if err := validateWebhookFilters(db, eventReceiverIDs, groupIDs); err != nil {
+		return nil, err
+	}
+
+	subscription, err := db.UpdateWebhookSubscription(id, update)
+	if err != nil {
+		slog.Error("error updating webhook subscription", "error", err, "id", id)
+		return nil, err
+	}
+	slog.Info("updated", "webhookSubscription", id, "state", subscription.State)
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription and its delivery log
+func DeleteWebhookSubscription(db storage.Repository, id graphql.ID) error {
+	if err := db.DeleteWebhookSubscription(id); err != nil {
+		slog.Error("error deleting webhook subscription", "error", err, "id", id)
+		return err
+	}
+	slog.Info("deleted", "webhookSubscription", id)
+	return nil
+}
+
+// RedeliverWebhookDelivery posts a delivery again, whatever its state, with a fresh set of retries
+func RedeliverWebhookDelivery(db storage.Repository, id graphql.ID) (*storage.WebhookDelivery, error) {
+	delivery, err := db.RedeliverWebhookDelivery(id)
+	if err != nil {
+		slog.Error("error redelivering webhook delivery", "error", err, "id", id)
+		return nil, err
+	}
+	slog.Info("redelivering", "webhookDelivery", id, "webhookSubscription", delivery.SubscriptionID)
+	return delivery, nil
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https url", raw)
+	}
+	return nil
+}
+
+func validateWebhookSecret(secret string) error {
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters long", minSecretLength)
+	}
+	return nil
+}
+
+// validateWebhookFilters checks that the receivers and groups exist
+func validateWebhookFilters(db storage.Repository, eventReceiverIDs, groupIDs []graphql.ID) error {
+	if len(eventReceiverIDs) > 0 {
+		receivers, err := db.FindEventReceiversByIDs(eventReceiverIDs)
+		if err != nil {
+			return err
+		}
+		for _, id := range eventReceiverIDs {
+			if !containsReceiver(receivers, id) {
+				return eprErrors.InvalidInputError{Msg: fmt.Sprintf("event receiver %s does not exist", id)}
+			}
+		}
+	}
+	for _, id := range groupIDs {
+		if _, err := db.FindEventReceiverGroupByID(id); err != nil {
+			var missing eprErrors.MissingObjectError
+			if errors.As(err, &missing) {
+				return eprErrors.InvalidInputError{Msg: fmt.Sprintf("event receiver group %s does not exist", id)}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func containsReceiver(receivers []storage.EventReceiver, id graphql.ID) bool {
+	for _, r := range receivers {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package epr
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestWebhookSubscription(t *testing.T) {
+	db := storage.NewMemory()
+	producer := &recorder{}
+	build := newTestReceiver(t, producer, db, "build")
+	input := WebhookSubscriptionInput{
+		Name:             "ci",
+		URL:              "https://ci.example.com/hooks",
+		Secret:           "0123456789abcdef",
+		EventReceiverIDs: []graphql.ID{build.ID},
+	}
+
+	subscription, err := CreateWebhookSubscription(db, input)
+	assert.NilError(t, err)
+	assert.Equal(t, subscription.State, storage.SubscriptionStateActive)
+	assert.DeepEqual(t, subscription.MessageTypes, []string{})
+
+	invalid := input
+	invalid.URL = "ci.example.com/hooks"
+	invalid.Secret = "short"
+	_, err = CreateWebhookSubscription(db, invalid)
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	assert.ErrorContains(t, err, "url")
+	assert.ErrorContains(t, err, "secret")
+
+	invalid = input
+	invalid.EventReceiverGroupIDs = []graphql.ID{"missing"}
+	_, err = CreateWebhookSubscription(db, invalid)
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+
+	// enabling a paused subscription resumes it
+	assert.NilError(t, db.PauseWebhookSubscription(subscription.ID, "the endpoint responded 410 Gone"))
+	enabled := true
+	subscription, err = UpdateWebhookSubscription(db, subscription.ID, storage.WebhookSubscriptionUpdate{Enabled: &enabled})
+	assert.NilError(t, err)
+	assert.Equal(t, subscription.State, storage.SubscriptionStateActive)
+	assert.Equal(t, subscription.PausedReason, "")
+
+	blank := " "
+	_, err = UpdateWebhookSubscription(db, subscription.ID, storage.WebhookSubscriptionUpdate{Name: &blank})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+	_, err = UpdateWebhookSubscription(db, "missing", storage.WebhookSubscriptionUpdate{Enabled: &enabled})
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	assert.NilError(t, DeleteWebhookSubscription(db, subscription.ID))
+	assert.ErrorType(t, DeleteWebhookSubscription(db, subscription.ID), eprErrors.MissingObjectError{})
+	_, err = RedeliverWebhookDelivery(db, "missing")
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gate
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// conditionEnv declares the event fields a condition can refer to, e.g.
+// `payload.critical == 0 && platform_id.startsWith("x86")`
+var conditionEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("id", cel.StringType),
+		cel.Variable("name", cel.StringType),
+		cel.Variable("version", cel.StringType),
+		cel.Variable("release", cel.StringType),
+		cel.Variable("platform_id", cel.StringType),
+		cel.Variable("package", cel.StringType),
+		cel.Variable("description", cel.StringType),
+		cel.Variable("success", cel.BoolType),
+		cel.Variable("event_receiver_id", cel.StringType),
+		cel.Variable("created_at", cel.TimestampType),
+		cel.Variable("payload", cel.DynType),
+	)
+})
+
+const (
+	// conditionCostLimit bounds the work a condition may do in one evaluation, in CEL cost
+	// units, so that a condition looping over a large payload cannot stall the server
+	conditionCostLimit = 1_000_000
+	// programCacheSize bounds the number of compiled conditions that are kept
+	programCacheSize = 1024
+)
+
+// programs caches compiled conditions by expression
+var programs = newProgramCache(programCacheSize)
+
+// CompileCondition checks that the expression is valid CEL that evaluates to a bool
+func CompileCondition(expression string) (cel.Program, error) {
+	if program, ok := programs.get(expression); ok {
+		return program, nil
+	}
+	env, err := conditionEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expression, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid condition %q: must evaluate to a bool, not %s", expression, ast.OutputType())
+	}
+	program, err := env.Program(ast, cel.CostLimit(conditionCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expression, err)
+	}
+	programs.add(expression, program)
+	return program, nil
+}
+
+// programCache keeps the most recently used compiled conditions
+type programCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cachedProgram struct {
+	expression string
+	program    cel.Program
+}
+
+func newProgramCache(size int) *programCache {
+	return &programCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *programCache) get(expression string) (cel.Program, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[expression]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(cachedProgram).program, true
+}
+
+// add caches the program and evicts the least recently used one when the cache is full
+func (c *programCache) add(expression string, program cel.Program) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[expression]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[expression] = c.order.PushFront(cachedProgram{expression: expression, program: program})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cachedProgram).expression)
+	}
+}
+
+// ValidateConditions checks that every condition compiles and belongs to a single member of the group
+func ValidateConditions(conditions []storage.EventReceiverCondition, eventReceiverIDs []graphql.ID) error {
+	var err error
+	seen := map[graphql.ID]bool{}
+	for _, c := range conditions {
+		if !containsID(eventReceiverIDs, c.EventReceiverID) {
+			err = errors.Join(err, fmt.Errorf("condition event receiver %s is not a member of the group", c.EventReceiverID))
+		}
+		if seen[c.EventReceiverID] {
+			err = errors.Join(err, fmt.Errorf("event receiver %s has more than one condition", c.EventReceiverID))
+		}
+		seen[c.EventReceiverID] = true
+		if strings.TrimSpace(c.Expression) == "" {
+			err = errors.Join(err, fmt.Errorf("condition of event receiver %s cannot be blank", c.EventReceiverID))
+			continue
+		}
+		if _, compileErr := CompileCondition(c.Expression); compileErr != nil {
+			err = errors.Join(err, compileErr)
+		}
+	}
+	return err
+}
+
+// EvaluateCondition runs the expression against the event
+func EvaluateCondition(expression string, event storage.Event) (bool, error) {
+	program, err := CompileCondition(expression)
+	if err != nil {
+		return false, err
+	}
+	var payload any
+	if len(event.Payload.JSON) > 0 {
+		if err := json.Unmarshal(event.Payload.JSON, &payload); err != nil {
+			return false, fmt.Errorf("decoding payload: %w", err)
+		}
+	}
+	out, _, err := program.Eval(map[string]any{
+		"id":                string(event.ID),
+		"name":              event.Name,
+		"version":           event.Version,
+		"release":           event.Release,
+		"platform_id":       event.PlatformID,
+		"package":           event.Package,
+		"description":       event.Description,
+		"success":           event.Success,
+		"event_receiver_id": string(event.EventReceiverID),
+		"created_at":        time.Time(event.CreatedAt.Date),
+		"payload":           payload,
+	})
+	if err != nil {
+		return false, err
+	}
+	satisfied, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("condition evaluated to %v, not a bool", out.Value())
+	}
+	return satisfied, nil
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gate
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	e := event("e1", "scan", true, 0)
+	e.Name = "foo"
+	e.PlatformID = "x86-64-gnu-linux-7"
+	e.Payload = types.JSON{JSON: []byte(`{"critical": 0, "high": 3, "scanner": {"name": "trivy"}}`)}
+
+	tests := map[string]struct {
+		expression string
+		satisfied  bool
+		err        string
+	}{
+		"payload number":       {expression: "payload.critical == 0", satisfied: true},
+		"payload comparison":   {expression: "payload.high < 2", satisfied: false},
+		"nested payload":       {expression: `payload.scanner.name == "trivy"`, satisfied: true},
+		"event fields":         {expression: `name == "foo" && platform_id.startsWith("x86") && success`, satisfied: true},
+		"has macro":            {expression: "has(payload.medium)", satisfied: false},
+		"missing payload key":  {expression: "payload.medium == 0", err: "no such key: medium"},
+		"not a bool":           {expression: "name", err: `invalid condition "name": must evaluate to a bool, not string`},
+		"undeclared reference": {expression: "commit == 1", err: "undeclared reference to 'commit'"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			satisfied, err := EvaluateCondition(tc.expression, e)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, satisfied, tc.satisfied)
+		})
+	}
+}
+
+func TestEvaluateConditionCostLimit(t *testing.T) {
+	items := make([]string, 2000)
+	for i := range items {
+		items[i] = strconv.Itoa(i)
+	}
+	e := event("e1", "scan", true, 0)
+	e.Payload = types.JSON{JSON: []byte(`{"items": [` + strings.Join(items, ",") + `]}`)}
+
+	satisfied, err := EvaluateCondition("payload.items.all(i, i >= 0)", e)
+	assert.NilError(t, err)
+	assert.Assert(t, satisfied)
+
+	_, err = EvaluateCondition("payload.items.all(i, payload.items.all(j, i != j || i == j))", e)
+	assert.ErrorContains(t, err, "cost limit exceeded")
+}
+
+func TestProgramCache(t *testing.T) {
+	cache := newProgramCache(2)
+	for _, expression := range []string{"success", "!success", "success || true"} {
+		if expression == "success || true" {
+			// success is used again and outlives !success
+			_, ok := cache.get("success")
+			assert.Assert(t, ok)
+		}
+		program, err := CompileCondition(expression)
+		assert.NilError(t, err)
+		cache.add(expression, program)
+	}
+	_, ok := cache.get("!success")
+	assert.Assert(t, !ok)
+	_, ok = cache.get("success")
+	assert.Assert(t, ok)
+	_, ok = cache.get("success || true")
+	assert.Assert(t, ok)
+	assert.Equal(t, cache.order.Len(), 2)
+}
+
+func TestValidateConditions(t *testing.T) {
+	ids := []graphql.ID{"a", "b"}
+	assert.NilError(t, ValidateConditions(nil, ids))
+	assert.NilError(t, ValidateConditions([]storage.EventReceiverCondition{{EventReceiverID: "a", Expression: "payload.critical == 0"}}, ids))
+
+	err := ValidateConditions([]storage.EventReceiverCondition{
+		{EventReceiverID: "c", Expression: "success"},
+		{EventReceiverID: "a", Expression: "payload.critical =="},
+		{EventReceiverID: "a", Expression: " "},
+	}, ids)
+	assert.ErrorContains(t, err, "condition event receiver c is not a member of the group")
+	assert.ErrorContains(t, err, `invalid condition "payload.critical =="`)
+	assert.ErrorContains(t, err, "event receiver a has more than one condition")
+	assert.ErrorContains(t, err, "condition of event receiver a cannot be blank")
+}
+
+func TestPolicyEvaluatorConditions(t *testing.T) {
+	passing := event("e1", "scan", true, 0)
+	passing.Payload = types.JSON{JSON: []byte(`{"critical": 0}`)}
+	critical := event("e2", "scan", true, 1)
+	critical.Payload = types.JSON{JSON: []byte(`{"critical": 2}`)}
+	broken := event("e3", "scan", true, 2)
+	broken.Payload = types.JSON{JSON: []byte(`{}`)}
+	failed := event("e4", "scan", false, 3)
+	failed.Payload = types.JSON{JSON: []byte(`{"critical": 0}`)}
+
+	tests := map[string]struct {
+		event     storage.Event
+		passed    bool
+		message   string
+		errorText string
+	}{
+		"condition satisfied": {
+			event:   passing,
+			passed:  true,
+			message: "latest event e1 of receiver scan succeeded and satisfies the condition",
+		},
+		"condition not satisfied": {
+			event:   critical,
+			message: "latest event e2 of receiver scan does not satisfy the condition",
+		},
+		"condition error": {
+			event:     broken,
+			message:   "condition of receiver scan could not be evaluated on latest event e3",
+			errorText: "no such key: critical",
+		},
+		"failure is not evaluated": {
+			event:   failed,
+			message: "latest event e4 of receiver scan failed",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := group("scan")
+			g.Conditions = []storage.EventReceiverCondition{{EventReceiverID: "scan", Expression: "payload.critical == 0"}}
+			result := PolicyEvaluator{}.Evaluate(Input{Group: g, Events: []storage.Event{tc.event}})
+			assert.Equal(t, result.Passed, tc.passed)
+			assert.Equal(t, result.Reasons[0].Condition, "payload.critical == 0")
+			assert.Equal(t, result.Reasons[0].Message, tc.message)
+			assert.Equal(t, result.Reasons[0].Error, tc.errorText)
+			assert.Equal(t, len(result.Errors()), map[bool]int{true: 1, false: 0}[tc.errorText != ""])
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gate decides whether an event receiver group passes for an artifact. An artifact is
+// identified by the name, version, release, platform id and package of its events. The caller
+// fetches the group, its receivers and the latest event of each receiver for the artifact; the
+// evaluators here only look at that data and never touch storage.
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// Input is everything an evaluator needs to decide on one group and artifact
+type Input struct {
+	Group     storage.EventReceiverGroup
+	Receivers []storage.EventReceiver
+	// Events holds at most one event per receiver: the latest one for the artifact
+	Events []storage.Event
+}
+
+// Reason explains how one receiver of the group contributed to the result
+type Reason struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	// EventID is the latest event of the receiver, empty when there is none
+	EventID   graphql.ID `json:"event_id,omitempty"`
+	Satisfied bool       `json:"satisfied"`
+	// Optional receivers are advisory, they are reported but never block the group
+	Optional bool `json:"optional,omitempty"`
+	// Condition is the expression the latest event had to satisfy, if any
+	Condition string `json:"condition,omitempty"`
+	// Error is set when the condition could not be evaluated
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of evaluating a group for an artifact
+type Result struct {
+	Passed bool `json:"passed"`
+	// Policy is the normalized policy the group was evaluated with
+	Policy storage.GatePolicy `json:"policy"`
+	// Needed is the number of required receivers that must be satisfied
+	Needed int `json:"needed"`
+	// Summary describes how the policy was or was not satisfied
+	Summary string   `json:"summary"`
+	Reasons []Reason `json:"reasons"`
+}
+
+// Failed reports whether the group cannot pass until a receiver sends a newer event, because
+// too many required receivers have a latest event that does not satisfy them.
+func (r Result) Failed() bool {
+	if r.Passed {
+		return false
+	}
+	possible := 0
+	for _, reason := range r.Reasons {
+		if !reason.Optional && (reason.Satisfied || reason.EventID == "") {
+			possible++
+		}
+	}
+	return possible < r.Needed
+}
+
+// Errors returns the reasons whose condition could not be evaluated
+func (r Result) Errors() []Reason {
+	reasons := []Reason{}
+	for _, reason := range r.Reasons {
+		if reason.Error != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// ReceiverResults returns how each receiver contributed to the result, to be stored with the
+// state or completion of the group
+func (r Result) ReceiverResults() []storage.ReceiverResult {
+	results := make([]storage.ReceiverResult, 0, len(r.Reasons))
+	for _, reason := range r.Reasons {
+		results = append(results, storage.ReceiverResult{
+			EventReceiverID: reason.EventReceiverID,
+			EventID:         reason.EventID,
+			Satisfied:       reason.Satisfied,
+			Optional:        reason.Optional,
+			Condition:       reason.Condition,
+			Error:           reason.Error,
+		})
+	}
+	return results
+}
+
+// EventIDs returns the events of the required receivers that were satisfied
+func (r Result) EventIDs() []graphql.ID {
+	ids := []graphql.ID{}
+	for _, reason := range r.Reasons {
+		if reason.Satisfied && !reason.Optional && reason.EventID != "" {
+			ids = append(ids, reason.EventID)
+		}
+	}
+	return ids
+}
+
+// Evaluator decides whether a group passes for an artifact
+type Evaluator interface {
+	Evaluate(input Input) Result
+}
+
+// Default is the evaluator used when nothing else is configured
+var Default Evaluator = PolicyEvaluator{}
+
+// PolicyEvaluator passes when the required receivers whose latest event is a success satisfy
+// the policy of the group. A group without a policy requires every receiver.
+type PolicyEvaluator struct{}
+
+// Evaluate implements Evaluator
+func (PolicyEvaluator) Evaluate(input Input) Result {
+	policy := NormalizePolicy(input.Group.Policy)
+	reasons := receiverReasons(input)
+
+	required, satisfied := 0, 0
+	for i := range reasons {
+		if containsID(policy.OptionalEventReceiverIDs, reasons[i].EventReceiverID) {
+			reasons[i].Optional = true
+			continue
+		}
+		required++
+		if reasons[i].Satisfied {
+			satisfied++
+		}
+	}
+
+	need := required
+	switch policy.Type {
+	case storage.PolicyAnyOf:
+		need = 1
+	case storage.PolicyNOf:
+		if policy.N != nil {
+			need = int(*policy.N)
+		}
+	}
+
+	result := Result{
+		Passed:  required > 0 && satisfied >= need,
+		Policy:  policy,
+		Needed:  need,
+		Reasons: reasons,
+	}
+	result.Summary = fmt.Sprintf("%s: %d of %d required receivers satisfied, %d needed", policyName(policy), satisfied, required, need)
+	return result
+}
+
+// NormalizePolicy fills in the defaults of a policy: an empty type means ALL_OF and the type
+// is matched case insensitively.
+func NormalizePolicy(policy storage.GatePolicy) storage.GatePolicy {
+	policy.Type = strings.ToUpper(strings.TrimSpace(policy.Type))
+	if policy.Type == "" {
+		policy.Type = storage.PolicyAllOf
+	}
+	if policy.OptionalEventReceiverIDs == nil {
+		policy.OptionalEventReceiverIDs = []graphql.ID{}
+	}
+	return policy
+}
+
+// ValidatePolicy checks that the policy can be evaluated for a group with the given receivers
+func ValidatePolicy(policy storage.GatePolicy, eventReceiverIDs []graphql.ID) error {
+	policy = NormalizePolicy(policy)
+
+	var err error
+	for _, id := range policy.OptionalEventReceiverIDs {
+		if !containsID(eventReceiverIDs, id) {
+			err = errors.Join(err, fmt.Errorf("optional event receiver %s is not a member of the group", id))
+		}
+	}
+	required := 0
+	for _, id := range eventReceiverIDs {
+		if !containsID(policy.OptionalEventReceiverIDs, id) {
+			required++
+		}
+	}
+	if required == 0 {
+		err = errors.Join(err, errors.New("policy needs at least one required event receiver"))
+	}
+
+	switch policy.Type {
+	case storage.PolicyAllOf, storage.PolicyAnyOf:
+		if policy.N != nil {
+			err = errors.Join(err, fmt.Errorf("policy %s does not take n", policy.Type))
+		}
+	case storage.PolicyNOf:
+		if policy.N == nil {
+			err = errors.Join(err, errors.New("policy N_OF needs n"))
+		} else if *policy.N < 1 || int(*policy.N) > required {
+			err = errors.Join(err, fmt.Errorf("policy N_OF needs n between 1 and %d, the number of required event receivers", required))
+		}
+	default:
+		err = errors.Join(err, fmt.Errorf("unknown policy type %q, expected one of %s, %s or %s", policy.Type, storage.PolicyAllOf, storage.PolicyAnyOf, storage.PolicyNOf))
+	}
+	return err
+}
+
+func policyName(policy storage.GatePolicy) string {
+	if policy.Type == storage.PolicyNOf && policy.N != nil {
+		return fmt.Sprintf("%s(%d)", policy.Type, *policy.N)
+	}
+	return policy.Type
+}
+
+func containsID(ids []graphql.ID, id graphql.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// receiverReasons checks the latest event of each receiver of the group, in the order the
+// receivers are listed on the group
+func receiverReasons(input Input) []Reason {
+	latest := map[graphql.ID]storage.Event{}
+	for _, event := range input.Events {
+		if current, ok := latest[event.EventReceiverID]; ok && !newer(event, current) {
+			continue
+		}
+		latest[event.EventReceiverID] = event
+	}
+	names := map[graphql.ID]string{}
+	for _, receiver := range input.Receivers {
+		names[receiver.ID] = receiver.Name
+	}
+
+	reasons := make([]Reason, 0, len(input.Group.EventReceiverIDs))
+	for _, id := range input.Group.EventReceiverIDs {
+		reason := Reason{EventReceiverID: id, Condition: input.Group.Condition(id)}
+		name := names[id]
+		if name == "" {
+			name = string(id)
+		}
+		event, ok := latest[id]
+		switch {
+		case !ok:
+			reason.Message = fmt.Sprintf("receiver %s has no event", name)
+		case !event.Success:
+			reason.EventID = event.ID
+			reason.Message = fmt.Sprintf("latest event %s of receiver %s failed", event.ID, name)
+		case reason.Condition == "":
+			reason.EventID = event.ID
+			reason.Satisfied = true
+			reason.Message = fmt.Sprintf("latest event %s of receiver %s succeeded", event.ID, name)
+		default:
+			reason.EventID = event.ID
+			satisfied, err := EvaluateCondition(reason.Condition, event)
+			switch {
+			case err != nil:
+				reason.Error = err.Error()
+				reason.Message = fmt.Sprintf("condition of receiver %s could not be evaluated on latest event %s", name, event.ID)
+			case !satisfied:
+				reason.Message = fmt.Sprintf("latest event %s of receiver %s does not satisfy the condition", event.ID, name)
+			default:
+				reason.Satisfied = true
+				reason.Message = fmt.Sprintf("latest event %s of receiver %s succeeded and satisfies the condition", event.ID, name)
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// Latest returns the most recently created of the events
+func Latest(events []storage.Event) (storage.Event, bool) {
+	if len(events) == 0 {
+		return storage.Event{}, false
+	}
+	latest := events[0]
+	for _, event := range events[1:] {
+		if newer(event, latest) {
+			latest = event
+		}
+	}
+	return latest, true
+}
+
+// newer reports whether a was created after b, using the ULID to break ties
+func newer(a, b storage.Event) bool {
+	at, bt := time.Time(a.CreatedAt.Date), time.Time(b.CreatedAt.Date)
+	if !at.Equal(bt) {
+		return at.After(bt)
+	}
+	return a.ID > b.ID
+}
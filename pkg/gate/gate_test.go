@@ -0,0 +1,337 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gorm.io/datatypes"
+	"gotest.tools/v3/assert"
+)
+
+var base = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func receiver(id string) storage.EventReceiver {
+	return storage.EventReceiver{ID: graphql.ID(id), Name: id}
+}
+
+func event(id, receiverID string, success bool, minute int) storage.Event {
+	return storage.Event{
+		ID:              graphql.ID(id),
+		EventReceiverID: graphql.ID(receiverID),
+		Success:         success,
+		CreatedAt:       types.Time{Date: datatypes.Date(base.Add(time.Duration(minute) * time.Minute))},
+	}
+}
+
+func group(receiverIDs ...string) storage.EventReceiverGroup {
+	ids := []graphql.ID{}
+	for _, id := range receiverIDs {
+		ids = append(ids, graphql.ID(id))
+	}
+	return storage.EventReceiverGroup{ID: "group", Enabled: true, EventReceiverIDs: ids}
+}
+
+func TestPolicyEvaluatorAllOf(t *testing.T) {
+	tests := map[string]struct {
+		input     Input
+		passed    bool
+		eventIDs  []graphql.ID
+		satisfied []bool
+		messages  []string
+	}{
+		"group without receivers": {
+			input:     Input{Group: group()},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{},
+			messages:  []string{},
+		},
+		"single success": {
+			input: Input{
+				Group:     group("build"),
+				Receivers: []storage.EventReceiver{receiver("build")},
+				Events:    []storage.Event{event("e1", "build", true, 0)},
+			},
+			passed:    true,
+			eventIDs:  []graphql.ID{"e1"},
+			satisfied: []bool{true},
+			messages:  []string{"latest event e1 of receiver build succeeded"},
+		},
+		"single failure": {
+			input: Input{
+				Group:     group("build"),
+				Receivers: []storage.EventReceiver{receiver("build")},
+				Events:    []storage.Event{event("e1", "build", false, 0)},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{false},
+			messages:  []string{"latest event e1 of receiver build failed"},
+		},
+		"no events": {
+			input: Input{
+				Group:     group("build"),
+				Receivers: []storage.EventReceiver{receiver("build")},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{false},
+			messages:  []string{"receiver build has no event"},
+		},
+		"all receivers succeeded": {
+			input: Input{
+				Group:     group("build", "test"),
+				Receivers: []storage.EventReceiver{receiver("build"), receiver("test")},
+				Events:    []storage.Event{event("e2", "test", true, 1), event("e1", "build", true, 0)},
+			},
+			passed:    true,
+			eventIDs:  []graphql.ID{"e1", "e2"},
+			satisfied: []bool{true, true},
+		},
+		"one receiver missing an event": {
+			input: Input{
+				Group:     group("build", "test"),
+				Receivers: []storage.EventReceiver{receiver("build"), receiver("test")},
+				Events:    []storage.Event{event("e1", "build", true, 0)},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{"e1"},
+			satisfied: []bool{true, false},
+		},
+		"one receiver failed": {
+			input: Input{
+				Group:     group("build", "test"),
+				Receivers: []storage.EventReceiver{receiver("build"), receiver("test")},
+				Events:    []storage.Event{event("e1", "build", true, 0), event("e2", "test", false, 1)},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{"e1"},
+			satisfied: []bool{true, false},
+		},
+		"newer failure overrides older success": {
+			input: Input{
+				Group:  group("build"),
+				Events: []storage.Event{event("e1", "build", true, 0), event("e2", "build", false, 1)},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{false},
+		},
+		"newer success overrides older failure": {
+			input: Input{
+				Group:  group("build"),
+				Events: []storage.Event{event("e2", "build", true, 1), event("e1", "build", false, 0)},
+			},
+			passed:    true,
+			eventIDs:  []graphql.ID{"e2"},
+			satisfied: []bool{true},
+		},
+		"same time uses the later id": {
+			input: Input{
+				Group:  group("build"),
+				Events: []storage.Event{event("01B", "build", false, 0), event("01A", "build", true, 0)},
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{false},
+		},
+		"events of other receivers are ignored": {
+			input: Input{
+				Group:  group("build"),
+				Events: []storage.Event{event("e1", "build", true, 0), event("e2", "other", false, 1)},
+			},
+			passed:    true,
+			eventIDs:  []graphql.ID{"e1"},
+			satisfied: []bool{true},
+		},
+		"unknown receivers are reported by id": {
+			input: Input{
+				Group: group("build"),
+			},
+			passed:    false,
+			eventIDs:  []graphql.ID{},
+			satisfied: []bool{false},
+			messages:  []string{"receiver build has no event"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := PolicyEvaluator{}.Evaluate(tc.input)
+			assert.Equal(t, result.Policy.Type, storage.PolicyAllOf)
+			assert.Equal(t, result.Passed, tc.passed)
+			assert.DeepEqual(t, result.EventIDs(), tc.eventIDs)
+
+			satisfied := []bool{}
+			messages := []string{}
+			for i, reason := range result.Reasons {
+				assert.Equal(t, reason.EventReceiverID, tc.input.Group.EventReceiverIDs[i])
+				satisfied = append(satisfied, reason.Satisfied)
+				messages = append(messages, reason.Message)
+			}
+			assert.DeepEqual(t, satisfied, tc.satisfied)
+			if tc.messages != nil {
+				assert.DeepEqual(t, messages, tc.messages)
+			}
+		})
+	}
+}
+
+func withPolicy(g storage.EventReceiverGroup, policy storage.GatePolicy) storage.EventReceiverGroup {
+	g.Policy = policy
+	return g
+}
+
+func n(i int32) *int32 {
+	return &i
+}
+
+func TestPolicyEvaluator(t *testing.T) {
+	receivers := []storage.EventReceiver{receiver("a"), receiver("b"), receiver("c")}
+	tests := map[string]struct {
+		policy   storage.GatePolicy
+		events   []storage.Event
+		passed   bool
+		eventIDs []graphql.ID
+		optional []bool
+		summary  string
+		failed   bool
+	}{
+		"any of with one success": {
+			policy:   storage.GatePolicy{Type: storage.PolicyAnyOf},
+			events:   []storage.Event{event("e1", "a", false, 0), event("e2", "b", true, 0)},
+			passed:   true,
+			eventIDs: []graphql.ID{"e2"},
+			optional: []bool{false, false, false},
+			summary:  "ANY_OF: 1 of 3 required receivers satisfied, 1 needed",
+		},
+		"any of without success": {
+			policy:   storage.GatePolicy{Type: "any_of"},
+			events:   []storage.Event{event("e1", "a", false, 0)},
+			passed:   false,
+			eventIDs: []graphql.ID{},
+			optional: []bool{false, false, false},
+			summary:  "ANY_OF: 0 of 3 required receivers satisfied, 1 needed",
+			failed:   false,
+		},
+		"any of with every receiver failed": {
+			policy:   storage.GatePolicy{Type: storage.PolicyAnyOf},
+			events:   []storage.Event{event("e1", "a", false, 0), event("e2", "b", false, 0), event("e3", "c", false, 0)},
+			passed:   false,
+			eventIDs: []graphql.ID{},
+			optional: []bool{false, false, false},
+			summary:  "ANY_OF: 0 of 3 required receivers satisfied, 1 needed",
+			failed:   true,
+		},
+		"two of three": {
+			policy:   storage.GatePolicy{Type: storage.PolicyNOf, N: n(2)},
+			events:   []storage.Event{event("e1", "a", true, 0), event("e2", "b", false, 0), event("e3", "c", true, 0)},
+			passed:   true,
+			eventIDs: []graphql.ID{"e1", "e3"},
+			optional: []bool{false, false, false},
+			summary:  "N_OF(2): 2 of 3 required receivers satisfied, 2 needed",
+		},
+		"one of two needed": {
+			policy:   storage.GatePolicy{Type: storage.PolicyNOf, N: n(2)},
+			events:   []storage.Event{event("e1", "a", true, 0), event("e2", "b", false, 0)},
+			passed:   false,
+			eventIDs: []graphql.ID{"e1"},
+			optional: []bool{false, false, false},
+			summary:  "N_OF(2): 1 of 3 required receivers satisfied, 2 needed",
+			failed:   false,
+		},
+		"two of three with two failures": {
+			policy:   storage.GatePolicy{Type: storage.PolicyNOf, N: n(2)},
+			events:   []storage.Event{event("e1", "a", true, 0), event("e2", "b", false, 0), event("e3", "c", false, 0)},
+			passed:   false,
+			eventIDs: []graphql.ID{"e1"},
+			optional: []bool{false, false, false},
+			summary:  "N_OF(2): 1 of 3 required receivers satisfied, 2 needed",
+			failed:   true,
+		},
+		"optional failure does not block": {
+			policy:   storage.GatePolicy{OptionalEventReceiverIDs: []graphql.ID{"c"}},
+			events:   []storage.Event{event("e1", "a", true, 0), event("e2", "b", true, 0), event("e3", "c", false, 0)},
+			passed:   true,
+			eventIDs: []graphql.ID{"e1", "e2"},
+			optional: []bool{false, false, true},
+			summary:  "ALL_OF: 2 of 2 required receivers satisfied, 2 needed",
+		},
+		"optional success does not count": {
+			policy:   storage.GatePolicy{Type: storage.PolicyAnyOf, OptionalEventReceiverIDs: []graphql.ID{"c"}},
+			events:   []storage.Event{event("e3", "c", true, 0)},
+			passed:   false,
+			eventIDs: []graphql.ID{},
+			optional: []bool{false, false, true},
+			summary:  "ANY_OF: 0 of 2 required receivers satisfied, 1 needed",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := PolicyEvaluator{}.Evaluate(Input{
+				Group:     withPolicy(group("a", "b", "c"), tc.policy),
+				Receivers: receivers,
+				Events:    tc.events,
+			})
+			assert.Equal(t, result.Passed, tc.passed)
+			assert.DeepEqual(t, result.EventIDs(), tc.eventIDs)
+			assert.Equal(t, result.Summary, tc.summary)
+			assert.Equal(t, result.Failed(), tc.failed)
+			optional := []bool{}
+			for _, reason := range result.Reasons {
+				optional = append(optional, reason.Optional)
+			}
+			assert.DeepEqual(t, optional, tc.optional)
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	ids := []graphql.ID{"a", "b", "c"}
+	tests := map[string]struct {
+		policy storage.GatePolicy
+		err    string
+	}{
+		"zero policy":         {policy: storage.GatePolicy{}},
+		"lower case type":     {policy: storage.GatePolicy{Type: "any_of"}},
+		"n of":                {policy: storage.GatePolicy{Type: storage.PolicyNOf, N: n(3)}},
+		"unknown type":        {policy: storage.GatePolicy{Type: "SOME_OF"}, err: `unknown policy type "SOME_OF", expected one of ALL_OF, ANY_OF or N_OF`},
+		"n of without n":      {policy: storage.GatePolicy{Type: storage.PolicyNOf}, err: "policy N_OF needs n"},
+		"n too large":         {policy: storage.GatePolicy{Type: storage.PolicyNOf, N: n(3), OptionalEventReceiverIDs: []graphql.ID{"c"}}, err: "policy N_OF needs n between 1 and 2, the number of required event receivers"},
+		"n zero":              {policy: storage.GatePolicy{Type: storage.PolicyNOf, N: n(0)}, err: "policy N_OF needs n between 1 and 3, the number of required event receivers"},
+		"n with all of":       {policy: storage.GatePolicy{Type: storage.PolicyAllOf, N: n(1)}, err: "policy ALL_OF does not take n"},
+		"optional non member": {policy: storage.GatePolicy{OptionalEventReceiverIDs: []graphql.ID{"d"}}, err: "optional event receiver d is not a member of the group"},
+		"all optional":        {policy: storage.GatePolicy{OptionalEventReceiverIDs: ids}, err: "policy needs at least one required event receiver"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidatePolicy(tc.policy, ids)
+			if tc.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tc.err)
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	_, ok := Latest(nil)
+	assert.Equal(t, ok, false)
+
+	latest, ok := Latest([]storage.Event{event("01A", "a", true, 1), event("01C", "b", true, 2), event("01B", "c", true, 2)})
+	assert.Equal(t, ok, true)
+	assert.Equal(t, latest.ID, graphql.ID("01C"))
+}
+
+func TestDefault(t *testing.T) {
+	assert.Equal(t, Default, Evaluator(PolicyEvaluator{}))
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	"github.com/sassoftware/event-provenance-registry/pkg/utils"
+)
+
+// ToCDEvent renders the message as a CDEvent in a CloudEvent, the message itself is the custom
+// data of the CDEvent. An event whose payload is the CDEvent of its receiver type is forwarded
+// as is. Other events are finished task runs and the completions, failures and regressions of
+// groups are finished pipeline runs. Messages about receivers and groups themselves have no
+// CDEvents counterpart, ok is false for them.
+func ToCDEvent(m Message) (event *cdevents.CloudEvent, ok bool, err error) {
+	now := utils.NowRFC3339()
+	switch {
+	case m.Data.Gate != nil && len(m.Data.EventReceiverGroups) > 0:
+		group := m.Data.EventReceiverGroups[0]
+		content := map[string]any{
+			"pipelineName": group.Name,
+			"url":          "/api/v1/groups/" + string(group.ID),
+			"outcome":      cdevents.OutcomeSuccess,
+		}
+		if !m.Success {
+			content["outcome"] = cdevents.OutcomeFailure
+			content["errors"] = m.Data.Gate.Summary
+		}
+		event, err = cdevents.New(utils.NewULIDAsString(), m.Source, cdevents.TypePipelineRunFinished, now, cdevents.Subject{
+			ID:      string(group.ID),
+			Source:  m.Source,
+			Type:    "pipelineRun",
+			Content: content,
+		}, m)
+		return event, err == nil, err
+	case len(m.Data.Events) > 0:
+		e := m.Data.Events[0]
+		if e.Revocation == nil {
+			var native cdevents.CDEvent
+			if json.Unmarshal(e.Payload.JSON, &native) == nil && native.Context.Type != "" && native.Context.Type == e.EventReceiver.Type {
+				event, err = cdevents.Wrap(native)
+				return event, err == nil, err
+			}
+		}
+		content := map[string]any{
+			"taskName": e.EventReceiver.Name,
+			"url":      "/api/v1/events/" + string(e.ID),
+			"outcome":  cdevents.OutcomeSuccess,
+		}
+		switch {
+		case e.Revocation != nil:
+			content["outcome"] = cdevents.OutcomeError
+			content["errors"] = "revoked: " + e.Revocation.Reason
+		case !e.Success:
+			content["outcome"] = cdevents.OutcomeFailure
+		}
+		event, err = cdevents.New(utils.NewULIDAsString(), m.Source, cdevents.TypeTaskRunFinished, now, cdevents.Subject{
+			ID:      string(e.ID),
+			Source:  m.Source,
+			Type:    "taskRun",
+			Content: content,
+		}, m)
+		return event, err == nil, err
+	default:
+		return nil, false, nil
+	}
+}
+
+type cdeventsProducer struct {
+	producer TopicProducer
+}
+
+// NewCDEventsProducer wraps the producer to send messages as CDEvents, see ToCDEvent. Messages
+// without a CDEvents counterpart are dropped.
+func NewCDEventsProducer(p TopicProducer) TopicProducer {
+	return &cdeventsProducer{producer: p}
+}
+
+func (c *cdeventsProducer) Async(data any) {
+	if event, ok := c.convert(data); ok {
+		c.producer.Async(event)
+	}
+}
+
+func (c *cdeventsProducer) Send(data any) error {
+	if event, ok := c.convert(data); ok {
+		return c.producer.Send(event)
+	}
+	return nil
+}
+
+func (c *cdeventsProducer) convert(data any) (any, bool) {
+	m, ok := data.(Message)
+	if !ok {
+		return data, true
+	}
+	event, ok, err := ToCDEvent(m)
+	if err != nil {
+		slog.Error("error converting message to a CDEvent", "error", err, "type", m.Type, "id", m.ID)
+		return nil, false
+	}
+	if !ok {
+		slog.Debug("message has no CDEvents counterpart", "type", m.Type, "id", m.ID)
+		return nil, false
+	}
+	return event, true
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/cdevents"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+type sent struct {
+	data []any
+}
+
+func (s *sent) Async(data any) {
+	s.data = append(s.data, data)
+}
+
+func (s *sent) Send(data any) error {
+	s.data = append(s.data, data)
+	return nil
+}
+
+func TestToCDEvent(t *testing.T) {
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build", Type: "epr.build"}
+	e := storage.Event{
+		ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", Name: "foo", Version: "1.0.0", Success: false,
+		Payload: types.JSON{JSON: []byte(`{}`)}, EventReceiverID: receiver.ID, EventReceiver: receiver,
+	}
+
+	cloudEvent, ok, err := ToCDEvent(NewEvent(e))
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Equal(t, cloudEvent.Type, cdevents.TypeTaskRunFinished)
+	event, err := cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Subject.ID, "01HKX1TMQZQDS6NC5DG7WNXXCJ")
+	assert.Equal(t, event.Subject.Content["taskName"], "build")
+	assert.Equal(t, event.Subject.Content["outcome"], cdevents.OutcomeFailure)
+	var custom Message
+	assert.NilError(t, json.Unmarshal(event.CustomData, &custom))
+	assert.Equal(t, custom.Name, "foo")
+
+	e.Revocation = &storage.EventRevocation{Reason: "compromised runner"}
+	cloudEvent, _, err = ToCDEvent(NewEventRevoked(e))
+	assert.NilError(t, err)
+	event, err = cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Subject.Content["outcome"], cdevents.OutcomeError)
+	assert.Equal(t, event.Subject.Content["errors"], "revoked: compromised runner")
+
+	group := storage.EventReceiverGroup{ID: "01HKX90FKWQZ49F6H5V5NQT95Z", Name: "release", Type: "epr.release"}
+	cloudEvent, ok, err = ToCDEvent(NewEventReceiverGroupComplete(e, group, gate.Result{Passed: true}))
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	event, err = cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Context.Type, cdevents.TypePipelineRunFinished)
+	assert.Equal(t, event.Subject.Content["pipelineName"], "release")
+	assert.Equal(t, event.Subject.Content["outcome"], cdevents.OutcomeSuccess)
+
+	cloudEvent, _, err = ToCDEvent(NewEventReceiverGroupRegressed(e, group, gate.Result{Summary: "build failed"}))
+	assert.NilError(t, err)
+	event, err = cloudEvent.CDEvent()
+	assert.NilError(t, err)
+	assert.Equal(t, event.Subject.Content["outcome"], cdevents.OutcomeFailure)
+	assert.Equal(t, event.Subject.Content["errors"], "build failed")
+
+	_, ok, err = ToCDEvent(NewEventReceiver(receiver))
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+}
+
+func TestToCDEventForwardsNativeCDEvents(t *testing.T) {
+	native, err := cdevents.New("271069a8-fc18-44f1-b38f-9d70a1695819", "/ci", "dev.cdevents.artifact.published.0.1.1", "2024-01-01T00:00:00Z", cdevents.Subject{
+		ID:      "pkg:oci/foo@sha256%3Aabc123",
+		Type:    "artifact",
+		Content: map[string]any{},
+	}, nil)
+	assert.NilError(t, err)
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "artifact-published", Type: "dev.cdevents.artifact.published.0.1.1"}
+	e := storage.Event{
+		ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", Success: true,
+		Payload: types.JSON{JSON: []byte(native.Data)}, EventReceiverID: receiver.ID, EventReceiver: receiver,
+	}
+
+	cloudEvent, ok, err := ToCDEvent(NewEvent(e))
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, cloudEvent, native)
+}
+
+func TestCDEventsProducer(t *testing.T) {
+	s := &sent{}
+	producer := NewCDEventsProducer(s)
+	producer.Async(NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41"}))
+	assert.Equal(t, len(s.data), 0)
+
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build", Type: "epr.build"}
+	assert.NilError(t, producer.Send(NewEvent(storage.Event{ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", EventReceiver: receiver})))
+	assert.Equal(t, len(s.data), 1)
+	cloudEvent := s.data[0].(*cdevents.CloudEvent)
+	assert.Equal(t, cloudEvent.SpecVersion, cdevents.CloudEventsSpecVersion)
+	assert.Equal(t, cloudEvent.Type, cdevents.TypeTaskRunFinished)
+}
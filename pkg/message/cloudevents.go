@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HeaderPrefix starts the Kafka header of each CloudEvents attribute in binary mode
+const HeaderPrefix = "ce_"
+
+// LegacyMessage is a Message as it was produced before messages were valid CloudEvents. The ID
+// is the ID of the event, receiver or group and there is no time, subject or content type.
+type LegacyMessage struct {
+	Success     bool   `json:"success"`
+	ID          string `json:"id"`
+	Specversion string `json:"specversion"`
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	APIVersion  string `json:"api_version"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Release     string `json:"release"`
+	PlatformID  string `json:"platform_id"`
+	Package     string `json:"package"`
+	Data        Data   `json:"data"`
+}
+
+// Legacy returns the message in the legacy format
+func (m *Message) Legacy() LegacyMessage {
+	return LegacyMessage{
+		Success:     m.Success,
+		ID:          m.Subject,
+		Specversion: m.Specversion,
+		Type:        m.Type,
+		Source:      m.Source,
+		APIVersion:  m.APIVersion,
+		Name:        m.Name,
+		Version:     m.Version,
+		Release:     m.Release,
+		PlatformID:  m.PlatformID,
+		Package:     m.Package,
+		Data:        m.Data,
+	}
+}
+
+// UnmarshalJSON reads a message in the CloudEvents or the legacy format
+func (m *Message) UnmarshalJSON(b []byte) error {
+	type message Message
+	var decoded struct {
+		message
+		LegacyAPIVersion string `json:"api_version"`
+		LegacyPlatformID string `json:"platform_id"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*m = Message(decoded.message)
+	if m.APIVersion == "" {
+		m.APIVersion = decoded.LegacyAPIVersion
+	}
+	if m.PlatformID == "" {
+		m.PlatformID = decoded.LegacyPlatformID
+	}
+	return nil
+}
+
+// Record is a Kafka record value along with its headers
+type Record struct {
+	Headers map[string]string `json:"headers"`
+	Value   json.RawMessage   `json:"value"`
+}
+
+// ToBinary renders a CloudEvent in the Kafka binary content mode: the data is the value of the
+// record, the content type its content-type header and every other attribute a ce_ header.
+func ToBinary(cloudEvent any) (*Record, error) {
+	encoded, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return nil, err
+	}
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &attributes); err != nil {
+		return nil, fmt.Errorf("CloudEvent is not a JSON object: %w", err)
+	}
+
+	record := &Record{Headers: map[string]string{}, Value: attributes["data"]}
+	for name, raw := range attributes {
+		if name == "data" {
+			continue
+		}
+		// strings are unquoted, booleans and numbers use their JSON form
+		value := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			value = s
+		}
+		if name == "datacontenttype" {
+			record.Headers["content-type"] = value
+			continue
+		}
+		record.Headers[HeaderPrefix+name] = value
+	}
+	return record, nil
+}
+
+// FromBinary reads a message sent in the Kafka binary content mode
+func FromBinary(headers map[string]string, value []byte) (*Message, error) {
+	attributes := map[string]any{"data": json.RawMessage(value)}
+	for name, header := range headers {
+		switch {
+		case strings.EqualFold(name, "content-type"):
+			attributes["datacontenttype"] = header
+		case strings.HasPrefix(name, HeaderPrefix):
+			attributes[strings.TrimPrefix(name, HeaderPrefix)] = header
+		}
+	}
+	if success, ok := attributes["success"].(string); ok {
+		parsed, err := strconv.ParseBool(success)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success header %q: %w", success, err)
+		}
+		attributes["success"] = parsed
+	}
+	if _, ok := attributes["specversion"]; !ok {
+		return nil, fmt.Errorf("record has no %sspecversion header", HeaderPrefix)
+	}
+
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{}
+	if err := json.Unmarshal(encoded, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// headers returns the record headers sorted by name
+func (r *Record) headers() [][2]string {
+	headers := make([][2]string, 0, len(r.Headers))
+	for name, value := range r.Headers {
+		headers = append(headers, [2]string{name, value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i][0] < headers[j][0] })
+	return headers
+}
+
+type binaryProducer struct {
+	producer TopicProducer
+}
+
+// NewBinaryProducer wraps the producer to send CloudEvents in the Kafka binary content mode
+func NewBinaryProducer(p TopicProducer) TopicProducer {
+	return &binaryProducer{producer: p}
+}
+
+func (b *binaryProducer) Async(data any) {
+	record, err := ToBinary(data)
+	if err != nil {
+		slog.Error("error converting message to binary mode", "error", err)
+		return
+	}
+	b.producer.Async(record)
+}
+
+func (b *binaryProducer) Send(data any) error {
+	record, err := ToBinary(data)
+	if err != nil {
+		return err
+	}
+	return b.producer.Send(record)
+}
+
+type legacyProducer struct {
+	producer TopicProducer
+}
+
+// NewLegacyProducer wraps the producer to send messages in the legacy format for consumers that
+// predate CloudEvents compliance
+func NewLegacyProducer(p TopicProducer) TopicProducer {
+	return &legacyProducer{producer: p}
+}
+
+func (l *legacyProducer) Async(data any) {
+	l.producer.Async(legacy(data))
+}
+
+func (l *legacyProducer) Send(data any) error {
+	return l.producer.Send(legacy(data))
+}
+
+func legacy(data any) any {
+	if m, ok := data.(Message); ok {
+		return m.Legacy()
+	}
+	return data
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/xeipuuv/gojsonschema"
+	"gotest.tools/v3/assert"
+)
+
+// the messages EPR produces, built anew on each call
+func conformanceMessages() map[string]func() any {
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build", Type: "epr.build", Version: "1.0.0"}
+	group := storage.EventReceiverGroup{ID: "01HKX90FKWQZ49F6H5V5NQT95Z", Name: "release", Type: "epr.release", Version: "1.0.0"}
+	event := storage.Event{
+		ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", Name: "foo", Version: "1.0.0", Release: "2024.01", PlatformID: "x86-64-gnu-linux-9",
+		Package: "rpm", Payload: types.JSON{JSON: []byte(`{}`)}, Success: true, EventReceiverID: receiver.ID, EventReceiver: receiver,
+	}
+	revoked := event
+	revoked.Revocation = &storage.EventRevocation{EventID: event.ID, RevokedBy: "jdoe", Reason: "mistake"}
+
+	return map[string]func() any{
+		"event":             func() any { return NewEvent(event) },
+		"event revoked":     func() any { return NewEventRevoked(revoked) },
+		"receiver created":  func() any { return NewEventReceiver(receiver) },
+		"receiver modified": func() any { return NewEventReceiverModified(receiver) },
+		"group created":     func() any { return NewEventReceiverGroupCreated(group) },
+		"group modified":    func() any { return NewEventReceiverGroupModified(group) },
+		"group deleted":     func() any { return NewEventReceiverGroupDeleted(group) },
+		"group complete":    func() any { return NewEventReceiverGroupComplete(event, group, gate.Result{Passed: true}) },
+		"group failed":      func() any { return NewEventReceiverGroupFailed(event, group, gate.Result{}) },
+		"group regressed":   func() any { return NewEventReceiverGroupRegressed(event, group, gate.Result{}) },
+		"cdevents event":    func() any { e, _, _ := ToCDEvent(NewEvent(event)); return e },
+		"cdevents group complete": func() any {
+			e, _, _ := ToCDEvent(NewEventReceiverGroupComplete(event, group, gate.Result{Passed: true}))
+			return e
+		},
+	}
+}
+
+func TestCloudEventsStructuredMode(t *testing.T) {
+	schema, err := os.ReadFile("testdata/cloudevents.schema.json")
+	assert.NilError(t, err)
+	loader := gojsonschema.NewBytesLoader(schema)
+
+	for name, build := range conformanceMessages() {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := json.Marshal(build())
+			assert.NilError(t, err)
+			result, err := gojsonschema.Validate(loader, gojsonschema.NewBytesLoader(encoded))
+			assert.NilError(t, err)
+			assert.Assert(t, result.Valid(), "%v", result.Errors())
+
+			var attributes map[string]any
+			assert.NilError(t, json.Unmarshal(encoded, &attributes))
+			_, err = time.Parse(time.RFC3339, attributes["time"].(string))
+			assert.NilError(t, err)
+			assert.Equal(t, attributes["datacontenttype"], "application/json")
+			_, isObject := attributes["data"].(map[string]any)
+			assert.Assert(t, isObject)
+
+			// source and id identify a single occurrence
+			var again map[string]any
+			encoded, err = json.Marshal(build())
+			assert.NilError(t, err)
+			assert.NilError(t, json.Unmarshal(encoded, &again))
+			assert.Assert(t, attributes["id"] != again["id"])
+		})
+	}
+}
+
+func TestCloudEventsBinaryMode(t *testing.T) {
+	headerName := regexp.MustCompile(`^(ce_[a-z0-9]{1,20}|content-type)$`)
+	for name, build := range conformanceMessages() {
+		t.Run(name, func(t *testing.T) {
+			msg := build()
+			record, err := ToBinary(msg)
+			assert.NilError(t, err)
+			for header := range record.Headers {
+				assert.Assert(t, headerName.MatchString(header), header)
+			}
+			for _, required := range []string{"ce_specversion", "ce_id", "ce_source", "ce_type"} {
+				assert.Assert(t, record.Headers[required] != "", required)
+			}
+			assert.Equal(t, record.Headers["ce_specversion"], CloudEventsSpec)
+			assert.Equal(t, record.Headers["content-type"], "application/json")
+
+			var structured map[string]json.RawMessage
+			encoded, err := json.Marshal(msg)
+			assert.NilError(t, err)
+			assert.NilError(t, json.Unmarshal(encoded, &structured))
+			assert.Equal(t, string(record.Value), string(structured["data"]))
+
+			if m, ok := msg.(Message); ok {
+				decoded, err := FromBinary(record.Headers, record.Value)
+				assert.NilError(t, err)
+				roundTrip, err := json.Marshal(decoded)
+				assert.NilError(t, err)
+				assert.Equal(t, string(roundTrip), string(encoded))
+				assert.Equal(t, decoded.Success, m.Success)
+			}
+		})
+	}
+}
+
+func TestLegacyMessages(t *testing.T) {
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build", Type: "epr.build"}
+	msg := NewEvent(storage.Event{ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", PlatformID: "linux", EventReceiver: receiver})
+
+	s := &sent{}
+	assert.NilError(t, NewLegacyProducer(s).Send(msg))
+	legacy := s.data[0].(LegacyMessage)
+	assert.Equal(t, legacy.ID, "01HKX1TMQZQDS6NC5DG7WNXXCJ")
+	encoded, err := json.Marshal(legacy)
+	assert.NilError(t, err)
+	var attributes map[string]any
+	assert.NilError(t, json.Unmarshal(encoded, &attributes))
+	assert.Equal(t, attributes["api_version"], APIv1)
+	assert.Equal(t, attributes["platform_id"], "linux")
+	_, hasTime := attributes["time"]
+	assert.Assert(t, !hasTime)
+
+	// consumers read both formats
+	var decoded Message
+	assert.NilError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, decoded.APIVersion, APIv1)
+	assert.Equal(t, decoded.PlatformID, "linux")
+}
+
+func TestBinaryProducer(t *testing.T) {
+	s := &sent{}
+	producer := NewBinaryProducer(s)
+	producer.Async(NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"}))
+	record := s.data[0].(*Record)
+	assert.Equal(t, record.Headers["ce_subject"], "01HKX0J9KS8AASMRYX61458N41")
+	assert.Equal(t, record.Headers["ce_success"], "true")
+	assert.Equal(t, record.Headers["ce_platformid"], "event-provenance-registry")
+
+	_, err := FromBinary(map[string]string{"ce_id": "1"}, []byte(`{}`))
+	assert.ErrorContains(t, err, "record has no ce_specversion header")
+}
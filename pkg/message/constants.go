@@ -9,3 +9,37 @@ const APIv1 string = "v1"
 // CloudEventsSpec is the string that represents
 // The Cloud Events Spec used for API version 2
 const CloudEventsSpec = "1.0"
+
+const (
+	// FormatEPR produces messages as Message structs
+	FormatEPR = "epr"
+	// FormatCDEvents produces messages as CDEvents in structured mode CloudEvents
+	FormatCDEvents = "cdevents"
+)
+
+// Source is the CloudEvents source of the messages EPR produces
+const Source = "epr"
+
+// DataContentType is the media type of the data of a Message
+const DataContentType = "application/json"
+
+const (
+	// ModeStructured sends a message as a JSON CloudEvent
+	ModeStructured = "structured"
+	// ModeBinary sends the data of a message as the record value and its attributes as ce_ headers
+	ModeBinary = "binary"
+)
+
+// StructuredContentType is the media type of a CloudEvent sent in structured mode over HTTP or NATS
+const StructuredContentType = "application/cloudevents+json"
+
+const (
+	// SinkKafka publishes messages to a Kafka topic
+	SinkKafka = "kafka"
+	// SinkNATS publishes messages to a NATS JetStream subject
+	SinkNATS = "nats"
+	// SinkWebhook posts messages to an HTTP endpoint
+	SinkWebhook = "webhook"
+	// SinkFile appends messages to a newline delimited JSON file
+	SinkFile = "file"
+)
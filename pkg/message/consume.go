@@ -166,35 +166,15 @@ func CreateConsumerGroup(kafkaVersion, groupID string, kafkaPeers []string) (sar
 // allows the consumer to authenticate with a SASL enabled kafka cluster. If left empty, a normal consumer will be created.
 // The previous two options imply TLS. TLS can be set separately in cases where TLS is required but auth is not.
 func CreateSecureConsumerGroup(kafkaVersion, groupID, saslUser, saslPass string, tls bool, kafkaPeers []string) (sarama.ConsumerGroup, error) {
-	saramaCfg, err := NewConfig(kafkaVersion)
-	if err != nil {
-		return nil, err
-	}
-
-	if tls {
-		slog.Info("Enabled Kafka TLS")
-		saramaCfg.Net.TLS.Enable = true
-	}
-
-	if groupID == "" {
-		groupID = "re.polaris.watcher"
-	}
-	saramaCfg.ClientID = groupID + "." + utils.NewULIDAsString()
-
+	security := Security{TLS: tls}
 	if saslUser != "" && saslPass != "" {
 		slog.Info("Enabled Kafka SASL")
-		saramaCfg, err = NewSCRAMConfig(saslUser, saslPass, kafkaVersion)
-		if err != nil {
-			return nil, err
-		}
+		security = Security{TLS: true, SASLMechanism: sarama.SASLTypeSCRAMSHA512, SASLUser: saslUser, SASLPassword: saslPass}
 	}
-
-	consGroup, err := sarama.NewConsumerGroup(kafkaPeers, groupID, saramaCfg)
-	if err != nil {
-		return nil, err
+	if groupID == "" {
+		groupID = "re.polaris.watcher"
 	}
-
-	return consGroup, nil
+	return newConsumerGroup(kafkaVersion, groupID, security, kafkaPeers)
 }
 
 // CreateConsumerGroupEnv returns a new ConsumerGroup with Kafka security options enabled or disabled depending on
@@ -203,24 +183,25 @@ func CreateSecureConsumerGroup(kafkaVersion, groupID, saslUser, saslPass string,
 // Supported SASL_MECHANISMS: SCRAM, PLAIN
 // Future    SASL_MECHANISMS: OAUTH2
 func CreateConsumerGroupEnv(kafkaVersion, groupID string, tls bool, kafkaPeers []string) (sarama.ConsumerGroup, error) {
-	saramaCfg, err := NewConfigEnv(kafkaVersion)
+	if groupID == "" {
+		return nil, fmt.Errorf("Consumer Group groupID cannot be empty")
+	}
+	security, err := GetSASLAuthentication().Security(tls)
 	if err != nil {
 		return nil, err
 	}
+	return newConsumerGroup(kafkaVersion, groupID, security, kafkaPeers)
+}
 
-	if tls {
-		slog.Info("Enabled Kafka TLS")
-		saramaCfg.Net.TLS.Enable = true
+func newConsumerGroup(kafkaVersion, groupID string, security Security, kafkaPeers []string) (sarama.ConsumerGroup, error) {
+	saramaCfg, err := NewSecureConfig(kafkaVersion, security)
+	if err != nil {
+		return nil, err
 	}
-
-	if groupID == "" {
-		return nil, fmt.Errorf("Consumer Group groupID cannot be empty")
+	if security.TLS {
+		slog.Info("Enabled Kafka TLS")
 	}
 	saramaCfg.ClientID = groupID + "." + utils.NewULIDAsString()
 
-	consGroup, err := sarama.NewConsumerGroup(kafkaPeers, groupID, saramaCfg)
-	if err != nil {
-		return nil, err
-	}
-	return consGroup, nil
+	return sarama.NewConsumerGroup(kafkaPeers, groupID, saramaCfg)
 }
@@ -7,26 +7,31 @@ import (
 	"encoding/json"
 	"io"
 
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/sassoftware/event-provenance-registry/pkg/utils"
 	yaml "gopkg.in/yaml.v3"
 )
 
 // Message is the struct for kafka message events it contains information from the receipt that created the event
-// Adheres to https://github.com/cloudevents/spec 1.0.1
+// Adheres to https://github.com/cloudevents/spec 1.0.2, the artifact tuple and the outcome are extension attributes
 type Message struct {
-	Success     bool   `json:"success"`     // Extension to Cloud Events Spec
-	ID          string `json:"id"`          // Cloud Events Spec 1.0.1
-	Specversion string `json:"specversion"` // Cloud Events Spec 1.0.1
-	Type        string `json:"type"`        // Cloud Events Spec 1.0.1
-	Source      string `json:"source"`      // Cloud Events Spec 1.0.1
-	APIVersion  string `json:"api_version"` // Extension to Cloud Events Spec
-	Name        string `json:"name"`        // Extension to Cloud Events Spec
-	Version     string `json:"version"`     // Extension to Cloud Events Spec
-	Release     string `json:"release"`     // Extension to Cloud Events Spec
-	PlatformID  string `json:"platform_id"` // Extension to Cloud Events Spec
-	Package     string `json:"package"`     // Extension to Cloud Events Spec
-	Data        Data   `json:"data"`        // Cloud Events Spec 1.0.1
+	Success         bool   `json:"success"`           // Extension to Cloud Events Spec
+	ID              string `json:"id"`                // Cloud Events Spec 1.0.2
+	Specversion     string `json:"specversion"`       // Cloud Events Spec 1.0.2
+	Type            string `json:"type"`              // Cloud Events Spec 1.0.2
+	Source          string `json:"source"`            // Cloud Events Spec 1.0.2
+	Subject         string `json:"subject,omitempty"` // Cloud Events Spec 1.0.2, the ID of the event, receiver or group
+	Time            string `json:"time,omitempty"`    // Cloud Events Spec 1.0.2
+	DataContentType string `json:"datacontenttype"`   // Cloud Events Spec 1.0.2
+	APIVersion      string `json:"apiversion"`        // Extension to Cloud Events Spec
+	Name            string `json:"name"`              // Extension to Cloud Events Spec
+	Version         string `json:"version"`           // Extension to Cloud Events Spec
+	Release         string `json:"release"`           // Extension to Cloud Events Spec
+	PlatformID      string `json:"platformid"`        // Extension to Cloud Events Spec
+	Package         string `json:"package"`           // Extension to Cloud Events Spec
+	Data            Data   `json:"data"`              // Cloud Events Spec 1.0.2
 }
 
 // Data contains the data that created the event
@@ -34,6 +39,29 @@ type Data struct {
 	Events              []storage.Event              `json:"events"`
 	EventReceivers      []storage.EventReceiver      `json:"event_receivers"`
 	EventReceiverGroups []storage.EventReceiverGroup `json:"event_receiver_groups"`
+	// Gate is the evaluation behind a group complete, failed or regressed message
+	Gate *gate.Result `json:"gate,omitempty"`
+}
+
+// EventReceiverIDs returns the IDs of the receivers the message is about
+func (m *Message) EventReceiverIDs() []graphql.ID {
+	ids := []graphql.ID{}
+	for _, e := range m.Data.Events {
+		ids = append(ids, e.EventReceiverID)
+	}
+	for _, r := range m.Data.EventReceivers {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// EventReceiverGroupIDs returns the IDs of the groups the message is about
+func (m *Message) EventReceiverGroupIDs() []graphql.ID {
+	ids := []graphql.ID{}
+	for _, g := range m.Data.EventReceiverGroups {
+		ids = append(ids, g.ID)
+	}
+	return ids
 }
 
 // ToJSON converts a Events struct to JSON
@@ -57,8 +85,9 @@ func (m *Message) ToYAML() (string, error) {
 // New returns a Message
 func New() *Message {
 	return &Message{
-		Specversion: CloudEventsSpec,
-		APIVersion:  APIv1,
+		Specversion:     CloudEventsSpec,
+		DataContentType: DataContentType,
+		APIVersion:      APIv1,
 	}
 }
 
@@ -73,20 +102,25 @@ func NewEvent(e storage.Event) Message {
 		Schema:      e.EventReceiver.Schema,
 		Fingerprint: e.EventReceiver.Fingerprint,
 		CreatedAt:   e.EventReceiver.CreatedAt,
+
+		SchemaRevision: e.EventReceiver.SchemaRevision,
 	}
 
 	return Message{
-		Success:     e.Success,
-		ID:          string(e.ID),
-		Specversion: CloudEventsSpec,
-		Source:      "epr",
-		Type:        e.EventReceiver.Type,
-		APIVersion:  APIv1,
-		Name:        e.Name,
-		Version:     e.Version,
-		Release:     e.Release,
-		PlatformID:  e.PlatformID,
-		Package:     e.Package,
+		Success:         e.Success,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            e.EventReceiver.Type,
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         e.Release,
+		PlatformID:      e.PlatformID,
+		Package:         e.Package,
 		Data: Data{
 			Events:         []storage.Event{e},
 			EventReceivers: []storage.EventReceiver{er},
@@ -94,20 +128,55 @@ func NewEvent(e storage.Event) Message {
 	}
 }
 
+// NewEventRevoked returns a message reporting that the event no longer counts, the revocation
+// is part of the event under data.events
+func NewEventRevoked(e storage.Event) Message {
+	msg := NewEvent(e)
+	msg.Type = "epr.event.revoked"
+	msg.Success = false
+	return msg
+}
+
 // NewEventReceiver returns a Message
 func NewEventReceiver(e storage.EventReceiver) Message {
 	return Message{
-		Success:     true,
-		ID:          string(e.ID),
-		Specversion: CloudEventsSpec,
-		Source:      "epr",
-		Type:        "epr.event.receiver.created",
-		APIVersion:  APIv1,
-		Name:        e.Name,
-		Version:     e.Version,
-		Release:     utils.NowRFC3339(),
-		PlatformID:  "event-provenance-registry",
-		Package:     "event.receiver",
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            "epr.event.receiver.created",
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         utils.NowRFC3339(),
+		PlatformID:      "event-provenance-registry",
+		Package:         "event.receiver",
+		Data: Data{
+			EventReceivers: []storage.EventReceiver{e},
+		},
+	}
+}
+
+// NewEventReceiverModified returns a Message
+func NewEventReceiverModified(e storage.EventReceiver) Message {
+	return Message{
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            "epr.event.receiver.modified",
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         utils.NowRFC3339(),
+		PlatformID:      "event-provenance-registry",
+		Package:         "event.receiver",
 		Data: Data{
 			EventReceivers: []storage.EventReceiver{e},
 		},
@@ -117,17 +186,20 @@ func NewEventReceiver(e storage.EventReceiver) Message {
 // NewEventReceiverGroupCreated returns a Message
 func NewEventReceiverGroupCreated(e storage.EventReceiverGroup) Message {
 	return Message{
-		Success:     true,
-		ID:          string(e.ID),
-		Specversion: CloudEventsSpec,
-		Source:      "epr",
-		Type:        "epr.event.receiver.group.created",
-		APIVersion:  APIv1,
-		Name:        e.Name,
-		Version:     e.Version,
-		Release:     utils.NowRFC3339(),
-		PlatformID:  "event-provenance-registry",
-		Package:     "event.receiver.group",
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            "epr.event.receiver.group.created",
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         utils.NowRFC3339(),
+		PlatformID:      "event-provenance-registry",
+		Package:         "event.receiver.group",
 		Data: Data{
 			EventReceiverGroups: []storage.EventReceiverGroup{e},
 		},
@@ -137,40 +209,106 @@ func NewEventReceiverGroupCreated(e storage.EventReceiverGroup) Message {
 // NewEventReceiverGroupModified returns a Message
 func NewEventReceiverGroupModified(e storage.EventReceiverGroup) Message {
 	return Message{
-		Success:     true,
-		ID:          string(e.ID),
-		Specversion: CloudEventsSpec,
-		Source:      "epr",
-		Type:        "epr.event.receiver.group.modified",
-		APIVersion:  APIv1,
-		Name:        e.Name,
-		Version:     e.Version,
-		Release:     utils.NowRFC3339(),
-		PlatformID:  "event-provenance-registry",
-		Package:     "event.receiver.group",
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            "epr.event.receiver.group.modified",
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         utils.NowRFC3339(),
+		PlatformID:      "event-provenance-registry",
+		Package:         "event.receiver.group",
 		Data: Data{
 			EventReceiverGroups: []storage.EventReceiverGroup{e},
 		},
 	}
 }
 
-// NewEventReceiverGroupComplete returns a message
-func NewEventReceiverGroupComplete(e storage.Event, erg storage.EventReceiverGroup) Message {
+// NewEventReceiverGroupDeleted returns a Message
+func NewEventReceiverGroupDeleted(e storage.EventReceiverGroup) Message {
+	return Message{
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(e.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            "epr.event.receiver.group.deleted",
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         utils.NowRFC3339(),
+		PlatformID:      "event-provenance-registry",
+		Package:         "event.receiver.group",
+		Data: Data{
+			EventReceiverGroups: []storage.EventReceiverGroup{e},
+		},
+	}
+}
+
+// NewEventReceiverGroupComplete returns a message reporting the policy that was satisfied
+func NewEventReceiverGroupComplete(e storage.Event, erg storage.EventReceiverGroup, result gate.Result) Message {
+	return Message{
+		Success:         true,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(erg.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            erg.Type,
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         e.Release,
+		Package:         e.Package,
+		PlatformID:      e.PlatformID,
+		Data: Data{
+			Events:              []storage.Event{e},
+			EventReceiverGroups: []storage.EventReceiverGroup{erg},
+			Gate:                &result,
+		},
+	}
+}
+
+// NewEventReceiverGroupFailed returns a message for a group that failed for the artifact of the
+// event before ever passing. The event is the one that made the group fail.
+func NewEventReceiverGroupFailed(e storage.Event, erg storage.EventReceiverGroup, result gate.Result) Message {
+	return newEventReceiverGroupTransition("epr.event.receiver.group.failed", e, erg, result)
+}
+
+// NewEventReceiverGroupRegressed returns a message for a group that passed for the artifact of
+// the event and no longer does. The event is the one that made the group regress.
+func NewEventReceiverGroupRegressed(e storage.Event, erg storage.EventReceiverGroup, result gate.Result) Message {
+	return newEventReceiverGroupTransition("epr.event.receiver.group.regressed", e, erg, result)
+}
+
+func newEventReceiverGroupTransition(eventType string, e storage.Event, erg storage.EventReceiverGroup, result gate.Result) Message {
 	return Message{
-		Success:     true,
-		ID:          string(erg.ID),
-		Specversion: CloudEventsSpec,
-		Source:      "epr",
-		Type:        erg.Type,
-		APIVersion:  APIv1,
-		Name:        e.Name,
-		Version:     e.Version,
-		Release:     e.Release,
-		Package:     e.Package,
-		PlatformID:  e.PlatformID,
+		Success:         false,
+		ID:              utils.NewULIDAsString(),
+		Specversion:     CloudEventsSpec,
+		Source:          Source,
+		Subject:         string(erg.ID),
+		Time:            utils.NowRFC3339(),
+		DataContentType: DataContentType,
+		Type:            eventType,
+		APIVersion:      APIv1,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         e.Release,
+		Package:         e.Package,
+		PlatformID:      e.PlatformID,
 		Data: Data{
 			Events:              []storage.Event{e},
 			EventReceiverGroups: []storage.EventReceiverGroup{erg},
+			Gate:                &result,
 		},
 	}
 }
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsPublishTimeout bounds the wait for JetStream to acknowledge a message
+const natsPublishTimeout = 10 * time.Second
+
+type natsSink struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// NewNATSSink returns a sink publishing messages to the JetStream subject, which must be bound to
+// a stream. The CloudEvents id of each message is its JetStream message id, so that a stream with
+// a duplicate window stores a message relayed twice once.
+func NewNATSSink(url, subject string) (Sink, error) {
+	conn, err := nats.Connect(url, nats.Name("epr-server"))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn, jetstream.WithPublishAsyncErrHandler(func(_ jetstream.JetStream, msg *nats.Msg, err error) {
+		slog.Error("error publishing message to nats", "subject", msg.Subject, "error", err)
+	}))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &natsSink{conn: conn, js: js, subject: subject}, nil
+}
+
+func (n *natsSink) Async(data any) {
+	msg, err := n.msg(data)
+	if err == nil {
+		_, err = n.js.PublishMsgAsync(msg)
+	}
+	if err != nil {
+		slog.Error("error publishing message to nats", "subject", n.subject, "error", err)
+	}
+}
+
+func (n *natsSink) Send(data any) error {
+	msg, err := n.msg(data)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsPublishTimeout)
+	defer cancel()
+	_, err = n.js.PublishMsg(ctx, msg)
+	return err
+}
+
+// Close waits for the acknowledgement of the messages published asynchronously
+func (n *natsSink) Close() error {
+	slog.Info("shutting down nats sink")
+	select {
+	case <-n.js.PublishAsyncComplete():
+	case <-time.After(natsPublishTimeout):
+		slog.Warn("closing nats sink with unacknowledged messages")
+	}
+	n.conn.Close()
+	return nil
+}
+
+// msg encodes the message following the CloudEvents NATS binding
+func (n *natsSink) msg(data any) (*nats.Msg, error) {
+	e, err := newEnvelope(data, HTTPHeaderPrefix)
+	if err != nil {
+		return nil, err
+	}
+	msg := nats.NewMsg(n.subject)
+	msg.Data = e.body
+	msg.Header.Set("content-type", e.contentType)
+	for name, value := range e.headers {
+		msg.Header.Set(name, value)
+	}
+	if e.id != "" {
+		msg.Header.Set(jetstream.MsgIDHeader, e.id)
+	}
+	return msg, nil
+}
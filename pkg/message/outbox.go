@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/metrics"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+const (
+	// DefaultRelayInterval is the time between two polls of the outbox
+	DefaultRelayInterval = time.Second
+	// DefaultRelayBatchSize is the largest number of messages published per poll
+	DefaultRelayBatchSize = 100
+	// DefaultRelayMaxBackoff bounds the time between two attempts to publish a message
+	DefaultRelayMaxBackoff = 5 * time.Minute
+)
+
+// Outbox is a TopicProducer that stores messages in the outbox of a repository rather than
+// producing them. A Relay publishes them once the transaction that stored them commits.
+type Outbox struct {
+	db storage.Repository
+	// committed gets the messages once they are committed, see Notify
+	committed TopicProducer
+}
+
+// NewOutbox returns the outbox of the repository
+func NewOutbox(db storage.Repository) *Outbox {
+	return &Outbox{db: db}
+}
+
+// In returns the outbox of the transaction, its messages are stored along with the other
+// writes of the transaction
+func (o *Outbox) In(tx storage.Repository) *Outbox {
+	return &Outbox{db: tx}
+}
+
+// Notify returns the outbox handing each message to the producer as well, once the message is
+// committed. Messages stored in a transaction, see In, are handed over by Committed.
+func (o *Outbox) Notify(producer TopicProducer) *Outbox {
+	return &Outbox{db: o.db, committed: producer}
+}
+
+// Committed hands the messages of a transaction that committed to the producer set by Notify
+func (o *Outbox) Committed(messages ...any) {
+	if o.committed == nil {
+		return
+	}
+	for _, msg := range messages {
+		o.committed.Async(msg)
+	}
+}
+
+// Async stores the message, errors are only logged
+func (o *Outbox) Async(data any) {
+	if err := o.Send(data); err != nil {
+		slog.Error("error storing outbox message", "error", err)
+	}
+}
+
+// Send stores the message
+func (o *Outbox) Send(data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err = o.db.CreateOutboxMessage(payload); err != nil {
+		return err
+	}
+	o.Committed(data)
+	return nil
+}
+
+// Relay publishes the pending messages of the outbox through a producer. A message that cannot
+// be published is retried with an exponential backoff.
+type Relay struct {
+	db       storage.Repository
+	producer TopicProducer
+
+	Interval   time.Duration
+	BatchSize  int
+	MaxBackoff time.Duration
+}
+
+// NewRelay returns a relay publishing the outbox of the repository with the producer
+func NewRelay(db storage.Repository, producer TopicProducer) *Relay {
+	return &Relay{
+		db:         db,
+		producer:   producer,
+		Interval:   DefaultRelayInterval,
+		BatchSize:  DefaultRelayBatchSize,
+		MaxBackoff: DefaultRelayMaxBackoff,
+	}
+}
+
+// Run polls the outbox until the context is done
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Publish(); err != nil {
+			slog.Error("error relaying outbox messages", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish publishes a batch of the messages that are due, oldest first, and returns how many
+// were published. The batch stops at the first message that fails since the broker is then
+// likely unavailable.
+func (r *Relay) Publish() (int, error) {
+	published := 0
+	err := r.db.Transaction(func(tx storage.Repository) error {
+		pending, err := tx.FindPendingOutboxMessages(r.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range pending {
+			if err := r.publish(entry); err != nil {
+				metrics.OutboxPublishFailures.Inc()
+				slog.Warn("error publishing outbox message", "id", entry.ID, "attempts", entry.Attempts+1, "error", err)
+				return tx.MarkOutboxMessageFailed(entry.ID, err.Error(), time.Now().Add(r.backoff(entry.Attempts+1)))
+			}
+			if err := tx.MarkOutboxMessageSent(entry.ID); err != nil {
+				return err
+			}
+			metrics.OutboxMessagesPublished.Inc()
+			published++
+		}
+		return nil
+	})
+	r.observe()
+	return published, err
+}
+
+// publish hands the stored message to the producer, which renders it in the configured format
+func (r *Relay) publish(entry storage.OutboxMessage) error {
+	var msg Message
+	if err := json.Unmarshal(entry.Payload.JSON, &msg); err != nil {
+		return fmt.Errorf("invalid outbox message: %w", err)
+	}
+	return r.producer.Send(msg)
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (r *Relay) backoff(attempts int32) time.Duration {
+	backoff := r.Interval
+	for i := int32(1); i < attempts && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, r.MaxBackoff)
+}
+
+// observe updates the outbox metrics
+func (r *Relay) observe() {
+	pending, oldest, err := r.db.CountPendingOutboxMessages()
+	if err != nil {
+		slog.Error("error counting pending outbox messages", "error", err)
+		return
+	}
+	metrics.OutboxMessagesPending.Set(float64(pending))
+	age := 0.0
+	if oldest != nil {
+		age = time.Since(*oldest).Seconds()
+	}
+	metrics.OutboxOldestPendingAge.Set(age)
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+// unavailable is a TopicProducer whose broker is down
+type unavailable struct{}
+
+func (unavailable) Async(_ any) {}
+
+func (unavailable) Send(_ any) error {
+	return errors.New("broker unavailable")
+}
+
+func TestRelay(t *testing.T) {
+	db := storage.NewMemory()
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build", Type: "epr.build"}
+	event := storage.Event{
+		ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", Name: "foo", Version: "1.0.0", Success: true,
+		Payload: types.JSON{JSON: []byte(`{"name":"joe"}`)}, EventReceiverID: receiver.ID, EventReceiver: receiver,
+	}
+	outbox := NewOutbox(db)
+	assert.NilError(t, outbox.Send(NewEventReceiver(receiver)))
+	assert.NilError(t, outbox.Send(NewEvent(event)))
+
+	// the broker is down, the first message waits for the next attempt and the batch stops
+	down := NewRelay(db, unavailable{})
+	published, err := down.Publish()
+	assert.NilError(t, err)
+	assert.Equal(t, published, 0)
+	stuck, err := db.FindStuckOutboxMessages(time.Now().Add(time.Second))
+	assert.NilError(t, err)
+	assert.Equal(t, len(stuck), 2)
+	assert.Equal(t, stuck[0].Attempts, int32(1))
+	assert.Equal(t, stuck[0].LastError, "broker unavailable")
+	assert.Equal(t, stuck[1].Attempts, int32(0))
+	assert.Assert(t, time.Time(stuck[0].NextAttemptAt.Date).After(time.Now()))
+
+	// the messages go through the format of the producer once it is back
+	s := &sent{}
+	up := NewRelay(db, NewCDEventsProducer(s))
+	published, err = up.Publish()
+	assert.NilError(t, err)
+	assert.Equal(t, published, 1)
+	assert.Equal(t, len(s.data), 1)
+
+	assert.NilError(t, db.MarkOutboxMessageFailed(stuck[0].ID, "broker unavailable", time.Now()))
+	published, err = up.Publish()
+	assert.NilError(t, err)
+	assert.Equal(t, published, 1)
+	pending, oldest, err := db.CountPendingOutboxMessages()
+	assert.NilError(t, err)
+	assert.Equal(t, pending, int64(0))
+	assert.Assert(t, oldest == nil)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(storage.NewMemory(), &sent{})
+	assert.Equal(t, r.backoff(1), time.Second)
+	assert.Equal(t, r.backoff(2), 2*time.Second)
+	assert.Equal(t, r.backoff(4), 8*time.Second)
+	assert.Equal(t, r.backoff(100), DefaultRelayMaxBackoff)
+}
@@ -44,41 +44,14 @@ func NewConfig(version string) (*sarama.Config, error) {
 	return config, nil
 }
 
-func newSASLConfig(user, password, version string) (*sarama.Config, error) {
-	config, err := NewConfig(version)
-	if err != nil {
-		return nil, err
-	}
-
-	config.Net.TLS.Enable = true
-	config.Net.SASL.Enable = true
-	config.Net.SASL.User = user
-	config.Net.SASL.Password = password
-	return config, nil
-}
-
 // NewSCRAMConfig creates a new SASL SCRAM enabled sarama config for communicating over TLS
 func NewSCRAMConfig(user, password, version string) (*sarama.Config, error) {
-	config, err := newSASLConfig(user, password, version)
-	if err != nil {
-		return nil, err
-	}
-	config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
-		return &SCRAMClient{HashGeneratorFcn: SHA512}
-	}
-	config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
-
-	return config, nil
+	return NewSecureConfig(version, Security{TLS: true, SASLMechanism: sarama.SASLTypeSCRAMSHA512, SASLUser: user, SASLPassword: password})
 }
 
 // NewPlainConfig creates a new SASL PLAINTEXT enabled sarama config for communicating over TLS
 func NewPlainConfig(user, password, version string) (*sarama.Config, error) {
-	config, err := newSASLConfig(user, password, version)
-	if err != nil {
-		return nil, err
-	}
-	config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
-	return config, nil
+	return NewSecureConfig(version, Security{TLS: true, SASLMechanism: sarama.SASLTypePlaintext, SASLUser: user, SASLPassword: password})
 }
 
 // NewConfigEnv returns a new Sarama config with Kafka security options enabled or disabled depending on
@@ -88,27 +61,11 @@ func NewPlainConfig(user, password, version string) (*sarama.Config, error) {
 // Supported SASL_MECHANISMS: SCRAM, PLAIN
 // Future    SASL_MECHANISMS: OAUTH2
 func NewConfigEnv(version string) (*sarama.Config, error) {
-	saslAuth := GetSASLAuthentication()
-
-	if !saslAuth.SASLEnabled() {
-		slog.Info("no Kafka Authentication Enabled")
-		return NewConfig(version)
-	}
-
-	// NONE case covered by the above .SASLEnabled() function
-	switch saslAuth.Mechanism { //nolint:exhaustive
-	case SCRAM:
-		slog.Info("Kafka Authentication Mechanism: SASL SCRAM")
-		return NewSCRAMConfig(saslAuth.Username, saslAuth.Password, version)
-	case PLAIN:
-		slog.Info("Kafka Authentication Mechanism: SASL PLAIN")
-		return NewPlainConfig(saslAuth.Username, saslAuth.Password, version)
-	case OAUTH2: // TODO: add support for OAUTH2
-		slog.Info("Kafka Authentication Mechanism: SASL OAUTH2")
-		return nil, fmt.Errorf("SASL_MECHANISM 'OAUTH2' not currently supported")
-	default:
-		return nil, fmt.Errorf("SASL_MECHANISM not supported")
+	security, err := GetSASLAuthentication().Security(false)
+	if err != nil {
+		return nil, err
 	}
+	return NewSecureConfig(version, security)
 }
 
 // NewProducer creates a producer instance
@@ -181,15 +138,7 @@ func (p *producer) Async(topic string, value interface{}) {
 		slog.Debug("kafka messaging disabled")
 		return
 	}
-	encodedMsg := &messageInfo{
-		msgType: value,
-		Topic:   topic,
-	}
-
-	p.async.Input() <- &sarama.ProducerMessage{
-		Topic: topic,
-		Value: encodedMsg,
-	}
+	p.async.Input() <- newProducerMessage(topic, value)
 }
 
 // Send encodes an arbitrary struct and sends it on the given topic synchronously.
@@ -198,15 +147,7 @@ func (p *producer) Send(topic string, value interface{}) error {
 		slog.Debug("kafka messaging disabled")
 		return nil
 	}
-	encodedMsg := &messageInfo{
-		msgType: value,
-		Topic:   topic,
-	}
-
-	_, _, err := p.sync.SendMessage(&sarama.ProducerMessage{
-		Topic: topic,
-		Value: encodedMsg,
-	})
+	_, _, err := p.sync.SendMessage(newProducerMessage(topic, value))
 	if err != nil {
 		return err
 	}
@@ -214,6 +155,22 @@ func (p *producer) Send(topic string, value interface{}) error {
 	return nil
 }
 
+// newProducerMessage encodes the value on the topic, a Record is sent with its headers
+func newProducerMessage(topic string, value interface{}) *sarama.ProducerMessage {
+	msg := &sarama.ProducerMessage{Topic: topic}
+	if record, ok := value.(*Record); ok {
+		for _, header := range record.headers() {
+			msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(header[0]), Value: []byte(header[1])})
+		}
+		value = record.Value
+	}
+	msg.Value = &messageInfo{
+		msgType: value,
+		Topic:   topic,
+	}
+	return msg
+}
+
 type TopicProducer interface {
 	Async(data any)
 	Send(data any) error
@@ -4,7 +4,11 @@
 package message
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+
+	"github.com/Shopify/sarama"
 )
 
 // Mechanism represents the SASL Authentication mechanism being used
@@ -49,3 +53,29 @@ func GetSASLAuthentication() *SASLAuthentication {
 		Mechanism: getMechanism(),
 	}
 }
+
+// Security returns the connection options of the authentication, SASL implies TLS. SCRAM
+// authenticates with SCRAM-SHA-512.
+func (s *SASLAuthentication) Security(tls bool) (Security, error) {
+	if !s.SASLEnabled() {
+		slog.Info("no Kafka Authentication Enabled")
+		return Security{TLS: tls}, nil
+	}
+
+	security := Security{TLS: true, SASLUser: s.Username, SASLPassword: s.Password}
+	// NONE case covered by the above .SASLEnabled() function
+	switch s.Mechanism { //nolint:exhaustive
+	case SCRAM:
+		slog.Info("Kafka Authentication Mechanism: SASL SCRAM")
+		security.SASLMechanism = sarama.SASLTypeSCRAMSHA512
+	case PLAIN:
+		slog.Info("Kafka Authentication Mechanism: SASL PLAIN")
+		security.SASLMechanism = sarama.SASLTypePlaintext
+	case OAUTH2: // TODO: add support for OAUTH2
+		slog.Info("Kafka Authentication Mechanism: SASL OAUTH2")
+		return Security{}, fmt.Errorf("SASL_MECHANISM 'OAUTH2' not currently supported")
+	default:
+		return Security{}, fmt.Errorf("SASL_MECHANISM not supported")
+	}
+	return security, nil
+}
@@ -4,11 +4,15 @@
 package message
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
 
 	"github.com/xdg/scram"
 )
 
+// SHA256 references the sha256 hash function.
+var SHA256 scram.HashGeneratorFcn = sha256.New
+
 // SHA512 references the sha512 hash function.
 var SHA512 scram.HashGeneratorFcn = sha512.New
 
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/Shopify/sarama"
+)
+
+// Security holds the TLS and SASL options of the connection to the Kafka brokers
+type Security struct {
+	TLS bool
+	// CAFile is the PEM bundle the certificates of the brokers are verified with, the system
+	// pool when empty
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key presented for mutual TLS
+	CertFile string
+	KeyFile  string
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, SASL is off when it is empty
+	SASLMechanism string
+	SASLUser      string
+	SASLPassword  string
+}
+
+// NewSecureConfig creates a new sarama config for the version with the TLS and SASL options
+// applied
+func NewSecureConfig(version string, security Security) (*sarama.Config, error) {
+	config, err := NewConfig(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kafka version %q: %w", version, err)
+	}
+
+	if security.TLS {
+		tlsConfig, err := newTLSConfig(security.CAFile, security.CertFile, security.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	} else if security.CAFile != "" || security.CertFile != "" || security.KeyFile != "" {
+		return nil, errors.New("kafka CA, certificate and key files require TLS to be enabled")
+	}
+
+	if security.SASLMechanism != "" {
+		if err := setSASL(config, security); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka config: %w", err)
+	}
+	return config, nil
+}
+
+func setSASL(config *sarama.Config, security Security) error {
+	switch security.SASLMechanism {
+	case sarama.SASLTypePlaintext:
+	case sarama.SASLTypeSCRAMSHA256:
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &SCRAMClient{HashGeneratorFcn: SHA256}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &SCRAMClient{HashGeneratorFcn: SHA512}
+		}
+	default:
+		return fmt.Errorf("unsupported kafka SASL mechanism %q, use %s, %s or %s",
+			security.SASLMechanism, sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512)
+	}
+	if security.SASLUser == "" || security.SASLPassword == "" {
+		return fmt.Errorf("kafka SASL %s requires a user and a password", security.SASLMechanism)
+	}
+	if security.SASLMechanism == sarama.SASLTypePlaintext && !security.TLS {
+		slog.Warn("kafka SASL PLAIN without TLS sends the password in clear text")
+	}
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = sarama.SASLMechanism(security.SASLMechanism)
+	config.Net.SASL.User = security.SASLUser
+	config.Net.SASL.Password = security.SASLPassword
+	return nil
+}
+
+func newTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafka CA file %s holds no PEM certificate", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("kafka client certificate and key must be given together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// CheckBrokers connects to each broker and fetches its metadata, which goes through the TLS
+// handshake and the SASL authentication. It fails when no broker can be reached, with the
+// reason for each of them, and logs the brokers that cannot be reached when others can.
+func CheckBrokers(brokers []string, config *sarama.Config) error {
+	errs := []error{}
+	for _, addr := range brokers {
+		if err := checkBroker(addr, config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(brokers) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Warn("kafka broker unavailable", "error", err)
+	}
+	return nil
+}
+
+func checkBroker(addr string, config *sarama.Config) error {
+	broker := sarama.NewBroker(addr)
+	if err := broker.Open(config); err != nil {
+		return brokerError(addr, config, err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	if _, err := broker.Connected(); err != nil {
+		return brokerError(addr, config, err)
+	}
+	if _, err := broker.GetMetadata(sarama.NewMetadataRequest(config.Version, nil)); err != nil {
+		return brokerError(addr, config, err)
+	}
+	return nil
+}
+
+// brokerError describes why the broker could not be reached and what to check
+func brokerError(addr string, config *sarama.Config, err error) error {
+	var (
+		hint       string
+		recordErr  tls.RecordHeaderError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	msg := err.Error()
+	switch {
+	case errors.As(err, &recordErr):
+		hint = "the broker does not speak TLS, disable TLS or use its TLS listener"
+	case errors.As(err, &unknownCA):
+		hint = "the broker certificate is not signed by a trusted CA, set the CA file"
+	case errors.As(err, &hostErr):
+		hint = "the broker certificate does not match its address"
+	case errors.As(err, &invalidErr):
+		hint = "the broker certificate is invalid or expired"
+	case strings.Contains(msg, "certificate required") || strings.Contains(msg, "bad certificate"):
+		hint = "the broker rejected the client certificate, set a certificate and key it trusts"
+	case errors.Is(err, sarama.ErrSASLAuthenticationFailed) || strings.Contains(msg, "SASL"):
+		hint = "check the SASL mechanism, user and password"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		hint = "nothing listens on the address, check the brokers"
+	case errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET):
+		hint = "the broker closed the connection, it may expect TLS or SASL"
+	}
+
+	mechanism := "off"
+	if config.Net.SASL.Enable {
+		mechanism = string(config.Net.SASL.Mechanism)
+	}
+	if hint == "" {
+		return fmt.Errorf("connecting to kafka broker %s (tls %v, sasl %s): %w", addr, config.Net.TLS.Enable, mechanism, err)
+	}
+	return fmt.Errorf("connecting to kafka broker %s (tls %v, sasl %s): %w: %s", addr, config.Net.TLS.Enable, mechanism, err, hint)
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"gotest.tools/v3/assert"
+)
+
+// writeCertificate writes a self-signed certificate and its key as PEM files
+func writeCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "epr"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.NilError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NilError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestNewSecureConfig(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	config, err := NewSecureConfig("3.4.0", Security{
+		TLS:           true,
+		CAFile:        certFile,
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		SASLMechanism: sarama.SASLTypeSCRAMSHA256,
+		SASLUser:      "epr",
+		SASLPassword:  "secret",
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, config.Net.TLS.Enable)
+	assert.Assert(t, config.Net.TLS.Config.RootCAs != nil)
+	assert.Equal(t, len(config.Net.TLS.Config.Certificates), 1)
+	assert.Assert(t, config.Net.SASL.Enable)
+	assert.Equal(t, config.Net.SASL.Mechanism, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256))
+
+	config, err = NewSecureConfig("3.4.0", Security{})
+	assert.NilError(t, err)
+	assert.Assert(t, !config.Net.TLS.Enable && !config.Net.SASL.Enable)
+
+	tests := []struct {
+		name     string
+		version  string
+		security Security
+		err      string
+	}{
+		{"version", "three", Security{}, "invalid kafka version"},
+		{"files without tls", "3.4.0", Security{CAFile: certFile}, "require TLS"},
+		{"missing ca", "3.4.0", Security{TLS: true, CAFile: filepath.Join(t.TempDir(), "ca.pem")}, "reading kafka CA file"},
+		{"not a ca", "3.4.0", Security{TLS: true, CAFile: keyFile}, "holds no PEM certificate"},
+		{"cert without key", "3.4.0", Security{TLS: true, CertFile: certFile}, "given together"},
+		{"mechanism", "3.4.0", Security{SASLMechanism: "GSSAPI", SASLUser: "epr", SASLPassword: "secret"}, "unsupported kafka SASL mechanism"},
+		{"password", "3.4.0", Security{SASLMechanism: sarama.SASLTypePlaintext, SASLUser: "epr"}, "requires a user and a password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSecureConfig(tt.version, tt.security)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestCheckBrokers(t *testing.T) {
+	// a listener that does not speak TLS
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+			_ = conn.Close()
+		}
+	}()
+
+	config, err := NewSecureConfig("3.4.0", Security{TLS: true})
+	assert.NilError(t, err)
+	config.Net.DialTimeout = time.Second
+	err = CheckBrokers([]string{listener.Addr().String()}, config)
+	assert.ErrorContains(t, err, "does not speak TLS")
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	addr := closed.Addr().String()
+	assert.NilError(t, closed.Close())
+	err = CheckBrokers([]string{addr}, config)
+	assert.ErrorContains(t, err, "nothing listens on the address")
+}
+
+func TestConfigEnv(t *testing.T) {
+	t.Setenv("SASL_USERNAME", "user")
+	t.Setenv("SASL_PASSWORD", "pass")
+
+	t.Setenv("SASL_MECHANISM", "SCRAM")
+	config, err := NewConfigEnv("3.4.0")
+	assert.NilError(t, err)
+	assert.Assert(t, config.Net.TLS.Enable)
+	assert.Equal(t, config.Net.SASL.Mechanism, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512))
+	assert.Assert(t, config.Net.SASL.SCRAMClientGeneratorFunc != nil)
+
+	t.Setenv("SASL_MECHANISM", "PLAIN")
+	config, err = NewConfigEnv("3.4.0")
+	assert.NilError(t, err)
+	assert.Equal(t, config.Net.SASL.Mechanism, sarama.SASLMechanism(sarama.SASLTypePlaintext))
+	assert.Equal(t, config.Net.SASL.User, "user")
+
+	t.Setenv("SASL_MECHANISM", "OAUTH2")
+	_, err = NewConfigEnv("3.4.0")
+	assert.ErrorContains(t, err, "OAUTH2")
+
+	t.Setenv("SASL_MECHANISM", "")
+	config, err = NewConfigEnv("3.4.0")
+	assert.NilError(t, err)
+	assert.Assert(t, !config.Net.SASL.Enable && !config.Net.TLS.Enable)
+}
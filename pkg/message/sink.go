@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Sink is a backend messages are published to. Messages are Message structs or what the format
+// and mode producers turn them into, a Record in binary mode.
+type Sink interface {
+	TopicProducer
+	// Close flushes the pending messages and releases the backend
+	Close() error
+}
+
+type kafkaSink struct {
+	TopicProducer
+	producer Producer
+}
+
+// NewKafkaSink returns a sink producing messages on the topic
+func NewKafkaSink(p Producer, topic string) Sink {
+	return &kafkaSink{TopicProducer: NewTopicProducer(p, topic), producer: p}
+}
+
+func (k *kafkaSink) Close() error {
+	return k.producer.Close()
+}
+
+type fanOut struct {
+	producers []TopicProducer
+}
+
+// NewFanOut returns a producer sending every message to each of the producers. Send fails when
+// any producer fails, the message may then have reached the others.
+func NewFanOut(producers ...TopicProducer) TopicProducer {
+	return &fanOut{producers: producers}
+}
+
+func (f *fanOut) Async(data any) {
+	for _, producer := range f.producers {
+		producer.Async(data)
+	}
+}
+
+func (f *fanOut) Send(data any) error {
+	var errs []error
+	for _, producer := range f.producers {
+		errs = append(errs, producer.Send(data))
+	}
+	return errors.Join(errs...)
+}
+
+type fanOutSink struct {
+	fanOut
+	sinks []Sink
+}
+
+// NewFanOutSink returns a sink publishing every message to each of the sinks, see NewFanOut
+func NewFanOutSink(sinks ...Sink) Sink {
+	producers := make([]TopicProducer, 0, len(sinks))
+	for _, sink := range sinks {
+		producers = append(producers, sink)
+	}
+	return &fanOutSink{fanOut: fanOut{producers: producers}, sinks: sinks}
+}
+
+func (f *fanOutSink) Close() error {
+	var errs []error
+	for _, sink := range f.sinks {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ChannelSink keeps the messages in a buffered channel, for tests
+type ChannelSink struct {
+	Messages chan any
+}
+
+// NewChannelSink returns a sink holding up to size messages
+func NewChannelSink(size int) *ChannelSink {
+	return &ChannelSink{Messages: make(chan any, size)}
+}
+
+// Async drops the message when the channel is full
+func (c *ChannelSink) Async(data any) {
+	if err := c.Send(data); err != nil {
+		slog.Warn("dropping message", "error", err)
+	}
+}
+
+// Send fails when the channel is full
+func (c *ChannelSink) Send(data any) error {
+	select {
+	case c.Messages <- data:
+		return nil
+	default:
+		return errors.New("channel sink is full")
+	}
+}
+
+// Close leaves the channel open so that late messages do not panic
+func (c *ChannelSink) Close() error {
+	return nil
+}
+
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a sink appending messages to the file as newline delimited JSON. A Record
+// is written as its headers and value.
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (f *fileSink) Async(data any) {
+	if err := f.Send(data); err != nil {
+		slog.Error("error writing message to file", "file", f.file.Name(), "error", err)
+	}
+}
+
+func (f *fileSink) Send(data any) error {
+	line, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+func (f *fileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// envelope is a message encoded for a sink that carries headers along with a body
+type envelope struct {
+	// id is the CloudEvents id, empty for legacy messages whose id is not unique
+	id          string
+	contentType string
+	// headers are the attributes of a message in binary mode
+	headers map[string]string
+	body    []byte
+}
+
+// newEnvelope encodes the message. The attributes of a Record are named with the prefix of the
+// binding rather than the ce_ of Kafka, any other message is sent in structured mode.
+func newEnvelope(data any, prefix string) (*envelope, error) {
+	if record, ok := data.(*Record); ok {
+		e := &envelope{
+			id:          record.Headers[HeaderPrefix+"id"],
+			contentType: record.Headers["content-type"],
+			headers:     map[string]string{},
+			body:        record.Value,
+		}
+		for _, header := range record.headers() {
+			if name, ok := strings.CutPrefix(header[0], HeaderPrefix); ok {
+				e.headers[prefix+name] = header[1]
+			}
+		}
+		return e, nil
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := data.(LegacyMessage); ok {
+		return &envelope{contentType: DataContentType, body: body}, nil
+	}
+	var attributes struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &attributes); err != nil {
+		return nil, fmt.Errorf("message is not a JSON object: %w", err)
+	}
+	return &envelope{id: attributes.ID, contentType: StructuredContentType, body: body}, nil
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+// failing is a Sink whose backend is down
+type failing struct {
+	unavailable
+}
+
+func (failing) Close() error { return nil }
+
+func TestFanOutSink(t *testing.T) {
+	first, second := NewChannelSink(1), NewChannelSink(1)
+	sink := NewFanOutSink(first, second)
+	msg := NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})
+
+	assert.NilError(t, sink.Send(msg))
+	assert.Equal(t, (<-first.Messages).(Message).ID, msg.ID)
+	assert.Equal(t, (<-second.Messages).(Message).ID, msg.ID)
+
+	// a message is still published to the sinks that are up
+	sink = NewFanOutSink(first, failing{})
+	assert.ErrorContains(t, sink.Send(msg), "broker unavailable")
+	assert.Equal(t, len(first.Messages), 1)
+
+	// the channel is full
+	assert.ErrorContains(t, first.Send(msg), "full")
+	assert.NilError(t, sink.Close())
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.ndjson")
+	sink, err := NewFileSink(path)
+	assert.NilError(t, err)
+	receiver := NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})
+	assert.NilError(t, sink.Send(receiver))
+	record, err := ToBinary(receiver)
+	assert.NilError(t, err)
+	sink.Async(record)
+	assert.NilError(t, sink.Close())
+
+	file, err := os.Open(path)
+	assert.NilError(t, err)
+	defer file.Close()
+	lines := bufio.NewScanner(file)
+	assert.Assert(t, lines.Scan())
+	var written Message
+	assert.NilError(t, json.Unmarshal(lines.Bytes(), &written))
+	assert.Equal(t, written.ID, receiver.ID)
+	assert.Assert(t, lines.Scan())
+	var writtenRecord Record
+	assert.NilError(t, json.Unmarshal(lines.Bytes(), &writtenRecord))
+	assert.Equal(t, writtenRecord.Headers[HeaderPrefix+"id"], receiver.ID)
+	assert.Assert(t, !lines.Scan())
+}
+
+func TestWebhookSink(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	var status atomic.Int32
+	status.Store(http.StatusAccepted)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	sink := NewWebhookSink(server.URL, time.Second)
+	defer sink.Close()
+	msg := NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})
+
+	// structured mode
+	assert.NilError(t, sink.Send(msg))
+	r := <-requests
+	assert.Equal(t, r.Method, http.MethodPost)
+	assert.Equal(t, r.Header.Get("Content-Type"), StructuredContentType)
+	var posted Message
+	assert.NilError(t, json.Unmarshal(<-bodies, &posted))
+	assert.Equal(t, posted.ID, msg.ID)
+
+	// binary mode uses the headers of the HTTP binding
+	record, err := ToBinary(msg)
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Send(record))
+	r = <-requests
+	assert.Equal(t, r.Header.Get("Content-Type"), DataContentType)
+	assert.Equal(t, r.Header.Get("ce-id"), msg.ID)
+	assert.Equal(t, r.Header.Get("ce-subject"), msg.Subject)
+	assert.Equal(t, r.Header.Get("ce_id"), "")
+	assert.Equal(t, string(<-bodies), string(record.Value))
+
+	// legacy messages are plain JSON
+	assert.NilError(t, sink.Send(msg.Legacy()))
+	assert.Equal(t, (<-requests).Header.Get("Content-Type"), DataContentType)
+	<-bodies
+
+	status.Store(http.StatusServiceUnavailable)
+	assert.ErrorContains(t, sink.Send(msg), "503")
+}
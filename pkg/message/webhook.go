@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// HTTPHeaderPrefix starts the HTTP header of each CloudEvents attribute in binary mode
+const HTTPHeaderPrefix = "ce-"
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting each message to the URL following the CloudEvents HTTP
+// binding. Any response other than 2xx is an error.
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (w *webhookSink) Async(data any) {
+	go func() {
+		if err := w.Send(data); err != nil {
+			slog.Error("error posting message to webhook", "url", w.url, "error", err)
+		}
+	}()
+}
+
+func (w *webhookSink) Send(data any) error {
+	e, err := newEnvelope(data, HTTPHeaderPrefix)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(e.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", e.contentType)
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain the body so that the connection is reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *webhookSink) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
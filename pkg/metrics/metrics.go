@@ -79,6 +79,45 @@ var (
 	},
 		[]string{"type"},
 	)
+
+	// OutboxMessagesPublished counts the outbox messages the relay published
+	OutboxMessagesPublished = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "server_outbox_messages_published_total",
+		Help: "Number of outbox messages published",
+	})
+
+	// OutboxPublishFailures counts the failed attempts to publish an outbox message
+	OutboxPublishFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "server_outbox_publish_failures_total",
+		Help: "Number of failed attempts to publish an outbox message",
+	})
+
+	// OutboxMessagesPending tracks the number of outbox messages waiting to be published
+	OutboxMessagesPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "server_outbox_messages_pending",
+		Help: "Number of outbox messages waiting to be published",
+	})
+
+	// OutboxOldestPendingAge tracks how long the oldest pending outbox message has waited, a
+	// growing value means messages are stuck
+	OutboxOldestPendingAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "server_outbox_oldest_pending_age_seconds",
+		Help: "Age of the oldest outbox message waiting to be published",
+	})
+	// WebhookDeliveryAttempts counts the attempts to post a message to a webhook subscription,
+	// labeled by outcome: succeeded, retried or failed
+	WebhookDeliveryAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_webhook_delivery_attempts_total",
+		Help: "Number of attempts to deliver a message to a webhook subscription",
+	},
+		[]string{"outcome"},
+	)
+	// WebhookSubscriptionsPaused counts the webhook subscriptions paused because their deliveries
+	// kept failing
+	WebhookSubscriptionsPaused = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "server_webhook_subscriptions_paused_total",
+		Help: "Number of webhook subscriptions paused after failed deliveries",
+	})
 )
 
 func init() {
@@ -90,4 +129,10 @@ func init() {
 	prometheus.MustRegister(Requests)
 	prometheus.MustRegister(ResponseTimes)
 	prometheus.MustRegister(QueriesRunning)
+	prometheus.MustRegister(OutboxMessagesPublished)
+	prometheus.MustRegister(OutboxPublishFailures)
+	prometheus.MustRegister(OutboxMessagesPending)
+	prometheus.MustRegister(OutboxOldestPendingAge)
+	prometheus.MustRegister(WebhookDeliveryAttempts)
+	prometheus.MustRegister(WebhookSubscriptionsPaused)
 }
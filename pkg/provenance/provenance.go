@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package provenance renders what EPR knows about an artifact as an in-toto Statement. The
+// predicate is either SLSA provenance, with the events and passed groups as byproducts, or the
+// EPR predicate holding them as they are stored.
+package provenance
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+const (
+	// StatementType is the type of an in-toto Statement
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateTypeSLSA is the predicate type of SLSA provenance
+	PredicateTypeSLSA = "https://slsa.dev/provenance/v1"
+	// PredicateTypeEPR is the predicate type of the EPR predicate
+	PredicateTypeEPR = "https://github.com/sassoftware/event-provenance-registry/provenance/v1"
+	// BuildType is the SLSA build type of an artifact moving through a pipeline recorded in EPR
+	BuildType = "https://github.com/sassoftware/event-provenance-registry/buildtypes/pipeline/v1"
+	// BuilderID identifies EPR as the builder in SLSA provenance
+	BuilderID = "https://github.com/sassoftware/event-provenance-registry"
+)
+
+const (
+	// FormatSLSA renders a SLSA provenance predicate
+	FormatSLSA = "SLSA"
+	// FormatEPR renders the EPR predicate
+	FormatEPR = "EPR"
+)
+
+// Statement is an in-toto Statement about the artifacts in the subject
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     any                  `json:"predicate"`
+}
+
+// ResourceDescriptor is the in-toto description of an artifact or a byproduct
+type ResourceDescriptor struct {
+	Name        string            `json:"name,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]any    `json:"annotations,omitempty"`
+}
+
+// Artifact is the tuple identifying an artifact in EPR
+type Artifact struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Release    string `json:"release"`
+	PlatformID string `json:"platform_id"`
+	Package    string `json:"package"`
+}
+
+// Predicate is the EPR predicate: the events of the artifact, oldest first, and the groups
+// that passed for it
+type Predicate struct {
+	Artifact Artifact        `json:"artifact"`
+	Events   []storage.Event `json:"events"`
+	Groups   []PassedGroup   `json:"groups"`
+}
+
+// PassedGroup is a completion of a group for the artifact
+type PassedGroup struct {
+	ID          graphql.ID   `json:"id"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Version     string       `json:"version"`
+	EventIDs    []graphql.ID `json:"event_ids"`
+	CompletedAt types.Time   `json:"completed_at"`
+}
+
+// SLSAPredicate is a SLSA v1 provenance predicate
+type SLSAPredicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs of the build, for EPR the artifact tuple
+type BuildDefinition struct {
+	BuildType          string   `json:"buildType"`
+	ExternalParameters Artifact `json:"externalParameters"`
+}
+
+// RunDetails describes the run of the pipeline
+type RunDetails struct {
+	Builder    Builder              `json:"builder"`
+	Metadata   BuildMetadata        `json:"metadata"`
+	Byproducts []ResourceDescriptor `json:"byproducts"`
+}
+
+// Builder identifies what produced the provenance
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// BuildMetadata spans the first to the last event of the artifact
+type BuildMetadata struct {
+	StartedOn  *time.Time `json:"startedOn,omitempty"`
+	FinishedOn *time.Time `json:"finishedOn,omitempty"`
+}
+
+// ValidateFormat checks that the predicate format is known
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatSLSA, FormatEPR:
+		return nil
+	default:
+		return fmt.Errorf("unknown provenance format %q, expected SLSA or EPR", format)
+	}
+}
+
+// ParseDigests turns digests of the form algorithm:hex into an in-toto digest set
+func ParseDigests(digests []string) (map[string]string, error) {
+	set := map[string]string{}
+	for _, digest := range digests {
+		algorithm, value, ok := strings.Cut(digest, ":")
+		algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if _, err := hex.DecodeString(value); !ok || algorithm == "" || value == "" || err != nil {
+			return nil, fmt.Errorf("digest %q is not of the form algorithm:hex", digest)
+		}
+		set[algorithm] = value
+	}
+	return set, nil
+}
+
+// NewStatement renders the predicate in the format with the artifact as the subject. The
+// digest set identifies the artifact to in-toto verifiers, it can be left empty when the
+// statement is only read by people.
+func NewStatement(predicate Predicate, format string, digest map[string]string) (*Statement, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(predicate.Events, func(i, j int) bool {
+		return time.Time(predicate.Events[i].CreatedAt.Date).Before(time.Time(predicate.Events[j].CreatedAt.Date))
+	})
+	sort.SliceStable(predicate.Groups, func(i, j int) bool {
+		return time.Time(predicate.Groups[i].CompletedAt.Date).Before(time.Time(predicate.Groups[j].CompletedAt.Date))
+	})
+
+	statement := &Statement{
+		Type:    StatementType,
+		Subject: []ResourceDescriptor{{Name: predicate.Artifact.Name, Digest: digest}},
+	}
+	if format == FormatEPR {
+		statement.PredicateType = PredicateTypeEPR
+		statement.Predicate = predicate
+		return statement, nil
+	}
+	statement.PredicateType = PredicateTypeSLSA
+	statement.Predicate = slsa(predicate)
+	return statement, nil
+}
+
+// slsa records the events and passed groups of the predicate as byproducts of the build
+func slsa(predicate Predicate) SLSAPredicate {
+	byproducts := []ResourceDescriptor{}
+	var metadata BuildMetadata
+	for _, event := range predicate.Events {
+		createdAt := time.Time(event.CreatedAt.Date)
+		if metadata.StartedOn == nil {
+			metadata.StartedOn = &createdAt
+		}
+		metadata.FinishedOn = &createdAt
+
+		annotations := map[string]any{
+			"event_receiver_id": event.EventReceiverID,
+			"success":           event.Success,
+			"description":       event.Description,
+			"created_at":        event.CreatedAt,
+		}
+		if event.EventReceiver.ID != "" {
+			annotations["event_receiver_name"] = event.EventReceiver.Name
+			annotations["event_receiver_type"] = event.EventReceiver.Type
+			annotations["event_receiver_version"] = event.EventReceiver.Version
+		}
+		if event.Signature != nil {
+			annotations["signature_verified"] = event.Signature.Verified
+		}
+		if event.Revocation != nil {
+			annotations["revoked_at"] = event.Revocation.RevokedAt
+			annotations["revocation_reason"] = event.Revocation.Reason
+		}
+		descriptor := ResourceDescriptor{
+			Name:        "events/" + string(event.ID),
+			Annotations: annotations,
+		}
+		if event.Hash != "" {
+			descriptor.Digest = map[string]string{"sha256": event.Hash}
+		}
+		byproducts = append(byproducts, descriptor)
+	}
+	for _, group := range predicate.Groups {
+		byproducts = append(byproducts, ResourceDescriptor{
+			Name: "groups/" + string(group.ID),
+			Annotations: map[string]any{
+				"name":         group.Name,
+				"type":         group.Type,
+				"version":      group.Version,
+				"event_ids":    group.EventIDs,
+				"completed_at": group.CompletedAt,
+			},
+		})
+	}
+
+	return SLSAPredicate{
+		BuildDefinition: BuildDefinition{
+			BuildType:          BuildType,
+			ExternalParameters: predicate.Artifact,
+		},
+		RunDetails: RunDetails{
+			Builder:    Builder{ID: BuilderID},
+			Metadata:   metadata,
+			Byproducts: byproducts,
+		},
+	}
+}
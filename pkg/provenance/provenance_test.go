@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package provenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gorm.io/datatypes"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseDigests(t *testing.T) {
+	digest, err := ParseDigests([]string{"sha256:AB12", "sha512: cd34"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, digest, map[string]string{"sha256": "ab12", "sha512": "cd34"})
+
+	for _, bad := range []string{"ab12", ":ab12", "sha256:", "sha256:xyz"} {
+		_, err = ParseDigests([]string{bad})
+		assert.Error(t, err, `digest "`+bad+`" is not of the form algorithm:hex`)
+	}
+}
+
+func TestNewStatement(t *testing.T) {
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+	predicate := Predicate{
+		Artifact: Artifact{Name: "foo", Version: "1.0.0", Release: "1", PlatformID: "linux", Package: "rpm"},
+		Events: []storage.Event{
+			{ID: "second", CreatedAt: types.Time{Date: datatypes.Date(last)}},
+			{ID: "first", CreatedAt: types.Time{Date: datatypes.Date(first)}, Hash: "ab12"},
+		},
+	}
+
+	statement, err := NewStatement(predicate, FormatSLSA, nil)
+	assert.NilError(t, err)
+	enc, err := json.Marshal(statement)
+	assert.NilError(t, err)
+	var decoded struct {
+		Type          string `json:"_type"`
+		Subject       []map[string]any
+		PredicateType string `json:"predicateType"`
+		Predicate     struct {
+			RunDetails struct {
+				Metadata   map[string]string
+				Byproducts []ResourceDescriptor
+			} `json:"runDetails"`
+		}
+	}
+	assert.NilError(t, json.Unmarshal(enc, &decoded))
+	assert.Equal(t, decoded.Type, StatementType)
+	assert.DeepEqual(t, decoded.Subject, []map[string]any{{"name": "foo"}})
+	assert.Equal(t, decoded.PredicateType, PredicateTypeSLSA)
+	assert.DeepEqual(t, decoded.Predicate.RunDetails.Metadata, map[string]string{
+		"startedOn":  "2024-01-01T10:00:00Z",
+		"finishedOn": "2024-01-01T11:00:00Z",
+	})
+	byproducts := decoded.Predicate.RunDetails.Byproducts
+	assert.Equal(t, byproducts[0].Name, "events/first")
+	assert.DeepEqual(t, byproducts[0].Digest, map[string]string{"sha256": "ab12"})
+	assert.Equal(t, byproducts[1].Name, "events/second")
+
+	_, err = NewStatement(predicate, "SPDX", nil)
+	assert.Error(t, err, `unknown provenance format "SPDX", expected SLSA or EPR`)
+}
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pubsub streams the messages of the server to the GraphQL subscriptions and the
+// Server-Sent Events of its API. The Broker gets each message once the transaction that stored
+// it commits and keeps the latest ones so that a subscriber can resume where it left off.
+package pubsub
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+)
+
+const (
+	// KindEventCreated is the kind of the messages announcing a new event
+	KindEventCreated = "event_created"
+	// KindEventReceiverGroupCompleted is the kind of the messages announcing that a group passed
+	// for an artifact
+	KindEventReceiverGroupCompleted = "event_receiver_group_completed"
+)
+
+const (
+	// DefaultHistorySize is the number of messages kept for subscribers that resume
+	DefaultHistorySize = 1000
+	// bufferSize is the number of messages a subscriber can fall behind before it is dropped
+	bufferSize = 64
+)
+
+// Kind returns the kind of the message, empty for the messages that are not streamed
+func Kind(msg message.Message) string {
+	switch {
+	case msg.Data.Gate != nil && len(msg.Data.EventReceiverGroups) > 0:
+		if msg.Success {
+			return KindEventReceiverGroupCompleted
+		}
+		return ""
+	case len(msg.Data.Events) > 0 && msg.Data.Events[0].Revocation == nil:
+		return KindEventCreated
+	default:
+		return ""
+	}
+}
+
+// Filter narrows a stream down to the messages that match each of its fields that is not empty
+type Filter struct {
+	Kinds                 []string
+	EventReceiverIDs      []graphql.ID
+	EventReceiverGroupIDs []graphql.ID
+	Name                  string
+	Version               string
+	Release               string
+	PlatformID            string
+	Package               string
+}
+
+// Matches reports whether the message is streamed and passes the filter
+func (f Filter) Matches(msg message.Message) bool {
+	kind := Kind(msg)
+	if kind == "" || (len(f.Kinds) > 0 && !slices.Contains(f.Kinds, kind)) {
+		return false
+	}
+	if len(f.EventReceiverIDs) > 0 && !containsAny(f.EventReceiverIDs, msg.EventReceiverIDs()) {
+		return false
+	}
+	if len(f.EventReceiverGroupIDs) > 0 && !containsAny(f.EventReceiverGroupIDs, msg.EventReceiverGroupIDs()) {
+		return false
+	}
+	for _, field := range [][2]string{
+		{f.Name, msg.Name},
+		{f.Version, msg.Version},
+		{f.Release, msg.Release},
+		{f.PlatformID, msg.PlatformID},
+		{f.Package, msg.Package},
+	} {
+		if field[0] != "" && field[0] != field[1] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(filter, ids []graphql.ID) bool {
+	for _, id := range ids {
+		if slices.Contains(filter, id) {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter   Filter
+	messages chan message.Message
+}
+
+// Broker is a TopicProducer that hands the messages it gets to its subscribers. It lives in
+// the server, so subscribers only get the messages of the requests that server handled.
+type Broker struct {
+	mu          sync.Mutex
+	history     []message.Message
+	historySize int
+	subscribers map[*subscriber]bool
+}
+
+// NewBroker returns a broker keeping the given number of messages for subscribers that resume
+func NewBroker(historySize int) *Broker {
+	return &Broker{historySize: historySize, subscribers: map[*subscriber]bool{}}
+}
+
+// Async publishes the message
+func (b *Broker) Async(data any) {
+	_ = b.Send(data)
+}
+
+// Send publishes the message. Anything but a Message is ignored.
+func (b *Broker) Send(data any) error {
+	if msg, ok := data.(message.Message); ok {
+		b.Publish(msg)
+	}
+	return nil
+}
+
+// Publish hands the message to the subscribers it matches without waiting for them. A
+// subscriber that fell too far behind is dropped, its channel is closed.
+func (b *Broker) Publish(msg message.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = slices.Delete(b.history, 0, 1)
+		}
+		b.history = append(b.history, msg)
+	}
+	for s := range b.subscribers {
+		if !s.filter.Matches(msg) {
+			continue
+		}
+		select {
+		case s.messages <- msg:
+		default:
+			slog.Warn("dropping stream subscriber that fell behind", "filter", s.filter)
+			b.unsubscribe(s)
+		}
+	}
+}
+
+// Subscribe returns the messages that match the filter until the context is done or the
+// subscriber falls behind, then the channel is closed. When lastID is not empty the kept
+// messages published after the one with that ID are sent first. An ID that is no longer kept
+// resumes from the oldest kept message with a greater ID.
+func (b *Broker) Subscribe(ctx context.Context, filter Filter, lastID string) <-chan message.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := []message.Message{}
+	if lastID != "" {
+		start := slices.IndexFunc(b.history, func(m message.Message) bool { return m.ID == lastID }) + 1
+		if start == 0 {
+			start = slices.IndexFunc(b.history, func(m message.Message) bool { return m.ID > lastID })
+			if start < 0 {
+				start = len(b.history)
+			}
+		}
+		for _, msg := range b.history[start:] {
+			if filter.Matches(msg) {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	s := &subscriber{filter: filter, messages: make(chan message.Message, bufferSize+len(replay))}
+	for _, msg := range replay {
+		s.messages <- msg
+	}
+	b.subscribers[s] = true
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(s)
+	}()
+	return s.messages
+}
+
+// unsubscribe closes the channel of the subscriber. Callers must hold the lock.
+func (b *Broker) unsubscribe(s *subscriber) {
+	if b.subscribers[s] {
+		delete(b.subscribers, s)
+		close(s.messages)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/gate"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func eventCreated(id string, receiverID graphql.ID, name string) message.Message {
+	return message.Message{
+		ID:   id,
+		Name: name,
+		Data: message.Data{Events: []storage.Event{{ID: graphql.ID(id), EventReceiverID: receiverID}}},
+	}
+}
+
+func receive(t *testing.T, messages <-chan message.Message, n int) []string {
+	t.Helper()
+	ids := []string{}
+	for i := 0; i < n; i++ {
+		msg, ok := <-messages
+		assert.Assert(t, ok)
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestKind(t *testing.T) {
+	group := []storage.EventReceiverGroup{{ID: "group"}}
+	tests := []struct {
+		name string
+		msg  message.Message
+		kind string
+	}{
+		{"event", eventCreated("1", "build", "foo"), KindEventCreated},
+		{"revoked event", message.Message{Data: message.Data{Events: []storage.Event{{Revocation: &storage.EventRevocation{}}}}}, ""},
+		{"group complete", message.Message{Success: true, Data: message.Data{Events: []storage.Event{{}}, EventReceiverGroups: group, Gate: &gate.Result{}}}, KindEventReceiverGroupCompleted},
+		{"group failed", message.Message{Data: message.Data{Events: []storage.Event{{}}, EventReceiverGroups: group, Gate: &gate.Result{}}}, ""},
+		{"receiver created", message.Message{Data: message.Data{EventReceivers: []storage.EventReceiver{{}}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Kind(tt.msg), tt.kind)
+		})
+	}
+}
+
+func TestBrokerFilters(t *testing.T) {
+	b := NewBroker(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := b.Subscribe(ctx, Filter{EventReceiverIDs: []graphql.ID{"build"}, Name: "foo"}, "")
+
+	assert.NilError(t, b.Send(eventCreated("1", "test", "foo")))
+	assert.NilError(t, b.Send(eventCreated("2", "build", "bar")))
+	assert.NilError(t, b.Send(eventCreated("3", "build", "foo")))
+	assert.NilError(t, b.Send("not a message"))
+	assert.DeepEqual(t, receive(t, messages, 1), []string{"3"})
+
+	cancel()
+	for range messages {
+	}
+}
+
+func TestBrokerResumes(t *testing.T) {
+	b := NewBroker(3)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		b.Publish(eventCreated(id, "build", "foo"))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.DeepEqual(t, receive(t, b.Subscribe(ctx, Filter{}, "2"), 2), []string{"3", "4"})
+	// the first message is no longer kept, the stream resumes from the oldest one after it
+	assert.DeepEqual(t, receive(t, b.Subscribe(ctx, Filter{}, "1"), 3), []string{"2", "3", "4"})
+
+	messages := b.Subscribe(ctx, Filter{}, "4")
+	b.Publish(eventCreated("5", "build", "foo"))
+	assert.DeepEqual(t, receive(t, messages, 1), []string{"5"})
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(0)
+	messages := b.Subscribe(context.Background(), Filter{}, "")
+	for i := 0; i <= bufferSize; i++ {
+		b.Publish(eventCreated("1", "build", "foo"))
+	}
+	assert.Equal(t, len(receive(t, messages, bufferSize)), bufferSize)
+	_, ok := <-messages
+	assert.Assert(t, !ok)
+}
@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sbom reads CycloneDX and SPDX JSON documents. A document is validated against the
+// official JSON schema of its format and spec version, vendored in the schemas directory, then
+// the artifact it describes and the components it lists are extracted.
+package sbom
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	// FormatCycloneDX is the format of CycloneDX documents
+	FormatCycloneDX = "CycloneDX"
+	// FormatSPDX is the format of SPDX documents
+	FormatSPDX = "SPDX"
+)
+
+//go:embed schemas
+var schemas embed.FS
+
+// bomSchemas are the schemas of the supported spec versions of each format
+var bomSchemas = map[string]map[string]string{
+	FormatCycloneDX: {
+		"1.4": "schemas/cyclonedx/bom-1.4.schema.json",
+		"1.5": "schemas/cyclonedx/bom-1.5.schema.json",
+		"1.6": "schemas/cyclonedx/bom-1.6.schema.json",
+	},
+	FormatSPDX: {
+		"2.3": "schemas/spdx/spdx-2.3.schema.json",
+	},
+}
+
+// referencedSchemas are the schemas the CycloneDX schemas refer to by their id, they are
+// loaded from the embedded files rather than fetched
+var referencedSchemas = []string{
+	"schemas/cyclonedx/spdx.schema.json",
+	"schemas/cyclonedx/jsf-0.82.schema.json",
+}
+
+// compiledSchemas compiles the schemas once, keyed by format and spec version
+var compiledSchemas = sync.OnceValues(func() (map[string]map[string]*gojsonschema.Schema, error) {
+	compiled := map[string]map[string]*gojsonschema.Schema{}
+	for format, versions := range bomSchemas {
+		compiled[format] = map[string]*gojsonschema.Schema{}
+		for version, path := range versions {
+			loader := gojsonschema.NewSchemaLoader()
+			for _, ref := range referencedSchemas {
+				raw, err := schemas.ReadFile(ref)
+				if err != nil {
+					return nil, err
+				}
+				if err := loader.AddSchemas(gojsonschema.NewBytesLoader(raw)); err != nil {
+					return nil, fmt.Errorf("failed to load schema %s: %w", ref, err)
+				}
+			}
+			raw, err := schemas.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			schema, err := loader.Compile(gojsonschema.NewBytesLoader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile schema %s: %w", path, err)
+			}
+			compiled[format][version] = schema
+		}
+	}
+	return compiled, nil
+})
+
+// Document is what the registry keeps of an SBOM
+type Document struct {
+	Format      string
+	SpecVersion string
+	// Name, Version and Package describe the artifact of the SBOM, they are empty when the
+	// document does not say
+	Name    string
+	Version string
+	Package string
+	// Components are the components the artifact contains, the artifact excluded
+	Components []storage.SBOMComponent
+}
+
+// Parse detects the format of the document, validates it and extracts the artifact and its
+// components
+func Parse(data []byte) (*Document, error) {
+	var probe struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM: %w", err)
+	}
+
+	switch {
+	case probe.BOMFormat != "":
+		if err := validate(FormatCycloneDX, probe.SpecVersion, data); err != nil {
+			return nil, err
+		}
+		return parseCycloneDX(data)
+	case probe.SPDXVersion != "":
+		if err := validate(FormatSPDX, strings.TrimPrefix(probe.SPDXVersion, "SPDX-"), data); err != nil {
+			return nil, err
+		}
+		return parseSPDX(data)
+	default:
+		return nil, errors.New("SBOM is neither a CycloneDX nor an SPDX JSON document")
+	}
+}
+
+// validate validates the document against the schema of its format and spec version
+func validate(format, version string, data []byte) error {
+	compiled, err := compiledSchemas()
+	if err != nil {
+		return fmt.Errorf("failed to validate SBOM: %w", err)
+	}
+	schema, ok := compiled[format][version]
+	if !ok {
+		supported := make([]string, 0, len(compiled[format]))
+		for v := range compiled[format] {
+			supported = append(supported, v)
+		}
+		slices.Sort(supported)
+		return fmt.Errorf("unsupported %s spec version %q, supported versions are %s",
+			format, version, strings.Join(supported, ", "))
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return fmt.Errorf("failed to validate SBOM: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	err = errors.New("SBOM does not match its schema")
+	for _, e := range result.Errors() {
+		err = errors.Join(err, errors.New(e.String()))
+	}
+	return err
+}
+
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	Group      string               `json:"group"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+func parseCycloneDX(data []byte) (*Document, error) {
+	var bom struct {
+		SpecVersion string `json:"specVersion"`
+		Metadata    struct {
+			Component *cycloneDXComponent `json:"component"`
+		} `json:"metadata"`
+		Components []cycloneDXComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM: %w", err)
+	}
+
+	doc := &Document{
+		Format:      FormatCycloneDX,
+		SpecVersion: bom.SpecVersion,
+		Components:  []storage.SBOMComponent{},
+	}
+	if root := bom.Metadata.Component; root != nil {
+		doc.Name = root.Name
+		doc.Version = root.Version
+		doc.Package = packageType(root.PURL, root.Type)
+	}
+
+	// components nest, e.g. the files of a library
+	var walk func(components []cycloneDXComponent)
+	walk = func(components []cycloneDXComponent) {
+		for _, c := range components {
+			doc.Components = append(doc.Components, storage.SBOMComponent{
+				Group:   c.Group,
+				Name:    c.Name,
+				Version: c.Version,
+				PURL:    c.PURL,
+				Type:    c.Type,
+			})
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+	return doc, nil
+}
+
+type spdxPackage struct {
+	SPDXID                string `json:"SPDXID"`
+	Name                  string `json:"name"`
+	VersionInfo           string `json:"versionInfo"`
+	PrimaryPackagePurpose string `json:"primaryPackagePurpose"`
+	ExternalRefs          []struct {
+		ReferenceType    string `json:"referenceType"`
+		ReferenceLocator string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+// purl returns the package URL among the external references of the package
+func (p spdxPackage) purl() string {
+	for _, ref := range p.ExternalRefs {
+		if ref.ReferenceType == "purl" {
+			return ref.ReferenceLocator
+		}
+	}
+	return ""
+}
+
+func parseSPDX(data []byte) (*Document, error) {
+	var spdx struct {
+		SPDXID            string        `json:"SPDXID"`
+		SPDXVersion       string        `json:"spdxVersion"`
+		Name              string        `json:"name"`
+		DocumentDescribes []string      `json:"documentDescribes"`
+		Packages          []spdxPackage `json:"packages"`
+		Relationships     []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM: %w", err)
+	}
+
+	// the document describes its artifact with documentDescribes since SPDX 2.2 and with a
+	// DESCRIBES relationship since SPDX 2.3
+	described := ""
+	if len(spdx.DocumentDescribes) > 0 {
+		described = spdx.DocumentDescribes[0]
+	}
+	for _, r := range spdx.Relationships {
+		if described == "" && r.SPDXElementID == spdx.SPDXID && r.RelationshipType == "DESCRIBES" {
+			described = r.RelatedSPDXElement
+		}
+	}
+
+	doc := &Document{
+		Format:      FormatSPDX,
+		SpecVersion: strings.TrimPrefix(spdx.SPDXVersion, "SPDX-"),
+		Name:        spdx.Name,
+		Components:  []storage.SBOMComponent{},
+	}
+	for _, p := range spdx.Packages {
+		purpose := strings.ToLower(strings.ReplaceAll(p.PrimaryPackagePurpose, "_", "-"))
+		if p.SPDXID == described {
+			doc.Name = p.Name
+			doc.Version = p.VersionInfo
+			doc.Package = packageType(p.purl(), purpose)
+			continue
+		}
+		doc.Components = append(doc.Components, storage.SBOMComponent{
+			Group:   purlNamespace(p.purl()),
+			Name:    p.Name,
+			Version: p.VersionInfo,
+			PURL:    p.purl(),
+			Type:    purpose,
+		})
+	}
+	return doc, nil
+}
+
+// packageType returns the type of the package URL, such as maven or oci, falling back to the
+// given type when there is no package URL
+func packageType(purl, fallback string) string {
+	if rest, ok := strings.CutPrefix(purl, "pkg:"); ok {
+		if typ, _, ok := strings.Cut(rest, "/"); ok {
+			return strings.ToLower(typ)
+		}
+	}
+	return fallback
+}
+
+// purlNamespace returns the namespace of the package URL, such as the Maven group id
+func purlNamespace(purl string) string {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return ""
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "@")
+	segments := strings.Split(rest, "/")
+	if len(segments) < 3 {
+		return ""
+	}
+	return strings.Join(segments[1:len(segments)-1], "/")
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func assertComponents(t *testing.T, got, want []storage.SBOMComponent) {
+	t.Helper()
+	assert.Equal(t, len(got), len(want))
+	for i := range want {
+		assert.Equal(t, got[i], want[i])
+	}
+}
+
+func TestParseCycloneDX(t *testing.T) {
+	data, err := os.ReadFile("testdata/cyclonedx.json")
+	assert.NilError(t, err)
+
+	doc, err := Parse(data)
+	assert.NilError(t, err)
+	assert.Equal(t, doc.Format, FormatCycloneDX)
+	assert.Equal(t, doc.SpecVersion, "1.5")
+	assert.Equal(t, doc.Name, "foo")
+	assert.Equal(t, doc.Version, "1.0.0")
+	assert.Equal(t, doc.Package, "maven")
+	assertComponents(t, doc.Components, []storage.SBOMComponent{
+		{
+			Group:   "org.apache.logging.log4j",
+			Name:    "log4j-core",
+			Version: "2.14.1",
+			PURL:    "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+			Type:    "library",
+		},
+		{Name: "log4j-core-2.14.1.jar", Type: "file"},
+		{
+			Group:   "org.apache.logging.log4j",
+			Name:    "log4j-api",
+			Version: "2.14.1",
+			PURL:    "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1",
+			Type:    "library",
+		},
+	})
+}
+
+func TestParseSPDX(t *testing.T) {
+	data, err := os.ReadFile("testdata/spdx.json")
+	assert.NilError(t, err)
+
+	doc, err := Parse(data)
+	assert.NilError(t, err)
+	assert.Equal(t, doc.Format, FormatSPDX)
+	assert.Equal(t, doc.SpecVersion, "2.3")
+	assert.Equal(t, doc.Name, "bar")
+	assert.Equal(t, doc.Version, "2.0.0")
+	assert.Equal(t, doc.Package, "oci")
+	assertComponents(t, doc.Components, []storage.SBOMComponent{
+		{
+			Group:   "org.apache.logging.log4j",
+			Name:    "log4j-core",
+			Version: "2.14.0",
+			PURL:    "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.0",
+			Type:    "library",
+		},
+	})
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		err  string
+	}{
+		"not json": {
+			data: `{`,
+			err:  "failed to parse SBOM",
+		},
+		"unknown format": {
+			data: `{"name": "foo"}`,
+			err:  "neither a CycloneDX nor an SPDX JSON document",
+		},
+		"cyclonedx component without name": {
+			data: `{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [{"type": "library"}]}`,
+			err:  "name is required",
+		},
+		"cyclonedx unknown component type": {
+			data: `{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [{"type": "jar", "name": "foo"}]}`,
+			err:  "components.0.type",
+		},
+		"cyclonedx 1.4 invalid serial number": {
+			data: `{"bomFormat": "CycloneDX", "specVersion": "1.4", "version": 1, "serialNumber": "foo"}`,
+			err:  "serialNumber: Does not match pattern",
+		},
+		"cyclonedx unsupported spec version": {
+			data: `{"bomFormat": "CycloneDX", "specVersion": "1.2"}`,
+			err:  `unsupported CycloneDX spec version "1.2", supported versions are 1.4, 1.5, 1.6`,
+		},
+		"spdx unsupported spec version": {
+			data: `{"SPDXID": "SPDXRef-DOCUMENT", "spdxVersion": "SPDX-2.2"}`,
+			err:  `unsupported SPDX spec version "2.2", supported versions are 2.3`,
+		},
+		"spdx without creation info": {
+			data: `{"SPDXID": "SPDXRef-DOCUMENT", "spdxVersion": "SPDX-2.3", "dataLicense": "CC0-1.0", "name": "foo", "documentNamespace": "https://example.com/foo"}`,
+			err:  "creationInfo is required",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signing signs events and verifies their signatures. A signed event comes with a DSSE
+// envelope, https://github.com/secure-systems-lab/dsse, with PayloadType as the payload type and
+// the JSON of the event fields the producer sets as the payload, see Payload. The signatures are
+// verified over the payload bytes of the envelope, which must then decode to the fields of the
+// event. Producers can use any DSSE library with an ed25519 or ECDSA key to make one.
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "crypto/sha512" // registers the hashes of the P-384 and P-521 curves
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+)
+
+// PayloadType is the DSSE payload type of a signed event
+const PayloadType = "application/vnd.epr.event+json"
+
+// Envelope is a DSSE envelope over an event
+type Envelope struct {
+	PayloadType string `json:"payloadType"`
+	// Payload is the base64 encoded JSON of the signed event fields
+	Payload    string      `json:"payload"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope
+type Signature struct {
+	// KeyID optionally names the key, the hex encoded SHA-256 of its PKIX DER encoding
+	KeyID string `json:"keyid,omitempty"`
+	// Sig is the base64 encoded signature
+	Sig string `json:"sig"`
+}
+
+// Payload returns the JSON of the event fields a producer signs: the payload and the other
+// fields the producer sets. Numbers and strings of the payload are kept as they are.
+func Payload(e storage.Event) ([]byte, error) {
+	var payload any
+	if len(e.Payload.JSON) > 0 {
+		var err error
+		if payload, err = decode(e.Payload.JSON); err != nil {
+			return nil, fmt.Errorf("failed to parse payload: %w", err)
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(map[string]any{
+		"name":              e.Name,
+		"version":           e.Version,
+		"release":           e.Release,
+		"platform_id":       e.PlatformID,
+		"package":           e.Package,
+		"description":       e.Description,
+		"payload":           payload,
+		"success":           e.Success,
+		"event_receiver_id": e.EventReceiverID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Match checks that the signed payload holds the fields of the event and nothing else. Numbers
+// match when they have the same value, whatever their notation.
+func Match(e storage.Event, payload []byte) error {
+	signed, err := decode(payload)
+	if err != nil {
+		return fmt.Errorf("signed payload is not JSON: %w", err)
+	}
+	fields, ok := signed.(map[string]any)
+	if !ok {
+		return errors.New("signed payload is not a JSON object")
+	}
+	b, err := Payload(e)
+	if err != nil {
+		return err
+	}
+	expected, err := decode(b)
+	if err != nil {
+		return err
+	}
+
+	var mismatched []string
+	for name, value := range expected.(map[string]any) {
+		if signedValue, ok := fields[name]; !ok || !equal(signedValue, value) {
+			mismatched = append(mismatched, name)
+		}
+	}
+	for name := range fields {
+		if _, ok := expected.(map[string]any)[name]; !ok {
+			mismatched = append(mismatched, name)
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return fmt.Errorf("signed payload does not match the event: %s", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+// decode parses the JSON document keeping its numbers as they are written
+func decode(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return v, nil
+}
+
+// equal compares decoded JSON values
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		return ok && decimal(a) == decimal(b)
+	default:
+		return a == b
+	}
+}
+
+// decimal returns the value of the JSON number as its sign, its significant digits and their
+// exponent, which is the same for every notation of the value
+func decimal(n json.Number) string {
+	s := string(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			// the exponent is out of range, only the same notation matches
+			return string(n)
+		}
+		s, exp = s[:i], e
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(s, "0")
+	exp += len(s) - len(trimmed)
+	return fmt.Sprintf("%s%se%d", sign, trimmed, exp)
+}
+
+// PAE is the DSSE pre-authentication encoding of the payload, the bytes that are signed
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// ParsePublicKey parses a PEM encoded PKIX ed25519 or ECDSA public key
+func ParsePublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T, expected ed25519 or ECDSA", key)
+	}
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS #8 ed25519 or ECDSA private key
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected ed25519 or ECDSA", key)
+	}
+}
+
+// KeyID returns the hex encoded SHA-256 of the PKIX DER encoding of the public key
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Sign signs the event with the ed25519 or ECDSA private key
+func Sign(e storage.Event, key crypto.Signer) (*Envelope, error) {
+	payload, err := Payload(e)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	message := PAE(PayloadType, payload)
+
+	var sig []byte
+	switch pub := key.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		h := hashFor(pub.Curve)
+		digest := h.New()
+		digest.Write(message)
+		sig, err = key.Sign(rand.Reader, digest.Sum(nil), h)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected ed25519 or ECDSA", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// Verify checks the signatures of the envelope against the trusted PEM encoded public keys,
+// then that its payload holds the fields of the event. A signature that names a key is only
+// tried with that key. The returned signature carries the signature and the id of the key that
+// verified, or the reason none did.
+func Verify(e storage.Event, envelope Envelope, trusted []string) storage.EventSignature {
+	signature := storage.EventSignature{Payload: envelope.Payload}
+	if len(envelope.Signatures) > 0 {
+		signature.KeyID = envelope.Signatures[0].KeyID
+		signature.Signature = envelope.Signatures[0].Sig
+	}
+
+	if envelope.PayloadType != PayloadType {
+		signature.Reason = fmt.Sprintf("payload type %q is not %s", envelope.PayloadType, PayloadType)
+		return signature
+	}
+	payload, err := decodeBase64(envelope.Payload)
+	if err != nil {
+		signature.Reason = "payload is not base64 encoded"
+		return signature
+	}
+	message := PAE(envelope.PayloadType, payload)
+
+	tried, encoded := 0, 0
+	for _, s := range envelope.Signatures {
+		sig, err := decodeBase64(s.Sig)
+		if err != nil {
+			continue
+		}
+		encoded++
+		for _, data := range trusted {
+			key, err := ParsePublicKey(data)
+			if err != nil {
+				continue
+			}
+			keyID, err := KeyID(key)
+			if err != nil || (s.KeyID != "" && s.KeyID != keyID) {
+				continue
+			}
+			tried++
+			if !verify(key, message, sig) {
+				continue
+			}
+			signature.KeyID = keyID
+			signature.Signature = s.Sig
+			if err := Match(e, payload); err != nil {
+				signature.Reason = err.Error()
+				return signature
+			}
+			signature.Verified = true
+			return signature
+		}
+	}
+
+	switch {
+	case len(envelope.Signatures) == 0:
+		signature.Reason = "envelope has no signatures"
+	case encoded == 0:
+		signature.Reason = "signature is not base64 encoded"
+	case len(trusted) == 0:
+		signature.Reason = "event receiver has no trusted public keys"
+	case tried == 0:
+		signature.Reason = fmt.Sprintf("no trusted public key with id %s", signature.KeyID)
+	default:
+		signature.Reason = "signature does not verify with the trusted public keys"
+	}
+	return signature
+}
+
+// decodeBase64 decodes standard or URL safe base64, padded or not, which DSSE both allows
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func verify(key crypto.PublicKey, message, sig []byte) bool {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, sig)
+	case *ecdsa.PublicKey:
+		digest := hashFor(key.Curve).New()
+		digest.Write(message)
+		return ecdsa.VerifyASN1(key, digest.Sum(nil), sig)
+	default:
+		return false
+	}
+}
+
+// hashFor returns the hash ECDSA signatures use with the curve
+func hashFor(curve elliptic.Curve) crypto.Hash {
+	switch curve.Params().BitSize {
+	case 384:
+		return crypto.SHA384
+	case 521:
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NilError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func testEvent() storage.Event {
+	return storage.Event{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{"sha": "abc", "count": 1}`)},
+		Success:         true,
+		EventReceiverID: "01HGDZ1D3KPZHYADNSJC4K4BQF",
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NilError(t, err)
+
+	for _, key := range []crypto.Signer{edKey, p256, p384} {
+		trusted := []string{publicKeyPEM(t, key.Public())}
+		event := testEvent()
+		envelope, err := Sign(event, key)
+		assert.NilError(t, err)
+		assert.Equal(t, envelope.PayloadType, PayloadType)
+
+		// the payload of the event only has to have the same value as the signed one
+		event.Payload = types.JSON{JSON: []byte(`{"count":1.0,"sha":"abc"}`)}
+		result := Verify(event, *envelope, trusted)
+		assert.Assert(t, result.Verified, result.Reason)
+		assert.Equal(t, result.KeyID, envelope.Signatures[0].KeyID)
+		assert.Equal(t, result.Payload, envelope.Payload)
+
+		// the key id is optional
+		anonymous := *envelope
+		anonymous.Signatures = []Signature{{Sig: envelope.Signatures[0].Sig}}
+		result = Verify(event, anonymous, trusted)
+		assert.Assert(t, result.Verified, result.Reason)
+		assert.Equal(t, result.KeyID, envelope.Signatures[0].KeyID)
+
+		event.Success = false
+		result = Verify(event, *envelope, trusted)
+		assert.Assert(t, !result.Verified)
+		assert.Equal(t, result.Reason, "signed payload does not match the event: success")
+
+		tampered := *envelope
+		tampered.Payload = base64.StdEncoding.EncodeToString([]byte(`{}`))
+		result = Verify(event, tampered, trusted)
+		assert.Assert(t, !result.Verified)
+		assert.Equal(t, result.Reason, "signature does not verify with the trusted public keys")
+	}
+}
+
+func TestVerifyUntrusted(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	envelope, err := Sign(testEvent(), key)
+	assert.NilError(t, err)
+	trusted := []string{publicKeyPEM(t, other)}
+
+	result := Verify(testEvent(), *envelope, nil)
+	assert.Equal(t, result.Reason, "event receiver has no trusted public keys")
+	result = Verify(testEvent(), *envelope, trusted)
+	assert.Equal(t, result.Reason, "no trusted public key with id "+envelope.Signatures[0].KeyID)
+	result = Verify(testEvent(), Envelope{PayloadType: PayloadType, Payload: envelope.Payload, Signatures: []Signature{{Sig: "%%%"}}}, trusted)
+	assert.Equal(t, result.Reason, "signature is not base64 encoded")
+	result = Verify(testEvent(), Envelope{PayloadType: PayloadType, Payload: envelope.Payload}, trusted)
+	assert.Equal(t, result.Reason, "envelope has no signatures")
+	result = Verify(testEvent(), Envelope{PayloadType: "application/json", Payload: envelope.Payload, Signatures: envelope.Signatures}, trusted)
+	assert.Equal(t, result.Reason, `payload type "application/json" is not `+PayloadType)
+}
+
+func TestPayload(t *testing.T) {
+	event := testEvent()
+	event.Payload = types.JSON{JSON: []byte(`{"url": "https://example.com/?a=1&b=<2>", "size": 12345678901234567890, "ratio": 1.50}`)}
+	payload, err := Payload(event)
+	assert.NilError(t, err)
+	assert.Equal(t, string(payload), `{"description":"test event","event_receiver_id":"01HGDZ1D3KPZHYADNSJC4K4BQF","name":"foo",`+
+		`"package":"rpm","payload":{"ratio":1.50,"size":12345678901234567890,"url":"https://example.com/?a=1&b=<2>"},`+
+		`"platform_id":"x86-64-gnu-linux-7","release":"20240101","success":true,"version":"1.0.0"}`)
+	assert.NilError(t, Match(event, payload))
+}
+
+func TestMatch(t *testing.T) {
+	event := testEvent()
+	event.Payload = types.JSON{JSON: []byte(`{"count": 100, "ratio": 0.5, "list": [1, "a", null]}`)}
+	payload, err := Payload(event)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+		err     string
+	}{
+		{"same value", `{"count": 1e2, "ratio": 5E-1, "list": [1.0, "a", null]}`, ""},
+		{"other number", `{"count": 101, "ratio": 0.5, "list": [1, "a", null]}`, "signed payload does not match the event: payload"},
+		{"other list", `{"count": 100, "ratio": 0.5, "list": [1, "a"]}`, "signed payload does not match the event: payload"},
+		{"number as string", `{"count": "100", "ratio": 0.5, "list": [1, "a", null]}`, "signed payload does not match the event: payload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := testEvent()
+			other.Payload = types.JSON{JSON: []byte(tt.payload)}
+			signed, err := Payload(other)
+			assert.NilError(t, err)
+			err = Match(event, signed)
+			if tt.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tt.err)
+			}
+		})
+	}
+
+	assert.Error(t, Match(event, []byte(`[]`)), "signed payload is not a JSON object")
+	extra := bytes.Replace(payload, []byte(`"name"`), []byte(`"extra":1,"name"`), 1)
+	assert.Error(t, Match(event, extra), "signed payload does not match the event: extra")
+	missing := bytes.Replace(payload, []byte(`"name":"foo",`), nil, 1)
+	assert.Error(t, Match(event, missing), "signed payload does not match the event: name")
+}
+
+func TestParseKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NilError(t, err)
+	_, err = ParsePublicKey(publicKeyPEM(t, rsaKey.Public()))
+	assert.ErrorContains(t, err, "unsupported public key type *rsa.PublicKey")
+	_, err = ParsePublicKey("not a key")
+	assert.Error(t, err, "public key is not PEM encoded")
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	assert.NilError(t, err)
+	signer, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, signer.Public(), edKey.Public())
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// chainedEvent is the canonical form of an event that is hashed. The fields are marshaled in
+// this order and the payload with sorted keys, so the hash does not depend on how the database
+// returns the JSON.
+type chainedEvent struct {
+	ID              graphql.ID      `json:"id"`
+	Name            string          `json:"name"`
+	Version         string          `json:"version"`
+	Release         string          `json:"release"`
+	PlatformID      string          `json:"platform_id"`
+	Package         string          `json:"package"`
+	Description     string          `json:"description"`
+	Payload         json.RawMessage `json:"payload"`
+	Success         bool            `json:"success"`
+	CreatedAt       string          `json:"created_at"`
+	EventReceiverID graphql.ID      `json:"event_receiver_id"`
+	SchemaRevision  int32           `json:"schema_revision"`
+	Sequence        int32           `json:"sequence"`
+	PreviousHash    string          `json:"previous_hash"`
+	Signature       *EventSignature `json:"signature,omitempty"`
+}
+
+// ContentHash returns the hex encoded SHA-256 of the canonical JSON of the event, which covers
+// the previous hash of its chain. The receiver, the revocation and the hash itself are left out.
+func (e Event) ContentHash() (string, error) {
+	payload, err := canonicalJSON(e.Payload.JSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize payload of event %s: %w", e.ID, err)
+	}
+	b, err := json.Marshal(chainedEvent{
+		ID:              e.ID,
+		Name:            e.Name,
+		Version:         e.Version,
+		Release:         e.Release,
+		PlatformID:      e.PlatformID,
+		Package:         e.Package,
+		Description:     e.Description,
+		Payload:         payload,
+		Success:         e.Success,
+		CreatedAt:       time.Time(e.CreatedAt.Date).UTC().Format(time.RFC3339Nano),
+		EventReceiverID: e.EventReceiverID,
+		SchemaRevision:  e.SchemaRevision,
+		Sequence:        e.Sequence,
+		PreviousHash:    e.PreviousHash,
+		Signature:       e.Signature,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Limits of the numeric type of Postgres, which jsonb stores numbers as
+const (
+	maxNumericDigits = 131072
+	maxNumericScale  = 16383
+)
+
+// canonicalJSON re-encodes the document with sorted keys and without insignificant whitespace.
+// Numbers are rewritten the way jsonb stores them, as decimals that keep their scale: 1.0 stays
+// 1.0 while 1e2 becomes 100 and 1.50e1 becomes 15.0.
+func canonicalJSON(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return []byte("null"), nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	v, err := canonicalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// canonicalValue rewrites the numbers of the decoded document, json.Marshal sorts the keys
+func canonicalValue(v any) (any, error) {
+	var err error
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if v[k], err = canonicalValue(item); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if v[i], err = canonicalValue(item); err != nil {
+				return nil, err
+			}
+		}
+	case json.Number:
+		return canonicalNumber(v)
+	}
+	return v, nil
+}
+
+// canonicalNumber returns the number as the numeric type of Postgres prints it: without an
+// exponent, with the digits after the point that the number has once its exponent is applied.
+// Negative zero is zero.
+func canonicalNumber(n json.Number) (json.Number, error) {
+	s := string(n)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		if exponent, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return "", fmt.Errorf("number %s is out of range", n)
+		}
+	}
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+
+	digits := integer + fraction
+	// point is the position of the decimal point in the digits
+	point := int64(len(integer)) + exponent
+	scale := max(int64(len(fraction))-exponent, 0)
+	if point > maxNumericDigits || scale > maxNumericScale {
+		return "", fmt.Errorf("number %s is out of range", n)
+	}
+	switch {
+	case point <= 0:
+		integer, fraction = "0", strings.Repeat("0", int(-point))+digits
+	case point >= int64(len(digits)):
+		integer, fraction = digits+strings.Repeat("0", int(point)-len(digits)), ""
+	default:
+		integer, fraction = digits[:point], digits[point:]
+	}
+	integer = strings.TrimLeft(integer, "0")
+	if integer == "" {
+		integer = "0"
+	}
+
+	canonical := integer
+	if scale > 0 {
+		canonical += "." + fraction
+	}
+	if negative && strings.Trim(integer+fraction, "0") != "" {
+		canonical = "-" + canonical
+	}
+	return json.Number(canonical), nil
+}
+
+// chain links the event to the head of the chain of its receiver, nil when it is the first
+// event, and sets its hash
+func chain(event *Event, head *Event) error {
+	event.Sequence = 1
+	event.PreviousHash = ""
+	if head != nil {
+		event.Sequence = head.Sequence + 1
+		event.PreviousHash = head.Hash
+	}
+	hash, err := event.ContentHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+	return nil
+}
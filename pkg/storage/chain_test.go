@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	event := Event{ID: "01HGDYVD995K6F24SAW6GP17HZ", Name: "test", Payload: types.JSON{JSON: []byte(`{"b": 1.50e1, "a": "x"}`)}}
+	hash, err := event.ContentHash()
+	assert.NilError(t, err)
+	assert.Equal(t, len(hash), 64)
+
+	// the database may return the payload with other key order, spacing and number formats
+	event.Payload = types.JSON{JSON: []byte(`{"a":"x","b":15.0}`)}
+	same, err := event.ContentHash()
+	assert.NilError(t, err)
+	assert.Equal(t, same, hash)
+
+	// jsonb keeps the scale of numbers
+	event.Payload = types.JSON{JSON: []byte(`{"a":"x","b":15}`)}
+	other, err := event.ContentHash()
+	assert.NilError(t, err)
+	assert.Assert(t, other != hash)
+	event.Payload = types.JSON{JSON: []byte(`{"a":"x","b":1.50e1}`)}
+
+	event.PreviousHash = hash
+	linked, err := event.ContentHash()
+	assert.NilError(t, err)
+	assert.Assert(t, linked != hash)
+
+	event.Payload = types.JSON{JSON: []byte(`{`)}
+	_, err = event.ContentHash()
+	assert.ErrorContains(t, err, "failed to canonicalize payload of event 01HGDYVD995K6F24SAW6GP17HZ")
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{`1`, `1`},
+		{`1.0`, `1.0`},
+		{`1.50`, `1.50`},
+		{`1e2`, `100`},
+		{`1E+2`, `100`},
+		{`1.50e1`, `15.0`},
+		{`1.5e-3`, `0.0015`},
+		{`-0.0`, `0.0`},
+		{`-12.340e-1`, `-1.2340`},
+		{`0.000`, `0.000`},
+		{`12345678901234567890123`, `12345678901234567890123`},
+		{`9007199254740993`, `9007199254740993`},
+		{`{"b": [1e0, {"d": 2.0}], "a": null}`, `{"a":null,"b":[1,{"d":2.0}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := canonicalJSON([]byte(tt.in))
+			assert.NilError(t, err)
+			assert.Equal(t, string(out), tt.out)
+		})
+	}
+
+	_, err := canonicalJSON([]byte(`1e1000000`))
+	assert.ErrorContains(t, err, "number 1e1000000 is out of range")
+	_, err = canonicalJSON([]byte(`{} {}`))
+	assert.ErrorContains(t, err, "after top-level value")
+}
+
+func TestMemoryEventChain(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	test := newTestReceiver(t, m, "test")
+
+	first := newTestEvent(t, m, build.ID, true)
+	assert.Equal(t, first.Sequence, int32(1))
+	assert.Equal(t, first.PreviousHash, "")
+	hash, err := first.ContentHash()
+	assert.NilError(t, err)
+	assert.Equal(t, first.Hash, hash)
+
+	other := newTestEvent(t, m, test.ID, true)
+	assert.Equal(t, other.Sequence, int32(1))
+
+	second := newTestEvent(t, m, build.ID, false)
+	assert.Equal(t, second.Sequence, int32(2))
+	assert.Equal(t, second.PreviousHash, first.Hash)
+
+	events, err := m.FindEventChain(build.ID, 0, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[1].ID, second.ID)
+
+	events, err = m.FindEventChain(build.ID, 1, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	events, err = m.FindEventChain(build.ID, 0, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, events[0].ID, first.ID)
+}
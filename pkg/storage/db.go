@@ -4,9 +4,6 @@
 package storage
 
 import (
-	"database/sql"
-	_ "embed"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -22,11 +19,16 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	gormlog "gorm.io/gorm/logger"
 )
 
+// Database is the Postgres implementation of Repository
 type Database struct {
 	Client *gorm.DB
+
+	// MaxPageSize bounds the number of records a paginated search returns
+	MaxPageSize int
 }
 
 func New(host, user, pass, sslMode, database string, port int) (*Database, error) {
@@ -50,13 +52,164 @@ func New(host, user, pass, sslMode, database string, port int) (*Database, error
 	return &Database{Client: client}, err
 }
 
-func (db *Database) SyncSchema() error {
-	return db.Client.AutoMigrate(
-		new(Event),
-		new(EventReceiver),
-		new(EventReceiverGroup),
-		new(EventReceiverGroupToEventReceiver),
-	)
+// CreateEvent implements Repository using the database client
+func (db *Database) CreateEvent(event Event) (*Event, error) {
+	return CreateEvent(db.Client, event)
+}
+
+// FindEventByID implements Repository using the database client
+func (db *Database) FindEventByID(id graphql.ID) ([]Event, error) {
+	return FindEventByID(db.Client, id)
+}
+
+// FindEvent implements Repository using the database client
+func (db *Database) FindEvent(e map[string]any) ([]Event, error) {
+	return FindEvent(db.Client, e)
+}
+
+// FindEventPage implements Repository using the database client
+func (db *Database) FindEventPage(filter EventFilter, page Page) (*Paginated[Event], error) {
+	return FindEventPage(db.Client, filter, page, db.MaxPageSize)
+}
+
+// FindEventChain implements Repository using the database client
+func (db *Database) FindEventChain(eventReceiverID graphql.ID, after int32, limit int) ([]Event, error) {
+	return FindEventChain(db.Client, eventReceiverID, after, limit)
+}
+
+// FindSBOMComponentPage implements Repository using the database client
+func (db *Database) FindSBOMComponentPage(filter SBOMComponentFilter, page Page) (*Paginated[SBOMComponent], error) {
+	return FindSBOMComponentPage(db.Client, filter, page, db.MaxPageSize)
+}
+
+// CreateEventReceiver implements Repository using the database client
+func (db *Database) CreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, error) {
+	return CreateEventReceiver(db.Client, eventReceiver)
+}
+
+// FindEventReceiverByID implements Repository using the database client
+func (db *Database) FindEventReceiverByID(id graphql.ID) ([]EventReceiver, error) {
+	return FindEventReceiverByID(db.Client, id)
+}
+
+// FindEventReceiver implements Repository using the database client
+func (db *Database) FindEventReceiver(er map[string]any) ([]EventReceiver, error) {
+	return FindEventReceiver(db.Client, er)
+}
+
+// FindEventReceiverPage implements Repository using the database client
+func (db *Database) FindEventReceiverPage(er map[string]any, page Page) (*Paginated[EventReceiver], error) {
+	return FindEventReceiverPage(db.Client, er, page, db.MaxPageSize)
+}
+
+// RevokeEvent implements Repository using the database client
+func (db *Database) RevokeEvent(revocation EventRevocation) (*Event, error) {
+	return RevokeEvent(db.Client, revocation)
+}
+
+// FindOrCreateEventReceiver implements Repository using the database client
+func (db *Database) FindOrCreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, bool, error) {
+	return FindOrCreateEventReceiver(db.Client, eventReceiver)
+}
+
+// CreateEventReceiverSchema implements Repository using the database client
+func (db *Database) CreateEventReceiverSchema(eventReceiverSchema EventReceiverSchema) (*EventReceiverSchema, error) {
+	return CreateEventReceiverSchema(db.Client, eventReceiverSchema)
+}
+
+// FindEventReceiverSchemas implements Repository using the database client
+func (db *Database) FindEventReceiverSchemas(id graphql.ID) ([]EventReceiverSchema, error) {
+	return FindEventReceiverSchemas(db.Client, id)
+}
+
+// SetEventReceiverSigning implements Repository using the database client
+func (db *Database) SetEventReceiverSigning(id graphql.ID, publicKeys []string, requireSignature bool) (*EventReceiver, error) {
+	return SetEventReceiverSigning(db.Client, id, publicKeys, requireSignature)
+}
+
+// CreateEventReceiverGroup implements Repository using the database client
+func (db *Database) CreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, error) {
+	return CreateEventReceiverGroup(db.Client, eventReceiverGroup)
+}
+
+// FindOrCreateEventReceiverGroup implements Repository using the database client
+func (db *Database) FindOrCreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, bool, error) {
+	return FindOrCreateEventReceiverGroup(db.Client, eventReceiverGroup)
+}
+
+// FindEventReceiverGroupByID implements Repository using the database client
+func (db *Database) FindEventReceiverGroupByID(id graphql.ID) ([]EventReceiverGroup, error) {
+	return FindEventReceiverGroupByID(db.Client, id)
+}
+
+// FindEventReceiverGroup implements Repository using the database client
+func (db *Database) FindEventReceiverGroup(erg map[string]any) ([]EventReceiverGroup, error) {
+	return FindEventReceiverGroup(db.Client, erg)
+}
+
+// FindEventReceiverGroupPage implements Repository using the database client
+func (db *Database) FindEventReceiverGroupPage(erg map[string]any, page Page) (*Paginated[EventReceiverGroup], error) {
+	return FindEventReceiverGroupPage(db.Client, erg, page, db.MaxPageSize)
+}
+
+// SetEventReceiverGroupEnabled implements Repository using the database client
+func (db *Database) SetEventReceiverGroupEnabled(id graphql.ID, enabled bool) error {
+	return SetEventReceiverGroupEnabled(db.Client, id, enabled)
+}
+
+// UpdateEventReceiverGroup implements Repository using the database client
+func (db *Database) UpdateEventReceiverGroup(id graphql.ID, update EventReceiverGroupUpdate) (*EventReceiverGroup, error) {
+	return UpdateEventReceiverGroup(db.Client, id, update)
+}
+
+// DeleteEventReceiverGroup implements Repository using the database client
+func (db *Database) DeleteEventReceiverGroup(id graphql.ID) error {
+	return DeleteEventReceiverGroup(db.Client, id)
+}
+
+// FindEventReceiverGroupsByEventReceiverID implements Repository using the database client
+func (db *Database) FindEventReceiverGroupsByEventReceiverID(id graphql.ID) ([]EventReceiverGroup, error) {
+	return FindEventReceiverGroupsByEventReceiverID(db.Client, id)
+}
+
+// FindEventReceiversByIDs implements Repository using the database client
+func (db *Database) FindEventReceiversByIDs(ids []graphql.ID) ([]EventReceiver, error) {
+	return FindEventReceiversByIDs(db.Client, ids)
+}
+
+// FindLatestEvents implements Repository using the database client
+func (db *Database) FindLatestEvents(eventReceiverIDs []graphql.ID, tuple Event) ([]Event, error) {
+	return FindLatestEvents(db.Client, eventReceiverIDs, tuple)
+}
+
+// FindEventTuples implements Repository using the database client
+func (db *Database) FindEventTuples(eventReceiverIDs []graphql.ID) ([]Event, error) {
+	return FindEventTuples(db.Client, eventReceiverIDs)
+}
+
+// SaveEventReceiverGroupCompletion implements Repository using the database client
+func (db *Database) SaveEventReceiverGroupCompletion(completion EventReceiverGroupCompletion) (*EventReceiverGroupCompletion, error) {
+	return SaveEventReceiverGroupCompletion(db.Client, completion)
+}
+
+// FindEventReceiverGroupCompletions implements Repository using the database client
+func (db *Database) FindEventReceiverGroupCompletions(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupCompletion, error) {
+	return FindEventReceiverGroupCompletions(db.Client, id, tuple)
+}
+
+// LockEventReceiverGroup implements Repository using the database client
+func (db *Database) LockEventReceiverGroup(id graphql.ID, tuple Event) error {
+	return LockEventReceiverGroup(db.Client, id, tuple)
+}
+
+// SaveEventReceiverGroupState implements Repository using the database client
+func (db *Database) SaveEventReceiverGroupState(state EventReceiverGroupState) (*EventReceiverGroupState, error) {
+	return SaveEventReceiverGroupState(db.Client, state)
+}
+
+// FindEventReceiverGroupStates implements Repository using the database client
+func (db *Database) FindEventReceiverGroupStates(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupState, error) {
+	return FindEventReceiverGroupStates(db.Client, id, tuple)
 }
 
 // CreateEvent creates and event record in the database. Throws an error if the event receiver does not exist or if the
@@ -81,15 +234,87 @@ func CreateEvent(tx *gorm.DB, event Event) (*Event, error) {
 		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
 	}
 	event.ID = graphql.ID(utils.NewULIDAsString())
+	event.CreatedAt = now()
+	event.SchemaRevision = receiver.SchemaRevision
+	for i := range event.Components {
+		event.Components[i].ID = graphql.ID(utils.NewULIDAsString())
+		event.Components[i].CreatedAt = event.CreatedAt
+	}
 
-	results := tx.Create(&event)
-	if results.Error != nil {
-		return nil, pgError(results.Error)
+	// append the event to the chain of its receiver while holding its lock
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		head, err := lockEventChain(tx, event.EventReceiverID)
+		if err != nil {
+			return err
+		}
+		if err := chain(&event, head); err != nil {
+			return err
+		}
+		return pgError(tx.Create(&event).Error)
+	})
+	if err != nil {
+		return nil, err
 	}
 	event.EventReceiver = receiver
 	return &event, nil
 }
 
+// lockEventChain takes a transaction scoped lock on the chain of the receiver and returns its
+// last event, nil when it has none
+func lockEventChain(tx *gorm.DB, eventReceiverID graphql.ID) (*Event, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "event chain "+string(eventReceiverID)).Error; err != nil {
+		return nil, pgError(err)
+	}
+	heads := []Event{}
+	result := tx.Where("event_receiver_id = ?", eventReceiverID).Order("sequence DESC").Limit(1).Find(&heads)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	if len(heads) == 0 {
+		return nil, nil
+	}
+	return &heads[0], nil
+}
+
+// FindEventChain returns up to limit events of the receiver chain after the sequence, in order
+func FindEventChain(tx *gorm.DB, eventReceiverID graphql.ID, after int32, limit int) ([]Event, error) {
+	events := []Event{}
+	result := tx.Where("event_receiver_id = ? AND sequence > ?", eventReceiverID, after).
+		Order("sequence").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return events, nil
+}
+
+// RevokeEvent records the revocation of the event and returns the event with it
+func RevokeEvent(tx *gorm.DB, revocation EventRevocation) (*Event, error) {
+	var event *Event
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		events, err := FindEventByID(tx, revocation.EventID)
+		if err != nil {
+			return err
+		}
+		event = &events[0]
+		if event.Revocation != nil {
+			return eprErrors.InvalidInputError{Msg: fmt.Sprintf("event with id %s is already revoked", revocation.EventID)}
+		}
+		revocation.RevokedAt = now()
+		result := tx.Create(&revocation)
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		event.Revocation = &revocation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
 func FindEventByID(tx *gorm.DB, id graphql.ID) ([]Event, error) {
 	events, err := FindEvent(tx, map[string]any{"id": id})
 	if err != nil {
@@ -106,27 +331,59 @@ func FindEventByID(tx *gorm.DB, id graphql.ID) ([]Event, error) {
 
 func FindEvent(tx *gorm.DB, e map[string]any) ([]Event, error) {
 	var events []Event
-	result := tx.Model(&Event{}).Preload("EventReceiver").Where(e).Find(&events)
+	result := tx.Model(&Event{}).Preload("EventReceiver").Preload("Revocation").Where(e).Find(&events)
 	if result.Error != nil {
 		return nil, pgError(result.Error)
 	}
 	return events, nil
 }
 
+// FindEventPage returns one page of the events matching the filter
+func FindEventPage(tx *gorm.DB, filter EventFilter, page Page, maxPageSize int) (*Paginated[Event], error) {
+	query, err := filter.apply(tx.Model(&Event{}).Preload("EventReceiver").Preload("Revocation"))
+	if err != nil {
+		return nil, err
+	}
+	return paginate(query, "events", page, maxPageSize, func(e Event) graphql.ID { return e.ID })
+}
+
+// FindSBOMComponentPage returns one page of the SBOM components matching the filter
+func FindSBOMComponentPage(tx *gorm.DB, filter SBOMComponentFilter, page Page, maxPageSize int) (*Paginated[SBOMComponent], error) {
+	query, err := filter.apply(tx.Model(&SBOMComponent{}).Preload("Event").Preload("Event.EventReceiver").Preload("Event.Revocation"))
+	if err != nil {
+		return nil, err
+	}
+	return paginate(query, "sbom_components", page, maxPageSize, func(c SBOMComponent) graphql.ID { return c.ID })
+}
+
 func CreateEventReceiver(tx *gorm.DB, eventReceiver EventReceiver) (*EventReceiver, error) {
 	eventReceiver.ID = graphql.ID(utils.NewULIDAsString())
-
-	seed := utils.Seed{
-		Name:        eventReceiver.Name,
-		Type:        eventReceiver.Type,
-		Version:     eventReceiver.Version,
-		Description: eventReceiver.Description,
+	eventReceiver.Fingerprint = fingerprint(eventReceiver.Name, eventReceiver.Type, eventReceiver.Version, eventReceiver.Description)
+	eventReceiver.SchemaRevision = 1
+	if eventReceiver.PublicKeys == nil {
+		eventReceiver.PublicKeys = []string{}
 	}
-	eventReceiver.Fingerprint = seed.Fingerprint()
 
-	result := tx.Create(&eventReceiver)
-	if result.Error != nil {
-		return nil, pgError(result.Error)
+	// create the receiver and its first schema revision in a single transaction
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(&eventReceiver)
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		result = tx.Create(&EventReceiverSchema{
+			EventReceiverID: eventReceiver.ID,
+			Revision:        eventReceiver.SchemaRevision,
+			Schema:          eventReceiver.Schema,
+			Compatibility:   CompatibilityNone,
+			CreatedAt:       now(),
+		})
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &eventReceiver, nil
 }
@@ -156,8 +413,124 @@ func FindEventReceiver(tx *gorm.DB, er map[string]any) ([]EventReceiver, error)
 	return eventReceivers, nil
 }
 
+// FindEventReceiverPage returns one page of the event receivers matching the given fields
+func FindEventReceiverPage(tx *gorm.DB, er map[string]any, page Page, maxPageSize int) (*Paginated[EventReceiver], error) {
+	query := tx.Model(&EventReceiver{}).Where(er)
+	return paginate(query, "event_receivers", page, maxPageSize, func(er EventReceiver) graphql.ID { return er.ID })
+}
+
+// FindOrCreateEventReceiver returns the oldest receiver with the fingerprint of the given one,
+// creating it when there is none. Concurrent calls for the same fingerprint are serialized so
+// only one of them creates the receiver.
+func FindOrCreateEventReceiver(tx *gorm.DB, eventReceiver EventReceiver) (*EventReceiver, bool, error) {
+	fp := fingerprint(eventReceiver.Name, eventReceiver.Type, eventReceiver.Version, eventReceiver.Description)
+	var receiver *EventReceiver
+	created := false
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		id, err := lockFingerprint(tx, &EventReceiver{}, fp)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			receiver, err = CreateEventReceiver(tx, eventReceiver)
+			created = err == nil
+			return err
+		}
+		receivers, err := FindEventReceiverByID(tx, id)
+		if err != nil {
+			return err
+		}
+		receiver = &receivers[0]
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return receiver, created, nil
+}
+
+// lockFingerprint takes a transaction scoped lock on the fingerprint and returns the id of the
+// oldest record of the model with it, empty when there is none
+func lockFingerprint(tx *gorm.DB, model any, fp string) (graphql.ID, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fp).Error; err != nil {
+		return "", pgError(err)
+	}
+	var ids []graphql.ID
+	result := tx.Model(model).Where("fingerprint = ?", fp).Order("created_at, id").Limit(1).Pluck("id", &ids)
+	if result.Error != nil {
+		return "", pgError(result.Error)
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
+// CreateEventReceiverSchema stores the schema revision and makes it the current schema of the
+// receiver. The receiver is only updated while it is still at the revision before, so of two
+// concurrent revisions one fails.
+func CreateEventReceiverSchema(tx *gorm.DB, eventReceiverSchema EventReceiverSchema) (*EventReceiverSchema, error) {
+	eventReceiverSchema.CreatedAt = now()
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&EventReceiver{}).
+			Where("id = ? AND schema_revision = ?", eventReceiverSchema.EventReceiverID, eventReceiverSchema.Revision-1).
+			Updates(map[string]any{"schema": eventReceiverSchema.Schema, "schema_revision": eventReceiverSchema.Revision})
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return schemaRevisionError(eventReceiverSchema)
+		}
+		result = tx.Create(&eventReceiverSchema)
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &eventReceiverSchema, nil
+}
+
+// SetEventReceiverSigning replaces the trusted public keys of the receiver and whether its
+// events must be signed
+func SetEventReceiverSigning(tx *gorm.DB, id graphql.ID, publicKeys []string, requireSignature bool) (*EventReceiver, error) {
+	if publicKeys == nil {
+		publicKeys = []string{}
+	}
+	result := tx.Model(&EventReceiver{ID: id}).
+		Select("public_keys", "require_signature").
+		Updates(EventReceiver{PublicKeys: publicKeys, RequireSignature: requireSignature})
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiver with id %s not found", id)}
+	}
+	receivers, err := FindEventReceiverByID(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &receivers[0], nil
+}
+
+// FindEventReceiverSchemas returns the schema revisions of the receiver, oldest first
+func FindEventReceiverSchemas(tx *gorm.DB, id graphql.ID) ([]EventReceiverSchema, error) {
+	if _, err := FindEventReceiverByID(tx, id); err != nil {
+		return nil, err
+	}
+	var schemas []EventReceiverSchema
+	result := tx.Model(&EventReceiverSchema{}).Where("event_receiver_id = ?", id).Order("revision").Find(&schemas)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return schemas, nil
+}
+
 func CreateEventReceiverGroup(tx *gorm.DB, eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, error) {
 	eventReceiverGroup.ID = graphql.ID(utils.NewULIDAsString())
+	eventReceiverGroup.Fingerprint = fingerprint(eventReceiverGroup.Name, eventReceiverGroup.Type, eventReceiverGroup.Version, eventReceiverGroup.Description)
 
 	// create our EventReceiverGroupToEventReceivers
 	eventReceiverGroupToEventReceivers := []*EventReceiverGroupToEventReceiver{}
@@ -165,6 +538,7 @@ func CreateEventReceiverGroup(tx *gorm.DB, eventReceiverGroup EventReceiverGroup
 		eventReceiverGroupToEventReceivers = append(eventReceiverGroupToEventReceivers, &EventReceiverGroupToEventReceiver{
 			EventReceiverID:      eventReceiverID,
 			EventReceiverGroupID: eventReceiverGroup.ID,
+			Condition:            eventReceiverGroup.Condition(eventReceiverID),
 		})
 	}
 
@@ -188,6 +562,36 @@ func CreateEventReceiverGroup(tx *gorm.DB, eventReceiverGroup EventReceiverGroup
 	return &eventReceiverGroup, nil
 }
 
+// FindOrCreateEventReceiverGroup returns the oldest group with the fingerprint of the given one,
+// creating it when there is none. Concurrent calls for the same fingerprint are serialized so
+// only one of them creates the group.
+func FindOrCreateEventReceiverGroup(tx *gorm.DB, eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, bool, error) {
+	fp := fingerprint(eventReceiverGroup.Name, eventReceiverGroup.Type, eventReceiverGroup.Version, eventReceiverGroup.Description)
+	var group *EventReceiverGroup
+	created := false
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		id, err := lockFingerprint(tx, &EventReceiverGroup{}, fp)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			group, err = CreateEventReceiverGroup(tx, eventReceiverGroup)
+			created = err == nil
+			return err
+		}
+		groups, err := FindEventReceiverGroupByID(tx, id)
+		if err != nil {
+			return err
+		}
+		group = &groups[0]
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return group, created, nil
+}
+
 func FindEventReceiverGroupByID(tx *gorm.DB, id graphql.ID) ([]EventReceiverGroup, error) {
 	groups, err := FindEventReceiverGroup(tx, map[string]any{"id": id})
 	if err != nil {
@@ -209,25 +613,143 @@ func FindEventReceiverGroup(tx *gorm.DB, erg map[string]any) ([]EventReceiverGro
 		return nil, pgError(result.Error)
 	}
 
+	if err := findEventReceiverIDs(tx, eventReceiverGroups); err != nil {
+		return nil, err
+	}
+	return eventReceiverGroups, nil
+}
+
+// FindEventReceiverGroupPage returns one page of the event receiver groups matching the given fields
+func FindEventReceiverGroupPage(tx *gorm.DB, erg map[string]any, page Page, maxPageSize int) (*Paginated[EventReceiverGroup], error) {
+	query := tx.Model(&EventReceiverGroup{}).Where(erg)
+	groups, err := paginate(query, "event_receiver_groups", page, maxPageSize, func(erg EventReceiverGroup) graphql.ID { return erg.ID })
+	if err != nil {
+		return nil, err
+	}
+	if err := findEventReceiverIDs(tx, groups.Items); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// findEventReceiverIDs fills in the event receiver ids and conditions of each group
+func findEventReceiverIDs(tx *gorm.DB, eventReceiverGroups []EventReceiverGroup) error {
 	for i := range eventReceiverGroups {
 		// need indirection so db query can modify array contents
 		eventReceiverGroup := &eventReceiverGroups[i]
-		result = tx.Model(&EventReceiverGroupToEventReceiver{}).
-			Select("event_receiver_id").
-			Find(&eventReceiverGroup.EventReceiverIDs, &EventReceiverGroupToEventReceiver{EventReceiverGroupID: eventReceiverGroup.ID})
+		var members []EventReceiverGroupToEventReceiver
+		result := tx.Model(&EventReceiverGroupToEventReceiver{}).
+			Select("event_receiver_id", "condition").
+			Order("id").
+			Find(&members, &EventReceiverGroupToEventReceiver{EventReceiverGroupID: eventReceiverGroup.ID})
 		if result.Error != nil {
-			return nil, pgError(result.Error)
+			return pgError(result.Error)
+		}
+		eventReceiverGroup.EventReceiverIDs = []graphql.ID{}
+		eventReceiverGroup.Conditions = []EventReceiverCondition{}
+		for _, member := range members {
+			eventReceiverGroup.EventReceiverIDs = append(eventReceiverGroup.EventReceiverIDs, member.EventReceiverID)
+			if member.Condition != "" {
+				eventReceiverGroup.Conditions = append(eventReceiverGroup.Conditions, EventReceiverCondition{
+					EventReceiverID: member.EventReceiverID,
+					Expression:      member.Condition,
+				})
+			}
 		}
 	}
-
-	return eventReceiverGroups, nil
+	return nil
 }
 
 func SetEventReceiverGroupEnabled(tx *gorm.DB, id graphql.ID, enabled bool) error {
-	result := tx.Model(&EventReceiverGroup{ID: id}).Update("enabled", enabled)
+	result := tx.Model(&EventReceiverGroup{ID: id}).Updates(map[string]any{"enabled": enabled, "updated_at": now()})
 	return pgError(result.Error)
 }
 
+// UpdateEventReceiverGroup changes the fields of the group that are set on the update and adds
+// and removes its receivers in a single transaction.
+func UpdateEventReceiverGroup(tx *gorm.DB, id graphql.ID, update EventReceiverGroupUpdate) (*EventReceiverGroup, error) {
+	var updated *EventReceiverGroup
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		groups, err := FindEventReceiverGroupByID(tx, id)
+		if err != nil {
+			return err
+		}
+		group := groups[0]
+
+		columns := []string{"updated_at"}
+		group.UpdatedAt = now()
+		if update.Description != nil {
+			group.Description = *update.Description
+			group.Fingerprint = fingerprint(group.Name, group.Type, group.Version, group.Description)
+			columns = append(columns, "description", "fingerprint")
+		}
+		if update.Enabled != nil {
+			group.Enabled = *update.Enabled
+			columns = append(columns, "enabled")
+		}
+		if update.Policy != nil {
+			group.Policy = *update.Policy
+			columns = append(columns, "policy")
+		}
+		// select the columns so that false and empty values are written too
+		result := tx.Model(&EventReceiverGroup{ID: id}).Select(columns).Updates(&group)
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+
+		if len(update.RemoveEventReceiverIDs) > 0 {
+			result = tx.Where("event_receiver_group_id = ? AND event_receiver_id IN ?", id, update.RemoveEventReceiverIDs).
+				Delete(&EventReceiverGroupToEventReceiver{})
+			if result.Error != nil {
+				return pgError(result.Error)
+			}
+		}
+		if len(update.AddEventReceivers) > 0 {
+			members := []*EventReceiverGroupToEventReceiver{}
+			for _, membership := range update.AddEventReceivers {
+				members = append(members, &EventReceiverGroupToEventReceiver{
+					EventReceiverID:      membership.EventReceiverID,
+					EventReceiverGroupID: id,
+					Condition:            membership.Condition,
+				})
+			}
+			result = tx.CreateInBatches(members, len(members))
+			if result.Error != nil {
+				return pgError(result.Error)
+			}
+		}
+
+		groups, err = FindEventReceiverGroupByID(tx, id)
+		if err != nil {
+			return err
+		}
+		updated = &groups[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteEventReceiverGroup soft deletes the group. Its memberships, completions and states are
+// kept for provenance.
+func DeleteEventReceiverGroup(tx *gorm.DB, id graphql.ID) error {
+	result := tx.Delete(&EventReceiverGroup{ID: id})
+	if result.Error != nil {
+		return pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiverGroup with id %s not found", id)}
+	}
+	return nil
+}
+
+func schemaRevisionError(eventReceiverSchema EventReceiverSchema) error {
+	return eprErrors.InvalidInputError{Msg: fmt.Sprintf("eventReceiver %s is not at schema revision %d",
+		eventReceiverSchema.EventReceiverID, eventReceiverSchema.Revision-1)}
+}
+
 func pgError(err error) error {
 	switch err := err.(type) {
 	case *pgconn.PgError:
@@ -260,57 +782,196 @@ func validateReceiverSchema(schema string, eventPayload types.JSON) error {
 	return nil
 }
 
-//go:embed megaQuery.sql
-var megaQuery string
+// FindEventReceiverGroupsByEventReceiverID returns every group the receiver is a member of
+func FindEventReceiverGroupsByEventReceiverID(tx *gorm.DB, id graphql.ID) ([]EventReceiverGroup, error) {
+	var eventReceiverGroups []EventReceiverGroup
+	members := tx.Model(&EventReceiverGroupToEventReceiver{}).
+		Select("event_receiver_group_id").
+		Where("event_receiver_id = ?", id)
+	result := tx.Model(&EventReceiverGroup{}).Where("id IN (?)", members).Find(&eventReceiverGroups)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	if err := findEventReceiverIDs(tx, eventReceiverGroups); err != nil {
+		return nil, err
+	}
+	return eventReceiverGroups, nil
+}
+
+// FindEventReceiversByIDs returns the receivers with the given ids. Unknown ids are left out.
+func FindEventReceiversByIDs(tx *gorm.DB, ids []graphql.ID) ([]EventReceiver, error) {
+	var eventReceivers []EventReceiver
+	result := tx.Model(&EventReceiver{}).Where("id IN ?", ids).Find(&eventReceivers)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return eventReceivers, nil
+}
 
-// Data represents the EventReceiverGroup data that comes back from
-// the mega query. It is necessary to allow us to automatically
-// insert event_receiver_ids by overriding the json tag
-type TriggeredEventReceiverGroups struct {
-	EventReceiverGroup
+// tupleFields are the event fields that identify an artifact
+var tupleFields = []string{"name", "version", "release", "platform_id", "package"}
 
-	// Database returns json array instead of a string
-	EventReceiverIDs types.JSON `json:"event_receiver_ids"`
+func tupleOf(event Event) map[string]any {
+	return map[string]any{
+		"name":        event.Name,
+		"version":     event.Version,
+		"release":     event.Release,
+		"platform_id": event.PlatformID,
+		"package":     event.Package,
+	}
 }
 
-func FindTriggeredEventReceiverGroups(tx *gorm.DB, event Event) ([]EventReceiverGroup, error) {
-	var triggeredEventReceiverGroups []TriggeredEventReceiverGroups
-	result := tx.Model("EventReceiverGroup").Raw(megaQuery,
-		sql.Named("name", event.Name),
-		sql.Named("version", event.Version),
-		sql.Named("release", event.Release),
-		sql.Named("platform_id", event.PlatformID),
-		sql.Named("package", event.Package),
-		sql.Named("event_receiver_id", event.EventReceiverID)).
-		Scan(&triggeredEventReceiverGroups)
+// FindLatestEvents returns the most recent event of each receiver for the name, version, release,
+// platform id and package of the tuple. Receivers without such an event are left out.
+func FindLatestEvents(tx *gorm.DB, eventReceiverIDs []graphql.ID, tuple Event) ([]Event, error) {
+	var events []Event
+	result := tx.Model(&Event{}).
+		Select(`DISTINCT ON ("event_receiver_id") *`).
+		Where("event_receiver_id IN ?", eventReceiverIDs).
+		Where(tupleOf(tuple)).
+		Where(`NOT EXISTS (SELECT 1 FROM "event_revocations" WHERE "event_revocations"."event_id" = "events"."id")`).
+		Order("event_receiver_id, created_at DESC, id DESC").
+		Find(&events)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("eventReceiverGroup %s not found", event.EventReceiverID)
-		}
 		return nil, pgError(result.Error)
 	}
-	if result.RowsAffected < 1 {
-		return nil, nil
+	return events, nil
+}
+
+// FindEventTuples returns the distinct artifact tuples the receivers have events for
+func FindEventTuples(tx *gorm.DB, eventReceiverIDs []graphql.ID) ([]Event, error) {
+	tuples := []Event{}
+	result := tx.Model(&Event{}).
+		Distinct(tupleFields).
+		Where("event_receiver_id IN ?", eventReceiverIDs).
+		Order("name, version, release, platform_id, package").
+		Find(&tuples)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
 	}
+	return tuples, nil
+}
 
-	eventReceiverGroups := []EventReceiverGroup{}
-	for _, triggeredEventReceiverGroup := range triggeredEventReceiverGroups {
-		var eventReceiverIDs []graphql.ID
-		err := json.Unmarshal([]byte(triggeredEventReceiverGroup.EventReceiverIDs.JSON), &eventReceiverIDs)
-		if err != nil {
-			return nil, err
-		}
-		eventReceiverGroup := EventReceiverGroup{
-			ID:               triggeredEventReceiverGroup.ID,
-			Name:             triggeredEventReceiverGroup.Name,
-			Version:          triggeredEventReceiverGroup.Version,
-			Description:      triggeredEventReceiverGroup.Description,
-			Enabled:          triggeredEventReceiverGroup.Enabled,
-			EventReceiverIDs: eventReceiverIDs,
-			CreatedAt:        triggeredEventReceiverGroup.CreatedAt,
-			UpdatedAt:        triggeredEventReceiverGroup.UpdatedAt,
+// SaveEventReceiverGroupCompletion stores the completion of a group for an artifact, replacing
+// the events and time of an earlier completion of the same group and artifact.
+func SaveEventReceiverGroupCompletion(tx *gorm.DB, completion EventReceiverGroupCompletion) (*EventReceiverGroupCompletion, error) {
+	completion.ID = graphql.ID(utils.NewULIDAsString())
+	if time.Time(completion.CompletedAt.Date).IsZero() {
+		completion.CompletedAt = now()
+	}
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_receiver_group_id"}, {Name: "name"}, {Name: "version"}, {Name: "release"}, {Name: "platform_id"}, {Name: "package"}},
+		DoUpdates: clause.AssignmentColumns([]string{"event_ids", "receiver_results", "completed_at"}),
+	}).Create(&completion)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+
+	// an update keeps the id of the earlier completion
+	var saved EventReceiverGroupCompletion
+	result = tx.Where("event_receiver_group_id = ?", completion.EventReceiverGroupID).
+		Where(map[string]any{
+			"name":        completion.Name,
+			"version":     completion.Version,
+			"release":     completion.Release,
+			"platform_id": completion.PlatformID,
+			"package":     completion.Package,
+		}).
+		First(&saved)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return &saved, nil
+}
+
+// FindEventReceiverGroupCompletions returns the completions of a group, optionally narrowed down
+// by the name, version, release, platform_id and package fields of an artifact.
+func FindEventReceiverGroupCompletions(tx *gorm.DB, id graphql.ID, tuple map[string]any) ([]EventReceiverGroupCompletion, error) {
+	if _, err := FindEventReceiverGroupByID(tx, id); err != nil {
+		return nil, err
+	}
+	if err := validateTuple(tuple); err != nil {
+		return nil, err
+	}
+	completions := []EventReceiverGroupCompletion{}
+	result := tx.Model(&EventReceiverGroupCompletion{}).
+		Where("event_receiver_group_id = ?", id).
+		Where(tuple).
+		Order("completed_at DESC").
+		Find(&completions)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return completions, nil
+}
+
+// LockEventReceiverGroup takes a transaction scoped lock on the group for the artifact of the tuple
+func LockEventReceiverGroup(tx *gorm.DB, id graphql.ID, tuple Event) error {
+	key := fmt.Sprintf("event receiver group %s %q %q %q %q %q", id, tuple.Name, tuple.Version, tuple.Release, tuple.PlatformID, tuple.Package)
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+		return pgError(err)
+	}
+	return nil
+}
+
+// SaveEventReceiverGroupState stores the state of a group for an artifact, replacing the
+// earlier state of the same group and artifact.
+func SaveEventReceiverGroupState(tx *gorm.DB, state EventReceiverGroupState) (*EventReceiverGroupState, error) {
+	state.ID = graphql.ID(utils.NewULIDAsString())
+	if time.Time(state.UpdatedAt.Date).IsZero() {
+		state.UpdatedAt = now()
+	}
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_receiver_group_id"}, {Name: "name"}, {Name: "version"}, {Name: "release"}, {Name: "platform_id"}, {Name: "package"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "event_id", "receiver_results", "updated_at"}),
+	}).Create(&state)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+
+	// an update keeps the id of the earlier state
+	var saved EventReceiverGroupState
+	result = tx.Where("event_receiver_group_id = ?", state.EventReceiverGroupID).
+		Where(map[string]any{
+			"name":        state.Name,
+			"version":     state.Version,
+			"release":     state.Release,
+			"platform_id": state.PlatformID,
+			"package":     state.Package,
+		}).
+		First(&saved)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return &saved, nil
+}
+
+// FindEventReceiverGroupStates returns the states of a group, optionally narrowed down by the
+// name, version, release, platform_id and package fields of an artifact.
+func FindEventReceiverGroupStates(tx *gorm.DB, id graphql.ID, tuple map[string]any) ([]EventReceiverGroupState, error) {
+	if _, err := FindEventReceiverGroupByID(tx, id); err != nil {
+		return nil, err
+	}
+	if err := validateTuple(tuple); err != nil {
+		return nil, err
+	}
+	states := []EventReceiverGroupState{}
+	result := tx.Model(&EventReceiverGroupState{}).
+		Where("event_receiver_group_id = ?", id).
+		Where(tuple).
+		Order("updated_at DESC").
+		Find(&states)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return states, nil
+}
+
+func validateTuple(tuple map[string]any) error {
+	for key := range tuple {
+		if !containsString(tupleFields, key) {
+			return eprErrors.InvalidInputError{Msg: fmt.Sprintf("%q does not identify an artifact", key)}
 		}
-		eventReceiverGroups = append(eventReceiverGroups, eventReceiverGroup)
 	}
-	return eventReceiverGroups, nil
+	return nil
 }
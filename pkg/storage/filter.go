@@ -0,0 +1,457 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// eventFields are the event columns that can be matched for equality
+var eventFields = []string{"id", "name", "version", "release", "platform_id", "package", "description", "success", "event_receiver_id"}
+
+// StringFilter matches a text column. Every criterion that is set must match.
+type StringFilter struct {
+	Prefix string
+	Regex  string
+	In     []string
+}
+
+// PayloadPath matches the text found at a dot separated path in the event payload,
+// e.g. "git.sha". Array elements are addressed by their index.
+type PayloadPath struct {
+	Path   string
+	Equals *string
+	Prefix *string
+}
+
+// ParsePayloadPath parses "path=value" into an equality match and "path^=value"
+// into a prefix match.
+func ParsePayloadPath(s string) (PayloadPath, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" || key == "^" {
+		return PayloadPath{}, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid payload path %q, expected path=value or path^=prefix", s)}
+	}
+	if path, isPrefix := strings.CutSuffix(key, "^"); isPrefix {
+		return PayloadPath{Path: path, Prefix: &value}, nil
+	}
+	return PayloadPath{Path: key, Equals: &value}, nil
+}
+
+func (p PayloadPath) keys() []string {
+	return strings.Split(p.Path, ".")
+}
+
+// EventFilter selects events. Every criterion that is set must match.
+type EventFilter struct {
+	// Fields are matched for equality against the column with the same name
+	Fields map[string]any
+
+	Name       StringFilter
+	Version    StringFilter
+	Release    StringFilter
+	PlatformID StringFilter
+	Package    StringFilter
+
+	EventReceiverIDs []graphql.ID
+
+	// CreatedAfter is inclusive and CreatedBefore is exclusive
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// PayloadContains is a JSON document the payload must contain, with the
+	// semantics of the Postgres jsonb @> operator
+	PayloadContains []byte
+	PayloadPaths    []PayloadPath
+}
+
+// Validate checks the filter before it is turned into a query
+func (f EventFilter) Validate() error {
+	for key := range f.Fields {
+		if !containsString(eventFields, key) {
+			return eprErrors.InvalidInputError{Msg: fmt.Sprintf("cannot filter events on %q", key)}
+		}
+	}
+	for column, sf := range f.strings() {
+		if sf.Regex == "" {
+			continue
+		}
+		if _, err := compileRegex(sf.Regex); err != nil {
+			return eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid %s regex: %s", column, err)}
+		}
+	}
+	if len(f.PayloadContains) > 0 && !json.Valid(f.PayloadContains) {
+		return eprErrors.InvalidInputError{Msg: "payload contains filter is not valid JSON"}
+	}
+	for _, p := range f.PayloadPaths {
+		if p.Path == "" || (p.Equals == nil && p.Prefix == nil) {
+			return eprErrors.InvalidInputError{Msg: fmt.Sprintf("payload path %q needs a path and a value to match", p.Path)}
+		}
+	}
+	return nil
+}
+
+func (f EventFilter) strings() map[string]StringFilter {
+	return map[string]StringFilter{
+		"name":        f.Name,
+		"version":     f.Version,
+		"release":     f.Release,
+		"platform_id": f.PlatformID,
+		"package":     f.Package,
+	}
+}
+
+// apply adds the filter to the query as parameterized conditions. Column names come
+// from the fixed set above and values are always passed as arguments.
+func (f EventFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if len(f.Fields) > 0 {
+		query = query.Where(f.Fields)
+	}
+	for column, sf := range f.strings() {
+		col := fmt.Sprintf(`"events".%q`, column)
+		if sf.Prefix != "" {
+			query = query.Where(col+` LIKE ? ESCAPE '\'`, escapeLike(sf.Prefix)+"%")
+		}
+		if sf.Regex != "" {
+			query = query.Where(col+" ~ ?", sf.Regex)
+		}
+		if sf.In != nil {
+			query = query.Where(col+" IN ?", sf.In)
+		}
+	}
+	if f.EventReceiverIDs != nil {
+		query = query.Where(`"events"."event_receiver_id" IN ?`, f.EventReceiverIDs)
+	}
+	if f.CreatedAfter != nil {
+		query = query.Where(`"events"."created_at" >= ?`, *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		query = query.Where(`"events"."created_at" < ?`, *f.CreatedBefore)
+	}
+	if len(f.PayloadContains) > 0 {
+		query = query.Where(`"events"."payload" @> CAST(? AS jsonb)`, string(f.PayloadContains))
+	}
+	for _, p := range f.PayloadPaths {
+		keys := p.keys()
+		args := make([]any, 0, len(keys)+1)
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		extract := fmt.Sprintf(`jsonb_extract_path_text("events"."payload"%s)`, strings.Repeat(", ?", len(keys)))
+		if p.Equals != nil {
+			query = query.Where(extract+" = ?", append(args, *p.Equals)...)
+		}
+		if p.Prefix != nil {
+			query = query.Where(extract+` LIKE ? ESCAPE '\'`, append(args, escapeLike(*p.Prefix)+"%")...)
+		}
+	}
+	return query, nil
+}
+
+// Match reports whether the event satisfies the filter. It mirrors apply for
+// backends that do not use SQL. The filter must be valid.
+func (f EventFilter) Match(event Event) bool {
+	if !matchFields(event, f.Fields) {
+		return false
+	}
+	values := map[string]string{
+		"name":        event.Name,
+		"version":     event.Version,
+		"release":     event.Release,
+		"platform_id": event.PlatformID,
+		"package":     event.Package,
+	}
+	for column, sf := range f.strings() {
+		if !sf.match(values[column]) {
+			return false
+		}
+	}
+	if f.EventReceiverIDs != nil && !containsID(f.EventReceiverIDs, event.EventReceiverID) {
+		return false
+	}
+	created := time.Time(event.CreatedAt.Date)
+	if f.CreatedAfter != nil && created.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !created.Before(*f.CreatedBefore) {
+		return false
+	}
+
+	var payload any
+	if len(f.PayloadContains) > 0 || len(f.PayloadPaths) > 0 {
+		if err := json.Unmarshal(event.Payload.JSON, &payload); err != nil {
+			return false
+		}
+	}
+	if len(f.PayloadContains) > 0 {
+		var want any
+		if err := json.Unmarshal(f.PayloadContains, &want); err != nil || !jsonContains(payload, want) {
+			return false
+		}
+	}
+	for _, p := range f.PayloadPaths {
+		text, ok := jsonPathText(payload, p.keys())
+		if !ok {
+			return false
+		}
+		if p.Equals != nil && text != *p.Equals {
+			return false
+		}
+		if p.Prefix != nil && !strings.HasPrefix(text, *p.Prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sf StringFilter) match(value string) bool {
+	if sf.Prefix != "" && !strings.HasPrefix(value, sf.Prefix) {
+		return false
+	}
+	if sf.Regex != "" {
+		re, err := compileRegex(sf.Regex)
+		if err != nil || !re.MatchString(value) {
+			return false
+		}
+	}
+	if sf.In != nil && !containsString(sf.In, value) {
+		return false
+	}
+	return true
+}
+
+// maxRegexRepeat is the largest bound of a Postgres regex repetition, RE2 allows up to 1000
+const maxRegexRepeat = 255
+
+// posixClasses are the character classes of bracket expressions both regex flavors know
+var posixClasses = []string{"alnum", "alpha", "blank", "cntrl", "digit", "graph", "lower", "print", "punct", "space", "upper", "xdigit"}
+
+// compileRegex compiles a regex filter for the events held in memory. The database
+// matches it with the Postgres ~ operator, whose advanced regular expressions differ
+// from the RE2 syntax of Go, so the pattern is restricted to the constructs both read
+// the same: literals, ., [] bracket expressions with the POSIX classes, the \d \s \w
+// classes and their negations, the \t \n \r \f \v and punctuation escapes, groups
+// and (?: ) groups, |, the ^ and $ anchors, and the *, +, ? and {n,m} repetitions,
+// greedy or not, with bounds up to 255. The dot matches newlines, as it does in Postgres.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if err := checkRegex(pattern); err != nil {
+		return nil, err
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRepeats(re); err != nil {
+		return nil, err
+	}
+	return regexp.Compile("(?s)" + pattern)
+}
+
+// checkRegex rejects the escapes, groups and bracket expressions Postgres and Go read differently
+func checkRegex(pattern string) error {
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(pattern) {
+				return errors.New("trailing backslash")
+			}
+			i++
+			e := pattern[i]
+			if !strings.ContainsRune("dDsSwWtnrfv", rune(e)) && !isASCIIPunct(e) {
+				return fmt.Errorf("escape \\%c is not supported", e)
+			}
+			if inClass && strings.ContainsRune("DSW", rune(e)) {
+				return fmt.Errorf("escape \\%c is not supported in a bracket expression", e)
+			}
+		case inClass && c == '[' && i+1 < len(pattern) && strings.ContainsRune(":.=", rune(pattern[i+1])):
+			if pattern[i+1] != ':' {
+				return fmt.Errorf("bracket expression [%c is not supported", pattern[i+1])
+			}
+			name, _, ok := strings.Cut(pattern[i+2:], ":]")
+			if !ok || !containsString(posixClasses, name) {
+				return fmt.Errorf("character class at offset %d is not supported", i)
+			}
+			i += len(name) + 3
+		case inClass && c == ']':
+			inClass = false
+		case inClass:
+		case c == '[':
+			inClass = true
+			// a ] right after the opening bracket, or after its ^, is a literal
+			if strings.HasPrefix(pattern[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(pattern[i+1:], "]") {
+				i++
+			}
+		case c == '(' && strings.HasPrefix(pattern[i+1:], "?") && !strings.HasPrefix(pattern[i+1:], "?:"):
+			return errors.New("only (?: ) groups are supported, not flags, named groups or lookarounds")
+		case c == '{':
+			if !repeatBound.MatchString(pattern[i:]) {
+				return fmt.Errorf("{ at offset %d must start a {n}, {n,} or {n,m} repetition, use \\{ for a literal", i)
+			}
+		}
+	}
+	return nil
+}
+
+func isASCIIPunct(c byte) bool {
+	return c > ' ' && c < 0x7f && !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z')
+}
+
+// repeatBound matches the start of a repetition bound
+var repeatBound = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}`)
+
+func checkRepeats(re *syntax.Regexp) error {
+	if re.Op == syntax.OpRepeat && (re.Min > maxRegexRepeat || re.Max > maxRegexRepeat) {
+		return fmt.Errorf("repetitions are bounded by %d", maxRegexRepeat)
+	}
+	for _, sub := range re.Sub {
+		if err := checkRepeats(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SBOMComponentFilter selects SBOM components. Every criterion that is set must match.
+type SBOMComponentFilter struct {
+	Name          string
+	Group         string
+	Version       string
+	VersionPrefix string
+	PURLPrefix    string
+}
+
+// Validate checks that the filter selects components by name, group or package URL
+func (f SBOMComponentFilter) Validate() error {
+	if f.Name == "" && f.Group == "" && f.PURLPrefix == "" {
+		return eprErrors.InvalidInputError{Msg: "component search needs a name, group or purl prefix"}
+	}
+	return nil
+}
+
+func (f SBOMComponentFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	for column, value := range map[string]string{"name": f.Name, "group": f.Group, "version": f.Version} {
+		if value != "" {
+			query = query.Where(fmt.Sprintf(`"sbom_components".%q = ?`, column), value)
+		}
+	}
+	if f.VersionPrefix != "" {
+		query = query.Where(`"sbom_components"."version" LIKE ? ESCAPE '\'`, escapeLike(f.VersionPrefix)+"%")
+	}
+	if f.PURLPrefix != "" {
+		query = query.Where(`"sbom_components"."purl" LIKE ? ESCAPE '\'`, escapeLike(f.PURLPrefix)+"%")
+	}
+	return query, nil
+}
+
+// Match reports whether the component satisfies the filter, mirroring apply
+func (f SBOMComponentFilter) Match(component SBOMComponent) bool {
+	return (f.Name == "" || component.Name == f.Name) &&
+		(f.Group == "" || component.Group == f.Group) &&
+		(f.Version == "" || component.Version == f.Version) &&
+		strings.HasPrefix(component.Version, f.VersionPrefix) &&
+		strings.HasPrefix(component.PURL, f.PURLPrefix)
+}
+
+// escapeLike escapes the LIKE wildcards so that a prefix is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// jsonContains implements the containment rules of the jsonb @> operator
+func jsonContains(have, want any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		obj, ok := have.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			if !jsonContains(obj[key], value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		arr, ok := have.([]any)
+		if !ok {
+			return false
+		}
+		for _, w := range want {
+			found := false
+			for _, h := range arr {
+				if jsonContains(h, w) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(have, want)
+	}
+}
+
+// jsonPathText returns the value at the path as jsonb_extract_path_text would
+func jsonPathText(doc any, keys []string) (string, bool) {
+	for _, key := range keys {
+		switch v := doc.(type) {
+		case map[string]any:
+			var ok bool
+			if doc, ok = v[key]; !ok {
+				return "", false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			doc = v[i]
+		default:
+			return "", false
+		}
+	}
+	switch v := doc.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	default:
+		content, err := json.Marshal(v)
+		return string(content), err == nil
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gotest.tools/v3/assert"
+)
+
+func TestEventFilterMatch(t *testing.T) {
+	m := NewMemory()
+	receiver := newTestReceiver(t, m, "build")
+	create := func(name, version string, payload string) graphql.ID {
+		event, err := m.CreateEvent(Event{
+			Name:            name,
+			Version:         version,
+			Release:         "20240101",
+			PlatformID:      "x86-64-gnu-linux-7",
+			Package:         "rpm",
+			Payload:         types.JSON{JSON: []byte(payload)},
+			EventReceiverID: receiver.ID,
+		})
+		assert.NilError(t, err)
+		return event.ID
+	}
+	foo := create("foo", "1.0.0", `{"git": {"sha": "abc123", "tags": ["v1", "latest"]}, "count": 1}`)
+	fooBar := create("foo-bar", "1.1.0", `{"git": {"sha": "def456"}}`)
+	baz := create("baz", "2.0.0", `{"git": {"sha": "abc789", "tags": ["v2"]}}`)
+
+	abc, one, sha, v := "abc", "1", "def456", "v"
+	future := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		filter EventFilter
+		want   []graphql.ID
+	}{
+		"fields":           {EventFilter{Fields: map[string]any{"name": "foo"}}, []graphql.ID{foo}},
+		"prefix":           {EventFilter{Name: StringFilter{Prefix: "foo"}}, []graphql.ID{foo, fooBar}},
+		"literal prefix":   {EventFilter{Name: StringFilter{Prefix: "fo_"}}, []graphql.ID{}},
+		"regex":            {EventFilter{Version: StringFilter{Regex: `^1\.\d+\.0$`}}, []graphql.ID{foo, fooBar}},
+		"in":               {EventFilter{Name: StringFilter{In: []string{"foo", "baz"}}}, []graphql.ID{foo, baz}},
+		"empty in":         {EventFilter{Name: StringFilter{In: []string{}}}, []graphql.ID{}},
+		"receiver in":      {EventFilter{EventReceiverIDs: []graphql.ID{"other"}}, []graphql.ID{}},
+		"created before":   {EventFilter{CreatedBefore: &future}, []graphql.ID{foo, fooBar, baz}},
+		"created after":    {EventFilter{CreatedAfter: &future}, []graphql.ID{}},
+		"contains object":  {EventFilter{PayloadContains: []byte(`{"git": {"tags": ["latest"]}}`)}, []graphql.ID{foo}},
+		"contains nothing": {EventFilter{PayloadContains: []byte(`{"git": {"sha": "abc"}}`)}, []graphql.ID{}},
+		"path prefix":      {EventFilter{PayloadPaths: []PayloadPath{{Path: "git.sha", Prefix: &abc}}}, []graphql.ID{foo, baz}},
+		"path equals":      {EventFilter{PayloadPaths: []PayloadPath{{Path: "git.sha", Equals: &sha}}}, []graphql.ID{fooBar}},
+		"path number":      {EventFilter{PayloadPaths: []PayloadPath{{Path: "count", Equals: &one}}}, []graphql.ID{foo}},
+		"path index":       {EventFilter{PayloadPaths: []PayloadPath{{Path: "git.tags.0", Prefix: &v}}}, []graphql.ID{foo, baz}},
+		"combined": {EventFilter{
+			Name:         StringFilter{Prefix: "foo"},
+			PayloadPaths: []PayloadPath{{Path: "git.sha", Prefix: &abc}},
+		}, []graphql.ID{foo}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := m.FindEventPage(tc.filter, Page{})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, eventIDs(page.Items), tc.want)
+		})
+	}
+}
+
+func TestEventFilterValidate(t *testing.T) {
+	tests := map[string]EventFilter{
+		"unknown field":  {Fields: map[string]any{"payload": "x"}},
+		"bad regex":      {Name: StringFilter{Regex: "("}},
+		"postgres regex": {Version: StringFilter{Regex: `\bfoo`}},
+		"bad json":       {PayloadContains: []byte("{")},
+		"path no values": {PayloadPaths: []PayloadPath{{Path: "git.sha"}}},
+	}
+	for name, filter := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorType(t, filter.Validate(), eprErrors.InvalidInputError{})
+		})
+	}
+}
+
+func TestCompileRegex(t *testing.T) {
+	valid := []string{
+		`^1\.\d+\.0$`,
+		`foo|ba[rz]`,
+		`^[[:alpha:]_-]+(?:-rc[0-9]{1,3})?$`,
+		`[]a]+?`,
+		`\w\s\S\{`,
+		`a{255}`,
+	}
+	for _, pattern := range valid {
+		_, err := compileRegex(pattern)
+		assert.NilError(t, err, pattern)
+	}
+
+	invalid := map[string]string{
+		"word boundary":  `\bfoo`,
+		"unicode class":  `\pL`,
+		"quoted":         `\Qa.b\E`,
+		"back reference": `(a)\1`,
+		"flags":          `(?i)foo`,
+		"named group":    `(?P<v>\d+)`,
+		"lookahead":      `foo(?=bar)`,
+		"collating":      `[[.a.]]`,
+		"word class":     `[[:word:]]`,
+		"negated class":  `[\D]`,
+		"literal brace":  `a{`,
+		"large repeat":   `a{256}`,
+		"syntax":         `(`,
+	}
+	for name, pattern := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileRegex(pattern)
+			assert.Assert(t, err != nil, pattern)
+		})
+	}
+
+	// the dot matches newlines as it does in Postgres
+	re, err := compileRegex(`^a.b$`)
+	assert.NilError(t, err)
+	assert.Assert(t, re.MatchString("a\nb"))
+}
+
+func TestParsePayloadPath(t *testing.T) {
+	path, err := ParsePayloadPath("git.sha=abc")
+	assert.NilError(t, err)
+	assert.Equal(t, path.Path, "git.sha")
+	assert.Equal(t, *path.Equals, "abc")
+	assert.Assert(t, path.Prefix == nil)
+
+	path, err = ParsePayloadPath("git.sha^=a=b")
+	assert.NilError(t, err)
+	assert.Equal(t, path.Path, "git.sha")
+	assert.Equal(t, *path.Prefix, "a=b")
+
+	_, err = ParsePayloadPath("git.sha")
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+}
+
+func TestEventFilterSQL(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NilError(t, err)
+
+	injection := "x'; DROP TABLE events; --"
+	filter := EventFilter{
+		Name:            StringFilter{Prefix: injection, Regex: injection, In: []string{injection}},
+		PayloadContains: []byte(`{"a": "x'; DROP TABLE events; --"}`),
+		PayloadPaths:    []PayloadPath{{Path: "git." + injection, Equals: &injection}},
+	}
+	query, err := filter.apply(db.Model(&Event{}))
+	assert.NilError(t, err)
+	stmt := query.Find(&[]Event{}).Statement
+
+	sql := stmt.SQL.String()
+	assert.Assert(t, !strings.Contains(sql, "DROP TABLE"), sql)
+	assert.Assert(t, strings.Contains(sql, `jsonb_extract_path_text("events"."payload", $`), sql)
+	assert.Equal(t, len(stmt.Vars), 7)
+}
@@ -0,0 +1,795 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/utils"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Memory is a Repository that keeps every record in process memory. It is meant for
+// local development, unit tests and build agents without a database. Nothing is
+// persisted across restarts.
+type Memory struct {
+	// MaxPageSize bounds the number of records a paginated search returns
+	MaxPageSize int
+
+	*memoryStore
+	// tx is set on the repository a transaction runs with, which holds txMu of the store
+	tx bool
+}
+
+type memoryStore struct {
+	// txMu serializes the transactions and the writes made outside of them, so that a
+	// rollback only undoes the writes of its own transaction. Reads do not wait for it
+	// and see the writes of a running transaction.
+	txMu sync.Mutex
+
+	mu         sync.RWMutex
+	events     []Event
+	components []SBOMComponent
+	receivers  []EventReceiver
+	schemas    []EventReceiverSchema
+	groups     []EventReceiverGroup
+
+	completions []EventReceiverGroupCompletion
+	states      []EventReceiverGroupState
+	outbox      []OutboxMessage
+
+	subscriptions []WebhookSubscription
+	deliveries    []WebhookDelivery
+}
+
+// NewMemory returns an empty in-memory repository
+func NewMemory() *Memory {
+	return &Memory{memoryStore: &memoryStore{}}
+}
+
+// lock takes the write lock of the store, first waiting for the running transaction to end
+// unless m is the repository of that transaction. The returned func releases it.
+func (m *Memory) lock() func() {
+	if !m.tx {
+		m.txMu.Lock()
+	}
+	m.mu.Lock()
+	return func() {
+		m.mu.Unlock()
+		if !m.tx {
+			m.txMu.Unlock()
+		}
+	}
+}
+
+// CreateEvent stores an event. Throws an error if the event receiver does not exist or if the
+// event payload does not match the receiver schema.
+func (m *Memory) CreateEvent(event Event) (*Event, error) {
+	defer m.lock()()
+
+	receiver, ok := m.receiver(event.EventReceiverID)
+	if !ok {
+		return nil, eprErrors.InvalidInputError{Msg: "receiver for event does not exist"}
+	}
+
+	if err := validateReceiverSchema(receiver.Schema.String(), event.Payload); err != nil {
+		return nil, eprErrors.InvalidInputError{Msg: err.Error()}
+	}
+	event.ID = graphql.ID(utils.NewULIDAsString())
+	event.CreatedAt = now()
+	event.SchemaRevision = receiver.SchemaRevision
+	event.EventReceiver = EventReceiver{}
+	if err := chain(&event, m.chainHead(event.EventReceiverID)); err != nil {
+		return nil, err
+	}
+
+	components := make([]SBOMComponent, len(event.Components))
+	for i, component := range event.Components {
+		component.ID = graphql.ID(utils.NewULIDAsString())
+		component.EventID = event.ID
+		component.CreatedAt = event.CreatedAt
+		component.Event = nil
+		components[i] = component
+	}
+	m.components = append(m.components, components...)
+	event.Components = nil
+
+	m.events = append(m.events, event)
+	event.EventReceiver = receiver
+	event.Components = components
+	return &event, nil
+}
+
+// chainHead returns the last event in the chain of the receiver, nil when it has none
+func (m *Memory) chainHead(eventReceiverID graphql.ID) *Event {
+	var head *Event
+	for i := range m.events {
+		e := &m.events[i]
+		if e.EventReceiverID == eventReceiverID && (head == nil || e.Sequence > head.Sequence) {
+			head = e
+		}
+	}
+	return head
+}
+
+// FindEventChain returns up to limit events of the receiver chain after the sequence, in order
+func (m *Memory) FindEventChain(eventReceiverID graphql.ID, after int32, limit int) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []Event{}
+	for _, e := range m.events {
+		if e.EventReceiverID == eventReceiverID && e.Sequence > after {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// RevokeEvent records the revocation of the event and returns the event with it
+func (m *Memory) RevokeEvent(revocation EventRevocation) (*Event, error) {
+	defer m.lock()()
+
+	for i := range m.events {
+		event := &m.events[i]
+		if event.ID != revocation.EventID {
+			continue
+		}
+		if event.Revocation != nil {
+			return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("event with id %s is already revoked", revocation.EventID)}
+		}
+		revocation.RevokedAt = now()
+		event.Revocation = &revocation
+
+		revoked := *event
+		revoked.EventReceiver, _ = m.receiver(revoked.EventReceiverID)
+		return &revoked, nil
+	}
+	return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("event with id %s not found", revocation.EventID)}
+}
+
+func (m *Memory) FindEventByID(id graphql.ID) ([]Event, error) {
+	events, err := m.FindEvent(map[string]any{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("event with id %s not found", id)}
+	}
+	return events, nil
+}
+
+func (m *Memory) FindEvent(e map[string]any) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []Event{}
+	for _, event := range m.events {
+		if !matchFields(event, e) {
+			continue
+		}
+		event.EventReceiver, _ = m.receiver(event.EventReceiverID)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// FindEventPage returns one page of the events matching the filter
+func (m *Memory) FindEventPage(filter EventFilter, page Page) (*Paginated[Event], error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	events := []Event{}
+	for _, event := range m.events {
+		if filter.Match(event) {
+			event.EventReceiver, _ = m.receiver(event.EventReceiverID)
+			events = append(events, event)
+		}
+	}
+	ranked := ranks(m.events, func(e Event) graphql.ID { return e.ID })
+	m.mu.RUnlock()
+	return paginateSlice(events, ranked, page, m.MaxPageSize, func(e Event) graphql.ID { return e.ID })
+}
+
+// FindSBOMComponentPage returns one page of the SBOM components matching the filter
+func (m *Memory) FindSBOMComponentPage(filter SBOMComponentFilter, page Page) (*Paginated[SBOMComponent], error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	components := []SBOMComponent{}
+	for _, component := range m.components {
+		if !filter.Match(component) {
+			continue
+		}
+		for _, event := range m.events {
+			if event.ID == component.EventID {
+				event.EventReceiver, _ = m.receiver(event.EventReceiverID)
+				component.Event = &event
+				break
+			}
+		}
+		components = append(components, component)
+	}
+	ranked := ranks(m.components, func(c SBOMComponent) graphql.ID { return c.ID })
+	m.mu.RUnlock()
+	return paginateSlice(components, ranked, page, m.MaxPageSize, func(c SBOMComponent) graphql.ID { return c.ID })
+}
+
+func (m *Memory) CreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, error) {
+	defer m.lock()()
+
+	return m.createEventReceiver(eventReceiver), nil
+}
+
+// FindOrCreateEventReceiver returns the oldest receiver with the fingerprint of the given one,
+// creating it when there is none
+func (m *Memory) FindOrCreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, bool, error) {
+	defer m.lock()()
+
+	fp := fingerprint(eventReceiver.Name, eventReceiver.Type, eventReceiver.Version, eventReceiver.Description)
+	for _, receiver := range m.receivers {
+		if receiver.Fingerprint == fp {
+			return &receiver, false, nil
+		}
+	}
+	return m.createEventReceiver(eventReceiver), true, nil
+}
+
+func (m *Memory) createEventReceiver(eventReceiver EventReceiver) *EventReceiver {
+	eventReceiver.ID = graphql.ID(utils.NewULIDAsString())
+	eventReceiver.PublicKeys = append([]string{}, eventReceiver.PublicKeys...)
+	eventReceiver.Fingerprint = fingerprint(eventReceiver.Name, eventReceiver.Type, eventReceiver.Version, eventReceiver.Description)
+	eventReceiver.CreatedAt = now()
+	eventReceiver.SchemaRevision = 1
+
+	m.receivers = append(m.receivers, eventReceiver)
+	m.schemas = append(m.schemas, EventReceiverSchema{
+		EventReceiverID: eventReceiver.ID,
+		Revision:        eventReceiver.SchemaRevision,
+		Schema:          eventReceiver.Schema,
+		Compatibility:   CompatibilityNone,
+		CreatedAt:       eventReceiver.CreatedAt,
+	})
+	return &eventReceiver
+}
+
+// CreateEventReceiverSchema stores the schema revision and makes it the current schema of the receiver
+func (m *Memory) CreateEventReceiverSchema(eventReceiverSchema EventReceiverSchema) (*EventReceiverSchema, error) {
+	defer m.lock()()
+
+	for i := range m.receivers {
+		receiver := &m.receivers[i]
+		if receiver.ID != eventReceiverSchema.EventReceiverID {
+			continue
+		}
+		if receiver.SchemaRevision != eventReceiverSchema.Revision-1 {
+			return nil, schemaRevisionError(eventReceiverSchema)
+		}
+		eventReceiverSchema.CreatedAt = now()
+		receiver.Schema = eventReceiverSchema.Schema
+		receiver.SchemaRevision = eventReceiverSchema.Revision
+		m.schemas = append(m.schemas, eventReceiverSchema)
+		return &eventReceiverSchema, nil
+	}
+	return nil, schemaRevisionError(eventReceiverSchema)
+}
+
+// SetEventReceiverSigning replaces the trusted public keys of the receiver and whether its
+// events must be signed
+func (m *Memory) SetEventReceiverSigning(id graphql.ID, publicKeys []string, requireSignature bool) (*EventReceiver, error) {
+	defer m.lock()()
+
+	for i := range m.receivers {
+		receiver := &m.receivers[i]
+		if receiver.ID != id {
+			continue
+		}
+		receiver.PublicKeys = append([]string{}, publicKeys...)
+		receiver.RequireSignature = requireSignature
+		updated := *receiver
+		return &updated, nil
+	}
+	return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiver with id %s not found", id)}
+}
+
+// FindEventReceiverSchemas returns the schema revisions of the receiver, oldest first
+func (m *Memory) FindEventReceiverSchemas(id graphql.ID) ([]EventReceiverSchema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.receiver(id); !ok {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiver with id %s not found", id)}
+	}
+	schemas := []EventReceiverSchema{}
+	for _, schema := range m.schemas {
+		if schema.EventReceiverID == id {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas, nil
+}
+
+// FindEventReceiverByID tries to find an event receiver by ID.
+func (m *Memory) FindEventReceiverByID(id graphql.ID) ([]EventReceiver, error) {
+	receivers, err := m.FindEventReceiver(map[string]any{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if len(receivers) == 0 {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiver with id %s not found", id)}
+	}
+	return receivers, nil
+}
+
+// FindEventReceiver tries to find an event receiver by matching fields
+func (m *Memory) FindEventReceiver(er map[string]any) ([]EventReceiver, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	receivers := []EventReceiver{}
+	for _, receiver := range m.receivers {
+		if matchFields(receiver, er) {
+			receivers = append(receivers, receiver)
+		}
+	}
+	return receivers, nil
+}
+
+// FindEventReceiverPage returns one page of the event receivers matching the given fields
+func (m *Memory) FindEventReceiverPage(er map[string]any, page Page) (*Paginated[EventReceiver], error) {
+	receivers, err := m.FindEventReceiver(er)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	ranked := ranks(m.receivers, func(er EventReceiver) graphql.ID { return er.ID })
+	m.mu.RUnlock()
+	return paginateSlice(receivers, ranked, page, m.MaxPageSize, func(er EventReceiver) graphql.ID { return er.ID })
+}
+
+func (m *Memory) CreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, error) {
+	defer m.lock()()
+
+	return m.createEventReceiverGroup(eventReceiverGroup)
+}
+
+// FindOrCreateEventReceiverGroup returns the oldest group with the fingerprint of the given one,
+// creating it when there is none
+func (m *Memory) FindOrCreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, bool, error) {
+	defer m.lock()()
+
+	fp := fingerprint(eventReceiverGroup.Name, eventReceiverGroup.Type, eventReceiverGroup.Version, eventReceiverGroup.Description)
+	for _, group := range m.groups {
+		if !group.DeletedAt.Valid && group.Fingerprint == fp {
+			return copyGroup(group), false, nil
+		}
+	}
+	group, err := m.createEventReceiverGroup(eventReceiverGroup)
+	if err != nil {
+		return nil, false, err
+	}
+	return group, true, nil
+}
+
+func (m *Memory) createEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, error) {
+	for _, eventReceiverID := range eventReceiverGroup.EventReceiverIDs {
+		if _, ok := m.receiver(eventReceiverID); !ok {
+			return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("eventReceiver with id %s does not exist", eventReceiverID)}
+		}
+	}
+
+	eventReceiverGroup.ID = graphql.ID(utils.NewULIDAsString())
+	eventReceiverGroup.Fingerprint = fingerprint(eventReceiverGroup.Name, eventReceiverGroup.Type, eventReceiverGroup.Version, eventReceiverGroup.Description)
+	eventReceiverGroup.EventReceiverIDs = append([]graphql.ID{}, eventReceiverGroup.EventReceiverIDs...)
+	eventReceiverGroup.CreatedAt = now()
+	eventReceiverGroup.UpdatedAt = eventReceiverGroup.CreatedAt
+
+	m.groups = append(m.groups, eventReceiverGroup)
+	return copyGroup(eventReceiverGroup), nil
+}
+
+func (m *Memory) FindEventReceiverGroupByID(id graphql.ID) ([]EventReceiverGroup, error) {
+	groups, err := m.FindEventReceiverGroup(map[string]any{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiverGroup with id %s not found", id)}
+	}
+	return groups, nil
+}
+
+func (m *Memory) FindEventReceiverGroup(erg map[string]any) ([]EventReceiverGroup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := []EventReceiverGroup{}
+	for _, group := range m.groups {
+		if !group.DeletedAt.Valid && matchFields(group, erg) {
+			groups = append(groups, *copyGroup(group))
+		}
+	}
+	return groups, nil
+}
+
+// FindEventReceiverGroupPage returns one page of the event receiver groups matching the given fields
+func (m *Memory) FindEventReceiverGroupPage(erg map[string]any, page Page) (*Paginated[EventReceiverGroup], error) {
+	groups, err := m.FindEventReceiverGroup(erg)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	ranked := ranks(m.groups, func(erg EventReceiverGroup) graphql.ID { return erg.ID })
+	m.mu.RUnlock()
+	return paginateSlice(groups, ranked, page, m.MaxPageSize, func(erg EventReceiverGroup) graphql.ID { return erg.ID })
+}
+
+func (m *Memory) SetEventReceiverGroupEnabled(id graphql.ID, enabled bool) error {
+	defer m.lock()()
+
+	for i := range m.groups {
+		if m.groups[i].ID == id && !m.groups[i].DeletedAt.Valid {
+			m.groups[i].Enabled = enabled
+			m.groups[i].UpdatedAt = now()
+		}
+	}
+	return nil
+}
+
+// UpdateEventReceiverGroup changes the fields of the group that are set on the update and adds
+// and removes its receivers
+func (m *Memory) UpdateEventReceiverGroup(id graphql.ID, update EventReceiverGroupUpdate) (*EventReceiverGroup, error) {
+	defer m.lock()()
+
+	group := m.group(id)
+	if group == nil {
+		return nil, eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiverGroup with id %s not found", id)}
+	}
+	for _, membership := range update.AddEventReceivers {
+		if _, ok := m.receiver(membership.EventReceiverID); !ok {
+			return nil, eprErrors.InvalidInputError{Msg: fmt.Sprintf("eventReceiver with id %s does not exist", membership.EventReceiverID)}
+		}
+	}
+
+	if update.Description != nil {
+		group.Description = *update.Description
+		group.Fingerprint = fingerprint(group.Name, group.Type, group.Version, group.Description)
+	}
+	if update.Enabled != nil {
+		group.Enabled = *update.Enabled
+	}
+	if update.Policy != nil {
+		group.Policy = *copyPolicy(*update.Policy)
+	}
+	eventReceiverIDs := []graphql.ID{}
+	for _, eventReceiverID := range group.EventReceiverIDs {
+		if !containsID(update.RemoveEventReceiverIDs, eventReceiverID) {
+			eventReceiverIDs = append(eventReceiverIDs, eventReceiverID)
+		}
+	}
+	conditions := []EventReceiverCondition{}
+	for _, c := range group.Conditions {
+		if !containsID(update.RemoveEventReceiverIDs, c.EventReceiverID) {
+			conditions = append(conditions, c)
+		}
+	}
+	for _, membership := range update.AddEventReceivers {
+		eventReceiverIDs = append(eventReceiverIDs, membership.EventReceiverID)
+		if membership.Condition != "" {
+			conditions = append(conditions, EventReceiverCondition{EventReceiverID: membership.EventReceiverID, Expression: membership.Condition})
+		}
+	}
+	group.EventReceiverIDs = eventReceiverIDs
+	group.Conditions = conditions
+	group.UpdatedAt = now()
+	return copyGroup(*group), nil
+}
+
+// DeleteEventReceiverGroup soft deletes the group
+func (m *Memory) DeleteEventReceiverGroup(id graphql.ID) error {
+	defer m.lock()()
+
+	group := m.group(id)
+	if group == nil {
+		return eprErrors.MissingObjectError{Msg: fmt.Sprintf("eventReceiverGroup with id %s not found", id)}
+	}
+	group.DeletedAt = gorm.DeletedAt{Time: time.Now().UTC(), Valid: true}
+	return nil
+}
+
+// group returns the group that has not been deleted. Callers must hold the lock.
+func (m *Memory) group(id graphql.ID) *EventReceiverGroup {
+	for i := range m.groups {
+		if m.groups[i].ID == id && !m.groups[i].DeletedAt.Valid {
+			return &m.groups[i]
+		}
+	}
+	return nil
+}
+
+// FindEventReceiverGroupsByEventReceiverID returns every group the receiver is a member of
+func (m *Memory) FindEventReceiverGroupsByEventReceiverID(id graphql.ID) ([]EventReceiverGroup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := []EventReceiverGroup{}
+	for _, group := range m.groups {
+		if !group.DeletedAt.Valid && containsID(group.EventReceiverIDs, id) {
+			groups = append(groups, *copyGroup(group))
+		}
+	}
+	return groups, nil
+}
+
+// FindEventReceiversByIDs returns the receivers with the given ids. Unknown ids are left out.
+func (m *Memory) FindEventReceiversByIDs(ids []graphql.ID) ([]EventReceiver, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	receivers := []EventReceiver{}
+	for _, receiver := range m.receivers {
+		if containsID(ids, receiver.ID) {
+			receivers = append(receivers, receiver)
+		}
+	}
+	return receivers, nil
+}
+
+// FindLatestEvents returns the most recent event of each receiver for the tuple
+func (m *Memory) FindLatestEvents(eventReceiverIDs []graphql.ID, tuple Event) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []Event{}
+	for _, eventReceiverID := range eventReceiverIDs {
+		if latest, ok := m.latestEvent(eventReceiverID, tuple); ok {
+			events = append(events, latest)
+		}
+	}
+	return events, nil
+}
+
+// FindEventTuples returns the distinct artifact tuples the receivers have events for, in the
+// order they were first seen
+func (m *Memory) FindEventTuples(eventReceiverIDs []graphql.ID) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tuples := []Event{}
+	seen := map[[5]string]bool{}
+	for _, e := range m.events {
+		key := [5]string{e.Name, e.Version, e.Release, e.PlatformID, e.Package}
+		if !containsID(eventReceiverIDs, e.EventReceiverID) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tuples = append(tuples, Event{Name: e.Name, Version: e.Version, Release: e.Release, PlatformID: e.PlatformID, Package: e.Package})
+	}
+	return tuples, nil
+}
+
+// SaveEventReceiverGroupCompletion stores the completion of a group for an artifact, replacing
+// an earlier completion of the same group and artifact.
+func (m *Memory) SaveEventReceiverGroupCompletion(completion EventReceiverGroupCompletion) (*EventReceiverGroupCompletion, error) {
+	defer m.lock()()
+
+	completion.EventIDs = append([]graphql.ID{}, completion.EventIDs...)
+	completion.ReceiverResults = append([]ReceiverResult{}, completion.ReceiverResults...)
+	if time.Time(completion.CompletedAt.Date).IsZero() {
+		completion.CompletedAt = now()
+	}
+	for i, existing := range m.completions {
+		if existing.EventReceiverGroupID == completion.EventReceiverGroupID && sameTuple(existing, completion) {
+			completion.ID = existing.ID
+			m.completions[i] = completion
+			return &completion, nil
+		}
+	}
+	completion.ID = graphql.ID(utils.NewULIDAsString())
+	m.completions = append(m.completions, completion)
+	return &completion, nil
+}
+
+// FindEventReceiverGroupCompletions returns the completions of a group matching the tuple fields
+func (m *Memory) FindEventReceiverGroupCompletions(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupCompletion, error) {
+	if _, err := m.FindEventReceiverGroupByID(id); err != nil {
+		return nil, err
+	}
+	if err := validateTuple(tuple); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	completions := []EventReceiverGroupCompletion{}
+	for i := len(m.completions) - 1; i >= 0; i-- {
+		completion := m.completions[i]
+		if completion.EventReceiverGroupID == id && matchFields(completion, tuple) {
+			completion.EventIDs = append([]graphql.ID{}, completion.EventIDs...)
+			completion.ReceiverResults = append([]ReceiverResult{}, completion.ReceiverResults...)
+			completions = append(completions, completion)
+		}
+	}
+	sort.SliceStable(completions, func(i, j int) bool {
+		return time.Time(completions[i].CompletedAt.Date).After(time.Time(completions[j].CompletedAt.Date))
+	})
+	return completions, nil
+}
+
+// LockEventReceiverGroup does nothing, transactions on memory run one at a time
+func (m *Memory) LockEventReceiverGroup(_ graphql.ID, _ Event) error {
+	return nil
+}
+
+// SaveEventReceiverGroupState stores the state of a group for an artifact, replacing the
+// earlier state of the same group and artifact.
+func (m *Memory) SaveEventReceiverGroupState(state EventReceiverGroupState) (*EventReceiverGroupState, error) {
+	defer m.lock()()
+
+	state.ReceiverResults = append([]ReceiverResult{}, state.ReceiverResults...)
+	if time.Time(state.UpdatedAt.Date).IsZero() {
+		state.UpdatedAt = now()
+	}
+	for i, existing := range m.states {
+		if existing.EventReceiverGroupID == state.EventReceiverGroupID && sameStateTuple(existing, state) {
+			state.ID = existing.ID
+			m.states[i] = state
+			return &state, nil
+		}
+	}
+	state.ID = graphql.ID(utils.NewULIDAsString())
+	m.states = append(m.states, state)
+	return &state, nil
+}
+
+// FindEventReceiverGroupStates returns the states of a group matching the tuple fields
+func (m *Memory) FindEventReceiverGroupStates(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupState, error) {
+	if _, err := m.FindEventReceiverGroupByID(id); err != nil {
+		return nil, err
+	}
+	if err := validateTuple(tuple); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := []EventReceiverGroupState{}
+	for i := len(m.states) - 1; i >= 0; i-- {
+		state := m.states[i]
+		if state.EventReceiverGroupID == id && matchFields(state, tuple) {
+			state.ReceiverResults = append([]ReceiverResult{}, state.ReceiverResults...)
+			states = append(states, state)
+		}
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return time.Time(states[i].UpdatedAt.Date).After(time.Time(states[j].UpdatedAt.Date))
+	})
+	return states, nil
+}
+
+func sameTuple(a, b EventReceiverGroupCompletion) bool {
+	return a.Name == b.Name && a.Version == b.Version && a.Release == b.Release &&
+		a.PlatformID == b.PlatformID && a.Package == b.Package
+}
+
+func sameStateTuple(a, b EventReceiverGroupState) bool {
+	return a.Name == b.Name && a.Version == b.Version && a.Release == b.Release &&
+		a.PlatformID == b.PlatformID && a.Package == b.Package
+}
+
+// latestEvent returns the most recently stored event for the receiver that shares the
+// name, version, release, platform and package of the given event. Callers must hold the lock.
+func (m *Memory) latestEvent(eventReceiverID graphql.ID, tuple Event) (Event, bool) {
+	for i := len(m.events) - 1; i >= 0; i-- {
+		e := m.events[i]
+		if e.EventReceiverID == eventReceiverID &&
+			e.Revocation == nil &&
+			e.Name == tuple.Name &&
+			e.Version == tuple.Version &&
+			e.Release == tuple.Release &&
+			e.PlatformID == tuple.PlatformID &&
+			e.Package == tuple.Package {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+// receiver looks up an event receiver by ID. Callers must hold the lock.
+func (m *Memory) receiver(id graphql.ID) (EventReceiver, bool) {
+	for _, receiver := range m.receivers {
+		if receiver.ID == id {
+			return receiver, true
+		}
+	}
+	return EventReceiver{}, false
+}
+
+func copyGroup(group EventReceiverGroup) *EventReceiverGroup {
+	group.EventReceiverIDs = append([]graphql.ID{}, group.EventReceiverIDs...)
+	group.Conditions = append([]EventReceiverCondition{}, group.Conditions...)
+	group.Policy = *copyPolicy(group.Policy)
+	return &group
+}
+
+func copyPolicy(policy GatePolicy) *GatePolicy {
+	policy.OptionalEventReceiverIDs = append([]graphql.ID{}, policy.OptionalEventReceiverIDs...)
+	if policy.N != nil {
+		n := *policy.N
+		policy.N = &n
+	}
+	return &policy
+}
+
+func containsID(ids []graphql.ID, id graphql.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// now returns the current time at the microsecond precision of timestamptz, so that times read
+// back from the database equal the ones that were hashed
+func now() types.Time {
+	return types.Time{Date: datatypes.Date(time.Now().UTC().Truncate(time.Microsecond))}
+}
+
+// matchFields reports whether every key of the filter equals the struct field carrying the
+// same json tag, mirroring what gorm does with a map passed to Where.
+func matchFields(record any, filter map[string]any) bool {
+	v := reflect.ValueOf(record)
+	t := v.Type()
+	for key, want := range filter {
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if tag != key {
+				continue
+			}
+			found = true
+			if fmt.Sprint(indirect(v.Field(i).Interface())) != fmt.Sprint(indirect(want)) {
+				return false
+			}
+			break
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// indirect dereferences pointers such as the *string values graphql.NullString carries
+func indirect(value any) any {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
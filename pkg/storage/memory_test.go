@@ -0,0 +1,324 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+func newTestReceiver(t *testing.T, m *Memory, name string) *EventReceiver {
+	t.Helper()
+	receiver, err := m.CreateEventReceiver(EventReceiver{
+		Name:        name,
+		Type:        "epr.test." + name,
+		Version:     "1.0.0",
+		Description: "test receiver",
+		Schema:      types.JSON{JSON: []byte(`{}`)},
+	})
+	assert.NilError(t, err)
+	return receiver
+}
+
+func newTestEvent(t *testing.T, m *Memory, receiverID graphql.ID, success bool) *Event {
+	t.Helper()
+	event, err := m.CreateEvent(Event{
+		Name:            "foo",
+		Version:         "1.0.0",
+		Release:         "20240101",
+		PlatformID:      "x86-64-gnu-linux-7",
+		Package:         "rpm",
+		Description:     "test event",
+		Payload:         types.JSON{JSON: []byte(`{"name": "value"}`)},
+		Success:         success,
+		EventReceiverID: receiverID,
+	})
+	assert.NilError(t, err)
+	return event
+}
+
+func TestMemoryTransactionRollback(t *testing.T) {
+	m := NewMemory()
+	receiver := newTestReceiver(t, m, "build")
+
+	event := Event{Name: "foo", Payload: types.JSON{JSON: []byte(`{}`)}, EventReceiverID: receiver.ID}
+	started := make(chan struct{})
+	written := make(chan graphql.ID)
+	err := m.Transaction(func(tx Repository) error {
+		if _, err := tx.CreateEvent(event); err != nil {
+			return err
+		}
+		// a write made outside of the transaction waits for it to end
+		go func() {
+			close(started)
+			created, err := m.CreateEvent(event)
+			if err != nil {
+				close(written)
+				return
+			}
+			written <- created.ID
+		}()
+		<-started
+		return errors.New("rolled back")
+	})
+	assert.ErrorContains(t, err, "rolled back")
+
+	// the rollback drops the event of the transaction and keeps the other one
+	id, ok := <-written
+	assert.Assert(t, ok)
+	events, err := m.FindEvent(map[string]any{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, id)
+}
+
+func TestMemoryEvent(t *testing.T) {
+	m := NewMemory()
+	receiver := newTestReceiver(t, m, "build")
+	assert.Assert(t, receiver.ID != "")
+	assert.Assert(t, receiver.Fingerprint != "")
+
+	event := newTestEvent(t, m, receiver.ID, true)
+	assert.Equal(t, event.EventReceiver.ID, receiver.ID)
+
+	events, err := m.FindEventByID(event.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].EventReceiver.Name, "build")
+
+	name := "foo"
+	events, err = m.FindEvent(map[string]any{"name": &name, "success": true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+
+	events, err = m.FindEvent(map[string]any{"name": "bar"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+
+	_, err = m.FindEventByID("missing")
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+
+	_, err = m.CreateEvent(Event{EventReceiverID: "missing"})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+}
+
+func TestMemoryEventSchemaValidation(t *testing.T) {
+	m := NewMemory()
+	receiver, err := m.CreateEventReceiver(EventReceiver{
+		Name:        "strict",
+		Type:        "epr.test.strict",
+		Version:     "1.0.0",
+		Description: "test receiver",
+		Schema:      types.JSON{JSON: []byte(`{"type": "object", "required": ["sha"]}`)},
+	})
+	assert.NilError(t, err)
+
+	_, err = m.CreateEvent(Event{
+		Name:            "foo",
+		Payload:         types.JSON{JSON: []byte(`{"name": "value"}`)},
+		EventReceiverID: receiver.ID,
+	})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+}
+
+func TestMemoryEventReceiverGroup(t *testing.T) {
+	m := NewMemory()
+	receiver := newTestReceiver(t, m, "build")
+
+	_, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "bad",
+		EventReceiverIDs: []graphql.ID{"missing"},
+	})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+
+	group, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{receiver.ID},
+	})
+	assert.NilError(t, err)
+
+	err = m.SetEventReceiverGroupEnabled(group.ID, false)
+	assert.NilError(t, err)
+
+	groups, err := m.FindEventReceiverGroupByID(group.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, groups[0].Enabled, false)
+	assert.DeepEqual(t, groups[0].EventReceiverIDs, []graphql.ID{receiver.ID})
+}
+
+func TestMemoryFindEventReceiverGroupsByEventReceiverID(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	test := newTestReceiver(t, m, "test")
+
+	group, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "release",
+		Type:             "epr.test.release",
+		Version:          "1.0.0",
+		Description:      "test group",
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+	_, err = m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "build-only",
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+
+	groups, err := m.FindEventReceiverGroupsByEventReceiverID(test.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 1)
+	assert.Equal(t, groups[0].ID, group.ID)
+	assert.DeepEqual(t, groups[0].EventReceiverIDs, []graphql.ID{build.ID, test.ID})
+
+	groups, err = m.FindEventReceiverGroupsByEventReceiverID(build.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 2)
+
+	receivers, err := m.FindEventReceiversByIDs([]graphql.ID{test.ID, "missing"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(receivers), 1)
+	assert.Equal(t, receivers[0].ID, test.ID)
+}
+
+func TestMemoryEventReceiverGroupCompletion(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	test := newTestReceiver(t, m, "test")
+	group, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "release",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID, test.ID},
+	})
+	assert.NilError(t, err)
+
+	newTestEvent(t, m, build.ID, false)
+	buildEvent := newTestEvent(t, m, build.ID, true)
+	testEvent := newTestEvent(t, m, test.ID, true)
+
+	latest, err := m.FindLatestEvents(group.EventReceiverIDs, *testEvent)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(latest), []graphql.ID{buildEvent.ID, testEvent.ID})
+
+	completion := EventReceiverGroupCompletion{
+		EventReceiverGroupID: group.ID,
+		Name:                 testEvent.Name,
+		Version:              testEvent.Version,
+		Release:              testEvent.Release,
+		PlatformID:           testEvent.PlatformID,
+		Package:              testEvent.Package,
+		EventIDs:             eventIDs(latest),
+	}
+	first, err := m.SaveEventReceiverGroupCompletion(completion)
+	assert.NilError(t, err)
+	assert.Assert(t, first.ID != "")
+
+	// completing the same artifact again replaces the record
+	retest := newTestEvent(t, m, test.ID, true)
+	completion.EventIDs = []graphql.ID{buildEvent.ID, retest.ID}
+	second, err := m.SaveEventReceiverGroupCompletion(completion)
+	assert.NilError(t, err)
+	assert.Equal(t, second.ID, first.ID)
+
+	completions, err := m.FindEventReceiverGroupCompletions(group.ID, map[string]any{"version": "1.0.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), 1)
+	assert.DeepEqual(t, completions[0].EventIDs, completion.EventIDs)
+
+	completions, err = m.FindEventReceiverGroupCompletions(group.ID, map[string]any{"version": "2.0.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(completions), 0)
+
+	_, err = m.FindEventReceiverGroupCompletions(group.ID, map[string]any{"success": true})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+
+	_, err = m.FindEventReceiverGroupCompletions("missing", nil)
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+}
+
+func TestMemoryEventReceiverGroupState(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	group, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "release",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	event := newTestEvent(t, m, build.ID, true)
+
+	state := EventReceiverGroupState{
+		EventReceiverGroupID: group.ID,
+		Name:                 event.Name,
+		Version:              event.Version,
+		Release:              event.Release,
+		PlatformID:           event.PlatformID,
+		Package:              event.Package,
+		State:                GroupStatePassed,
+		EventID:              event.ID,
+	}
+	first, err := m.SaveEventReceiverGroupState(state)
+	assert.NilError(t, err)
+
+	state.State = GroupStateFailed
+	second, err := m.SaveEventReceiverGroupState(state)
+	assert.NilError(t, err)
+	assert.Equal(t, second.ID, first.ID)
+
+	states, err := m.FindEventReceiverGroupStates(group.ID, map[string]any{"name": event.Name})
+	assert.NilError(t, err)
+	assert.Equal(t, len(states), 1)
+	assert.Equal(t, states[0].State, GroupStateFailed)
+
+	_, err = m.FindEventReceiverGroupStates(group.ID, map[string]any{"success": true})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+}
+
+func TestMemoryUpdateAndDeleteEventReceiverGroup(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	test := newTestReceiver(t, m, "test")
+	group, err := m.CreateEventReceiverGroup(EventReceiverGroup{
+		Name:             "release",
+		Enabled:          true,
+		EventReceiverIDs: []graphql.ID{build.ID},
+		Conditions:       []EventReceiverCondition{{EventReceiverID: build.ID, Expression: "success"}},
+	})
+	assert.NilError(t, err)
+
+	enabled := false
+	updated, err := m.UpdateEventReceiverGroup(group.ID, EventReceiverGroupUpdate{
+		Enabled:                &enabled,
+		AddEventReceivers:      []EventReceiverMembership{{EventReceiverID: test.ID, Condition: "payload.critical == 0"}},
+		RemoveEventReceiverIDs: []graphql.ID{build.ID},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, updated.Enabled, false)
+	assert.DeepEqual(t, updated.EventReceiverIDs, []graphql.ID{test.ID})
+	assert.DeepEqual(t, updated.Conditions, []EventReceiverCondition{{EventReceiverID: test.ID, Expression: "payload.critical == 0"}})
+
+	_, err = m.UpdateEventReceiverGroup(group.ID, EventReceiverGroupUpdate{AddEventReceivers: []EventReceiverMembership{{EventReceiverID: "missing"}}})
+	assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+
+	assert.NilError(t, m.DeleteEventReceiverGroup(group.ID))
+	groups, err := m.FindEventReceiverGroup(map[string]any{"name": "release"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 0)
+	groups, err = m.FindEventReceiverGroupsByEventReceiverID(test.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 0)
+
+	_, err = m.UpdateEventReceiverGroup(group.ID, EventReceiverGroupUpdate{Enabled: &enabled})
+	assert.ErrorType(t, err, eprErrors.MissingObjectError{})
+	assert.ErrorType(t, m.DeleteEventReceiverGroup(group.ID), eprErrors.MissingObjectError{})
+}
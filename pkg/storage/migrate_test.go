@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	assert.NilError(t, err)
+	assert.Assert(t, len(migrations) > 0)
+
+	// the former AutoMigrate output is the first migration
+	assert.Equal(t, migrations[0].Version, 1)
+	assert.Equal(t, migrations[0].Name, "initial_schema")
+	assert.Assert(t, strings.Contains(migrations[0].Up, `CREATE TABLE IF NOT EXISTS "events"`))
+	assert.Assert(t, strings.Contains(migrations[0].Down, `DROP TABLE IF EXISTS "events"`))
+
+	for i, migration := range migrations {
+		assert.Assert(t, migration.Up != "", "migration %d has no up", migration.Version)
+		assert.Assert(t, migration.Down != "", "migration %d has no down", migration.Version)
+		if i > 0 {
+			assert.Assert(t, migration.Version > migrations[i-1].Version, "migrations are not ordered")
+		}
+	}
+
+	m := &Migrator{migrations: migrations}
+	assert.Equal(t, m.Latest(), migrations[len(migrations)-1].Version)
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "event_receiver_group_to_event_receivers";
+DROP TABLE IF EXISTS "event_receiver_groups";
+DROP TABLE IF EXISTS "events";
+DROP TABLE IF EXISTS "event_receivers";
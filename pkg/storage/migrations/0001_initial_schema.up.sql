@@ -0,0 +1,51 @@
+-- Initial schema, equivalent to what gorm AutoMigrate created before versioned
+-- migrations existed. IF NOT EXISTS lets databases created by AutoMigrate adopt
+-- this migration without changes.
+CREATE TABLE IF NOT EXISTS "event_receivers" (
+	"id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"type" varchar(255) NOT NULL,
+	"version" varchar(255) NOT NULL,
+	"description" varchar(255) NOT NULL,
+	"schema" JSONB NOT NULL,
+	"fingerprint" varchar(255) NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "events" (
+	"id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"version" varchar(255) NOT NULL,
+	"release" varchar(255) NOT NULL,
+	"platform_id" varchar(255) NOT NULL,
+	"package" varchar(255) NOT NULL,
+	"description" varchar(255) NOT NULL,
+	"payload" JSONB NOT NULL,
+	"success" boolean NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"event_receiver_id" varchar(255) NOT NULL,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_events_event_receiver" FOREIGN KEY ("event_receiver_id") REFERENCES "event_receivers"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "event_receiver_groups" (
+	"id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"type" varchar(255) NOT NULL,
+	"version" varchar(255) NOT NULL,
+	"description" varchar(255) NOT NULL,
+	"enabled" boolean NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "event_receiver_group_to_event_receivers" (
+	"id" bigserial,
+	"event_receiver_id" varchar(255) NOT NULL,
+	"event_receiver_group_id" varchar(255) NOT NULL,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_event_receiver_group_to_event_receivers_event_receiver" FOREIGN KEY ("event_receiver_id") REFERENCES "event_receivers"("id"),
+	CONSTRAINT "fk_event_receiver_group_to_event_receivers_event_receiver_group" FOREIGN KEY ("event_receiver_group_id") REFERENCES "event_receiver_groups"("id")
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_events_receiver_tuple";
+DROP TABLE IF EXISTS "event_receiver_group_completions";
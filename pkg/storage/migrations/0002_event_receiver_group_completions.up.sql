@@ -0,0 +1,21 @@
+-- One completion record per group and artifact tuple
+CREATE TABLE "event_receiver_group_completions" (
+	"id" varchar(255) NOT NULL,
+	"event_receiver_group_id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"version" varchar(255) NOT NULL,
+	"release" varchar(255) NOT NULL,
+	"platform_id" varchar(255) NOT NULL,
+	"package" varchar(255) NOT NULL,
+	"event_ids" JSONB NOT NULL,
+	"completed_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_event_receiver_group_completions_event_receiver_group" FOREIGN KEY ("event_receiver_group_id") REFERENCES "event_receiver_groups"("id")
+);
+
+CREATE UNIQUE INDEX "idx_event_receiver_group_completions_tuple"
+	ON "event_receiver_group_completions" ("event_receiver_group_id", "name", "version", "release", "platform_id", "package");
+
+-- latest event lookups for a receiver and artifact tuple
+CREATE INDEX "idx_events_receiver_tuple"
+	ON "events" ("event_receiver_id", "name", "version", "release", "platform_id", "package", "created_at");
@@ -0,0 +1 @@
+ALTER TABLE "event_receiver_groups" DROP COLUMN IF EXISTS "policy";
@@ -0,0 +1,3 @@
+-- Gate policy of each group, existing groups keep requiring every receiver
+ALTER TABLE "event_receiver_groups"
+	ADD COLUMN "policy" JSONB NOT NULL DEFAULT '{"type": "ALL_OF", "optional_event_receiver_ids": []}';
@@ -0,0 +1 @@
+ALTER TABLE "event_receiver_group_to_event_receivers" DROP COLUMN IF EXISTS "condition";
@@ -0,0 +1,3 @@
+-- CEL condition of a receiver membership, empty when the membership only needs a success
+ALTER TABLE "event_receiver_group_to_event_receivers"
+	ADD COLUMN "condition" TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+-- Current state of each group for each artifact tuple
+CREATE TABLE "event_receiver_group_states" (
+	"id" varchar(255) NOT NULL,
+	"event_receiver_group_id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"version" varchar(255) NOT NULL,
+	"release" varchar(255) NOT NULL,
+	"platform_id" varchar(255) NOT NULL,
+	"package" varchar(255) NOT NULL,
+	"state" varchar(255) NOT NULL,
+	"event_id" varchar(255) NOT NULL,
+	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_event_receiver_group_states_event_receiver_group" FOREIGN KEY ("event_receiver_group_id") REFERENCES "event_receiver_groups"("id")
+);
+
+CREATE UNIQUE INDEX "idx_event_receiver_group_states_tuple"
+	ON "event_receiver_group_states" ("event_receiver_group_id", "name", "version", "release", "platform_id", "package");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_event_receiver_groups_deleted_at";
+ALTER TABLE "event_receiver_groups" DROP COLUMN IF EXISTS "deleted_at";
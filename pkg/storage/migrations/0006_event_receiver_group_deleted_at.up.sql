@@ -0,0 +1,4 @@
+-- Soft delete of groups, deleted groups keep their memberships, completions and states
+ALTER TABLE "event_receiver_groups" ADD COLUMN "deleted_at" timestamptz;
+
+CREATE INDEX "idx_event_receiver_groups_deleted_at" ON "event_receiver_groups" ("deleted_at");
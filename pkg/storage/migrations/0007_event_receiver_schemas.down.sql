@@ -0,0 +1,3 @@
+ALTER TABLE "events" DROP COLUMN IF EXISTS "schema_revision";
+ALTER TABLE "event_receivers" DROP COLUMN IF EXISTS "schema_revision";
+DROP TABLE IF EXISTS "event_receiver_schemas";
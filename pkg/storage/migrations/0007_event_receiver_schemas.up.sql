@@ -0,0 +1,19 @@
+-- Schema revisions of each receiver, existing receivers start at revision 1
+CREATE TABLE "event_receiver_schemas" (
+	"event_receiver_id" varchar(255) NOT NULL,
+	"revision" integer NOT NULL,
+	"schema" JSONB NOT NULL,
+	"compatibility" varchar(255) NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("event_receiver_id", "revision"),
+	CONSTRAINT "fk_event_receiver_schemas_event_receiver" FOREIGN KEY ("event_receiver_id") REFERENCES "event_receivers"("id")
+);
+
+INSERT INTO "event_receiver_schemas" ("event_receiver_id", "revision", "schema", "compatibility", "created_at")
+	SELECT "id", 1, "schema", 'NONE', "created_at" FROM "event_receivers";
+
+ALTER TABLE "event_receivers"
+	ADD COLUMN "schema_revision" integer NOT NULL DEFAULT 1;
+
+ALTER TABLE "events"
+	ADD COLUMN "schema_revision" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_event_receiver_groups_fingerprint";
+ALTER TABLE "event_receiver_groups" DROP COLUMN IF EXISTS "fingerprint";
+DROP INDEX IF EXISTS "idx_event_receivers_fingerprint";
@@ -0,0 +1,11 @@
+-- Lookup of receivers and groups by fingerprint. The index is not unique because databases may
+-- already hold duplicate receivers; get-or-create returns the oldest one.
+CREATE INDEX "idx_event_receivers_fingerprint" ON "event_receivers" ("fingerprint");
+
+ALTER TABLE "event_receiver_groups" ADD COLUMN "fingerprint" varchar(255) NOT NULL DEFAULT '';
+
+-- same seed as utils.Seed.Fingerprint
+UPDATE "event_receiver_groups" SET "fingerprint" = encode(sha256(convert_to(
+	'v1 ' || "type" || ' ' || "description" || ' ' || "name" || ' ' || "version", 'UTF8')), 'hex');
+
+CREATE INDEX "idx_event_receiver_groups_fingerprint" ON "event_receiver_groups" ("fingerprint");
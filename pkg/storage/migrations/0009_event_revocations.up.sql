@@ -0,0 +1,9 @@
+-- Revocations of events, the events themselves are left untouched
+CREATE TABLE "event_revocations" (
+	"event_id" varchar(255) NOT NULL,
+	"revoked_by" varchar(255) NOT NULL,
+	"reason" text NOT NULL,
+	"revoked_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("event_id"),
+	CONSTRAINT "fk_events_revocation" FOREIGN KEY ("event_id") REFERENCES "events"("id")
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "idx_events_chain";
+
+ALTER TABLE "events"
+	DROP COLUMN IF EXISTS "hash",
+	DROP COLUMN IF EXISTS "previous_hash",
+	DROP COLUMN IF EXISTS "sequence";
@@ -0,0 +1,7 @@
+-- Per receiver hash chain over events. Events stored before it have sequence 0 and no hash.
+ALTER TABLE "events"
+	ADD COLUMN "sequence" integer NOT NULL DEFAULT 0,
+	ADD COLUMN "previous_hash" varchar(64) NOT NULL DEFAULT '',
+	ADD COLUMN "hash" varchar(64) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX "idx_events_chain" ON "events" ("event_receiver_id", "sequence") WHERE "sequence" > 0;
@@ -0,0 +1,5 @@
+ALTER TABLE "events" DROP COLUMN IF EXISTS "signature";
+
+ALTER TABLE "event_receivers"
+	DROP COLUMN IF EXISTS "require_signature",
+	DROP COLUMN IF EXISTS "public_keys";
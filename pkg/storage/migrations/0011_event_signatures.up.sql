@@ -0,0 +1,6 @@
+-- Keys trusted to sign the events of a receiver and the signatures attached to events
+ALTER TABLE "event_receivers"
+	ADD COLUMN "public_keys" jsonb NOT NULL DEFAULT '[]',
+	ADD COLUMN "require_signature" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "events" ADD COLUMN "signature" jsonb;
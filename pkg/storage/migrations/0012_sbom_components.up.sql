@@ -0,0 +1,17 @@
+-- Components of the SBOMs carried by events, searchable by name, version and package URL
+CREATE TABLE "sbom_components" (
+	"id" varchar(255) NOT NULL,
+	"event_id" varchar(255) NOT NULL,
+	"group" text NOT NULL,
+	"name" text NOT NULL,
+	"version" text NOT NULL,
+	"purl" text NOT NULL,
+	"type" varchar(255) NOT NULL,
+	"created_at" timestamptz NOT NULL,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_events_components" FOREIGN KEY ("event_id") REFERENCES "events"("id")
+);
+
+CREATE INDEX "idx_sbom_components_event_id" ON "sbom_components" ("event_id");
+CREATE INDEX "idx_sbom_components_name_version" ON "sbom_components" ("name", "version");
+CREATE INDEX "idx_sbom_components_purl" ON "sbom_components" ("purl");
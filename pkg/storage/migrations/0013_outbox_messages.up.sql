@@ -0,0 +1,13 @@
+-- Messages written in the same transaction as the records they announce, published by the relay
+CREATE TABLE "outbox_messages" (
+	"id" varchar(255) NOT NULL,
+	"payload" jsonb NOT NULL,
+	"attempts" integer NOT NULL DEFAULT 0,
+	"last_error" text NOT NULL DEFAULT '',
+	"created_at" timestamptz NOT NULL,
+	"next_attempt_at" timestamptz NOT NULL,
+	"sent_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_outbox_messages_pending" ON "outbox_messages" ("next_attempt_at") WHERE "sent_at" IS NULL;
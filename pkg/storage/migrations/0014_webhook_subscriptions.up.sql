@@ -0,0 +1,36 @@
+-- Webhook subscriptions and the log of the messages delivered to them
+CREATE TABLE "webhook_subscriptions" (
+	"id" varchar(255) NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"url" text NOT NULL,
+	"secret" varchar(255) NOT NULL,
+	"message_types" jsonb NOT NULL DEFAULT '[]',
+	"event_receiver_ids" jsonb NOT NULL DEFAULT '[]',
+	"event_receiver_group_ids" jsonb NOT NULL DEFAULT '[]',
+	"state" varchar(255) NOT NULL,
+	"paused_reason" text NOT NULL DEFAULT '',
+	"consecutive_failures" integer NOT NULL DEFAULT 0,
+	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE "webhook_deliveries" (
+	"id" varchar(255) NOT NULL,
+	"subscription_id" varchar(255) NOT NULL,
+	"message_id" varchar(255) NOT NULL,
+	"message_type" varchar(255) NOT NULL,
+	"payload" jsonb NOT NULL,
+	"state" varchar(255) NOT NULL,
+	"retries" integer NOT NULL DEFAULT 0,
+	"attempts" jsonb NOT NULL DEFAULT '[]',
+	"created_at" timestamptz NOT NULL,
+	"next_attempt_at" timestamptz NOT NULL,
+	"delivered_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id") ON DELETE CASCADE
+);
+
+-- a message is delivered once per subscription, even when the relay publishes it twice
+CREATE UNIQUE INDEX "idx_webhook_deliveries_message" ON "webhook_deliveries" ("subscription_id", "message_id");
+CREATE INDEX "idx_webhook_deliveries_pending" ON "webhook_deliveries" ("next_attempt_at") WHERE "state" = 'PENDING';
@@ -0,0 +1,3 @@
+ALTER TABLE "event_receiver_group_completions" DROP COLUMN IF EXISTS "receiver_results";
+
+ALTER TABLE "event_receiver_group_states" DROP COLUMN IF EXISTS "receiver_results";
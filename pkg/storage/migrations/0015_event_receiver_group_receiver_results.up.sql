@@ -0,0 +1,4 @@
+-- How each receiver contributed to the evaluation behind a group state or completion
+ALTER TABLE "event_receiver_group_states" ADD COLUMN "receiver_results" jsonb NOT NULL DEFAULT '[]';
+
+ALTER TABLE "event_receiver_group_completions" ADD COLUMN "receiver_results" jsonb NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+-- A delivery is leased while a deliverer posts it, outside of any transaction
+ALTER TABLE "webhook_deliveries" ADD COLUMN "leased_until" timestamptz;
@@ -0,0 +1,317 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/utils"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// OutboxMessage is a message stored in the same transaction as the record it announces. It is
+// pending until the relay of the server publishes it.
+type OutboxMessage struct {
+	ID      graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	Payload types.JSON `json:"payload" gorm:"not null"`
+	// Attempts counts the failed attempts to publish the message
+	Attempts  int32      `json:"attempts" gorm:"not null;default:0"`
+	LastError string     `json:"last_error" gorm:"type:text;not null;default:''"`
+	CreatedAt types.Time `json:"created_at" gorm:"type:timestamptz;not null"`
+	// NextAttemptAt is the earliest time the relay publishes the message again
+	NextAttemptAt types.Time `json:"next_attempt_at" gorm:"type:timestamptz;not null"`
+	// SentAt is nil while the message is pending
+	SentAt *types.Time `json:"sent_at,omitempty" gorm:"type:timestamptz"`
+}
+
+// Transaction implements Repository using the database client
+func (db *Database) Transaction(fn func(tx Repository) error) error {
+	return db.Client.Transaction(func(tx *gorm.DB) error {
+		return fn(&Database{Client: tx, MaxPageSize: db.MaxPageSize})
+	})
+}
+
+// CreateOutboxMessage implements Repository using the database client
+func (db *Database) CreateOutboxMessage(payload []byte) (*OutboxMessage, error) {
+	return CreateOutboxMessage(db.Client, payload)
+}
+
+// FindPendingOutboxMessages implements Repository using the database client
+func (db *Database) FindPendingOutboxMessages(limit int) ([]OutboxMessage, error) {
+	return FindPendingOutboxMessages(db.Client, limit)
+}
+
+// MarkOutboxMessageSent implements Repository using the database client
+func (db *Database) MarkOutboxMessageSent(id graphql.ID) error {
+	return MarkOutboxMessageSent(db.Client, id)
+}
+
+// MarkOutboxMessageFailed implements Repository using the database client
+func (db *Database) MarkOutboxMessageFailed(id graphql.ID, reason string, nextAttemptAt time.Time) error {
+	return MarkOutboxMessageFailed(db.Client, id, reason, nextAttemptAt)
+}
+
+// FindStuckOutboxMessages implements Repository using the database client
+func (db *Database) FindStuckOutboxMessages(createdBefore time.Time) ([]OutboxMessage, error) {
+	return FindStuckOutboxMessages(db.Client, createdBefore, db.MaxPageSize)
+}
+
+// CountPendingOutboxMessages implements Repository using the database client
+func (db *Database) CountPendingOutboxMessages() (int64, *time.Time, error) {
+	return CountPendingOutboxMessages(db.Client)
+}
+
+// CreateOutboxMessage stores the message as pending, to be published right away
+func CreateOutboxMessage(tx *gorm.DB, payload []byte) (*OutboxMessage, error) {
+	msg := newOutboxMessage(payload)
+	if result := tx.Create(&msg); result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return &msg, nil
+}
+
+// FindPendingOutboxMessages returns up to limit pending messages that are due, oldest first.
+// Rows are locked so that concurrent relays publish each message once.
+func FindPendingOutboxMessages(tx *gorm.DB, limit int) ([]OutboxMessage, error) {
+	messages := []OutboxMessage{}
+	result := tx.Raw(`SELECT * FROM "outbox_messages" WHERE "sent_at" IS NULL AND "next_attempt_at" <= ? `+
+		`ORDER BY "id" LIMIT ? FOR UPDATE SKIP LOCKED`, time.Now().UTC(), limit).
+		Scan(&messages)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return messages, nil
+}
+
+// MarkOutboxMessageSent records that the message was published
+func MarkOutboxMessageSent(tx *gorm.DB, id graphql.ID) error {
+	result := tx.Model(&OutboxMessage{ID: id}).Update("sent_at", now())
+	if result.Error != nil {
+		return pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return missingOutboxMessage(id)
+	}
+	return nil
+}
+
+// MarkOutboxMessageFailed records a failed attempt to publish the message and when to try again
+func MarkOutboxMessageFailed(tx *gorm.DB, id graphql.ID, reason string, nextAttemptAt time.Time) error {
+	result := tx.Model(&OutboxMessage{ID: id}).Updates(map[string]any{
+		"attempts":        gorm.Expr(`"attempts" + 1`),
+		"last_error":      reason,
+		"next_attempt_at": nextAttemptAt.UTC(),
+	})
+	if result.Error != nil {
+		return pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return missingOutboxMessage(id)
+	}
+	return nil
+}
+
+// FindStuckOutboxMessages returns up to limit messages created before the time that are still
+// pending, oldest first
+func FindStuckOutboxMessages(tx *gorm.DB, createdBefore time.Time, limit int) ([]OutboxMessage, error) {
+	if limit <= 0 {
+		limit = DefaultMaxPageSize
+	}
+	messages := []OutboxMessage{}
+	result := tx.Where(`"sent_at" IS NULL AND "created_at" < ?`, createdBefore.UTC()).
+		Order("id").
+		Limit(limit).
+		Find(&messages)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return messages, nil
+}
+
+// CountPendingOutboxMessages returns the number of pending messages and the creation time of
+// the oldest one, nil when there is none
+func CountPendingOutboxMessages(tx *gorm.DB) (int64, *time.Time, error) {
+	var stats struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	result := tx.Model(&OutboxMessage{}).
+		Select(`count(*) AS "count", min("created_at") AS "oldest"`).
+		Where(`"sent_at" IS NULL`).
+		Scan(&stats)
+	if result.Error != nil {
+		return 0, nil, pgError(result.Error)
+	}
+	return stats.Count, stats.Oldest, nil
+}
+
+func newOutboxMessage(payload []byte) OutboxMessage {
+	created := now()
+	return OutboxMessage{
+		ID:            graphql.ID(utils.NewULIDAsString()),
+		Payload:       types.JSON{JSON: datatypes.JSON(payload)},
+		CreatedAt:     created,
+		NextAttemptAt: created,
+	}
+}
+
+func missingOutboxMessage(id graphql.ID) error {
+	return eprErrors.MissingObjectError{Msg: fmt.Sprintf("outbox message with id %s not found", id)}
+}
+
+// Transaction runs fn with a repository that holds the transaction lock of the store, so that
+// no other write happens until fn returns, and restores the records as they were when fn fails
+func (m *Memory) Transaction(fn func(tx Repository) error) error {
+	if !m.tx {
+		m.txMu.Lock()
+		defer m.txMu.Unlock()
+	}
+	m.mu.RLock()
+	saved := m.snapshot()
+	m.mu.RUnlock()
+
+	if err := fn(&Memory{MaxPageSize: m.MaxPageSize, memoryStore: m.memoryStore, tx: true}); err != nil {
+		m.mu.Lock()
+		m.restore(saved)
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// snapshot copies the records of the repository
+func (m *Memory) snapshot() *memoryStore {
+	return &memoryStore{
+		events:        slices.Clone(m.events),
+		components:    slices.Clone(m.components),
+		receivers:     slices.Clone(m.receivers),
+		schemas:       slices.Clone(m.schemas),
+		groups:        slices.Clone(m.groups),
+		completions:   slices.Clone(m.completions),
+		states:        slices.Clone(m.states),
+		outbox:        slices.Clone(m.outbox),
+		subscriptions: slices.Clone(m.subscriptions),
+		deliveries:    slices.Clone(m.deliveries),
+	}
+}
+
+// restore puts back the records of a snapshot
+func (m *Memory) restore(saved *memoryStore) {
+	m.events = saved.events
+	m.components = saved.components
+	m.receivers = saved.receivers
+	m.schemas = saved.schemas
+	m.groups = saved.groups
+	m.completions = saved.completions
+	m.states = saved.states
+	m.outbox = saved.outbox
+	m.subscriptions = saved.subscriptions
+	m.deliveries = saved.deliveries
+}
+
+// CreateOutboxMessage stores the message as pending, to be published right away
+func (m *Memory) CreateOutboxMessage(payload []byte) (*OutboxMessage, error) {
+	defer m.lock()()
+
+	msg := newOutboxMessage(payload)
+	m.outbox = append(m.outbox, msg)
+	return &msg, nil
+}
+
+// FindPendingOutboxMessages returns up to limit pending messages that are due, oldest first
+func (m *Memory) FindPendingOutboxMessages(limit int) ([]OutboxMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	due := time.Now()
+	messages := []OutboxMessage{}
+	for _, msg := range m.outbox {
+		if len(messages) < limit && msg.SentAt == nil && !time.Time(msg.NextAttemptAt.Date).After(due) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// MarkOutboxMessageSent records that the message was published
+func (m *Memory) MarkOutboxMessageSent(id graphql.ID) error {
+	defer m.lock()()
+
+	msg := m.outboxMessage(id)
+	if msg == nil {
+		return missingOutboxMessage(id)
+	}
+	sent := now()
+	msg.SentAt = &sent
+	return nil
+}
+
+// MarkOutboxMessageFailed records a failed attempt to publish the message and when to try again
+func (m *Memory) MarkOutboxMessageFailed(id graphql.ID, reason string, nextAttemptAt time.Time) error {
+	defer m.lock()()
+
+	msg := m.outboxMessage(id)
+	if msg == nil {
+		return missingOutboxMessage(id)
+	}
+	msg.Attempts++
+	msg.LastError = reason
+	msg.NextAttemptAt = types.Time{Date: datatypes.Date(nextAttemptAt.UTC())}
+	return nil
+}
+
+// FindStuckOutboxMessages returns the messages created before the time that are still pending,
+// oldest first
+func (m *Memory) FindStuckOutboxMessages(createdBefore time.Time) ([]OutboxMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	limit := m.MaxPageSize
+	if limit <= 0 {
+		limit = DefaultMaxPageSize
+	}
+	messages := []OutboxMessage{}
+	for _, msg := range m.outbox {
+		if len(messages) < limit && msg.SentAt == nil && time.Time(msg.CreatedAt.Date).Before(createdBefore) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// CountPendingOutboxMessages returns the number of pending messages and the creation time of
+// the oldest one, nil when there is none
+func (m *Memory) CountPendingOutboxMessages() (int64, *time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	var oldest *time.Time
+	for _, msg := range m.outbox {
+		if msg.SentAt != nil {
+			continue
+		}
+		count++
+		if created := time.Time(msg.CreatedAt.Date); oldest == nil || created.Before(*oldest) {
+			oldest = &created
+		}
+	}
+	return count, oldest, nil
+}
+
+// outboxMessage looks up an outbox message by ID. Callers must hold the lock.
+func (m *Memory) outboxMessage(id graphql.ID) *OutboxMessage {
+	for i := range m.outbox {
+		if m.outbox[i].ID == id {
+			return &m.outbox[i]
+		}
+	}
+	return nil
+}
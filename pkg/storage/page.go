@@ -0,0 +1,290 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/oklog/ulid"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxPageSize is the largest page a search returns when no maximum is configured
+const DefaultMaxPageSize = 1000
+
+const (
+	// OrderAsc sorts results from the oldest to the newest created_at
+	OrderAsc = "ASC"
+	// OrderDesc sorts results from the newest to the oldest created_at
+	OrderDesc = "DESC"
+)
+
+// Page selects a window of a search result. First/After page forward and
+// Last/Before page backward through the results sorted by created_at.
+// Cursors are opaque values returned in PageInfo and on each item. A cursor
+// must point at a stored record, which need not match the search.
+type Page struct {
+	First  *int
+	After  string
+	Last   *int
+	Before string
+	Order  string
+}
+
+// PageInfo describes the window of results that was returned
+type PageInfo struct {
+	TotalCount      int
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     string
+	EndCursor       string
+}
+
+// Paginated is a single page of search results
+type Paginated[T any] struct {
+	Items    []T
+	PageInfo PageInfo
+}
+
+// EncodeCursor returns the opaque cursor for the record with the given ULID
+func EncodeCursor(id graphql.ID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// DecodeCursor returns the ULID a cursor points at
+func DecodeCursor(cursor string) (graphql.ID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid cursor %q", cursor)}
+	}
+	if _, err := ulid.ParseStrict(string(raw)); err != nil {
+		return "", eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid cursor %q", cursor)}
+	}
+	return graphql.ID(raw), nil
+}
+
+// normalize validates the page and bounds its size by maxPageSize. The returned
+// limit is the number of items to return and backward is true when paging with Last.
+func (p Page) normalize(maxPageSize int) (limit int, backward bool, err error) {
+	if maxPageSize <= 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
+	if p.First != nil && p.Last != nil {
+		return 0, false, eprErrors.InvalidInputError{Msg: "first and last cannot be used together"}
+	}
+	order := strings.ToUpper(p.Order)
+	if order != "" && order != OrderAsc && order != OrderDesc {
+		return 0, false, eprErrors.InvalidInputError{Msg: fmt.Sprintf("invalid order %q", p.Order)}
+	}
+
+	limit = maxPageSize
+	switch {
+	case p.First != nil:
+		limit = *p.First
+	case p.Last != nil:
+		limit = *p.Last
+		backward = true
+	}
+	if limit < 0 {
+		return 0, false, eprErrors.InvalidInputError{Msg: "page size cannot be negative"}
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return limit, backward, nil
+}
+
+func (p Page) descending() bool {
+	return strings.ToUpper(p.Order) == OrderDesc
+}
+
+// paginate runs the query for one page of results on the given table, ordered by
+// created_at and then by id so that records created in the same instant keep a stable order.
+func paginate[T any](query *gorm.DB, table string, page Page, maxPageSize int, id func(T) graphql.ID) (*Paginated[T], error) {
+	limit, backward, err := page.normalize(maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// a new session lets the count and the page share the filters without one modifying the other
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, pgError(err)
+	}
+
+	// the comparison used for "after" in the requested order, flipped for "before"
+	after, before := ">", "<"
+	if page.descending() {
+		after, before = before, after
+	}
+	keyset := `("%[1]s"."created_at", "%[1]s"."id") %[2]s (SELECT "created_at", "id" FROM "%[1]s" WHERE "id" = ?)`
+	if page.After != "" {
+		cursor, err := DecodeCursor(page.After)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCursor(query, table, cursor); err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf(keyset, table, after), cursor)
+	}
+	if page.Before != "" {
+		cursor, err := DecodeCursor(page.Before)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCursor(query, table, cursor); err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf(keyset, table, before), cursor)
+	}
+
+	// walk backwards from the end of the window when paging with last
+	descending := page.descending() != backward
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	var items []T
+	result := query.
+		Order(fmt.Sprintf(`"%s"."created_at" %s, "%s"."id" %s`, table, direction, table, direction)).
+		Limit(limit + 1).
+		Find(&items)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+
+	return window(items, int(total), limit, backward, page, id), nil
+}
+
+// checkCursor rejects a cursor that points at no record of the table, the keyset
+// comparison against it would otherwise match nothing
+func checkCursor(query *gorm.DB, table string, cursor graphql.ID) error {
+	var count int64
+	err := query.Session(&gorm.Session{NewDB: true}).Table(table).Where(`"id" = ?`, cursor).Count(&count).Error
+	if err != nil {
+		return pgError(err)
+	}
+	if count == 0 {
+		return eprErrors.InvalidInputError{Msg: fmt.Sprintf("unknown cursor %q", EncodeCursor(cursor))}
+	}
+	return nil
+}
+
+// ranks returns the position of each record in creation order, which stands in for
+// the (created_at, id) keyset of the records held in memory
+func ranks[T any](records []T, id func(T) graphql.ID) map[graphql.ID]int {
+	ranked := make(map[graphql.ID]int, len(records))
+	for i, record := range records {
+		ranked[id(record)] = i
+	}
+	return ranked
+}
+
+// cursorRank returns the rank of the record the cursor points at
+func cursorRank(cursor string, ranked map[graphql.ID]int) (int, error) {
+	id, err := DecodeCursor(cursor)
+	if err != nil {
+		return 0, err
+	}
+	rank, ok := ranked[id]
+	if !ok {
+		return 0, eprErrors.InvalidInputError{Msg: fmt.Sprintf("unknown cursor %q", cursor)}
+	}
+	return rank, nil
+}
+
+// paginateSlice applies a page to records already held in memory. The records must be
+// in ascending creation order and ranked holds the rank of every stored record, so
+// that cursors compare like the keysets of the query even when they point at a
+// record the search filtered out.
+func paginateSlice[T any](records []T, ranked map[graphql.ID]int, page Page, maxPageSize int, id func(T) graphql.ID) (*Paginated[T], error) {
+	limit, backward, err := page.normalize(maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	var after, before int
+	if page.After != "" {
+		if after, err = cursorRank(page.After, ranked); err != nil {
+			return nil, err
+		}
+	}
+	if page.Before != "" {
+		if before, err = cursorRank(page.Before, ranked); err != nil {
+			return nil, err
+		}
+	}
+
+	// follows reports whether rank a comes after rank b in the requested order
+	follows := func(a, b int) bool {
+		if page.descending() {
+			return a < b
+		}
+		return a > b
+	}
+	ordered := []T{}
+	for _, record := range records {
+		rank := ranked[id(record)]
+		if page.After != "" && !follows(rank, after) {
+			continue
+		}
+		if page.Before != "" && !follows(before, rank) {
+			continue
+		}
+		ordered = append(ordered, record)
+	}
+	if page.descending() {
+		reverse(ordered)
+	}
+
+	// mirror the query which fetches one extra record to detect further pages
+	if backward {
+		reverse(ordered)
+	}
+	if len(ordered) > limit+1 {
+		ordered = ordered[:limit+1]
+	}
+
+	return window(ordered, len(records), limit, backward, page, id), nil
+}
+
+func reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// window trims the extra record fetched to detect more pages, restores the requested
+// order for backward pages and fills in the page info.
+func window[T any](items []T, total, limit int, backward bool, page Page, id func(T) graphql.ID) *Paginated[T] {
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	if backward {
+		reverse(items)
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	info := PageInfo{TotalCount: total}
+	if backward {
+		info.HasPreviousPage = more
+		info.HasNextPage = page.Before != ""
+	} else {
+		info.HasNextPage = more
+		info.HasPreviousPage = page.After != ""
+	}
+	if len(items) > 0 {
+		info.StartCursor = EncodeCursor(id(items[0]))
+		info.EndCursor = EncodeCursor(id(items[len(items)-1]))
+	}
+	return &Paginated[T]{Items: items, PageInfo: info}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+func eventIDs(events []Event) []graphql.ID {
+	ids := []graphql.ID{}
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestPagination(t *testing.T) {
+	m := NewMemory()
+	m.MaxPageSize = 3
+	receiver := newTestReceiver(t, m, "build")
+	var all []graphql.ID
+	for i := 0; i < 5; i++ {
+		all = append(all, newTestEvent(t, m, receiver.ID, true).ID)
+	}
+	two := 2
+
+	// no page size returns at most the maximum
+	page, err := m.FindEventPage(EventFilter{}, Page{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), all[:3])
+	assert.Equal(t, page.PageInfo.TotalCount, 5)
+	assert.Assert(t, page.PageInfo.HasNextPage)
+	assert.Assert(t, !page.PageInfo.HasPreviousPage)
+
+	// forward
+	page, err = m.FindEventPage(EventFilter{}, Page{First: &two, After: EncodeCursor(all[1])})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), all[2:4])
+	assert.Assert(t, page.PageInfo.HasNextPage)
+	assert.Assert(t, page.PageInfo.HasPreviousPage)
+	assert.Equal(t, page.PageInfo.EndCursor, EncodeCursor(all[3]))
+
+	// backward
+	page, err = m.FindEventPage(EventFilter{}, Page{Last: &two})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), all[3:])
+	assert.Assert(t, page.PageInfo.HasPreviousPage)
+	assert.Assert(t, !page.PageInfo.HasNextPage)
+
+	page, err = m.FindEventPage(EventFilter{}, Page{Last: &two, Before: EncodeCursor(all[1])})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), all[:1])
+	assert.Assert(t, !page.PageInfo.HasPreviousPage)
+	assert.Assert(t, page.PageInfo.HasNextPage)
+
+	// descending
+	page, err = m.FindEventPage(EventFilter{}, Page{First: &two, Order: OrderDesc})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), []graphql.ID{all[4], all[3]})
+
+	page, err = m.FindEventPage(EventFilter{}, Page{First: &two, After: page.PageInfo.EndCursor, Order: OrderDesc})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), []graphql.ID{all[2], all[1]})
+}
+
+func TestPaginationFilteredCursor(t *testing.T) {
+	m := NewMemory()
+	build := newTestReceiver(t, m, "build")
+	test := newTestReceiver(t, m, "test")
+	first := newTestEvent(t, m, build.ID, true)
+	skipped := newTestEvent(t, m, test.ID, true)
+	last := newTestEvent(t, m, build.ID, true)
+	filter := EventFilter{EventReceiverIDs: []graphql.ID{build.ID}}
+
+	// the cursor compares like a keyset even though its record does not match the filter
+	page, err := m.FindEventPage(filter, Page{After: EncodeCursor(skipped.ID)})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), []graphql.ID{last.ID})
+
+	page, err = m.FindEventPage(filter, Page{Before: EncodeCursor(skipped.ID), Order: OrderDesc})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), []graphql.ID{last.ID})
+
+	page, err = m.FindEventPage(filter, Page{After: EncodeCursor(skipped.ID), Order: OrderDesc})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, eventIDs(page.Items), []graphql.ID{first.ID})
+}
+
+func TestPaginationInvalid(t *testing.T) {
+	m := NewMemory()
+	one, negative := 1, -1
+
+	tests := map[string]Page{
+		"first and last": {First: &one, Last: &one},
+		"negative size":  {First: &negative},
+		"bad order":      {Order: "sideways"},
+		"bad cursor":     {After: "not-a-cursor"},
+		"non ulid":       {Before: EncodeCursor("foo")},
+		"unknown cursor": {After: EncodeCursor("01HKNDTSFT6ZZ8Q8YNK736TT43")},
+	}
+	for name, page := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.FindEventPage(EventFilter{}, page)
+			assert.ErrorType(t, err, eprErrors.InvalidInputError{})
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	id := graphql.ID("01HKNDTSFT6ZZ8Q8YNK736TT43")
+	decoded, err := DecodeCursor(EncodeCursor(id))
+	assert.NilError(t, err)
+	assert.Equal(t, decoded, id)
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// DriverPostgres selects the Postgres backed Database repository
+	DriverPostgres = "postgres"
+	// DriverMemory selects the in-memory Memory repository
+	DriverMemory = "memory"
+)
+
+// ensure both backends implement the interface
+var (
+	_ Repository = &Database{}
+	_ Repository = &Memory{}
+)
+
+// Repository is the set of storage operations the server depends on. The
+// epr package, the REST handlers and the GraphQL resolvers only talk to
+// storage through this interface so that the backend can be swapped.
+type Repository interface {
+	// Transaction runs fn with a repository whose writes are committed together once fn
+	// returns, or rolled back when it fails
+	Transaction(fn func(tx Repository) error) error
+
+	CreateEvent(event Event) (*Event, error)
+	FindEventByID(id graphql.ID) ([]Event, error)
+	FindEvent(e map[string]any) ([]Event, error)
+	FindEventPage(filter EventFilter, page Page) (*Paginated[Event], error)
+	// RevokeEvent records the revocation and returns the revoked event. An event can only be
+	// revoked once.
+	RevokeEvent(revocation EventRevocation) (*Event, error)
+	// FindEventChain returns up to limit events of the hash chain of the receiver whose
+	// sequence is after the given one, in sequence order
+	FindEventChain(eventReceiverID graphql.ID, after int32, limit int) ([]Event, error)
+	// FindSBOMComponentPage returns one page of the SBOM components matching the filter, with
+	// the event carrying their SBOM
+	FindSBOMComponentPage(filter SBOMComponentFilter, page Page) (*Paginated[SBOMComponent], error)
+
+	CreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, error)
+	FindEventReceiverByID(id graphql.ID) ([]EventReceiver, error)
+	FindEventReceiver(er map[string]any) ([]EventReceiver, error)
+	FindEventReceiverPage(er map[string]any, page Page) (*Paginated[EventReceiver], error)
+	// FindOrCreateEventReceiver returns the oldest receiver with the fingerprint of the given
+	// one, creating it when there is none. The boolean reports whether it was created.
+	FindOrCreateEventReceiver(eventReceiver EventReceiver) (*EventReceiver, bool, error)
+
+	// CreateEventReceiverSchema makes the schema the current one of its receiver. The revision
+	// must directly follow the current revision of the receiver.
+	CreateEventReceiverSchema(eventReceiverSchema EventReceiverSchema) (*EventReceiverSchema, error)
+	// FindEventReceiverSchemas returns the schema revisions of the receiver, oldest first
+	FindEventReceiverSchemas(id graphql.ID) ([]EventReceiverSchema, error)
+	// SetEventReceiverSigning replaces the trusted public keys of the receiver and whether its
+	// events must be signed
+	SetEventReceiverSigning(id graphql.ID, publicKeys []string, requireSignature bool) (*EventReceiver, error)
+
+	CreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, error)
+	FindEventReceiverGroupByID(id graphql.ID) ([]EventReceiverGroup, error)
+	FindEventReceiverGroup(erg map[string]any) ([]EventReceiverGroup, error)
+	FindEventReceiverGroupPage(erg map[string]any, page Page) (*Paginated[EventReceiverGroup], error)
+	// FindOrCreateEventReceiverGroup returns the oldest group with the fingerprint of the given
+	// one, creating it when there is none. The boolean reports whether it was created.
+	FindOrCreateEventReceiverGroup(eventReceiverGroup EventReceiverGroup) (*EventReceiverGroup, bool, error)
+	SetEventReceiverGroupEnabled(id graphql.ID, enabled bool) error
+	UpdateEventReceiverGroup(id graphql.ID, update EventReceiverGroupUpdate) (*EventReceiverGroup, error)
+	DeleteEventReceiverGroup(id graphql.ID) error
+
+	FindEventReceiverGroupsByEventReceiverID(id graphql.ID) ([]EventReceiverGroup, error)
+	FindEventReceiversByIDs(ids []graphql.ID) ([]EventReceiver, error)
+
+	// FindLatestEvents returns the most recent event of each of the receivers
+	// for the name, version, release, platform and package of the tuple. Revoked
+	// events are skipped.
+	FindLatestEvents(eventReceiverIDs []graphql.ID, tuple Event) ([]Event, error)
+
+	// FindEventTuples returns the distinct artifact tuples the receivers have events for. Only
+	// the name, version, release, platform_id and package of the returned events are set.
+	FindEventTuples(eventReceiverIDs []graphql.ID) ([]Event, error)
+
+	SaveEventReceiverGroupCompletion(completion EventReceiverGroupCompletion) (*EventReceiverGroupCompletion, error)
+	FindEventReceiverGroupCompletions(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupCompletion, error)
+
+	// LockEventReceiverGroup holds the group for the artifact of the tuple until the transaction
+	// ends, so that concurrent evaluations of the group for the artifact see the events and the
+	// state stored by each other
+	LockEventReceiverGroup(id graphql.ID, tuple Event) error
+	SaveEventReceiverGroupState(state EventReceiverGroupState) (*EventReceiverGroupState, error)
+	FindEventReceiverGroupStates(id graphql.ID, tuple map[string]any) ([]EventReceiverGroupState, error)
+
+	// CreateOutboxMessage stores the payload of a message to publish once the transaction
+	// writing it commits
+	CreateOutboxMessage(payload []byte) (*OutboxMessage, error)
+	// FindPendingOutboxMessages returns up to limit pending messages that are due, oldest first
+	FindPendingOutboxMessages(limit int) ([]OutboxMessage, error)
+	MarkOutboxMessageSent(id graphql.ID) error
+	// MarkOutboxMessageFailed records a failed attempt to publish the message and when to try again
+	MarkOutboxMessageFailed(id graphql.ID, reason string, nextAttemptAt time.Time) error
+	// FindStuckOutboxMessages returns the messages created before the time that are still pending
+	FindStuckOutboxMessages(createdBefore time.Time) ([]OutboxMessage, error)
+	// CountPendingOutboxMessages returns the number of pending messages and the creation time of
+	// the oldest one, nil when there is none
+	CountPendingOutboxMessages() (int64, *time.Time, error)
+
+	CreateWebhookSubscription(subscription WebhookSubscription) (*WebhookSubscription, error)
+	// FindWebhookSubscriptions returns the subscription with the id, or every subscription when
+	// the id is empty
+	FindWebhookSubscriptions(id graphql.ID) ([]WebhookSubscription, error)
+	UpdateWebhookSubscription(id graphql.ID, update WebhookSubscriptionUpdate) (*WebhookSubscription, error)
+	// DeleteWebhookSubscription deletes the subscription along with its deliveries
+	DeleteWebhookSubscription(id graphql.ID) error
+	// RecordWebhookSubscriptionFailure counts a delivery that failed for good and returns the
+	// number of consecutive failures of the subscription
+	RecordWebhookSubscriptionFailure(id graphql.ID) (int32, error)
+	ResetWebhookSubscriptionFailures(id graphql.ID) error
+	// PauseWebhookSubscription stops the deliveries of an active subscription
+	PauseWebhookSubscription(id graphql.ID, reason string) error
+
+	// CreateWebhookDelivery stores a pending delivery unless the message was already delivered
+	// to the subscription. The boolean reports whether it was stored.
+	CreateWebhookDelivery(delivery WebhookDelivery) (bool, error)
+	// ClaimWebhookDeliveries leases up to limit pending deliveries of active subscriptions that
+	// are due and not leased, oldest first, and returns them with their subscription. Other
+	// claims skip them until the lease expires or an attempt is recorded.
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error)
+	// RecordWebhookDeliveryAttempt appends the attempt to the log of the delivery, moves it to
+	// the state and releases its lease. A pending delivery is attempted again at nextAttemptAt.
+	RecordWebhookDeliveryAttempt(id graphql.ID, attempt WebhookDeliveryAttempt, state string, nextAttemptAt time.Time) error
+	// FindWebhookDeliveries returns the deliveries of the subscription newest first, only those
+	// in the state unless it is empty
+	FindWebhookDeliveries(subscriptionID graphql.ID, state string) ([]WebhookDelivery, error)
+	// RedeliverWebhookDelivery makes the delivery pending again with a fresh set of retries
+	RedeliverWebhookDelivery(id graphql.ID) (*WebhookDelivery, error)
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/utils"
 	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
 )
 
 // Event type represents an event with various properties and a relationship to an event receiver.
@@ -28,6 +30,62 @@ type Event struct {
 
 	EventReceiverID graphql.ID `json:"event_receiver_id" gorm:"type:varchar(255);not null"`
 	EventReceiver   EventReceiver
+	// SchemaRevision is the revision of the receiver schema the payload was validated against
+	SchemaRevision int32 `json:"schema_revision" gorm:"not null;default:1"`
+	// Sequence is the position of the event in the hash chain of its receiver, starting at 1.
+	// Events stored before the chain was introduced have 0.
+	Sequence int32 `json:"sequence" gorm:"not null;default:0"`
+	// PreviousHash is the hash of the event before it in the chain
+	PreviousHash string `json:"previous_hash" gorm:"type:varchar(64);not null;default:''"`
+	// Hash is the ContentHash of the event
+	Hash string `json:"hash" gorm:"type:varchar(64);not null;default:''"`
+	// Signature is the signature the producer attached to the event, nil for unsigned events
+	Signature *EventSignature `json:"signature,omitempty" gorm:"serializer:json;type:jsonb"`
+	// Revocation is set once the event is revoked
+	Revocation *EventRevocation `json:"revocation,omitempty" gorm:"foreignKey:EventID"`
+	// Components are the components of the SBOM in the payload, stored with the event so that
+	// they can be searched. They are only set on events created from an SBOM.
+	Components []SBOMComponent `json:"-" gorm:"foreignKey:EventID"`
+}
+
+// EventSignature is the DSSE signature of an event and the outcome of its verification against
+// the trusted keys of the receiver when the event was created
+type EventSignature struct {
+	// KeyID identifies the key, the hex encoded SHA-256 of its PKIX DER encoding
+	KeyID string `json:"key_id"`
+	// Signature is the base64 encoded DSSE signature that verified, or the first one
+	Signature string `json:"signature"`
+	// Payload is the base64 encoded payload of the envelope, which the signature covers
+	Payload  string `json:"payload,omitempty"`
+	Verified bool   `json:"verified"`
+	// Reason tells why the signature did not verify
+	Reason string `json:"reason,omitempty"`
+}
+
+// EventRevocation retracts an event that was sent by mistake or by a compromised job. Revoked
+// events stay in the registry for the audit trail but no longer count towards any group.
+type EventRevocation struct {
+	EventID   graphql.ID `json:"event_id" gorm:"type:varchar(255);primaryKey;not null"`
+	RevokedBy string     `json:"revoked_by" gorm:"type:varchar(255);not null"`
+	Reason    string     `json:"reason" gorm:"type:text;not null"`
+	RevokedAt types.Time `json:"revoked_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// SBOMComponent is a component listed in the SBOM carried by the payload of an event
+type SBOMComponent struct {
+	ID      graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	EventID graphql.ID `json:"event_id" gorm:"type:varchar(255);not null;index"`
+	// Group is the namespace of the component, such as the Maven group id
+	Group   string `json:"group" gorm:"type:text;not null"`
+	Name    string `json:"name" gorm:"type:text;not null"`
+	Version string `json:"version" gorm:"type:text;not null"`
+	// PURL is the package URL of the component, empty when the SBOM does not give one
+	PURL string `json:"purl" gorm:"type:text;not null"`
+	Type string `json:"type" gorm:"type:varchar(255);not null"`
+	// CreatedAt is the creation time of the event
+	CreatedAt types.Time `json:"created_at" gorm:"type:timestamptz;not null"`
+
+	Event *Event `json:"event,omitempty" gorm:"foreignKey:EventID"`
 }
 
 // EventReceiver type represents an event receiver with various properties such as ID, name, type, version, etc...
@@ -39,8 +97,36 @@ type EventReceiver struct {
 	Description string     `json:"description" gorm:"type:varchar(255);not null"`
 
 	Schema      types.JSON `json:"schema" gorm:"not null"`
-	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(255);not null"`
+	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(255);not null;index"`
 	CreatedAt   types.Time `json:"created_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+	// SchemaRevision is the revision of Schema, new events are validated against it
+	SchemaRevision int32 `json:"schema_revision" gorm:"not null;default:1"`
+	// PublicKeys are the PEM encoded public keys trusted to sign events of the receiver
+	PublicKeys []string `json:"public_keys" gorm:"serializer:json;type:jsonb;not null;default:'[]'"`
+	// RequireSignature rejects events without a signature that verifies with one of PublicKeys
+	RequireSignature bool `json:"require_signature" gorm:"not null;default:false"`
+}
+
+const (
+	// CompatibilityBackward accepts a schema that every payload valid under the previous revision satisfies
+	CompatibilityBackward = "BACKWARD"
+	// CompatibilityForward accepts a schema whose payloads all satisfy the previous revision
+	CompatibilityForward = "FORWARD"
+	// CompatibilityFull accepts a schema that is both backward and forward compatible
+	CompatibilityFull = "FULL"
+	// CompatibilityNone accepts any schema
+	CompatibilityNone = "NONE"
+)
+
+// EventReceiverSchema is a revision of the JSON schema of a receiver. The first revision is the
+// schema the receiver was created with.
+type EventReceiverSchema struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id" gorm:"type:varchar(255);primaryKey;not null"`
+	Revision        int32      `json:"revision" gorm:"primaryKey;autoIncrement:false;not null"`
+	Schema          types.JSON `json:"schema" gorm:"not null"`
+	// Compatibility is the check the revision passed against the one before it
+	Compatibility string     `json:"compatibility" gorm:"type:varchar(255);not null"`
+	CreatedAt     types.Time `json:"created_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
 }
 
 // EventReceiverGroup represents a group of event receivers with various properties.
@@ -51,11 +137,138 @@ type EventReceiverGroup struct {
 	Version     string     `json:"version" gorm:"type:varchar(255);not null"`
 	Description string     `json:"description" gorm:"type:varchar(255);not null"`
 	Enabled     bool       `json:"enabled" gorm:"not null"`
+	Policy      GatePolicy `json:"policy" gorm:"serializer:json;type:jsonb;not null"`
+	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(255);not null;index"`
 
 	EventReceiverIDs []graphql.ID `json:"event_receiver_ids" gorm:"-"`
+	// Conditions holds the memberships that carry a condition, in the order of EventReceiverIDs
+	Conditions []EventReceiverCondition `json:"conditions" gorm:"-"`
 
 	CreatedAt types.Time `json:"created_at" gorm:"type:timestamptz; not null; default:CURRENT_TIMESTAMP"`
 	UpdatedAt types.Time `json:"updated_at" gorm:"type:timestamptz; not null; default:CURRENT_TIMESTAMP"`
+	// DeletedAt soft deletes the group, deleted groups are left out of every query
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// fingerprint identifies a receiver or a group by its type, description, name and version
+func fingerprint(name, typ, version, description string) string {
+	seed := utils.Seed{
+		Name:        name,
+		Type:        typ,
+		Version:     version,
+		Description: description,
+	}
+	return seed.Fingerprint()
+}
+
+// EventReceiverGroupUpdate changes an existing group. Nil fields are left alone.
+type EventReceiverGroupUpdate struct {
+	Description *string     `json:"description,omitempty"`
+	Enabled     *bool       `json:"enabled,omitempty"`
+	Policy      *GatePolicy `json:"policy,omitempty"`
+
+	AddEventReceivers      []EventReceiverMembership `json:"add_event_receivers,omitempty"`
+	RemoveEventReceiverIDs []graphql.ID              `json:"remove_event_receiver_ids,omitempty"`
+}
+
+// EventReceiverMembership is a receiver joining a group, with the condition its latest event
+// must satisfy, if any, and whether it is one of the optional receivers of the policy
+type EventReceiverMembership struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	Condition       string     `json:"condition,omitempty"`
+	Optional        bool       `json:"optional,omitempty"`
+}
+
+const (
+	// PolicyAllOf passes when every required receiver is satisfied
+	PolicyAllOf = "ALL_OF"
+	// PolicyAnyOf passes when at least one required receiver is satisfied
+	PolicyAnyOf = "ANY_OF"
+	// PolicyNOf passes when at least N required receivers are satisfied
+	PolicyNOf = "N_OF"
+)
+
+// GatePolicy decides how many receivers of a group must be satisfied for the group to pass.
+// Optional receivers are evaluated and reported but never count towards the policy.
+type GatePolicy struct {
+	Type                     string       `json:"type"`
+	N                        *int32       `json:"n,omitempty"`
+	OptionalEventReceiverIDs []graphql.ID `json:"optional_event_receiver_ids"`
+}
+
+// EventReceiverCondition is a CEL expression the latest event of a receiver must satisfy, on top
+// of being a success, for its membership in a group to count.
+type EventReceiverCondition struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	Expression      string     `json:"expression"`
+}
+
+// Condition returns the expression of the receiver, empty when it has none
+func (erg EventReceiverGroup) Condition(eventReceiverID graphql.ID) string {
+	for _, c := range erg.Conditions {
+		if c.EventReceiverID == eventReceiverID {
+			return c.Expression
+		}
+	}
+	return ""
+}
+
+// EventReceiverGroupCompletion records that a group passed for an artifact, identified by the
+// name, version, release, platform id and package of its events. There is at most one record
+// per group and artifact; it holds the events that most recently satisfied the group.
+type EventReceiverGroupCompletion struct {
+	ID                   graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	EventReceiverGroupID graphql.ID `json:"event_receiver_group_id" gorm:"type:varchar(255);not null"`
+	Name                 string     `json:"name" gorm:"type:varchar(255);not null"`
+	Version              string     `json:"version" gorm:"type:varchar(255);not null"`
+	Release              string     `json:"release" gorm:"type:varchar(255);not null"`
+	PlatformID           string     `json:"platform_id" gorm:"type:varchar(255);not null"`
+	Package              string     `json:"package" gorm:"type:varchar(255);not null"`
+
+	EventIDs []graphql.ID `json:"event_ids" gorm:"serializer:json;type:jsonb;not null"`
+	// ReceiverResults is how each receiver contributed to the evaluation that passed
+	ReceiverResults []ReceiverResult `json:"receiver_results" gorm:"serializer:json;type:jsonb;not null"`
+	CompletedAt     types.Time       `json:"completed_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// ReceiverResult is how one receiver of a group contributed to its evaluation for an artifact
+type ReceiverResult struct {
+	EventReceiverID graphql.ID `json:"event_receiver_id"`
+	// EventID is the latest event of the receiver, empty when there is none
+	EventID   graphql.ID `json:"event_id"`
+	Satisfied bool       `json:"satisfied"`
+	Optional  bool       `json:"optional"`
+	// Condition is the expression the latest event had to satisfy, if any
+	Condition string `json:"condition"`
+	// Error is set when the condition could not be evaluated
+	Error string `json:"error"`
+}
+
+const (
+	// GroupStatePending means the group has not passed or failed for the artifact yet
+	GroupStatePending = "pending"
+	// GroupStatePassed means the group passed for the artifact
+	GroupStatePassed = "passed"
+	// GroupStateFailed means the group cannot pass for the artifact until a receiver sends a newer event
+	GroupStateFailed = "failed"
+)
+
+// EventReceiverGroupState is the current state of a group for an artifact tuple
+type EventReceiverGroupState struct {
+	ID                   graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	EventReceiverGroupID graphql.ID `json:"event_receiver_group_id" gorm:"type:varchar(255);not null"`
+	Name                 string     `json:"name" gorm:"type:varchar(255);not null"`
+	Version              string     `json:"version" gorm:"type:varchar(255);not null"`
+	Release              string     `json:"release" gorm:"type:varchar(255);not null"`
+	PlatformID           string     `json:"platform_id" gorm:"type:varchar(255);not null"`
+	Package              string     `json:"package" gorm:"type:varchar(255);not null"`
+
+	State string `json:"state" gorm:"type:varchar(255);not null"`
+	// EventID is the event that caused the last change of state
+	EventID graphql.ID `json:"event_id" gorm:"type:varchar(255);not null"`
+	// ReceiverResults is how each receiver contributed to the latest evaluation
+	ReceiverResults []ReceiverResult `json:"receiver_results" gorm:"serializer:json;type:jsonb;not null"`
+	UpdatedAt       types.Time       `json:"updated_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
 }
 
 // EventReceiverGroupToEventReceiver represents the relationship between an
@@ -67,6 +280,8 @@ type EventReceiverGroupToEventReceiver struct {
 
 	EventReceiverGroup   EventReceiverGroup
 	EventReceiverGroupID graphql.ID `json:"event_receiver_group_id" gorm:"type:varchar(255);not null"`
+
+	Condition string `json:"condition" gorm:"type:text;not null;default:''"`
 }
 
 // ToJSON() function is a method defined on the `Event` struct. It converts an instance of the
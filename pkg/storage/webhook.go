@@ -0,0 +1,679 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	eprErrors "github.com/sassoftware/event-provenance-registry/pkg/errors"
+	"github.com/sassoftware/event-provenance-registry/pkg/utils"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// SubscriptionStateActive delivers the matching messages
+	SubscriptionStateActive = "ACTIVE"
+	// SubscriptionStateDisabled was turned off, nothing is delivered until it is enabled again
+	SubscriptionStateDisabled = "DISABLED"
+	// SubscriptionStatePaused was turned off because its deliveries kept failing, nothing is
+	// delivered until it is enabled again
+	SubscriptionStatePaused = "PAUSED"
+)
+
+const (
+	// DeliveryStatePending is waiting for its next attempt
+	DeliveryStatePending = "PENDING"
+	// DeliveryStateSucceeded got a 2xx response
+	DeliveryStateSucceeded = "SUCCEEDED"
+	// DeliveryStateFailed ran out of retries, it can still be redelivered by hand
+	DeliveryStateFailed = "FAILED"
+)
+
+// WebhookSubscription posts the messages that match its filters to a URL. A message matches
+// when it matches every filter that is not empty.
+type WebhookSubscription struct {
+	ID   graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	Name string     `json:"name" gorm:"type:varchar(255);not null"`
+	URL  string     `json:"url" gorm:"type:text;not null"`
+	// Secret is the key of the HMAC signature of each delivery, it is never returned
+	Secret string `json:"-" gorm:"type:varchar(255);not null"`
+	// MessageTypes are the types of the messages to deliver
+	MessageTypes []string `json:"message_types" gorm:"serializer:json;type:jsonb;not null;default:'[]'"`
+	// EventReceiverIDs are the receivers the messages to deliver are about
+	EventReceiverIDs []graphql.ID `json:"event_receiver_ids" gorm:"serializer:json;type:jsonb;not null;default:'[]'"`
+	// EventReceiverGroupIDs are the groups the messages to deliver are about
+	EventReceiverGroupIDs []graphql.ID `json:"event_receiver_group_ids" gorm:"serializer:json;type:jsonb;not null;default:'[]'"`
+	State                 string       `json:"state" gorm:"type:varchar(255);not null"`
+	// PausedReason tells why the subscription was paused
+	PausedReason string `json:"paused_reason" gorm:"type:text;not null;default:''"`
+	// ConsecutiveFailures counts the deliveries that failed for good since the last success
+	ConsecutiveFailures int32      `json:"consecutive_failures" gorm:"not null;default:0"`
+	CreatedAt           types.Time `json:"created_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt           types.Time `json:"updated_at" gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// WebhookSubscriptionUpdate changes an existing subscription. Nil fields are left alone, the
+// filters are replaced as a whole. Enabling a subscription resumes it when it was paused.
+type WebhookSubscriptionUpdate struct {
+	Name                  *string       `json:"name,omitempty"`
+	URL                   *string       `json:"url,omitempty"`
+	Secret                *string       `json:"secret,omitempty"`
+	MessageTypes          *[]string     `json:"message_types,omitempty"`
+	EventReceiverIDs      *[]graphql.ID `json:"event_receiver_ids,omitempty"`
+	EventReceiverGroupIDs *[]graphql.ID `json:"event_receiver_group_ids,omitempty"`
+	Enabled               *bool         `json:"enabled,omitempty"`
+}
+
+// WebhookDelivery is a message posted, or to post, to the URL of a subscription along with the
+// log of its attempts
+type WebhookDelivery struct {
+	ID             graphql.ID `json:"id" gorm:"type:varchar(255);primary_key;not null"`
+	SubscriptionID graphql.ID `json:"subscription_id" gorm:"type:varchar(255);not null"`
+	// MessageID is the CloudEvents id of the message, a message is delivered once per subscription
+	MessageID   string     `json:"message_id" gorm:"type:varchar(255);not null"`
+	MessageType string     `json:"message_type" gorm:"type:varchar(255);not null"`
+	Payload     types.JSON `json:"payload" gorm:"not null"`
+	State       string     `json:"state" gorm:"type:varchar(255);not null"`
+	// Retries counts the failed attempts since the delivery was created or redelivered
+	Retries int32 `json:"retries" gorm:"not null;default:0"`
+	// Attempts is the log of every attempt, oldest first
+	Attempts      []WebhookDeliveryAttempt `json:"attempts" gorm:"serializer:json;type:jsonb;not null;default:'[]'"`
+	CreatedAt     types.Time               `json:"created_at" gorm:"type:timestamptz;not null"`
+	NextAttemptAt types.Time               `json:"next_attempt_at" gorm:"type:timestamptz;not null"`
+	// DeliveredAt is the time of the last successful attempt
+	DeliveredAt *types.Time `json:"delivered_at,omitempty" gorm:"type:timestamptz"`
+	// LeasedUntil is set while a deliverer attempts the delivery, no other deliverer claims it
+	// before then
+	LeasedUntil *types.Time `json:"leased_until,omitempty" gorm:"type:timestamptz"`
+
+	Subscription *WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID"`
+}
+
+// WebhookDeliveryAttempt is an attempt to post a delivery
+type WebhookDeliveryAttempt struct {
+	AttemptedAt types.Time `json:"attempted_at"`
+	// ResponseCode is the HTTP status of the response, 0 when there was none
+	ResponseCode int32  `json:"response_code"`
+	Error        string `json:"error,omitempty"`
+	DurationMS   int32  `json:"duration_ms"`
+}
+
+// CreateWebhookSubscription implements Repository using the database client
+func (db *Database) CreateWebhookSubscription(subscription WebhookSubscription) (*WebhookSubscription, error) {
+	return CreateWebhookSubscription(db.Client, subscription)
+}
+
+// FindWebhookSubscriptions implements Repository using the database client
+func (db *Database) FindWebhookSubscriptions(id graphql.ID) ([]WebhookSubscription, error) {
+	return FindWebhookSubscriptions(db.Client, id)
+}
+
+// UpdateWebhookSubscription implements Repository using the database client
+func (db *Database) UpdateWebhookSubscription(id graphql.ID, update WebhookSubscriptionUpdate) (*WebhookSubscription, error) {
+	return UpdateWebhookSubscription(db.Client, id, update)
+}
+
+// DeleteWebhookSubscription implements Repository using the database client
+func (db *Database) DeleteWebhookSubscription(id graphql.ID) error {
+	return DeleteWebhookSubscription(db.Client, id)
+}
+
+// RecordWebhookSubscriptionFailure implements Repository using the database client
+func (db *Database) RecordWebhookSubscriptionFailure(id graphql.ID) (int32, error) {
+	return RecordWebhookSubscriptionFailure(db.Client, id)
+}
+
+// ResetWebhookSubscriptionFailures implements Repository using the database client
+func (db *Database) ResetWebhookSubscriptionFailures(id graphql.ID) error {
+	return ResetWebhookSubscriptionFailures(db.Client, id)
+}
+
+// PauseWebhookSubscription implements Repository using the database client
+func (db *Database) PauseWebhookSubscription(id graphql.ID, reason string) error {
+	return PauseWebhookSubscription(db.Client, id, reason)
+}
+
+// CreateWebhookDelivery implements Repository using the database client
+func (db *Database) CreateWebhookDelivery(delivery WebhookDelivery) (bool, error) {
+	return CreateWebhookDelivery(db.Client, delivery)
+}
+
+// ClaimWebhookDeliveries implements Repository using the database client
+func (db *Database) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	return ClaimWebhookDeliveries(db.Client, limit, lease)
+}
+
+// RecordWebhookDeliveryAttempt implements Repository using the database client
+func (db *Database) RecordWebhookDeliveryAttempt(id graphql.ID, attempt WebhookDeliveryAttempt, state string, nextAttemptAt time.Time) error {
+	return RecordWebhookDeliveryAttempt(db.Client, id, attempt, state, nextAttemptAt)
+}
+
+// FindWebhookDeliveries implements Repository using the database client
+func (db *Database) FindWebhookDeliveries(subscriptionID graphql.ID, state string) ([]WebhookDelivery, error) {
+	return FindWebhookDeliveries(db.Client, subscriptionID, state, db.MaxPageSize)
+}
+
+// RedeliverWebhookDelivery implements Repository using the database client
+func (db *Database) RedeliverWebhookDelivery(id graphql.ID) (*WebhookDelivery, error) {
+	return RedeliverWebhookDelivery(db.Client, id)
+}
+
+// CreateWebhookSubscription stores an active subscription
+func CreateWebhookSubscription(tx *gorm.DB, subscription WebhookSubscription) (*WebhookSubscription, error) {
+	subscription = newWebhookSubscription(subscription)
+	if result := tx.Create(&subscription); result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return &subscription, nil
+}
+
+// FindWebhookSubscriptions returns the subscription with the id, or every subscription when the
+// id is empty, oldest first
+func FindWebhookSubscriptions(tx *gorm.DB, id graphql.ID) ([]WebhookSubscription, error) {
+	query := tx.Model(&WebhookSubscription{}).Order("id")
+	if id != "" {
+		query = query.Where(`"id" = ?`, id)
+	}
+	subscriptions := []WebhookSubscription{}
+	if result := query.Find(&subscriptions); result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	if id != "" && len(subscriptions) == 0 {
+		return nil, missingWebhookSubscription(id)
+	}
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription applies the update to the subscription and returns it
+func UpdateWebhookSubscription(tx *gorm.DB, id graphql.ID, update WebhookSubscriptionUpdate) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(`"id" = ?`, id).Limit(1).Find(&subscription)
+		if result.Error != nil {
+			return pgError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return missingWebhookSubscription(id)
+		}
+		subscription = update.apply(subscription)
+		if result := tx.Save(&subscription); result.Error != nil {
+			return pgError(result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription along with its deliveries
+func DeleteWebhookSubscription(tx *gorm.DB, id graphql.ID) error {
+	result := tx.Delete(&WebhookSubscription{ID: id})
+	if result.Error != nil {
+		return pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return missingWebhookSubscription(id)
+	}
+	return nil
+}
+
+// RecordWebhookSubscriptionFailure counts a delivery that failed for good and returns the number
+// of consecutive failures
+func RecordWebhookSubscriptionFailure(tx *gorm.DB, id graphql.ID) (int32, error) {
+	var subscription WebhookSubscription
+	result := tx.Model(&subscription).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "consecutive_failures"}}}).
+		Where(`"id" = ?`, id).
+		Update("consecutive_failures", gorm.Expr(`"consecutive_failures" + 1`))
+	if result.Error != nil {
+		return 0, pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, missingWebhookSubscription(id)
+	}
+	return subscription.ConsecutiveFailures, nil
+}
+
+// ResetWebhookSubscriptionFailures records that a delivery of the subscription succeeded
+func ResetWebhookSubscriptionFailures(tx *gorm.DB, id graphql.ID) error {
+	result := tx.Model(&WebhookSubscription{}).
+		Where(`"id" = ? AND "consecutive_failures" <> 0`, id).
+		Update("consecutive_failures", 0)
+	return pgError(result.Error)
+}
+
+// PauseWebhookSubscription stops the deliveries of an active subscription
+func PauseWebhookSubscription(tx *gorm.DB, id graphql.ID, reason string) error {
+	result := tx.Model(&WebhookSubscription{}).
+		Where(`"id" = ? AND "state" = ?`, id, SubscriptionStateActive).
+		Updates(map[string]any{
+			"state":         SubscriptionStatePaused,
+			"paused_reason": reason,
+			"updated_at":    now(),
+		})
+	return pgError(result.Error)
+}
+
+// CreateWebhookDelivery stores a pending delivery to attempt right away. Nothing is stored when
+// the message was already delivered to the subscription, the boolean reports whether it was.
+func CreateWebhookDelivery(tx *gorm.DB, delivery WebhookDelivery) (bool, error) {
+	delivery = newWebhookDelivery(delivery)
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&delivery)
+	if result.Error != nil {
+		return false, pgError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ClaimWebhookDeliveries leases up to limit pending deliveries of active subscriptions that are
+// due and not leased, oldest first, and returns them with their subscription. Rows are locked
+// while they are claimed so that concurrent servers claim each delivery once.
+func ClaimWebhookDeliveries(tx *gorm.DB, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	claimedAt := time.Now().UTC()
+	result := tx.Raw(`UPDATE "webhook_deliveries" SET "leased_until" = ? WHERE "id" IN (`+
+		`SELECT "webhook_deliveries"."id" FROM "webhook_deliveries" `+
+		`JOIN "webhook_subscriptions" ON "webhook_subscriptions"."id" = "webhook_deliveries"."subscription_id" `+
+		`WHERE "webhook_deliveries"."state" = ? AND "webhook_deliveries"."next_attempt_at" <= ? `+
+		`AND ("webhook_deliveries"."leased_until" IS NULL OR "webhook_deliveries"."leased_until" <= ?) `+
+		`AND "webhook_subscriptions"."state" = ? `+
+		`ORDER BY "webhook_deliveries"."id" LIMIT ? FOR UPDATE OF "webhook_deliveries" SKIP LOCKED) RETURNING *`,
+		claimedAt.Add(lease), DeliveryStatePending, claimedAt, claimedAt, SubscriptionStateActive, limit).
+		Scan(&deliveries)
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+
+	ids := []graphql.ID{}
+	for _, d := range deliveries {
+		ids = append(ids, d.SubscriptionID)
+	}
+	subscriptions := []WebhookSubscription{}
+	if result := tx.Where(`"id" IN ?`, ids).Find(&subscriptions); result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	for i := range deliveries {
+		for j := range subscriptions {
+			if subscriptions[j].ID == deliveries[i].SubscriptionID {
+				deliveries[i].Subscription = &subscriptions[j]
+			}
+		}
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt appends the attempt to the log of the delivery, moves it to the
+// state and releases its lease. A delivery that is still pending is attempted again at
+// nextAttemptAt.
+func RecordWebhookDeliveryAttempt(tx *gorm.DB, id graphql.ID, attempt WebhookDeliveryAttempt, state string, nextAttemptAt time.Time) error {
+	logged, err := json.Marshal([]WebhookDeliveryAttempt{attempt})
+	if err != nil {
+		return err
+	}
+	updates := map[string]any{
+		"attempts":        gorm.Expr(`"attempts" || ?::jsonb`, string(logged)),
+		"state":           state,
+		"next_attempt_at": nextAttemptAt.UTC(),
+		"leased_until":    nil,
+	}
+	if state == DeliveryStateSucceeded {
+		updates["delivered_at"] = attempt.AttemptedAt
+	} else {
+		updates["retries"] = gorm.Expr(`"retries" + 1`)
+	}
+	result := tx.Model(&WebhookDelivery{ID: id}).Updates(updates)
+	if result.Error != nil {
+		return pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return missingWebhookDelivery(id)
+	}
+	return nil
+}
+
+// FindWebhookDeliveries returns up to limit deliveries of the subscription, newest first, only
+// those in the state unless it is empty
+func FindWebhookDeliveries(tx *gorm.DB, subscriptionID graphql.ID, state string, limit int) ([]WebhookDelivery, error) {
+	if _, err := FindWebhookSubscriptions(tx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultMaxPageSize
+	}
+	query := tx.Where(`"subscription_id" = ?`, subscriptionID)
+	if state != "" {
+		query = query.Where(`"state" = ?`, state)
+	}
+	deliveries := []WebhookDelivery{}
+	if result := query.Order("id DESC").Limit(limit).Find(&deliveries); result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery makes the delivery pending again with a fresh set of retries, to be
+// attempted right away. Deliveries of a subscription that is not active wait until it is.
+func RedeliverWebhookDelivery(tx *gorm.DB, id graphql.ID) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	result := tx.Model(&delivery).
+		Clauses(clause.Returning{}).
+		Where(`"id" = ?`, id).
+		Updates(map[string]any{
+			"state":           DeliveryStatePending,
+			"retries":         0,
+			"next_attempt_at": now(),
+		})
+	if result.Error != nil {
+		return nil, pgError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, missingWebhookDelivery(id)
+	}
+	return &delivery, nil
+}
+
+func newWebhookSubscription(subscription WebhookSubscription) WebhookSubscription {
+	subscription.ID = graphql.ID(utils.NewULIDAsString())
+	subscription.State = SubscriptionStateActive
+	subscription.PausedReason = ""
+	subscription.ConsecutiveFailures = 0
+	subscription.CreatedAt = now()
+	subscription.UpdatedAt = subscription.CreatedAt
+	if subscription.MessageTypes == nil {
+		subscription.MessageTypes = []string{}
+	}
+	if subscription.EventReceiverIDs == nil {
+		subscription.EventReceiverIDs = []graphql.ID{}
+	}
+	if subscription.EventReceiverGroupIDs == nil {
+		subscription.EventReceiverGroupIDs = []graphql.ID{}
+	}
+	return subscription
+}
+
+func newWebhookDelivery(delivery WebhookDelivery) WebhookDelivery {
+	delivery.ID = graphql.ID(utils.NewULIDAsString())
+	delivery.State = DeliveryStatePending
+	delivery.Retries = 0
+	delivery.Attempts = []WebhookDeliveryAttempt{}
+	delivery.CreatedAt = now()
+	delivery.NextAttemptAt = delivery.CreatedAt
+	delivery.DeliveredAt = nil
+	delivery.LeasedUntil = nil
+	delivery.Subscription = nil
+	return delivery
+}
+
+// apply returns the subscription with the update
+func (u WebhookSubscriptionUpdate) apply(subscription WebhookSubscription) WebhookSubscription {
+	if u.Name != nil {
+		subscription.Name = *u.Name
+	}
+	if u.URL != nil {
+		subscription.URL = *u.URL
+	}
+	if u.Secret != nil {
+		subscription.Secret = *u.Secret
+	}
+	if u.MessageTypes != nil {
+		subscription.MessageTypes = slices.Clone(*u.MessageTypes)
+	}
+	if u.EventReceiverIDs != nil {
+		subscription.EventReceiverIDs = slices.Clone(*u.EventReceiverIDs)
+	}
+	if u.EventReceiverGroupIDs != nil {
+		subscription.EventReceiverGroupIDs = slices.Clone(*u.EventReceiverGroupIDs)
+	}
+	if u.Enabled != nil {
+		subscription.State = SubscriptionStateDisabled
+		if *u.Enabled {
+			subscription.State = SubscriptionStateActive
+			subscription.ConsecutiveFailures = 0
+		}
+		subscription.PausedReason = ""
+	}
+	subscription.UpdatedAt = now()
+	return subscription
+}
+
+func missingWebhookSubscription(id graphql.ID) error {
+	return eprErrors.MissingObjectError{Msg: fmt.Sprintf("webhook subscription with id %s not found", id)}
+}
+
+func missingWebhookDelivery(id graphql.ID) error {
+	return eprErrors.MissingObjectError{Msg: fmt.Sprintf("webhook delivery with id %s not found", id)}
+}
+
+// CreateWebhookSubscription stores an active subscription
+func (m *Memory) CreateWebhookSubscription(subscription WebhookSubscription) (*WebhookSubscription, error) {
+	defer m.lock()()
+
+	subscription = newWebhookSubscription(subscription)
+	m.subscriptions = append(m.subscriptions, subscription)
+	return &subscription, nil
+}
+
+// FindWebhookSubscriptions returns the subscription with the id, or every subscription when the
+// id is empty, oldest first
+func (m *Memory) FindWebhookSubscriptions(id graphql.ID) ([]WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscriptions := []WebhookSubscription{}
+	for _, s := range m.subscriptions {
+		if id == "" || s.ID == id {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	if id != "" && len(subscriptions) == 0 {
+		return nil, missingWebhookSubscription(id)
+	}
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription applies the update to the subscription and returns it
+func (m *Memory) UpdateWebhookSubscription(id graphql.ID, update WebhookSubscriptionUpdate) (*WebhookSubscription, error) {
+	defer m.lock()()
+
+	subscription := m.subscription(id)
+	if subscription == nil {
+		return nil, missingWebhookSubscription(id)
+	}
+	*subscription = update.apply(*subscription)
+	updated := *subscription
+	return &updated, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription along with its deliveries
+func (m *Memory) DeleteWebhookSubscription(id graphql.ID) error {
+	defer m.lock()()
+
+	if m.subscription(id) == nil {
+		return missingWebhookSubscription(id)
+	}
+	m.subscriptions = slices.DeleteFunc(m.subscriptions, func(s WebhookSubscription) bool { return s.ID == id })
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(d WebhookDelivery) bool { return d.SubscriptionID == id })
+	return nil
+}
+
+// RecordWebhookSubscriptionFailure counts a delivery that failed for good and returns the number
+// of consecutive failures
+func (m *Memory) RecordWebhookSubscriptionFailure(id graphql.ID) (int32, error) {
+	defer m.lock()()
+
+	subscription := m.subscription(id)
+	if subscription == nil {
+		return 0, missingWebhookSubscription(id)
+	}
+	subscription.ConsecutiveFailures++
+	return subscription.ConsecutiveFailures, nil
+}
+
+// ResetWebhookSubscriptionFailures records that a delivery of the subscription succeeded
+func (m *Memory) ResetWebhookSubscriptionFailures(id graphql.ID) error {
+	defer m.lock()()
+
+	if subscription := m.subscription(id); subscription != nil {
+		subscription.ConsecutiveFailures = 0
+	}
+	return nil
+}
+
+// PauseWebhookSubscription stops the deliveries of an active subscription
+func (m *Memory) PauseWebhookSubscription(id graphql.ID, reason string) error {
+	defer m.lock()()
+
+	if subscription := m.subscription(id); subscription != nil && subscription.State == SubscriptionStateActive {
+		subscription.State = SubscriptionStatePaused
+		subscription.PausedReason = reason
+		subscription.UpdatedAt = now()
+	}
+	return nil
+}
+
+// CreateWebhookDelivery stores a pending delivery to attempt right away. Nothing is stored when
+// the message was already delivered to the subscription, the boolean reports whether it was.
+func (m *Memory) CreateWebhookDelivery(delivery WebhookDelivery) (bool, error) {
+	defer m.lock()()
+
+	if m.subscription(delivery.SubscriptionID) == nil {
+		return false, eprErrors.InvalidInputError{Msg: "subscription for delivery does not exist"}
+	}
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == delivery.SubscriptionID && d.MessageID == delivery.MessageID {
+			return false, nil
+		}
+	}
+	m.deliveries = append(m.deliveries, newWebhookDelivery(delivery))
+	return true, nil
+}
+
+// ClaimWebhookDeliveries leases up to limit pending deliveries of active subscriptions that are
+// due and not leased, oldest first, and returns them with their subscription
+func (m *Memory) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	defer m.lock()()
+
+	claimedAt := time.Now()
+	leasedUntil := types.Time{Date: datatypes.Date(claimedAt.Add(lease).UTC())}
+	deliveries := []WebhookDelivery{}
+	for i := range m.deliveries {
+		if len(deliveries) == limit {
+			break
+		}
+		d := &m.deliveries[i]
+		subscription := m.subscription(d.SubscriptionID)
+		if d.State != DeliveryStatePending || time.Time(d.NextAttemptAt.Date).After(claimedAt) || subscription.State != SubscriptionStateActive {
+			continue
+		}
+		if d.LeasedUntil != nil && time.Time(d.LeasedUntil.Date).After(claimedAt) {
+			continue
+		}
+		leased := leasedUntil
+		d.LeasedUntil = &leased
+		claimed := *d
+		s := *subscription
+		claimed.Subscription = &s
+		claimed.Attempts = slices.Clone(d.Attempts)
+		deliveries = append(deliveries, claimed)
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt appends the attempt to the log of the delivery, moves it to the
+// state and releases its lease. A delivery that is still pending is attempted again at
+// nextAttemptAt.
+func (m *Memory) RecordWebhookDeliveryAttempt(id graphql.ID, attempt WebhookDeliveryAttempt, state string, nextAttemptAt time.Time) error {
+	defer m.lock()()
+
+	delivery := m.delivery(id)
+	if delivery == nil {
+		return missingWebhookDelivery(id)
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.State = state
+	delivery.NextAttemptAt = types.Time{Date: datatypes.Date(nextAttemptAt.UTC())}
+	delivery.LeasedUntil = nil
+	if state == DeliveryStateSucceeded {
+		delivered := attempt.AttemptedAt
+		delivery.DeliveredAt = &delivered
+	} else {
+		delivery.Retries++
+	}
+	return nil
+}
+
+// FindWebhookDeliveries returns the deliveries of the subscription, newest first, only those in
+// the state unless it is empty
+func (m *Memory) FindWebhookDeliveries(subscriptionID graphql.ID, state string) ([]WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.subscription(subscriptionID) == nil {
+		return nil, missingWebhookSubscription(subscriptionID)
+	}
+	limit := m.MaxPageSize
+	if limit <= 0 {
+		limit = DefaultMaxPageSize
+	}
+	deliveries := []WebhookDelivery{}
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && (state == "" || d.State == state) {
+			d.Attempts = slices.Clone(d.Attempts)
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery makes the delivery pending again with a fresh set of retries, to be
+// attempted right away
+func (m *Memory) RedeliverWebhookDelivery(id graphql.ID) (*WebhookDelivery, error) {
+	defer m.lock()()
+
+	delivery := m.delivery(id)
+	if delivery == nil {
+		return nil, missingWebhookDelivery(id)
+	}
+	delivery.State = DeliveryStatePending
+	delivery.Retries = 0
+	delivery.NextAttemptAt = now()
+	redelivered := *delivery
+	redelivered.Attempts = slices.Clone(delivery.Attempts)
+	return &redelivered, nil
+}
+
+// subscription looks up a webhook subscription by ID. Callers must hold the lock.
+func (m *Memory) subscription(id graphql.ID) *WebhookSubscription {
+	for i := range m.subscriptions {
+		if m.subscriptions[i].ID == id {
+			return &m.subscriptions[i]
+		}
+	}
+	return nil
+}
+
+// delivery looks up a webhook delivery by ID. Callers must hold the lock.
+func (m *Memory) delivery(id graphql.ID) *WebhookDelivery {
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == id {
+			return &m.deliveries[i]
+		}
+	}
+	return nil
+}
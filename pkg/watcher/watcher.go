@@ -81,19 +81,35 @@ func (w *Watcher) ConsumeRecords(matches func(message *message.Message) bool) {
 
 		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
 			p.EachRecord(func(r *kgo.Record) {
-				var msg message.Message
-				err := json.Unmarshal(r.Value, &msg)
+				msg, err := decode(r)
 				if err != nil {
 					panic(err)
 				}
-				if match := matches(&msg); match {
-					w.taskChan <- &msg
+				if match := matches(msg); match {
+					w.taskChan <- msg
 				}
 			})
 		})
 	}
 }
 
+// decode reads the message of a record sent in the structured or the binary content mode
+func decode(r *kgo.Record) (*message.Message, error) {
+	headers := map[string]string{}
+	for _, header := range r.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	if _, ok := headers[message.HeaderPrefix+"specversion"]; ok {
+		return message.FromBinary(headers, r.Value)
+	}
+
+	var msg message.Message
+	if err := json.Unmarshal(r.Value, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
 // StartTaskHandler returns nil
 func (w *Watcher) StartTaskHandler(taskHandler func(*message.Message) error) {
 	for {
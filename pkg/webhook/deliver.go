@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/metrics"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gorm.io/datatypes"
+)
+
+const (
+	// DefaultInterval is the time between two polls of the pending deliveries
+	DefaultInterval = time.Second
+	// DefaultBatchSize is the largest number of deliveries attempted per poll
+	DefaultBatchSize = 50
+	// DefaultTimeout bounds the wait for the response of a subscriber
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxAttempts is the number of attempts after which a delivery fails
+	DefaultMaxAttempts = 8
+	// DefaultMinBackoff is the wait after the first failed attempt, it doubles after each one
+	DefaultMinBackoff = 10 * time.Second
+	// DefaultMaxBackoff bounds the wait between two attempts
+	DefaultMaxBackoff = time.Hour
+	// DefaultPauseAfter is the number of deliveries in a row that fail before a subscription is
+	// paused
+	DefaultPauseAfter = 5
+	// DefaultLease is how long the deliveries of a batch are kept from other deliverers, long
+	// enough to attempt each of them
+	DefaultLease = DefaultBatchSize * DefaultTimeout
+)
+
+// Deliverer posts the pending deliveries of the active subscriptions. A delivery that fails is
+// retried with an exponential backoff. A subscription is paused once PauseAfter deliveries in a
+// row failed, or right away when its endpoint responds 410 Gone.
+type Deliverer struct {
+	db     storage.Repository
+	client *http.Client
+
+	Interval  time.Duration
+	BatchSize int
+	// Lease is how long a claimed batch is kept from other deliverers, the deliveries not
+	// attempted by then are left for the next claim
+	Lease       time.Duration
+	MaxAttempts int32
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	PauseAfter  int32
+}
+
+// NewDeliverer returns a deliverer of the deliveries stored in the repository
+func NewDeliverer(db storage.Repository) *Deliverer {
+	return &Deliverer{
+		db:          db,
+		client:      &http.Client{Timeout: DefaultTimeout},
+		Interval:    DefaultInterval,
+		BatchSize:   DefaultBatchSize,
+		Lease:       DefaultLease,
+		MaxAttempts: DefaultMaxAttempts,
+		MinBackoff:  DefaultMinBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		PauseAfter:  DefaultPauseAfter,
+	}
+}
+
+// Run polls the pending deliveries until the context is done
+func (d *Deliverer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Deliver(); err != nil {
+			slog.Error("error delivering webhooks", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver attempts a batch of the deliveries that are due, oldest first, and returns how many
+// succeeded. The batch is claimed in a transaction of its own, the deliveries are posted outside
+// of any transaction and each attempt is recorded in a transaction of its own.
+func (d *Deliverer) Deliver() (int, error) {
+	var claimed []storage.WebhookDelivery
+	err := d.db.Transaction(func(tx storage.Repository) error {
+		var err error
+		claimed, err = tx.ClaimWebhookDeliveries(d.BatchSize, d.Lease)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	// the deliveries of a subscription paused during the batch wait for it to be enabled
+	paused := map[graphql.ID]bool{}
+	for _, delivery := range claimed {
+		if paused[delivery.SubscriptionID] {
+			continue
+		}
+		if delivery.LeasedUntil != nil && time.Now().After(time.Time(delivery.LeasedUntil.Date)) {
+			// another deliverer may have claimed the rest of the batch
+			break
+		}
+		attempt := d.post(delivery.Subscription, delivery)
+		var ok, pause bool
+		err := d.db.Transaction(func(tx storage.Repository) error {
+			var err error
+			ok, pause, err = d.record(tx, delivery, attempt)
+			return err
+		})
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+		paused[delivery.SubscriptionID] = pause
+	}
+	return delivered, nil
+}
+
+// record records the outcome of the attempt, reporting whether the delivery succeeded and
+// whether the subscription was paused
+func (d *Deliverer) record(tx storage.Repository, delivery storage.WebhookDelivery, attempt storage.WebhookDeliveryAttempt) (bool, bool, error) {
+	subscription := delivery.Subscription
+	now := time.Time(attempt.AttemptedAt.Date)
+
+	if attempt.Error == "" {
+		metrics.WebhookDeliveryAttempts.WithLabelValues("succeeded").Inc()
+		if err := tx.RecordWebhookDeliveryAttempt(delivery.ID, attempt, storage.DeliveryStateSucceeded, now); err != nil {
+			return false, false, err
+		}
+		return true, false, tx.ResetWebhookSubscriptionFailures(subscription.ID)
+	}
+
+	slog.Warn("error delivering webhook", "subscription", subscription.ID, "delivery", delivery.ID, "attempts", delivery.Retries+1, "error", attempt.Error)
+	if attempt.ResponseCode != http.StatusGone && delivery.Retries+1 < d.MaxAttempts {
+		metrics.WebhookDeliveryAttempts.WithLabelValues("retried").Inc()
+		return false, false, tx.RecordWebhookDeliveryAttempt(delivery.ID, attempt, storage.DeliveryStatePending, now.Add(d.backoff(delivery.Retries+1)))
+	}
+
+	metrics.WebhookDeliveryAttempts.WithLabelValues("failed").Inc()
+	if err := tx.RecordWebhookDeliveryAttempt(delivery.ID, attempt, storage.DeliveryStateFailed, now); err != nil {
+		return false, false, err
+	}
+	failures, err := tx.RecordWebhookSubscriptionFailure(subscription.ID)
+	if err != nil {
+		return false, false, err
+	}
+	reason := ""
+	switch {
+	case attempt.ResponseCode == http.StatusGone:
+		reason = "the endpoint responded 410 Gone"
+	case failures >= d.PauseAfter:
+		reason = fmt.Sprintf("%d deliveries in a row failed, the last one with: %s", failures, attempt.Error)
+	default:
+		return false, false, nil
+	}
+	slog.Warn("pausing webhook subscription", "subscription", subscription.ID, "reason", reason)
+	metrics.WebhookSubscriptionsPaused.Inc()
+	return false, true, tx.PauseWebhookSubscription(subscription.ID, reason)
+}
+
+// post posts the payload of the delivery to the URL of the subscription
+func (d *Deliverer) post(subscription *storage.WebhookSubscription, delivery storage.WebhookDelivery) storage.WebhookDeliveryAttempt {
+	start := time.Now()
+	attempt := storage.WebhookDeliveryAttempt{
+		AttemptedAt: types.Time{Date: datatypes.Date(start.UTC())},
+	}
+	err := func() error {
+		req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload.JSON))
+		if err != nil {
+			return err
+		}
+		timestamp := start.Unix()
+		req.Header.Set("Content-Type", message.StructuredContentType)
+		req.Header.Set(DeliveryHeader, string(delivery.ID))
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload.JSON))
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// drain the body so that the connection is reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		attempt.ResponseCode = int32(resp.StatusCode)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("endpoint responded %s", resp.Status)
+		}
+		return nil
+	}()
+	attempt.DurationMS = int32(time.Since(start).Milliseconds())
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (d *Deliverer) backoff(attempts int32) time.Duration {
+	backoff := d.MinBackoff
+	for i := int32(1); i < attempts && backoff < d.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.MaxBackoff)
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhook delivers messages to webhook subscriptions. The Dispatcher stores a delivery
+// for each subscription a message matches and the Deliverer posts them. Each delivery is signed
+// with the secret of its subscription, see Sign, so that consumers can tell it comes from EPR.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/api/graphql/schema/types"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gorm.io/datatypes"
+)
+
+const (
+	// SignatureHeader carries the signature of a delivery, see Sign
+	SignatureHeader = "X-EPR-Signature"
+	// TimestampHeader carries the Unix time the delivery was signed at
+	TimestampHeader = "X-EPR-Timestamp"
+	// DeliveryHeader carries the ID of the delivery, the same on every attempt
+	DeliveryHeader = "X-EPR-Delivery"
+)
+
+// Sign returns the signature of a delivery: sha256= followed by the hex encoded HMAC-SHA256,
+// keyed with the secret, of the timestamp, a dot and the body. Consumers should reject old
+// timestamps to guard against replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature is the signature of the body at the timestamp
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Matches reports whether the message passes every filter of the subscription that is not empty
+func Matches(subscription storage.WebhookSubscription, msg message.Message) bool {
+	if len(subscription.MessageTypes) > 0 && !slices.Contains(subscription.MessageTypes, msg.Type) {
+		return false
+	}
+	if len(subscription.EventReceiverIDs) > 0 && !containsAny(subscription.EventReceiverIDs, msg.EventReceiverIDs()) {
+		return false
+	}
+	if len(subscription.EventReceiverGroupIDs) > 0 && !containsAny(subscription.EventReceiverGroupIDs, msg.EventReceiverGroupIDs()) {
+		return false
+	}
+	return true
+}
+
+func containsAny(filter, ids []graphql.ID) bool {
+	for _, id := range ids {
+		if slices.Contains(filter, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher is a TopicProducer that stores a delivery of each message for every active
+// subscription it matches. It is meant to be fed by the outbox relay, a message relayed twice is
+// delivered once.
+type Dispatcher struct {
+	db storage.Repository
+}
+
+// NewDispatcher returns a dispatcher storing deliveries in the repository
+func NewDispatcher(db storage.Repository) *Dispatcher {
+	return &Dispatcher{db: db}
+}
+
+// Async stores the deliveries of the message, errors are only logged
+func (d *Dispatcher) Async(data any) {
+	if err := d.Send(data); err != nil {
+		slog.Error("error dispatching message to webhook subscriptions", "error", err)
+	}
+}
+
+// Send stores the deliveries of the message. Anything but a Message is ignored.
+func (d *Dispatcher) Send(data any) error {
+	msg, ok := data.(message.Message)
+	if !ok {
+		return nil
+	}
+	subscriptions, err := d.db.FindWebhookSubscriptions("")
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, subscription := range subscriptions {
+		if subscription.State != storage.SubscriptionStateActive || !Matches(subscription, msg) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(msg); err != nil {
+				return err
+			}
+		}
+		_, err := d.db.CreateWebhookDelivery(storage.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			MessageID:      msg.ID,
+			MessageType:    msg.Type,
+			Payload:        types.JSON{JSON: datatypes.JSON(payload)},
+		})
+		if err != nil {
+			return fmt.Errorf("error storing delivery for subscription %s: %w", subscription.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2024, SAS Institute Inc., Cary, NC, USA.  All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/message"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
+	"gotest.tools/v3/assert"
+)
+
+const secret = "0123456789abcdef"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"01HKX0J9KS8AASMRYX61458N41"}`)
+	signature := Sign(secret, 1700000000, body)
+	assert.Equal(t, signature[:7], "sha256=")
+	assert.Assert(t, Verify(secret, signature, 1700000000, body))
+	assert.Assert(t, !Verify(secret, signature, 1700000001, body))
+	assert.Assert(t, !Verify("fedcba9876543210", signature, 1700000000, body))
+	assert.Assert(t, !Verify(secret, signature, 1700000000, []byte(`{}`)))
+}
+
+func TestMatches(t *testing.T) {
+	receiver := storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"}
+	msg := message.NewEvent(storage.Event{ID: "01HKX1TMQZQDS6NC5DG7WNXXCJ", Name: "foo", EventReceiverID: receiver.ID, EventReceiver: receiver})
+
+	tests := []struct {
+		name         string
+		subscription storage.WebhookSubscription
+		matches      bool
+	}{
+		{"no filters", storage.WebhookSubscription{}, true},
+		{"type", storage.WebhookSubscription{MessageTypes: []string{msg.Type}}, true},
+		{"other type", storage.WebhookSubscription{MessageTypes: []string{"other"}}, false},
+		{"receiver", storage.WebhookSubscription{EventReceiverIDs: []graphql.ID{receiver.ID}}, true},
+		{"other receiver", storage.WebhookSubscription{EventReceiverIDs: []graphql.ID{"other"}}, false},
+		{"group", storage.WebhookSubscription{EventReceiverGroupIDs: []graphql.ID{"01HKX0KK9KS8AASMRYX6145811"}}, false},
+		{"type and other receiver", storage.WebhookSubscription{MessageTypes: []string{msg.Type}, EventReceiverIDs: []graphql.ID{"other"}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, Matches(test.subscription, msg), test.matches)
+		})
+	}
+}
+
+func TestDeliverer(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil || !Verify(secret, r.Header.Get(SignatureHeader), timestamp, body) || r.Header.Get(DeliveryHeader) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	db := storage.NewMemory()
+	subscription, err := db.CreateWebhookSubscription(storage.WebhookSubscription{Name: "ci", URL: server.URL, Secret: secret})
+	assert.NilError(t, err)
+	other, err := db.CreateWebhookSubscription(storage.WebhookSubscription{Name: "other", URL: server.URL, Secret: secret, MessageTypes: []string{"other"}})
+	assert.NilError(t, err)
+
+	// a message relayed twice is delivered once, and only to the subscriptions it matches
+	dispatcher := NewDispatcher(db)
+	msg := message.NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})
+	assert.NilError(t, dispatcher.Send(msg))
+	assert.NilError(t, dispatcher.Send(msg))
+	deliveries, err := db.FindWebhookDeliveries(subscription.ID, "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+	deliveries, err = db.FindWebhookDeliveries(other.ID, "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 0)
+
+	deliverer := NewDeliverer(db)
+	deliverer.MinBackoff = 0
+	deliverer.MaxAttempts = 2
+	deliverer.PauseAfter = 2
+
+	// the first attempt fails and is retried
+	delivered, err := deliverer.Deliver()
+	assert.NilError(t, err)
+	assert.Equal(t, delivered, 0)
+	deliveries, err = db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStatePending)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, deliveries[0].Retries, int32(1))
+	assert.Equal(t, deliveries[0].Attempts[0].ResponseCode, int32(http.StatusServiceUnavailable))
+
+	// the last attempt fails the delivery but one failure does not pause the subscription
+	_, err = deliverer.Deliver()
+	assert.NilError(t, err)
+	deliveries, err = db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStateFailed)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Equal(t, len(deliveries[0].Attempts), 2)
+	found, err := db.FindWebhookSubscriptions(subscription.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, found[0].State, storage.SubscriptionStateActive)
+	assert.Equal(t, found[0].ConsecutiveFailures, int32(1))
+
+	// a redelivery succeeds once the endpoint is back and resets the failures
+	status.Store(http.StatusNoContent)
+	_, err = db.RedeliverWebhookDelivery(deliveries[0].ID)
+	assert.NilError(t, err)
+	delivered, err = deliverer.Deliver()
+	assert.NilError(t, err)
+	assert.Equal(t, delivered, 1)
+	deliveries, err = db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStateSucceeded)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Assert(t, deliveries[0].DeliveredAt != nil)
+	assert.Equal(t, len(deliveries[0].Attempts), 3)
+	found, err = db.FindWebhookSubscriptions(subscription.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, found[0].ConsecutiveFailures, int32(0))
+
+	// 410 Gone pauses the subscription right away, its deliveries wait until it is enabled
+	status.Store(http.StatusGone)
+	assert.NilError(t, dispatcher.Send(message.NewEventReceiverModified(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})))
+	_, err = deliverer.Deliver()
+	assert.NilError(t, err)
+	found, err = db.FindWebhookSubscriptions(subscription.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, found[0].State, storage.SubscriptionStatePaused)
+	assert.Equal(t, found[0].PausedReason, "the endpoint responded 410 Gone")
+	assert.NilError(t, dispatcher.Send(message.NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N42", Name: "test"})))
+	deliveries, err = db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStatePending)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 0)
+
+	before := requests.Load()
+	enabled := true
+	_, err = db.UpdateWebhookSubscription(subscription.ID, storage.WebhookSubscriptionUpdate{Enabled: &enabled})
+	assert.NilError(t, err)
+	status.Store(http.StatusOK)
+	_, err = deliverer.Deliver()
+	assert.NilError(t, err)
+	assert.Equal(t, requests.Load(), before)
+}
+
+func TestDelivererPausesAfterFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	db := storage.NewMemory()
+	subscription, err := db.CreateWebhookSubscription(storage.WebhookSubscription{Name: "ci", URL: server.URL, Secret: secret})
+	assert.NilError(t, err)
+	dispatcher := NewDispatcher(db)
+	for _, id := range []graphql.ID{"01HKX0J9KS8AASMRYX61458N41", "01HKX0J9KS8AASMRYX61458N42", "01HKX0J9KS8AASMRYX61458N43"} {
+		assert.NilError(t, dispatcher.Send(message.NewEventReceiver(storage.EventReceiver{ID: id, Name: "build"})))
+	}
+
+	deliverer := NewDeliverer(db)
+	deliverer.MaxAttempts = 1
+	deliverer.PauseAfter = 2
+	_, err = deliverer.Deliver()
+	assert.NilError(t, err)
+
+	found, err := db.FindWebhookSubscriptions(subscription.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, found[0].State, storage.SubscriptionStatePaused)
+	assert.Assert(t, found[0].ConsecutiveFailures == 2)
+	// the third delivery waits for the subscription to be enabled
+	deliveries, err := db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStatePending)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+}
+
+// transactions tracks whether a transaction is open
+type transactions struct {
+	*storage.Memory
+	open atomic.Bool
+}
+
+func (t *transactions) Transaction(fn func(tx storage.Repository) error) error {
+	return t.Memory.Transaction(func(tx storage.Repository) error {
+		t.open.Store(true)
+		defer t.open.Store(false)
+		return fn(tx)
+	})
+}
+
+func TestDelivererLeasesDeliveries(t *testing.T) {
+	db := &transactions{Memory: storage.NewMemory()}
+	var inTransaction atomic.Bool
+	var reclaimed atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		inTransaction.Store(db.open.Load())
+		// the delivery being posted is leased, another deliverer cannot claim it
+		claimed, _ := db.ClaimWebhookDeliveries(DefaultBatchSize, DefaultLease)
+		reclaimed.Add(int32(len(claimed)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	subscription, err := db.CreateWebhookSubscription(storage.WebhookSubscription{Name: "ci", URL: server.URL, Secret: secret})
+	assert.NilError(t, err)
+	assert.NilError(t, NewDispatcher(db).Send(message.NewEventReceiver(storage.EventReceiver{ID: "01HKX0J9KS8AASMRYX61458N41", Name: "build"})))
+
+	// a lease that expired, e.g. of a deliverer that stopped, does not keep the delivery
+	claimed, err := db.ClaimWebhookDeliveries(DefaultBatchSize, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(claimed), 1)
+
+	delivered, err := NewDeliverer(db).Deliver()
+	assert.NilError(t, err)
+	assert.Equal(t, delivered, 1)
+	assert.Assert(t, !inTransaction.Load())
+	assert.Equal(t, reclaimed.Load(), int32(0))
+
+	deliveries, err := db.FindWebhookDeliveries(subscription.ID, storage.DeliveryStateSucceeded)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deliveries), 1)
+	assert.Assert(t, deliveries[0].LeasedUntil == nil)
+}
@@ -1,7 +1,10 @@
 package e2e
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 )
@@ -44,3 +47,23 @@ func (e eventInput) toPayload() string {
 	"event_receiver_id": "%s"
 }`, e.Name, e.Version, e.Release, e.PlatformID, e.Package, e.Description, e.Payload, e.Success, e.EventReceiverID)
 }
+
+// createEvent creates an event with the given input, returning its ID
+// or any errors that occurred
+func createEvent(client *http.Client, input eventInput) (string, error) {
+	resp, err := client.Post(eventURI, "application/json", strings.NewReader(input.toPayload()))
+	if err != nil {
+		return "", fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body postEventResponse
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode event resp body: %w", err)
+	}
+	if len(body.Errors) > 0 {
+		return "", fmt.Errorf("event resp body had errors: %v", body.Errors)
+	}
+	return body.Data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/sassoftware/event-provenance-registry/pkg/storage"
@@ -16,6 +17,11 @@ type getGroupResponse struct {
 	Errors []string
 }
 
+type getGroupStatesResponse struct {
+	Data   []storage.EventReceiverGroupState
+	Errors []string
+}
+
 type postGroupResponse struct {
 	// ID of the created group
 	Data   string
@@ -122,3 +128,29 @@ func toggleGroup(client *http.Client, id string, enabled bool) error {
 	}
 	return nil
 }
+
+// getGroupStates returns the states of a group for the artifact of the event
+func getGroupStates(client *http.Client, id string, tuple eventInput) ([]storage.EventReceiverGroupState, error) {
+	query := url.Values{
+		"name":        {tuple.Name},
+		"version":     {tuple.Version},
+		"release":     {tuple.Release},
+		"platform_id": {tuple.PlatformID},
+		"package":     {tuple.Package},
+	}
+	resp, err := client.Get(groupURI + id + "/states?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get states of group %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	var body getGroupStatesResponse
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode group states resp body: %w", err)
+	}
+	if len(body.Errors) > 0 {
+		return nil, fmt.Errorf("group states resp body had errors: %v", body.Errors)
+	}
+	return body.Data, nil
+}
@@ -2,12 +2,15 @@ package e2e
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/graph-gophers/graphql-go"
+	"github.com/sassoftware/event-provenance-registry/pkg/storage"
 	"github.com/sassoftware/event-provenance-registry/tests/common"
 	"gotest.tools/v3/assert"
 )
@@ -280,6 +283,74 @@ func TestToggleGroup(t *testing.T) {
 	assert.Check(t, !afterEnableUpdatedAt.Equal(afterDisableUpdatedAt), "updated time should be updated after enable")
 }
 
+func TestGroupCompletesWithParallelEvents(t *testing.T) {
+	client := common.NewHTTPClient()
+	run := time.Now().UnixNano()
+
+	var receiverIDs []string
+	for _, name := range []string{"parallel-build", "parallel-test"} {
+		receiverID, err := createReceiver(client, eventReceiverInput{
+			Name:        fmt.Sprintf("%s-%d", name, run),
+			Type:        "parallel." + name,
+			Version:     "1.0.0",
+			Description: "receiver of events created in parallel",
+			Schema:      `{}`,
+		})
+		assert.NilError(t, err)
+		receiverIDs = append(receiverIDs, receiverID)
+	}
+	groupID, err := createGroup(client, eventReceiverGroupInput{
+		Name:        fmt.Sprintf("parallel-group-%d", run),
+		Type:        "parallel.group",
+		Version:     "1.0.0",
+		Description: "group whose events arrive at the same time",
+		Enabled:     true,
+		Receivers:   receiverIDs,
+	})
+	assert.NilError(t, err)
+
+	// both events of each artifact are created at the same time, the group must still pass
+	var artifacts []eventInput
+	for i := 0; i < 10; i++ {
+		artifacts = append(artifacts, eventInput{
+			Name:        "parallel-artifact",
+			Version:     fmt.Sprintf("%d.%d", run, i),
+			Release:     "2024.01.22",
+			PlatformID:  "amd64-oci-linux",
+			Package:     "docker",
+			Description: "event created in parallel",
+			Payload:     "{}",
+			Success:     true,
+		})
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(artifacts)*len(receiverIDs))
+	for _, artifact := range artifacts {
+		for _, receiverID := range receiverIDs {
+			input := artifact
+			input.EventReceiverID = receiverID
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := createEvent(client, input)
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+
+	for _, artifact := range artifacts {
+		states, err := getGroupStates(client, groupID, artifact)
+		assert.NilError(t, err)
+		assert.Equal(t, len(states), 1, artifact.Version)
+		assert.Equal(t, states[0].State, storage.GroupStatePassed, artifact.Version)
+	}
+}
+
 func TestGetNonExistentGroup(t *testing.T) {
 	client := common.NewHTTPClient()
 